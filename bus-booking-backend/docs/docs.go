@@ -18,42 +18,39 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Xác thực người dùng bằng email và mật khẩu, trả về một token JWT.",
-                "consumes": [
-                    "application/json"
+        "/admin/companies": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Chỉ dành cho quản trị viên. Mặc định chỉ trả về nhà xe đang hoạt động, sắp xếp theo tên.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin - Companies"
                 ],
-                "summary": "Đăng nhập vào hệ thống",
+                "summary": "Danh sách nhà xe",
                 "parameters": [
                     {
-                        "description": "Thông tin đăng nhập",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/services.LoginInput"
-                        }
+                        "type": "boolean",
+                        "description": "Trả về cả nhà xe đã ngừng hoạt động",
+                        "name": "includeInactive",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Đăng nhập thành công, trả về token",
+                        "description": "Body: {thông báo: string, dữ_liệu: []models.Company}",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Dữ liệu đầu vào không hợp lệ",
+                    "401": {
+                        "description": "Chưa xác thực",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -61,8 +58,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Email hoặc mật khẩu không chính xác",
+                    "403": {
+                        "description": "Không phải quản trị viên",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -71,11 +68,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/auth/register": {
+            },
             "post": {
-                "description": "Tạo một tài khoản mới cho người dùng với email, số điện thoại, tên và mật khẩu.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Chỉ dành cho quản trị viên. Mã nhà xe (2-10 ký tự chữ và số) là duy nhất và được lưu dạng chữ in hoa.",
                 "consumes": [
                     "application/json"
                 ],
@@ -83,23 +83,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin - Companies"
                 ],
-                "summary": "Đăng ký tài khoản người dùng mới",
+                "summary": "Tạo nhà xe",
                 "parameters": [
                     {
-                        "description": "Thông tin đăng ký của người dùng",
-                        "name": "user",
+                        "description": "Thông tin nhà xe",
+                        "name": "company",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.RegisterInput"
+                            "$ref": "#/definitions/services.CompanyInput"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Trả về thông tin người dùng đã tạo",
+                        "description": "Nhà xe đã tạo. Body: {thông báo: string, dữ_liệu: models.Company}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -114,8 +114,26 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không phải quản trị viên",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "409": {
-                        "description": "Email đã được sử dụng",
+                        "description": "Mã nhà xe đã được sử dụng",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -126,14 +144,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/bookings": {
-            "post": {
+        "/admin/companies/{companyId}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Giữ chỗ cho người dùng đã đăng nhập. Yêu cầu token xác thực.",
+                "description": "Chỉ dành cho quản trị viên. Ghi đè toàn bộ thông tin có thể sửa của nhà xe.",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,30 +159,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Bookings"
+                    "Admin - Companies"
                 ],
-                "summary": "Tạo một booking mới (Giữ chỗ)",
+                "summary": "Cập nhật nhà xe",
                 "parameters": [
                     {
-                        "description": "Thông tin để giữ chỗ (tripId, seatNumbers)",
-                        "name": "booking",
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của nhà xe",
+                        "name": "companyId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Thông tin nhà xe",
+                        "name": "company",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.CreateBookingInput"
+                            "$ref": "#/definitions/services.CompanyInput"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Giữ chỗ thành công. Body: {thông báo: string, dữ_liệu: models.Booking}",
+                    "200": {
+                        "description": "Nhà xe sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Company}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Dữ liệu đầu vào không hợp lệ hoặc lỗi xử lý khác",
+                        "description": "ID hoặc dữ liệu đầu vào không hợp lệ",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -173,7 +199,7 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Yêu cầu token xác thực hoặc không tìm thấy thông tin người dùng",
+                        "description": "Chưa xác thực",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -181,8 +207,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Không tìm thấy chuyến đi",
+                    "403": {
+                        "description": "Không phải quản trị viên",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -190,8 +216,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "Ghế đã được người khác chọn (Conflict)",
+                    "404": {
+                        "description": "Không tìm thấy nhà xe",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -199,8 +225,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Lỗi máy chủ nội bộ (ví dụ: lỗi định dạng ID người dùng)",
+                    "409": {
+                        "description": "Mã nhà xe đã được sử dụng",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -211,31 +237,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/bookings/my": {
-            "get": {
+        "/admin/companies/{companyId}/deactivate": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Lấy danh sách tất cả các booking của người dùng đang đăng nhập. Yêu cầu token xác thực.",
+                "description": "Chỉ dành cho quản trị viên. Chuyến đi của nhà xe không còn xuất hiện khi tìm kiếm và không nhận đặt chỗ mới; booking đã có không bị ảnh hưởng.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bookings"
+                    "Admin - Companies"
+                ],
+                "summary": "Ngừng hoạt động nhà xe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của nhà xe",
+                        "name": "companyId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Lấy lịch sử đặt vé của người dùng hiện tại",
                 "responses": {
                     "200": {
-                        "description": "Lấy lịch sử booking thành công. Body: {thông báo: string, dữ_liệu: []models.Booking}",
+                        "description": "Nhà xe sau khi ngừng hoạt động. Body: {thông báo: string, dữ_liệu: models.Company}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "ID nhà xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "401": {
-                        "description": "Yêu cầu token xác thực hoặc không thể xác định người dùng",
+                        "description": "Chưa xác thực",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -243,8 +288,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Lỗi máy chủ nội bộ",
+                    "403": {
+                        "description": "Không phải quản trị viên",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy nhà xe",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -255,41 +309,87 @@ const docTemplate = `{
                 }
             }
         },
-        "/bookings/{bookingId}": {
+        "/admin/locations": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Lấy thông tin chi tiết của một booking dựa trên ID. Yêu cầu token xác thực và booking phải thuộc về người dùng đang đăng nhập.",
+                "description": "Chỉ dành cho quản trị viên. Trả về toàn bộ danh mục địa điểm, sắp xếp theo tên.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Bookings"
+                    "Admin - Locations"
                 ],
-                "summary": "Lấy chi tiết một booking",
+                "summary": "Danh sách địa điểm",
+                "responses": {
+                    "200": {
+                        "description": "Body: {thông báo: string, dữ_liệu: []models.Location}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không phải quản trị viên",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Chỉ dành cho quản trị viên. Bỏ trống slug để sinh từ tên. Slug, tên và các tên gọi khác (sau khi bỏ dấu) không được trùng với địa điểm khác.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Locations"
+                ],
+                "summary": "Tạo địa điểm",
                 "parameters": [
                     {
-                        "type": "string",
-                        "format": "ObjectID",
-                        "description": "ID của Booking",
-                        "name": "bookingId",
-                        "in": "path",
-                        "required": true
+                        "description": "Thông tin địa điểm",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.LocationInput"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Lấy chi tiết booking thành công. Body: {thông báo: string, dữ_liệu: models.Booking}",
+                    "201": {
+                        "description": "Địa điểm đã tạo. Body: {thông báo: string, dữ_liệu: models.Location}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "ID booking không hợp lệ",
+                        "description": "Dữ liệu đầu vào không hợp lệ",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -298,7 +398,7 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Yêu cầu token xác thực hoặc không thể xác định người dùng",
+                        "description": "Chưa xác thực",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -306,8 +406,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Không tìm thấy booking hoặc không có quyền xem",
+                    "403": {
+                        "description": "Không phải quản trị viên",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -315,8 +415,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Lỗi máy chủ nội bộ",
+                    "409": {
+                        "description": "Slug, tên hoặc tên gọi khác đã được sử dụng",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -327,9 +427,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/trips": {
-            "get": {
-                "description": "Tìm kiếm các chuyến đi dựa trên điểm đi, điểm đến và ngày khởi hành.",
+        "/admin/locations/{locationId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Chỉ dành cho quản trị viên. Ghi đè toàn bộ thông tin địa điểm; chuyến đi đã tạo giữ nguyên tên cũ.",
                 "consumes": [
                     "application/json"
                 ],
@@ -337,42 +442,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Trips"
+                    "Admin - Locations"
                 ],
-                "summary": "Tìm kiếm chuyến đi",
+                "summary": "Cập nhật địa điểm",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Tên điểm đi (Ví dụ: 'TP. Hồ Chí Minh')",
-                        "name": "from",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Tên điểm đến (Ví dụ: 'Đà Lạt')",
-                        "name": "to",
-                        "in": "query",
+                        "format": "ObjectID",
+                        "description": "ID của địa điểm",
+                        "name": "locationId",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Ngày đi theo định dạng YYYY-MM-DD (Ví dụ: '2024-05-25')",
-                        "name": "date",
-                        "in": "query",
-                        "required": true
+                        "description": "Thông tin địa điểm",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.LocationInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Danh sách các chuyến đi phù hợp",
+                        "description": "Địa điểm sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Location}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Các tham số query bắt buộc bị thiếu",
+                        "description": "ID hoặc dữ liệu đầu vào không hợp lệ",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -380,8 +481,35 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Lỗi máy chủ",
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không phải quản trị viên",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy địa điểm",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Slug, tên hoặc tên gọi khác đã được sử dụng",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -392,38 +520,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/trips/{tripId}": {
+        "/admin/schedules": {
             "get": {
-                "description": "Lấy toàn bộ thông tin chi tiết của một chuyến đi, bao gồm cả sơ đồ ghế.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Quản trị viên có thể lọc theo nhà xe; nhân viên nhà xe luôn chỉ thấy lịch của nhà xe mình.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Trips"
+                    "Admin - Schedules"
                 ],
-                "summary": "Lấy thông tin chi tiết một chuyến đi",
+                "summary": "Danh sách lịch chạy",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID của chuyến đi",
-                        "name": "tripId",
-                        "in": "path",
-                        "required": true
+                        "format": "ObjectID",
+                        "description": "Lọc theo ID nhà xe",
+                        "name": "companyId",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Thông tin chi tiết của chuyến đi",
+                        "description": "Body: {thông báo: string, dữ_liệu: []models.Schedule}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "ID chuyến đi không hợp lệ",
+                        "description": "ID nhà xe không hợp lệ",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -431,20 +561,2658 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Không tìm thấy chuyến đi",
+                    "401": {
+                        "description": "Chưa xác thực",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Dành cho quản trị viên và nhân viên nhà xe (chỉ với nhà xe của mình). Các chuyến đi trong những ngày tới được sinh ngay sau khi tạo, sau đó bộ sinh chạy định kỳ. weekdays: 0 là Chủ nhật; ngày dạng YYYY-MM-DD và giờ dạng HH:MM theo múi giờ vận hành.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Schedules"
+                ],
+                "summary": "Tạo lịch chạy",
+                "parameters": [
+                    {
+                        "description": "Thông tin lịch chạy",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.ScheduleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Lịch chạy đã tạo. Body: {thông báo: string, dữ_liệu: models.Schedule}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào hoặc xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy nhà xe hoặc xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Nhà xe đã ngừng hoạt động",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/schedules/{scheduleId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ghi đè toàn bộ lịch chạy, trừ skippedDates (ngày có chuyến đã bị xóa hoặc dời ngày). Thay đổi chỉ áp dụng cho các chuyến được sinh sau đó, trừ giờ khởi hành và thời gian chạy: các chuyến sắp tới chưa có booking được dời theo giờ mới.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Schedules"
+                ],
+                "summary": "Cập nhật lịch chạy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của lịch chạy",
+                        "name": "scheduleId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Thông tin lịch chạy",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.ScheduleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lịch chạy sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Schedule}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID, dữ liệu đầu vào hoặc xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy lịch chạy, nhà xe hoặc xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Nhà xe đã ngừng hoạt động",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/schedules/{scheduleId}/disable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ngừng sinh chuyến đi mới từ lịch và xóa các chuyến sắp tới do lịch sinh ra mà chưa có booking. Chuyến đã có booking được giữ nguyên.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Schedules"
+                ],
+                "summary": "Ngừng lịch chạy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của lịch chạy",
+                        "name": "scheduleId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Body: {thông báo: string, dữ_liệu: models.Schedule, removedTrips: int}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID lịch chạy không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy lịch chạy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/trips": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Dành cho quản trị viên và nhân viên nhà xe (chỉ với nhà xe của mình). Danh sách ghế được sinh từ sơ đồ ghế của xe, tất cả ở trạng thái \"available\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Trips"
+                ],
+                "summary": "Tạo chuyến đi",
+                "parameters": [
+                    {
+                        "description": "Thông tin chuyến đi",
+                        "name": "trip",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.TripInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Chuyến đi đã tạo. Body: {thông báo: string, dữ_liệu: models.Trip}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào, thời gian hoặc xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy nhà xe hoặc xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Nhà xe đã ngừng hoạt động",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/trips/{tripId}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ghi đè toàn bộ thông tin chuyến đi. Khi chuyến đi đang có booking còn hiệu lực (đang giữ chỗ chưa hết hạn hoặc đã xác nhận), đổi giá vé trả về mã trip_fares_locked, đổi giờ khởi hành hoặc giờ đến trả về trip_times_locked, đổi nhà xe, xe hoặc số điểm dừng trả về trip_has_bookings; các thay đổi khác như tên điểm dừng vẫn được lưu. Đổi xe sẽ sinh lại danh sách ghế.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Trips"
+                ],
+                "summary": "Cập nhật chuyến đi",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của chuyến đi",
+                        "name": "tripId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Thông tin chuyến đi",
+                        "name": "trip",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.TripInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Chuyến đi sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Trip}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID, dữ liệu đầu vào, thời gian hoặc xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy chuyến đi, nhà xe hoặc xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Thay đổi bị khóa vì chuyến đi đang có booking (trip_fares_locked, trip_times_locked, trip_has_bookings) hoặc nhà xe đã ngừng hoạt động",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Chỉ xóa được chuyến đi chưa có booking nào, kể cả booking đã hủy. Chuyến sinh từ lịch chạy sẽ không được sinh lại vào ngày đó.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Trips"
+                ],
+                "summary": "Xóa chuyến đi",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của chuyến đi",
+                        "name": "tripId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Xóa chuyến đi thành công",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ID chuyến đi không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy chuyến đi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Chuyến đi đã có booking",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vehicles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Quản trị viên có thể lọc theo nhà xe; nhân viên nhà xe luôn chỉ thấy xe của nhà xe mình.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Vehicles"
+                ],
+                "summary": "Danh sách xe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "Lọc theo ID nhà xe",
+                        "name": "companyId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Body: {thông báo: string, dữ_liệu: []models.Vehicle}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID nhà xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Dành cho quản trị viên và nhân viên nhà xe (chỉ với nhà xe của mình). Số ô \"seat\" trong sơ đồ ghế phải bằng totalSeats và mã ghế phải duy nhất.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Vehicles"
+                ],
+                "summary": "Tạo xe",
+                "parameters": [
+                    {
+                        "description": "Thông tin xe và sơ đồ ghế",
+                        "name": "vehicle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.VehicleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Xe đã tạo. Body: {thông báo: string, dữ_liệu: models.Vehicle}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào hoặc sơ đồ ghế không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy nhà xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Nhà xe đã ngừng hoạt động",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vehicles/{vehicleId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Trả về xe kèm sơ đồ ghế. Nhân viên nhà xe chỉ xem được xe của nhà xe mình.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Vehicles"
+                ],
+                "summary": "Chi tiết xe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của xe",
+                        "name": "vehicleId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Body: {thông báo: string, dữ_liệu: models.Vehicle}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ghi đè toàn bộ thông tin xe. Không thể đổi sơ đồ ghế, số ghế hoặc nhà xe khi xe đã được gán cho chuyến đi.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Vehicles"
+                ],
+                "summary": "Cập nhật xe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của xe",
+                        "name": "vehicleId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Thông tin xe và sơ đồ ghế",
+                        "name": "vehicle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.VehicleInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Xe sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Vehicle}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID, dữ liệu đầu vào hoặc sơ đồ ghế không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không có quyền với nhà xe này",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy xe hoặc nhà xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Xe đang được sử dụng hoặc nhà xe đã ngừng hoạt động",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Chỉ xóa được xe chưa được gán cho chuyến đi nào.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin - Vehicles"
+                ],
+                "summary": "Xóa xe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của xe",
+                        "name": "vehicleId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Xóa xe thành công",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ID xe không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy xe",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Xe đang được sử dụng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Xác thực người dùng bằng email và mật khẩu, trả về access token JWT ngắn hạn và refresh token để làm mới.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Đăng nhập vào hệ thống",
+                "parameters": [
+                    {
+                        "description": "Thông tin đăng nhập",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.LoginInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Đăng nhập thành công, trả về token, refreshToken và expiresIn (giây)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Email hoặc mật khẩu không chính xác",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "Thu hồi phiên đăng nhập của refresh token; các access token của phiên này cũng bị từ chối ngay.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Đăng xuất",
+                "parameters": [
+                    {
+                        "description": "Refresh token của phiên cần đăng xuất",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.RefreshInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Đăng xuất thành công",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh token không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Thu hồi mọi phiên đăng nhập của người dùng hiện tại, kể cả phiên đang dùng.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Đăng xuất khỏi tất cả thiết bị",
+                "responses": {
+                    "200": {
+                        "description": "Trả về số phiên đã bị thu hồi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Đổi refresh token lấy cặp token mới. Refresh token cũ hết hiệu lực ngay; dùng lại token cũ sẽ thu hồi cả phiên đăng nhập.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Làm mới access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token hiện tại",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.RefreshInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trả về token, refreshToken và expiresIn (giây)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh token không hợp lệ, đã dùng lại, hoặc phiên đã bị thu hồi/hết hạn",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Tạo một tài khoản mới cho người dùng với email, số điện thoại, tên và mật khẩu.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Đăng ký tài khoản người dùng mới",
+                "parameters": [
+                    {
+                        "description": "Thông tin đăng ký của người dùng",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.RegisterInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Trả về thông tin người dùng đã tạo",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Email đã được sử dụng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Giữ chỗ cho người dùng đã đăng nhập. Yêu cầu token xác thực.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bookings"
+                ],
+                "summary": "Tạo một booking mới (Giữ chỗ)",
+                "parameters": [
+                    {
+                        "description": "Thông tin để giữ chỗ (tripId, seatNumbers, from/to khi đi một chặng)",
+                        "name": "booking",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.CreateBookingInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Giữ chỗ thành công. Body: {thông báo: string, dữ_liệu: models.Booking}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu đầu vào không hợp lệ hoặc lỗi xử lý khác",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Yêu cầu token xác thực hoặc không tìm thấy thông tin người dùng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy chuyến đi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Ghế đã được người khác chọn (Conflict)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ nội bộ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/my": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lấy danh sách tất cả các booking của người dùng đang đăng nhập. Yêu cầu token xác thực.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bookings"
+                ],
+                "summary": "Lấy lịch sử đặt vé của người dùng hiện tại",
+                "responses": {
+                    "200": {
+                        "description": "Lấy lịch sử booking thành công. Body: {thông báo: string, dữ_liệu: []models.Booking}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Yêu cầu token xác thực hoặc không thể xác định người dùng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ nội bộ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{bookingId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lấy thông tin chi tiết của một booking dựa trên ID. Yêu cầu token xác thực và booking phải thuộc về người dùng đang đăng nhập.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bookings"
+                ],
+                "summary": "Lấy chi tiết một booking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của Booking",
+                        "name": "bookingId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lấy chi tiết booking thành công. Body: {thông báo: string, dữ_liệu: models.Booking}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID booking không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Yêu cầu token xác thực hoặc không thể xác định người dùng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy booking hoặc không có quyền xem",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ nội bộ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{bookingId}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Hủy booking đang giữ chỗ hoặc đã thanh toán của người dùng, trả ghế lại cho chuyến đi. Booking đã thanh toán được hoàn tiền theo chính sách của nhà xe (mặc định: 100% nếu hủy trước 48 giờ, 50% nếu trước 6 giờ, sau đó 0%).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bookings"
+                ],
+                "summary": "Hủy một booking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của Booking",
+                        "name": "bookingId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Lý do hủy vé",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/services.CancelBookingInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Hủy vé thành công. Body: {thông báo: string, dữ_liệu: services.CancelBookingResult}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID booking không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Yêu cầu token xác thực hoặc không thể xác định người dùng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy booking hoặc không có quyền xem",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Booking không thể hủy hoặc chuyến đi đã khởi hành",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ nội bộ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{bookingId}/passengers": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cập nhật họ tên và số điện thoại cho từng ghế. Ghế không được gửi lên hoặc để trống tên sẽ mặc định là chủ tài khoản. Chỉ được phép khi booking đang giữ chỗ hoặc đã xác nhận và chuyến đi chưa khởi hành.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bookings"
+                ],
+                "summary": "Cập nhật thông tin hành khách của booking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của Booking",
+                        "name": "bookingId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Danh sách hành khách theo ghế",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UpdatePassengersInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cập nhật thành công. Body: {thông báo: string, dữ_liệu: models.Booking}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Dữ liệu hành khách không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Yêu cầu token xác thực hoặc không thể xác định người dùng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy booking hoặc không có quyền xem",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Booking không còn cho phép cập nhật hành khách",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ nội bộ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{bookingId}/pay": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Thu tiền qua cổng thanh toán cho booking đang ở trạng thái \"held\". Thành công thì booking chuyển sang \"confirmed\" và ghế chuyển sang \"booked\".",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payments"
+                ],
+                "summary": "Thanh toán một booking đang giữ chỗ",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của Booking",
+                        "name": "bookingId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Thanh toán thành công. Body: {thông báo: string, dữ_liệu: models.Payment}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID booking không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Yêu cầu token xác thực hoặc không thể xác định người dùng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "402": {
+                        "description": "Cổng thanh toán từ chối giao dịch",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy booking hoặc không có quyền xem",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Booking không còn ở trạng thái chờ thanh toán",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Chưa có họ tên hành khách cho tất cả các ghế",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Cổng thanh toán không phản hồi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{bookingId}/ticket.pdf": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Vé gồm tuyến đường, giờ khởi hành, ghế, hành khách, nhà xe và mã QR đã ký (chứa mã vé và ID booking). Chỉ có khi booking đã được xác nhận.",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Tải vé điện tử dạng PDF",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của Booking",
+                        "name": "bookingId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File PDF của vé",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "ID booking không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy booking hoặc không có quyền xem",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Vé chưa được phát hành",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bookings/{bookingId}/ticket.png": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cùng nội dung với bản PDF, phù hợp để lưu vào điện thoại.",
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Tải vé điện tử dạng ảnh PNG",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "ObjectID",
+                        "description": "ID của Booking",
+                        "name": "bookingId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ảnh PNG của vé",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "ID booking không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy booking hoặc không có quyền xem",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Vé chưa được phát hành",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/suggest": {
+            "get": {
+                "description": "Gợi ý địa điểm cho ô điểm đi/điểm đến. Không phân biệt hoa thường và dấu, khớp theo tiền tố của bất kỳ từ nào trong tên, tên gọi khác hoặc slug (ví dụ \"ho chi\", \"tp hcm\", \"sai gon\").",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Locations"
+                ],
+                "summary": "Gợi ý địa điểm",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chuỗi người dùng đang gõ",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Số gợi ý tối đa (1-20, mặc định 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Body: {thông báo: string, dữ_liệu: []models.Location}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/payments/webhook": {
+            "post": {
+                "description": "Xác thực chữ ký HMAC-SHA256 trong header X-Webhook-Signature (\"sha256=\u003chex\u003e\") và áp dụng sự kiện thanh toán. Mỗi sự kiện chỉ được xử lý một lần theo mã sự kiện.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payments"
+                ],
+                "summary": "Nhận webhook từ cổng thanh toán",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chữ ký HMAC-SHA256 của body",
+                        "name": "X-Webhook-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Sự kiện thanh toán",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payments.WebhookEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Đã xử lý sự kiện (hoặc sự kiện đã được xử lý trước đó)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Nội dung webhook không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chữ ký không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy giao dịch",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Sự kiện đang được xử lý",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ nội bộ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{code}/check-in": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Nhân viên nhà xe (chỉ với chuyến của nhà xe mình) hoặc quản trị viên xác nhận hành khách đã lên xe; booking chuyển từ \"confirmed\" sang \"checked_in\".",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Soát vé lên xe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Mã vé hoặc nội dung mã QR trên vé",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Soát vé thành công. Body: {thông báo: string, dữ_liệu: services.TicketVerification}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Mã vé sai định dạng",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Chưa xác thực",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Không phải nhân viên nhà xe/quản trị viên, hoặc vé thuộc nhà xe khác",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy vé",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Vé không ở trạng thái có thể soát (chưa thanh toán, đã soát, đã hủy...)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{code}/verify": {
+            "get": {
+                "description": "Dành cho nhân viên nhà xe kiểm tra vé mà không cần token của hành khách. Chỉ trả về chuyến đi, ghế và trạng thái, không có thông tin cá nhân.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Kiểm tra vé theo mã vé",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Mã vé (ví dụ: 8Q5-B8M-B2E) hoặc nội dung mã QR trên vé",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Kết quả kiểm tra. Body: {thông báo: string, dữ_liệu: services.TicketVerification}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Mã vé sai định dạng hoặc sai chữ số kiểm tra",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy vé",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ nội bộ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trips": {
+            "get": {
+                "description": "Tìm kiếm chuyến đi với bộ lọc, sắp xếp và phân trang theo cursor; mọi tham số đều tùy chọn. Điểm đi và điểm đến có thể là điểm dừng bất kỳ trên tuyến (đúng chiều); khi có, kết quả kèm segmentInfo với giá và giờ của chặng. Ngày, khung giờ, giá và số ghế trống đều tính theo chặng đã chọn; ngày và giờ theo múi giờ vận hành (mặc định Asia/Ho_Chi_Minh) và chuyến đã qua giờ lên xe bị bỏ qua. facets đếm số chuyến theo nhà xe và loại xe. Số ghế trống được tính trong cơ sở dữ liệu; kết quả không kèm danh sách ghế (xem GET /trips/{tripId}).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trips"
+                ],
+                "summary": "Tìm kiếm chuyến đi",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tên điểm đi (Ví dụ: 'TP. Hồ Chí Minh')",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tên điểm đến (Ví dụ: 'Đà Lạt')",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ngày đi theo định dạng YYYY-MM-DD (Ví dụ: '2024-05-25')",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "ID nhà xe; lặp lại tham số hoặc phân tách bằng dấu phẩy",
+                        "name": "companyId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Loại xe",
+                        "name": "vehicleType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Giá vé tối thiểu",
+                        "name": "minPrice",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Giá vé tối đa",
+                        "name": "maxPrice",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Giờ lên xe sớm nhất, dạng HH:MM",
+                        "name": "departAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Giờ lên xe muộn nhất, dạng HH:MM",
+                        "name": "departBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Số ghế trống tối thiểu",
+                        "name": "minSeats",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "seat",
+                            "bed",
+                            "vip"
+                        ],
+                        "type": "string",
+                        "description": "Hạng ghế cần còn trống",
+                        "name": "seatType",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "departure",
+                            "price",
+                            "duration",
+                            "rating"
+                        ],
+                        "type": "string",
+                        "description": "Cách sắp xếp (mặc định departure)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor trang tiếp theo (nextCursor của lần gọi trước)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Số chuyến mỗi trang (mặc định 20, tối đa 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Bao gồm cả chuyến có giờ lên xe đã qua",
+                        "name": "includePast",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Danh sách chuyến đi, nextCursor và facets",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Tham số truy vấn hoặc cursor không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trips/calendar": {
+            "get": {
+                "description": "Với chặng from → to, trả về cho từng ngày trong khoảng [start, start+days) giá vé thấp nhất trong các chuyến còn ghế (minPrice, null nếu không còn chuyến bán được), số chuyến và tổng số ghế trống. Ngày tính theo giờ xe tới điểm lên, theo múi giờ vận hành.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trips"
+                ],
+                "summary": "Lịch giá vé theo ngày",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tên điểm đi",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tên điểm đến",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ngày bắt đầu YYYY-MM-DD (mặc định hôm nay)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Số ngày (mặc định 7, tối đa 31)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Thống kê theo từng ngày",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Tham số truy vấn không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Lỗi máy chủ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trips/{tripId}": {
+            "get": {
+                "description": "Lấy toàn bộ thông tin chi tiết của một chuyến đi, bao gồm cả sơ đồ ghế. Khi có from và to, trạng thái ghế, số ghế trống và segmentInfo được tính cho chặng đó.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trips"
+                ],
+                "summary": "Lấy thông tin chi tiết một chuyến đi",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID của chuyến đi",
+                        "name": "tripId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Điểm lên xe (tên điểm dừng)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Điểm xuống xe (tên điểm dừng)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Thông tin chi tiết của chuyến đi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "ID chuyến đi không hợp lệ hoặc chuyến không bán vé chặng đã chọn",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy chuyến đi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trips/{tripId}/seats/stream": {
+            "get": {
+                "description": "Luồng Server-Sent Events: mỗi sự kiện \"seat\" chứa tripId, seatNumbers, status mới (\"held\", \"booked\" hoặc \"available\" khi ghế được trả lại) và segment (chặng bị ảnh hưởng, không có nghĩa là cả chuyến). Khi luồng bị đóng, client nên tải lại chi tiết chuyến đi rồi kết nối lại.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Trips"
+                ],
+                "summary": "Theo dõi trạng thái ghế theo thời gian thực",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID của chuyến đi",
+                        "name": "tripId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Luồng sự kiện",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "ID chuyến đi không hợp lệ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Không tìm thấy chuyến đi",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
             }
         }
     },
     "definitions": {
+        "models.CellKind": {
+            "type": "string",
+            "enum": [
+                "seat",
+                "aisle",
+                "disabled"
+            ],
+            "x-enum-comments": {
+                "CellAisle": "lối đi",
+                "CellDisabled": "vị trí có ghế nhưng không bán (ghế hỏng, ghế phụ xe...)",
+                "CellSeat": "ghế bán được"
+            },
+            "x-enum-varnames": [
+                "CellSeat",
+                "CellAisle",
+                "CellDisabled"
+            ]
+        },
+        "models.Deck": {
+            "type": "object",
+            "properties": {
+                "cells": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SeatCell"
+                    }
+                },
+                "columns": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "rows": {
+                    "type": "integer",
+                    "maximum": 30,
+                    "minimum": 1
+                }
+            }
+        },
+        "models.Fare": {
+            "type": "object",
+            "required": [
+                "from",
+                "price",
+                "to"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "price": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "models.GeoPoint": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90
+                },
+                "lng": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180
+                }
+            }
+        },
+        "models.LocationPoint": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "locationId": {
+                    "description": "LocationID trỏ tới địa điểm trong danh mục; nil với tên nhập tự do không khớp địa điểm nào.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "models.RefundPolicy": {
+            "type": "object",
+            "required": [
+                "tiers"
+            ],
+            "properties": {
+                "tiers": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.RefundTier"
+                    }
+                }
+            }
+        },
+        "models.RefundTier": {
+            "type": "object",
+            "properties": {
+                "minHoursBeforeDeparture": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "percent": {
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0
+                }
+            }
+        },
+        "models.Route": {
+            "type": "object",
+            "required": [
+                "from",
+                "to"
+            ],
+            "properties": {
+                "from": {
+                    "$ref": "#/definitions/models.LocationPoint"
+                },
+                "stops": {
+                    "description": "Stops là các điểm dừng theo thứ tự, gồm cả điểm đầu và điểm cuối. Rỗng với tuyến chỉ có\nđiểm đi và điểm đến.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Stop"
+                    }
+                },
+                "to": {
+                    "$ref": "#/definitions/models.LocationPoint"
+                }
+            }
+        },
+        "models.SeatCell": {
+            "type": "object",
+            "required": [
+                "kind"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 10
+                },
+                "column": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "kind": {
+                    "enum": [
+                        "seat",
+                        "aisle",
+                        "disabled"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CellKind"
+                        }
+                    ]
+                },
+                "row": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "seatType": {
+                    "enum": [
+                        "seat",
+                        "bed",
+                        "vip"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SeatType"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.SeatMap": {
+            "type": "object",
+            "required": [
+                "decks"
+            ],
+            "properties": {
+                "decks": {
+                    "type": "array",
+                    "maxItems": 2,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.Deck"
+                    }
+                }
+            }
+        },
+        "models.SeatType": {
+            "type": "string",
+            "enum": [
+                "seat",
+                "bed",
+                "vip"
+            ],
+            "x-enum-varnames": [
+                "SeatTypeSeat",
+                "SeatTypeBed",
+                "SeatTypeVIP"
+            ]
+        },
+        "models.Stop": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "locationId": {
+                    "description": "LocationID trỏ tới địa điểm trong danh mục, giống LocationPoint.LocationID.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "offsetMinutes": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "payments.WebhookEvent": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "intentId": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.CancelBookingInput": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "services.CompanyInput": {
+            "type": "object",
+            "required": [
+                "code",
+                "name"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 10,
+                    "minLength": 2
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "logoUrl": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "rating": {
+                    "type": "number",
+                    "maximum": 5,
+                    "minimum": 0
+                },
+                "refundPolicy": {
+                    "$ref": "#/definitions/models.RefundPolicy"
+                }
+            }
+        },
         "services.CreateBookingInput": {
             "type": "object",
             "required": [
@@ -452,6 +3220,10 @@ const docTemplate = `{
                 "tripId"
             ],
             "properties": {
+                "from": {
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "seatNumbers": {
                     "type": "array",
                     "minItems": 1,
@@ -459,11 +3231,46 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "to": {
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "tripId": {
                     "type": "string"
                 }
             }
         },
+        "services.LocationInput": {
+            "type": "object",
+            "required": [
+                "aliases",
+                "name"
+            ],
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "coordinates": {
+                    "$ref": "#/definitions/models.GeoPoint"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "province": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
         "services.LoginInput": {
             "type": "object",
             "required": [
@@ -479,6 +3286,34 @@ const docTemplate = `{
                 }
             }
         },
+        "services.PassengerInput": {
+            "type": "object",
+            "required": [
+                "seatNumber"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "seatNumber": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.RefreshInput": {
+            "type": "object",
+            "required": [
+                "refreshToken"
+            ],
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
         "services.RegisterInput": {
             "type": "object",
             "required": [
@@ -502,6 +3337,83 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "services.ScheduleInput": {
+            "type": "object"
+        },
+        "services.TripInput": {
+            "type": "object",
+            "required": [
+                "companyId",
+                "departureTime",
+                "expectedArrivalTime",
+                "price",
+                "route",
+                "vehicleId"
+            ],
+            "properties": {
+                "companyId": {
+                    "type": "string"
+                },
+                "departureTime": {
+                    "type": "string"
+                },
+                "expectedArrivalTime": {
+                    "type": "string"
+                },
+                "fares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Fare"
+                    }
+                },
+                "price": {
+                    "type": "number"
+                },
+                "route": {
+                    "$ref": "#/definitions/models.Route"
+                },
+                "vehicleId": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.UpdatePassengersInput": {
+            "type": "object",
+            "properties": {
+                "passengers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.PassengerInput"
+                    }
+                }
+            }
+        },
+        "services.VehicleInput": {
+            "type": "object",
+            "required": [
+                "companyId",
+                "seatMap",
+                "totalSeats",
+                "type"
+            ],
+            "properties": {
+                "companyId": {
+                    "type": "string"
+                },
+                "seatMap": {
+                    "$ref": "#/definitions/models.SeatMap"
+                },
+                "totalSeats": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "type": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
         }
     },
     "securityDefinitions": {
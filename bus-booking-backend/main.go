@@ -1,11 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/Go_final_exam/bus-booking-backend/docs"
 	"github.com/Go_final_exam/bus-booking-backend/src/config"
+	"github.com/Go_final_exam/bus-booking-backend/src/controllers"
+	"github.com/Go_final_exam/bus-booking-backend/src/middlewares"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+	"github.com/Go_final_exam/bus-booking-backend/src/realtime"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
 	"github.com/Go_final_exam/bus-booking-backend/src/routes"
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+	"github.com/Go_final_exam/bus-booking-backend/src/utils"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -34,6 +43,53 @@ func main() {
 
 	config.ConnectDB(cfg)
 
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := repositories.EnsureIndexes(indexCtx, config.DB); err != nil {
+		log.Fatalf("Không thể tạo chỉ mục MongoDB: %v", err)
+	}
+	cancelIndexes()
+
+	repos := repositories.NewMongoRepositories(config.DB)
+
+	mockOutcome, err := payments.ParseMockOutcome(cfg.MockPaymentOutcome)
+	if err != nil {
+		log.Fatalf("Cấu hình MOCK_PAYMENT_OUTCOME không hợp lệ: %v", err)
+	}
+	gateway := payments.NewMockGateway(mockOutcome, cfg.PaymentWebhookSecret)
+	if cfg.PaymentWebhookSecret == "" {
+		log.Println("Cảnh báo: PAYMENT_WEBHOOK_SECRET chưa được thiết lập, mọi webhook thanh toán sẽ bị từ chối.")
+	}
+
+	tokenIssuer := utils.NewTokenIssuer(cfg.JwtSecretKey, cfg.AccessTokenTTL)
+
+	// Các thay đổi trạng thái ghế được phát trong tiến trình tới luồng SSE của trang chi tiết chuyến đi.
+	seatEvents := realtime.NewMemorySeatEventBus(64)
+
+	authService := services.NewAuthService(repos.Users, repos.Sessions, tokenIssuer, cfg.RefreshTokenTTL)
+	authMiddleware := middlewares.AuthMiddleware(authService)
+	tripService := services.NewTripService(repos.Trips, repos.Companies, repos.Vehicles, repos.Bookings, repos.Locations, repos.Schedules, cfg.Timezone, seatEvents)
+	bookingService := services.NewBookingService(repos.Bookings, repos.Trips, repos.Users, repos.Payments, repos.Companies, gateway, cfg.HoldTTL, cfg.Timezone, seatEvents)
+	paymentService := services.NewPaymentService(repos.Bookings, repos.Trips, repos.Payments, repos.WebhookEvents, gateway, seatEvents)
+	ticketService := services.NewTicketService(repos.Bookings, repos.Trips, repos.Companies, []byte(cfg.TicketSigningSecret), cfg.Timezone)
+	companyService := services.NewCompanyService(repos.Companies)
+	locationService := services.NewLocationService(repos.Locations)
+	vehicleService := services.NewVehicleService(repos.Vehicles, repos.Companies, repos.Trips)
+	// Ngày và giờ trong lịch chạy được hiểu theo múi giờ vận hành (TIMEZONE).
+	scheduleService := services.NewScheduleService(repos.Schedules, repos.Trips, repos.Vehicles, repos.Companies, repos.Bookings, repos.Locations, cfg.Timezone, cfg.ScheduleHorizonDays)
+
+	authController := controllers.NewAuthController(authService)
+	tripController := controllers.NewTripController(tripService)
+	bookingController := controllers.NewBookingController(bookingService)
+	paymentController := controllers.NewPaymentController(paymentService)
+	ticketController := controllers.NewTicketController(ticketService)
+	companyController := controllers.NewCompanyController(companyService)
+	locationController := controllers.NewLocationController(locationService)
+	vehicleController := controllers.NewVehicleController(vehicleService)
+	scheduleController := controllers.NewScheduleController(scheduleService)
+
+	go bookingService.StartHoldExpiryWorker(context.Background(), cfg.HoldSweepInterval)
+	go scheduleService.StartGeneratorWorker(context.Background(), cfg.ScheduleGenerateInterval)
+
 	docs.SwaggerInfo.Title = "API Dịch vụ Đặt vé xe"
 	docs.SwaggerInfo.Description = "Đây là tài liệu API cho ứng dụng Backend đặt vé xe viết bằng Go."
 	docs.SwaggerInfo.Version = "1.0"
@@ -41,12 +97,15 @@ func main() {
 	docs.SwaggerInfo.BasePath = "/api/v1"
 	docs.SwaggerInfo.Schemes = []string{"http", "httpshttps"}
 
-	gin.SetMode(gin.ReleaseMode)
+	if !cfg.Debug {
+		gin.SetMode(gin.ReleaseMode)
+	}
 
 	router := gin.New()
 
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middlewares.ErrorHandler())
 
 	router.SetTrustedProxies([]string{"127.0.0.1"})
 
@@ -63,13 +122,19 @@ func main() {
 		c.Next()
 	})
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if cfg.SwaggerEnabled {
+		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
 
 	api := router.Group("/api/v1")
 	{
-		routes.AuthRoutes(api)
-		routes.TripRoutes(api)
-		routes.BookingRoutes(api)
+		routes.AuthRoutes(api, authMiddleware, authController)
+		routes.TripRoutes(api, tripController)
+		routes.LocationRoutes(api, locationController)
+		routes.BookingRoutes(api, authMiddleware, bookingController, paymentController, ticketController)
+		routes.PaymentRoutes(api, paymentController)
+		routes.TicketRoutes(api, authMiddleware, ticketController)
+		routes.AdminRoutes(api, authMiddleware, companyController, vehicleController, tripController, scheduleController, locationController)
 	}
 	
 	log.Printf("Server đang chạy trên cổng %s", cfg.Port)
@@ -0,0 +1,86 @@
+// Package apperrors định nghĩa các lỗi nghiệp vụ dùng chung giữa các tầng. Service bọc lỗi
+// bằng các nhóm lỗi (Kind) ở đây để controller/middleware phân loại bằng errors.Is/As thay vì
+// so khớp chuỗi thông báo.
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind là một nhóm lỗi (sentinel) gắn với mã HTTP và mã lỗi máy đọc được.
+type Kind struct {
+	Code    string
+	Status  int
+	message string
+}
+
+func (k *Kind) Error() string { return k.message }
+
+func newKind(code string, status int, message string) *Kind {
+	return &Kind{Code: code, Status: status, message: message}
+}
+
+var (
+	ErrInvalidInput    = newKind("invalid_input", http.StatusBadRequest, "dữ liệu đầu vào không hợp lệ")
+	ErrInvalidID       = newKind("invalid_id", http.StatusBadRequest, "ID không hợp lệ")
+	ErrUnauthorized    = newKind("unauthorized", http.StatusUnauthorized, "yêu cầu xác thực")
+	ErrPaymentRequired = newKind("payment_required", http.StatusPaymentRequired, "thanh toán thất bại")
+	ErrForbidden       = newKind("forbidden", http.StatusForbidden, "không có quyền thực hiện thao tác này")
+	ErrNotFound        = newKind("not_found", http.StatusNotFound, "không tìm thấy dữ liệu")
+	ErrConflict        = newKind("conflict", http.StatusConflict, "xung đột trạng thái dữ liệu")
+	ErrSeatConflict    = newKind("seat_conflict", http.StatusConflict, "một hoặc nhiều ghế đã được người khác chọn")
+	ErrUnprocessable   = newKind("unprocessable", http.StatusUnprocessableEntity, "yêu cầu chưa thể xử lý")
+	ErrInternal        = newKind("internal_error", http.StatusInternalServerError, "lỗi máy chủ nội bộ")
+	ErrTimeout         = newKind("upstream_timeout", http.StatusGatewayTimeout, "dịch vụ bên ngoài không phản hồi")
+)
+
+// Error là lỗi nghiệp vụ cụ thể thuộc một Kind. Code mặc định là mã của Kind, có thể
+// được thay bằng mã chi tiết hơn (ví dụ "trip_departed") qua WithCode.
+type Error struct {
+	Kind    *Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap trả về cả Kind lẫn lỗi gốc để errors.Is/As hoạt động với cả hai.
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// New tạo lỗi nghiệp vụ thuộc nhóm kind với thông báo message.
+func New(kind *Kind, message string) *Error {
+	return &Error{Kind: kind, Code: kind.Code, Message: message}
+}
+
+// Wrap giống New nhưng giữ lại lỗi gốc để ghi log và so khớp bằng errors.Is.
+func Wrap(kind *Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Code: kind.Code, Message: message, Err: err}
+}
+
+// WithCode trả về bản sao của lỗi với mã lỗi máy đọc được khác.
+func (e *Error) WithCode(code string) *Error {
+	cp := *e
+	cp.Code = code
+	return &cp
+}
+
+// Classify trả về mã HTTP và mã lỗi máy đọc được cho err. Lỗi không thuộc nhóm nào
+// được xem là lỗi máy chủ nội bộ.
+func Classify(err error) (status int, code string) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind.Status, appErr.Code
+	}
+	var kind *Kind
+	if errors.As(err, &kind) {
+		return kind.Status, kind.Code
+	}
+	return ErrInternal.Status, ErrInternal.Code
+}
@@ -2,47 +2,230 @@ package config
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
+	// Nhúng cơ sở dữ liệu múi giờ để TIMEZONE dùng được cả trên máy không cài tzdata.
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Config là cấu hình đã được kiểm tra của ứng dụng. Cấu hình chỉ được đọc một lần
+// khi khởi động trong main.go rồi truyền xuống các thành phần cần dùng.
 type Config struct {
-	Port                string
-	MongoURI            string
-	MongoDatabaseName   string
-	JwtSecretKey        string
-	JwtExpirationInHours string
+	Port              string
+	MongoURI          string
+	MongoDatabaseName string
+
+	JwtSecretKey string
+	// AccessTokenTTL là thời hạn của access token; nên ngắn và được làm mới bằng refresh token.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL là thời hạn của refresh token, được gia hạn sau mỗi lần làm mới.
+	RefreshTokenTTL time.Duration
+
+	// HoldTTL là thời gian giữ ghế trước khi booking chưa thanh toán bị hủy.
+	HoldTTL time.Duration
+	// HoldSweepInterval là chu kỳ quét các booking giữ chỗ đã hết hạn.
+	HoldSweepInterval time.Duration
+
+	// ScheduleHorizonDays là số ngày (tính cả hôm nay) được sinh chuyến đi trước từ lịch chạy.
+	ScheduleHorizonDays int
+	// ScheduleGenerateInterval là chu kỳ chạy bộ sinh chuyến đi từ lịch chạy.
+	ScheduleGenerateInterval time.Duration
+
+	// Timezone là múi giờ vận hành: ngày tìm kiếm, giờ trong lịch chạy, giờ in trên vé và giờ
+	// trả về trong API đều theo múi giờ này.
+	Timezone *time.Location
+
+	MockPaymentOutcome   string
+	PaymentWebhookSecret string
+	// TicketSigningSecret là khóa ký mã QR trên vé. Khi TICKET_SIGNING_SECRET không được thiết
+	// lập, khóa được dẫn xuất từ JWT_SECRET_KEY bằng HMAC với nhãn ticketSigningKeyLabel để khóa
+	// ký vé không trùng khóa ký token.
+	TicketSigningSecret string
+
+	// Debug bật chế độ debug của gin.
+	Debug bool
+	// SwaggerEnabled cho phép phục vụ tài liệu API tại /swagger.
+	SwaggerEnabled bool
 }
 
+// Giá trị mặc định khi biến môi trường tương ứng không được thiết lập.
+const (
+	defaultAccessTokenTTL           = 15 * time.Minute
+	defaultRefreshTokenTTL          = 30 * 24 * time.Hour
+	defaultHoldTTLMinutes           = 15
+	defaultHoldSweepInterval        = 30 * time.Second
+	defaultMockPaymentOutcome       = "success"
+	defaultScheduleHorizonDays      = 14
+	defaultScheduleGenerateInterval = time.Hour
+	defaultTimezone                 = "Asia/Ho_Chi_Minh"
+)
+
+// ticketSigningKeyLabel là nhãn cố định khi dẫn xuất khóa ký vé từ JWT_SECRET_KEY. Đổi nhãn này
+// làm mọi mã QR đã phát hành không còn hợp lệ.
+const ticketSigningKeyLabel = "bus-booking/ticket-signing/v1"
+
 var DB *mongo.Database
 
+// LoadConfig đọc biến môi trường (và file .env nếu có), áp dụng giá trị mặc định
+// và kiểm tra toàn bộ các trường. Mọi trường không hợp lệ được gộp vào một lỗi duy nhất.
 func LoadConfig() (Config, error) {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("Cảnh báo: Không tìm thấy file .env, đang sử dụng biến môi trường hệ thống.")
 	}
 
+	l := &loader{}
 	cfg := Config{
-		Port:                os.Getenv("PORT"),
-		MongoURI:            os.Getenv("MONGODB_URI"),
-		MongoDatabaseName:   os.Getenv("MONGODB_DATABASE_NAME"),
-		JwtSecretKey:        os.Getenv("JWT_SECRET_KEY"),
-		JwtExpirationInHours: os.Getenv("JWT_EXPIRATION_HOURS"),
+		Port:                     l.requiredString("PORT"),
+		MongoURI:                 l.requiredString("MONGODB_URI"),
+		MongoDatabaseName:        l.requiredString("MONGODB_DATABASE_NAME"),
+		JwtSecretKey:             l.requiredString("JWT_SECRET_KEY"),
+		AccessTokenTTL:           l.accessTokenTTL(),
+		RefreshTokenTTL:          l.positiveDuration("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL),
+		HoldTTL:                  time.Duration(l.positiveInt("HOLD_TTL_MINUTES", defaultHoldTTLMinutes)) * time.Minute,
+		HoldSweepInterval:        l.positiveDuration("HOLD_SWEEP_INTERVAL", defaultHoldSweepInterval),
+		ScheduleHorizonDays:      l.positiveInt("SCHEDULE_HORIZON_DAYS", defaultScheduleHorizonDays),
+		ScheduleGenerateInterval: l.positiveDuration("SCHEDULE_GENERATE_INTERVAL", defaultScheduleGenerateInterval),
+		Timezone:                 l.location("TIMEZONE", defaultTimezone),
+		MockPaymentOutcome:       l.oneOf("MOCK_PAYMENT_OUTCOME", defaultMockPaymentOutcome, "success", "failure", "timeout"),
+		PaymentWebhookSecret:     os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+		TicketSigningSecret:      os.Getenv("TICKET_SIGNING_SECRET"),
+		Debug:                    l.bool("DEBUG", false),
+		SwaggerEnabled:           l.bool("SWAGGER_ENABLED", true),
+	}
+
+	if cfg.TicketSigningSecret == "" && cfg.JwtSecretKey != "" {
+		log.Println("Cảnh báo: TICKET_SIGNING_SECRET chưa được thiết lập, khóa ký vé được dẫn xuất từ JWT_SECRET_KEY. Hãy đặt một khóa riêng cho môi trường production.")
+		cfg.TicketSigningSecret = deriveKey(cfg.JwtSecretKey, ticketSigningKeyLabel)
+	}
+
+	if cfg.AccessTokenTTL >= cfg.RefreshTokenTTL {
+		l.fail("ACCESS_TOKEN_TTL", "phải ngắn hơn REFRESH_TOKEN_TTL (%s)", cfg.RefreshTokenTTL)
 	}
 
-	if cfg.Port == "" || cfg.MongoURI == "" || cfg.MongoDatabaseName == "" || cfg.JwtSecretKey == "" {
-		return Config{}, fmt.Errorf("lỗi: một hoặc nhiều biến môi trường quan trọng chưa được thiết lập")
+	if len(l.errs) > 0 {
+		return Config{}, fmt.Errorf("cấu hình không hợp lệ:\n%w", errors.Join(l.errs...))
 	}
 
 	return cfg, nil
 }
 
+// deriveKey dẫn xuất một khóa riêng cho mục đích label từ secret bằng HMAC-SHA256.
+func deriveKey(secret, label string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(label))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loader đọc từng biến môi trường và ghi lại lỗi thay vì dừng ở lỗi đầu tiên.
+type loader struct {
+	errs []error
+}
+
+func (l *loader) fail(key, format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf("- %s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (l *loader) requiredString(key string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		l.fail(key, "bắt buộc nhưng chưa được thiết lập")
+	}
+	return v
+}
+
+func (l *loader) positiveInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		l.fail(key, "phải là số nguyên dương, nhận được '%s'", v)
+		return def
+	}
+	return n
+}
+
+func (l *loader) positiveDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		l.fail(key, "phải là khoảng thời gian dương (ví dụ 30s, 5m), nhận được '%s'", v)
+		return def
+	}
+	return d
+}
+
+// accessTokenTTL đọc ACCESS_TOKEN_TTL. Biến cũ JWT_EXPIRATION_HOURS (số giờ) vẫn được nhận khi
+// ACCESS_TOKEN_TTL chưa được thiết lập nhưng sẽ bị bỏ trong phiên bản sau.
+func (l *loader) accessTokenTTL() time.Duration {
+	legacy := os.Getenv("JWT_EXPIRATION_HOURS")
+	if legacy == "" {
+		return l.positiveDuration("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+	}
+	if os.Getenv("ACCESS_TOKEN_TTL") != "" {
+		log.Println("Cảnh báo: JWT_EXPIRATION_HOURS đã lỗi thời và bị bỏ qua vì ACCESS_TOKEN_TTL đã được thiết lập.")
+		return l.positiveDuration("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+	}
+	log.Println("Cảnh báo: JWT_EXPIRATION_HOURS đã lỗi thời, hãy dùng ACCESS_TOKEN_TTL (ví dụ 15m).")
+	return time.Duration(l.positiveInt("JWT_EXPIRATION_HOURS", 0)) * time.Hour
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.fail(key, "phải là true hoặc false, nhận được '%s'", v)
+		return def
+	}
+	return b
+}
+
+func (l *loader) location(key, def string) *time.Location {
+	v := os.Getenv(key)
+	if v == "" {
+		v = def
+	}
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		l.fail(key, "phải là tên múi giờ IANA (ví dụ Asia/Ho_Chi_Minh), nhận được '%s'", v)
+		return time.UTC
+	}
+	return loc
+}
+
+func (l *loader) oneOf(key, def string, allowed ...string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	l.fail(key, "phải là một trong %v, nhận được '%s'", allowed, v)
+	return def
+}
+
 func ConnectDB(cfg Config) {
 	clientOptions := options.Client().ApplyURI(cfg.MongoURI)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -60,4 +243,4 @@ func ConnectDB(cfg Config) {
 
 	log.Println("Đã kết nối thành công đến MongoDB!")
 	DB = client.Database(cfg.MongoDatabaseName)
-}
\ No newline at end of file
+}
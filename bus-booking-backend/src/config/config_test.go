@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// setRequiredEnv thiết lập các biến bắt buộc và xóa các biến mà kiểm thử so sánh.
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("PORT", "8080")
+	t.Setenv("MONGODB_URI", "mongodb://localhost:27017")
+	t.Setenv("MONGODB_DATABASE_NAME", "bus_booking_test")
+	t.Setenv("JWT_SECRET_KEY", "jwt-secret")
+	for _, key := range []string{"ACCESS_TOKEN_TTL", "JWT_EXPIRATION_HOURS", "TICKET_SIGNING_SECRET"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadConfigAccessTokenTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr string
+	}{
+		{name: "mặc định", want: defaultAccessTokenTTL},
+		{name: "ACCESS_TOKEN_TTL", env: map[string]string{"ACCESS_TOKEN_TTL": "10m"}, want: 10 * time.Minute},
+		{name: "biến cũ JWT_EXPIRATION_HOURS", env: map[string]string{"JWT_EXPIRATION_HOURS": "2"}, want: 2 * time.Hour},
+		{name: "ACCESS_TOKEN_TTL thắng biến cũ", env: map[string]string{"ACCESS_TOKEN_TTL": "10m", "JWT_EXPIRATION_HOURS": "2"}, want: 10 * time.Minute},
+		{name: "biến cũ không hợp lệ", env: map[string]string{"JWT_EXPIRATION_HOURS": "abc"}, wantErr: "JWT_EXPIRATION_HOURS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := LoadConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig: err = %v, muốn lỗi về %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.AccessTokenTTL != tt.want {
+				t.Fatalf("AccessTokenTTL = %s, muốn %s", cfg.AccessTokenTTL, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTicketSigningSecret(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.TicketSigningSecret == "" || cfg.TicketSigningSecret == cfg.JwtSecretKey {
+		t.Fatalf("TicketSigningSecret = %q, muốn khóa dẫn xuất khác JWT_SECRET_KEY", cfg.TicketSigningSecret)
+	}
+	// Khóa dẫn xuất phải ổn định giữa các lần khởi động để mã QR đã phát hành vẫn hợp lệ.
+	again, err := LoadConfig()
+	if err != nil || again.TicketSigningSecret != cfg.TicketSigningSecret {
+		t.Fatalf("lần đọc thứ hai: %q, err = %v; muốn cùng khóa %q", again.TicketSigningSecret, err, cfg.TicketSigningSecret)
+	}
+
+	t.Setenv("TICKET_SIGNING_SECRET", "ticket-secret")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.TicketSigningSecret != "ticket-secret" {
+		t.Fatalf("TicketSigningSecret = %q, muốn giá trị của TICKET_SIGNING_SECRET", cfg.TicketSigningSecret)
+	}
+}
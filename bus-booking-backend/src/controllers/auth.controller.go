@@ -11,6 +11,16 @@ import (
 
 var validate = validator.New()
 
+// AuthController xử lý các endpoint đăng ký, đăng nhập và quản lý phiên đăng nhập.
+type AuthController struct {
+	auth *services.AuthService
+}
+
+func NewAuthController(auth *services.AuthService) *AuthController {
+	return &AuthController{auth: auth}
+}
+
+
 
 // @Summary Đăng ký tài khoản người dùng mới
 // @Description Tạo một tài khoản mới cho người dùng với email, số điện thoại, tên và mật khẩu.
@@ -22,22 +32,17 @@ var validate = validator.New()
 // @Failure 400 {object} map[string]string "Dữ liệu đầu vào không hợp lệ"
 // @Failure 409 {object} map[string]string "Email đã được sử dụng"
 // @Router /auth/register [post]
-func RegisterController(c *gin.Context) {
+func (ctl *AuthController) Register(c *gin.Context) {
 	var input services.RegisterInput
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": "Dữ liệu đầu vào không hợp lệ"})
-		return
-	}
-
-	if err := validate.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": err.Error()})
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
-	user, err := services.Register(input)
+	user, err := ctl.auth.Register(input)
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"lỗi": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -48,42 +53,124 @@ func RegisterController(c *gin.Context) {
 			"name":      user.Name,
 			"email":     user.Email,
 			"phone":     user.Phone,
+			"role":      user.Role,
 			"createdAt": user.CreatedAt,
 		},
 	})
 }
 
 // @Summary Đăng nhập vào hệ thống
-// @Description Xác thực người dùng bằng email và mật khẩu, trả về một token JWT.
+// @Description Xác thực người dùng bằng email và mật khẩu, trả về access token JWT ngắn hạn và refresh token để làm mới.
 // @Tags Authentication
 // @Accept  json
 // @Produce  json
 // @Param   credentials body services.LoginInput true "Thông tin đăng nhập"
-// @Success 200 {object} map[string]string "Đăng nhập thành công, trả về token"
+// @Success 200 {object} map[string]interface{} "Đăng nhập thành công, trả về token, refreshToken và expiresIn (giây)"
 // @Failure 400 {object} map[string]string "Dữ liệu đầu vào không hợp lệ"
 // @Failure 401 {object} map[string]string "Email hoặc mật khẩu không chính xác"
 // @Router /auth/login [post]
-func LoginController(c *gin.Context) {
+func (ctl *AuthController) Login(c *gin.Context) {
 	var input services.LoginInput
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": "Dữ liệu đầu vào không hợp lệ"})
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokens, err := ctl.auth.Login(input, services.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := validate.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": err.Error()})
+	c.JSON(http.StatusOK, tokenResponse("Đăng nhập thành công!", tokens))
+}
+
+// @Summary Làm mới access token
+// @Description Đổi refresh token lấy cặp token mới. Refresh token cũ hết hiệu lực ngay; dùng lại token cũ sẽ thu hồi cả phiên đăng nhập.
+// @Tags Authentication
+// @Accept  json
+// @Produce  json
+// @Param   body body services.RefreshInput true "Refresh token hiện tại"
+// @Success 200 {object} map[string]interface{} "Trả về token, refreshToken và expiresIn (giây)"
+// @Failure 400 {object} map[string]string "Dữ liệu đầu vào không hợp lệ"
+// @Failure 401 {object} map[string]string "Refresh token không hợp lệ, đã dùng lại, hoặc phiên đã bị thu hồi/hết hạn"
+// @Router /auth/refresh [post]
+func (ctl *AuthController) Refresh(c *gin.Context) {
+	var input services.RefreshInput
+
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
-	token, err := services.Login(input)
+	tokens, err := ctl.auth.Refresh(input)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"lỗi": err.Error()})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse("Làm mới phiên đăng nhập thành công!", tokens))
+}
+
+// @Summary Đăng xuất
+// @Description Thu hồi phiên đăng nhập của refresh token; các access token của phiên này cũng bị từ chối ngay.
+// @Tags Authentication
+// @Accept  json
+// @Produce  json
+// @Param   body body services.RefreshInput true "Refresh token của phiên cần đăng xuất"
+// @Success 200 {object} map[string]string "Đăng xuất thành công"
+// @Failure 400 {object} map[string]string "Dữ liệu đầu vào không hợp lệ"
+// @Failure 401 {object} map[string]string "Refresh token không hợp lệ"
+// @Router /auth/logout [post]
+func (ctl *AuthController) Logout(c *gin.Context) {
+	var input services.RefreshInput
+
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctl.auth.Logout(input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"thông báo": "Đăng xuất thành công!"})
+}
+
+// @Summary Đăng xuất khỏi tất cả thiết bị
+// @Description Thu hồi mọi phiên đăng nhập của người dùng hiện tại, kể cả phiên đang dùng.
+// @Tags Authentication
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Trả về số phiên đã bị thu hồi"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Router /auth/logout-all [post]
+func (ctl *AuthController) LogoutAll(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	revoked, err := ctl.auth.LogoutAll(userID)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"thông báo": "Đăng nhập thành công!",
-		"token":     token,
+		"thông báo": "Đã đăng xuất khỏi tất cả thiết bị!",
+		"dữ_liệu":   gin.H{"revokedSessions": revoked},
 	})
+}
+
+func tokenResponse(message string, tokens *services.AuthTokens) gin.H {
+	return gin.H{
+		"thông báo":    message,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    int64(tokens.ExpiresIn.Seconds()),
+	}
 }
\ No newline at end of file
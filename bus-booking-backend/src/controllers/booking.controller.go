@@ -2,65 +2,51 @@ package controllers
 
 import (
 	"net/http"
-	"strings"
 
 	"github.com/Go_final_exam/bus-booking-backend/src/models"
 	"github.com/Go_final_exam/bus-booking-backend/src/services"
 	"github.com/gin-gonic/gin"
 )
 
+// BookingController xử lý các endpoint giữ chỗ, xem, hủy booking và cập nhật hành khách.
+type BookingController struct {
+	bookings *services.BookingService
+}
+
+func NewBookingController(bookings *services.BookingService) *BookingController {
+	return &BookingController{bookings: bookings}
+}
+
 // @Summary Tạo một booking mới (Giữ chỗ)
 // @Description Giữ chỗ cho người dùng đã đăng nhập. Yêu cầu token xác thực.
 // @Tags Bookings
 // @Accept  json
 // @Produce  json
 // @Security BearerAuth
-// @Param   booking body services.CreateBookingInput true "Thông tin để giữ chỗ (tripId, seatNumbers)"
+// @Param   booking body services.CreateBookingInput true "Thông tin để giữ chỗ (tripId, seatNumbers, from/to khi đi một chặng)"
 // @Success 201 {object} map[string]interface{} "Giữ chỗ thành công. Body: {thông báo: string, dữ_liệu: models.Booking}"
 // @Failure 400 {object} map[string]string "Dữ liệu đầu vào không hợp lệ hoặc lỗi xử lý khác"
 // @Failure 401 {object} map[string]string "Yêu cầu token xác thực hoặc không tìm thấy thông tin người dùng"
 // @Failure 404 {object} map[string]string "Không tìm thấy chuyến đi"
 // @Failure 409 {object} map[string]string "Ghế đã được người khác chọn (Conflict)"
-// @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ (ví dụ: lỗi định dạng ID người dùng)"
+// @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ"
 // @Router /bookings [post]
-func CreateBookingController(c *gin.Context) {
+func (ctl *BookingController) CreateBooking(c *gin.Context) {
 	var input services.CreateBookingInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": "Dữ liệu đầu vào không hợp lệ: " + err.Error()})
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := validate.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": "Lỗi xác thực dữ liệu: " + err.Error()})
-		return
-	}
-
-	userID, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Không tìm thấy thông tin người dùng. Vui lòng đăng nhập lại."})
-		return
-	}
-	userIDStr, ok := userID.(string)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Lỗi định dạng ID người dùng từ token."})
+	userIDStr, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	booking, err := services.CreateBooking(input, userIDStr)
+	booking, err := ctl.bookings.CreateBooking(input, userIDStr)
 	if err != nil {
-		if strings.Contains(err.Error(), "không tìm thấy chuyến đi") {
-			c.JSON(http.StatusNotFound, gin.H{"lỗi": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "một hoặc nhiều ghế đã được người khác chọn") {
-			c.JSON(http.StatusConflict, gin.H{"lỗi": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "ID chuyến đi không hợp lệ") || strings.Contains(err.Error(), "ID người dùng không hợp lệ"){
-			 c.JSON(http.StatusBadRequest, gin.H{"lỗi": err.Error()})
-             return
-		}
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": "Không thể xử lý yêu cầu giữ chỗ: " + err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -82,26 +68,17 @@ func CreateBookingController(c *gin.Context) {
 // @Failure 404 {object} map[string]string "Không tìm thấy booking hoặc không có quyền xem"
 // @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ"
 // @Router /bookings/{bookingId} [get]
-func GetBookingDetailsController(c *gin.Context) {
+func (ctl *BookingController) GetBookingDetails(c *gin.Context) {
 	bookingIDStr := c.Param("bookingId")
-	userID, _ := c.Get("userId")
-	userIDStr, ok := userID.(string)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Không thể xác định người dùng."})
+	userIDStr, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	booking, err := services.GetBookingDetailsByID(bookingIDStr, userIDStr)
+	booking, err := ctl.bookings.GetBookingDetailsByID(bookingIDStr, userIDStr)
 	if err != nil {
-		if strings.Contains(err.Error(), "không tìm thấy booking") || strings.Contains(err.Error(), "không có quyền xem") {
-			c.JSON(http.StatusNotFound, gin.H{"lỗi": err.Error()})
-			return
-		}
-		if strings.Contains(err.Error(), "ID booking không hợp lệ") || strings.Contains(err.Error(), "ID người dùng không hợp lệ") {
-			c.JSON(http.StatusBadRequest, gin.H{"lỗi": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"lỗi": "Lỗi máy chủ khi lấy chi tiết booking."})
+		c.Error(err)
 		return
 	}
 
@@ -120,21 +97,16 @@ func GetBookingDetailsController(c *gin.Context) {
 // @Failure 401 {object} map[string]string "Yêu cầu token xác thực hoặc không thể xác định người dùng"
 // @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ"
 // @Router /bookings/my [get]
-func GetMyBookingsController(c *gin.Context) {
-	userID, _ := c.Get("userId")
-	userIDStr, ok := userID.(string)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Không thể xác định người dùng."})
+func (ctl *BookingController) GetMyBookings(c *gin.Context) {
+	userIDStr, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	bookings, err := services.GetBookingsByUserID(userIDStr)
+	bookings, err := ctl.bookings.GetBookingsByUserID(userIDStr)
 	if err != nil {
-		if strings.Contains(err.Error(), "ID người dùng không hợp lệ") {
-             c.JSON(http.StatusUnauthorized, gin.H{"lỗi": err.Error()}) 
-             return
-        }
-		c.JSON(http.StatusInternalServerError, gin.H{"lỗi": "Lỗi máy chủ khi lấy lịch sử booking."})
+		c.Error(err)
 		return
 	}
 
@@ -146,4 +118,87 @@ func GetMyBookingsController(c *gin.Context) {
 		"thông báo": "Lấy lịch sử booking thành công!",
 		"dữ_liệu":   bookings, 
 	})
-}
\ No newline at end of file
+}
+// @Summary Hủy một booking
+// @Description Hủy booking đang giữ chỗ hoặc đã thanh toán của người dùng, trả ghế lại cho chuyến đi. Booking đã thanh toán được hoàn tiền theo chính sách của nhà xe (mặc định: 100% nếu hủy trước 48 giờ, 50% nếu trước 6 giờ, sau đó 0%).
+// @Tags Bookings
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   bookingId path string true "ID của Booking" Format(ObjectID)
+// @Param   body body services.CancelBookingInput false "Lý do hủy vé"
+// @Success 200 {object} map[string]interface{} "Hủy vé thành công. Body: {thông báo: string, dữ_liệu: services.CancelBookingResult}"
+// @Failure 400 {object} map[string]string "ID booking không hợp lệ"
+// @Failure 401 {object} map[string]string "Yêu cầu token xác thực hoặc không thể xác định người dùng"
+// @Failure 404 {object} map[string]string "Không tìm thấy booking hoặc không có quyền xem"
+// @Failure 409 {object} map[string]string "Booking không thể hủy hoặc chuyến đi đã khởi hành"
+// @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ"
+// @Router /bookings/{bookingId}/cancel [post]
+func (ctl *BookingController) CancelBooking(c *gin.Context) {
+	bookingIDStr := c.Param("bookingId")
+	userIDStr, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var input services.CancelBookingInput
+	if c.Request.ContentLength > 0 {
+		if err := bindAndValidate(c, &input); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	result, err := ctl.bookings.CancelBooking(bookingIDStr, userIDStr, input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Hủy vé thành công!",
+		"dữ_liệu":   result,
+	})
+}
+
+// @Summary Cập nhật thông tin hành khách của booking
+// @Description Cập nhật họ tên và số điện thoại cho từng ghế. Ghế không được gửi lên hoặc để trống tên sẽ mặc định là chủ tài khoản. Chỉ được phép khi booking đang giữ chỗ hoặc đã xác nhận và chuyến đi chưa khởi hành.
+// @Tags Bookings
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   bookingId path string true "ID của Booking" Format(ObjectID)
+// @Param   body body services.UpdatePassengersInput true "Danh sách hành khách theo ghế"
+// @Success 200 {object} map[string]interface{} "Cập nhật thành công. Body: {thông báo: string, dữ_liệu: models.Booking}"
+// @Failure 400 {object} map[string]string "Dữ liệu hành khách không hợp lệ"
+// @Failure 401 {object} map[string]string "Yêu cầu token xác thực hoặc không thể xác định người dùng"
+// @Failure 404 {object} map[string]string "Không tìm thấy booking hoặc không có quyền xem"
+// @Failure 409 {object} map[string]string "Booking không còn cho phép cập nhật hành khách"
+// @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ"
+// @Router /bookings/{bookingId}/passengers [put]
+func (ctl *BookingController) UpdatePassengers(c *gin.Context) {
+	bookingIDStr := c.Param("bookingId")
+	userIDStr, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var input services.UpdatePassengersInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	booking, err := ctl.bookings.UpdatePassengers(bookingIDStr, userIDStr, input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Cập nhật thông tin hành khách thành công!",
+		"dữ_liệu":   booking,
+	})
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+)
+
+// CompanyController xử lý các endpoint quản trị nhà xe.
+type CompanyController struct {
+	companies *services.CompanyService
+}
+
+func NewCompanyController(companies *services.CompanyService) *CompanyController {
+	return &CompanyController{companies: companies}
+}
+
+// @Summary Tạo nhà xe
+// @Description Chỉ dành cho quản trị viên. Mã nhà xe (2-10 ký tự chữ và số) là duy nhất và được lưu dạng chữ in hoa.
+// @Tags Admin - Companies
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   company body services.CompanyInput true "Thông tin nhà xe"
+// @Success 201 {object} map[string]interface{} "Nhà xe đã tạo. Body: {thông báo: string, dữ_liệu: models.Company}"
+// @Failure 400 {object} map[string]string "Dữ liệu đầu vào không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải quản trị viên"
+// @Failure 409 {object} map[string]string "Mã nhà xe đã được sử dụng"
+// @Router /admin/companies [post]
+func (ctl *CompanyController) CreateCompany(c *gin.Context) {
+	var input services.CompanyInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	company, err := ctl.companies.CreateCompany(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"thông báo": "Tạo nhà xe thành công!",
+		"dữ_liệu":   company,
+	})
+}
+
+// @Summary Cập nhật nhà xe
+// @Description Chỉ dành cho quản trị viên. Ghi đè toàn bộ thông tin có thể sửa của nhà xe.
+// @Tags Admin - Companies
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   companyId path string true "ID của nhà xe" Format(ObjectID)
+// @Param   company body services.CompanyInput true "Thông tin nhà xe"
+// @Success 200 {object} map[string]interface{} "Nhà xe sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Company}"
+// @Failure 400 {object} map[string]string "ID hoặc dữ liệu đầu vào không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải quản trị viên"
+// @Failure 404 {object} map[string]string "Không tìm thấy nhà xe"
+// @Failure 409 {object} map[string]string "Mã nhà xe đã được sử dụng"
+// @Router /admin/companies/{companyId} [put]
+func (ctl *CompanyController) UpdateCompany(c *gin.Context) {
+	var input services.CompanyInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	company, err := ctl.companies.UpdateCompany(c.Param("companyId"), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Cập nhật nhà xe thành công!",
+		"dữ_liệu":   company,
+	})
+}
+
+// @Summary Ngừng hoạt động nhà xe
+// @Description Chỉ dành cho quản trị viên. Chuyến đi của nhà xe không còn xuất hiện khi tìm kiếm và không nhận đặt chỗ mới; booking đã có không bị ảnh hưởng.
+// @Tags Admin - Companies
+// @Produce  json
+// @Security BearerAuth
+// @Param   companyId path string true "ID của nhà xe" Format(ObjectID)
+// @Success 200 {object} map[string]interface{} "Nhà xe sau khi ngừng hoạt động. Body: {thông báo: string, dữ_liệu: models.Company}"
+// @Failure 400 {object} map[string]string "ID nhà xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải quản trị viên"
+// @Failure 404 {object} map[string]string "Không tìm thấy nhà xe"
+// @Router /admin/companies/{companyId}/deactivate [post]
+func (ctl *CompanyController) DeactivateCompany(c *gin.Context) {
+	company, err := ctl.companies.DeactivateCompany(c.Param("companyId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Đã ngừng hoạt động nhà xe.",
+		"dữ_liệu":   company,
+	})
+}
+
+// @Summary Danh sách nhà xe
+// @Description Chỉ dành cho quản trị viên. Mặc định chỉ trả về nhà xe đang hoạt động, sắp xếp theo tên.
+// @Tags Admin - Companies
+// @Produce  json
+// @Security BearerAuth
+// @Param   includeInactive query bool false "Trả về cả nhà xe đã ngừng hoạt động"
+// @Success 200 {object} map[string]interface{} "Body: {thông báo: string, dữ_liệu: []models.Company}"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải quản trị viên"
+// @Router /admin/companies [get]
+func (ctl *CompanyController) ListCompanies(c *gin.Context) {
+	companies, err := ctl.companies.ListCompanies(c.Query("includeInactive") == "true")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Lấy danh sách nhà xe thành công!",
+		"dữ_liệu":   companies,
+	})
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+)
+
+var errUnknownUser = apperrors.New(apperrors.ErrUnauthorized, "Không thể xác định người dùng.").WithCode("unknown_user")
+
+// currentUserID trả về ID người dùng do AuthMiddleware gắn vào context.
+func currentUserID(c *gin.Context) (string, error) {
+	userID, _ := c.Get("userId")
+	userIDStr, ok := userID.(string)
+	if !ok || userIDStr == "" {
+		return "", errUnknownUser
+	}
+	return userIDStr, nil
+}
+
+// currentClaims trả về thông tin xác thực (vai trò, nhà xe) do AuthMiddleware gắn vào context.
+func currentClaims(c *gin.Context) (*services.AccessClaims, error) {
+	value, _ := c.Get("authClaims")
+	claims, ok := value.(*services.AccessClaims)
+	if !ok {
+		return nil, errUnknownUser
+	}
+	return claims, nil
+}
+
+// bindAndValidate đọc body JSON vào input và kiểm tra các ràng buộc `validate`.
+func bindAndValidate(c *gin.Context, input interface{}) error {
+	if err := c.ShouldBindJSON(input); err != nil {
+		return apperrors.Wrap(apperrors.ErrInvalidInput, "Dữ liệu đầu vào không hợp lệ: "+err.Error(), err)
+	}
+	if err := validate.Struct(input); err != nil {
+		return apperrors.Wrap(apperrors.ErrInvalidInput, "Lỗi xác thực dữ liệu: "+err.Error(), err).WithCode("validation_failed")
+	}
+	return nil
+}
+
+// bindQueryAndValidate đọc query string vào input (theo tag `form`) và kiểm tra các ràng buộc `validate`.
+func bindQueryAndValidate(c *gin.Context, input interface{}) error {
+	if err := c.ShouldBindQuery(input); err != nil {
+		return apperrors.Wrap(apperrors.ErrInvalidInput, "Tham số truy vấn không hợp lệ: "+err.Error(), err)
+	}
+	if err := validate.Struct(input); err != nil {
+		return apperrors.Wrap(apperrors.ErrInvalidInput, "Lỗi xác thực dữ liệu: "+err.Error(), err).WithCode("validation_failed")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+)
+
+// LocationController xử lý gợi ý địa điểm và các endpoint quản trị danh mục địa điểm.
+type LocationController struct {
+	locations *services.LocationService
+}
+
+func NewLocationController(locations *services.LocationService) *LocationController {
+	return &LocationController{locations: locations}
+}
+
+// @Summary Gợi ý địa điểm
+// @Description Gợi ý địa điểm cho ô điểm đi/điểm đến. Không phân biệt hoa thường và dấu, khớp theo tiền tố của bất kỳ từ nào trong tên, tên gọi khác hoặc slug (ví dụ "ho chi", "tp hcm", "sai gon").
+// @Tags Locations
+// @Produce  json
+// @Param   q query string true "Chuỗi người dùng đang gõ"
+// @Param   limit query int false "Số gợi ý tối đa (1-20, mặc định 10)"
+// @Success 200 {object} map[string]interface{} "Body: {thông báo: string, dữ_liệu: []models.Location}"
+// @Failure 500 {object} map[string]string "Lỗi máy chủ"
+// @Router /locations/suggest [get]
+func (ctl *LocationController) SuggestLocations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	locations, err := ctl.locations.SuggestLocations(c.Query("q"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Lấy gợi ý địa điểm thành công!",
+		"dữ_liệu":   locations,
+	})
+}
+
+// @Summary Tạo địa điểm
+// @Description Chỉ dành cho quản trị viên. Bỏ trống slug để sinh từ tên. Slug, tên và các tên gọi khác (sau khi bỏ dấu) không được trùng với địa điểm khác.
+// @Tags Admin - Locations
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   location body services.LocationInput true "Thông tin địa điểm"
+// @Success 201 {object} map[string]interface{} "Địa điểm đã tạo. Body: {thông báo: string, dữ_liệu: models.Location}"
+// @Failure 400 {object} map[string]string "Dữ liệu đầu vào không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải quản trị viên"
+// @Failure 409 {object} map[string]string "Slug, tên hoặc tên gọi khác đã được sử dụng"
+// @Router /admin/locations [post]
+func (ctl *LocationController) CreateLocation(c *gin.Context) {
+	var input services.LocationInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	location, err := ctl.locations.CreateLocation(input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"thông báo": "Tạo địa điểm thành công!",
+		"dữ_liệu":   location,
+	})
+}
+
+// @Summary Cập nhật địa điểm
+// @Description Chỉ dành cho quản trị viên. Ghi đè toàn bộ thông tin địa điểm; chuyến đi đã tạo giữ nguyên tên cũ.
+// @Tags Admin - Locations
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   locationId path string true "ID của địa điểm" Format(ObjectID)
+// @Param   location body services.LocationInput true "Thông tin địa điểm"
+// @Success 200 {object} map[string]interface{} "Địa điểm sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Location}"
+// @Failure 400 {object} map[string]string "ID hoặc dữ liệu đầu vào không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải quản trị viên"
+// @Failure 404 {object} map[string]string "Không tìm thấy địa điểm"
+// @Failure 409 {object} map[string]string "Slug, tên hoặc tên gọi khác đã được sử dụng"
+// @Router /admin/locations/{locationId} [put]
+func (ctl *LocationController) UpdateLocation(c *gin.Context) {
+	var input services.LocationInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	location, err := ctl.locations.UpdateLocation(c.Param("locationId"), input)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Cập nhật địa điểm thành công!",
+		"dữ_liệu":   location,
+	})
+}
+
+// @Summary Danh sách địa điểm
+// @Description Chỉ dành cho quản trị viên. Trả về toàn bộ danh mục địa điểm, sắp xếp theo tên.
+// @Tags Admin - Locations
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Body: {thông báo: string, dữ_liệu: []models.Location}"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải quản trị viên"
+// @Router /admin/locations [get]
+func (ctl *LocationController) ListLocations(c *gin.Context) {
+	locations, err := ctl.locations.ListLocations()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Lấy danh sách địa điểm thành công!",
+		"dữ_liệu":   locations,
+	})
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentController xử lý thanh toán booking và webhook của cổng thanh toán.
+type PaymentController struct {
+	payments *services.PaymentService
+}
+
+func NewPaymentController(payments *services.PaymentService) *PaymentController {
+	return &PaymentController{payments: payments}
+}
+
+// @Summary Thanh toán một booking đang giữ chỗ
+// @Description Thu tiền qua cổng thanh toán cho booking đang ở trạng thái "held". Thành công thì booking chuyển sang "confirmed" và ghế chuyển sang "booked".
+// @Tags Payments
+// @Produce  json
+// @Security BearerAuth
+// @Param   bookingId path string true "ID của Booking" Format(ObjectID)
+// @Success 200 {object} map[string]interface{} "Thanh toán thành công. Body: {thông báo: string, dữ_liệu: models.Payment}"
+// @Failure 400 {object} map[string]string "ID booking không hợp lệ"
+// @Failure 401 {object} map[string]string "Yêu cầu token xác thực hoặc không thể xác định người dùng"
+// @Failure 402 {object} map[string]string "Cổng thanh toán từ chối giao dịch"
+// @Failure 404 {object} map[string]string "Không tìm thấy booking hoặc không có quyền xem"
+// @Failure 409 {object} map[string]string "Booking không còn ở trạng thái chờ thanh toán"
+// @Failure 422 {object} map[string]string "Chưa có họ tên hành khách cho tất cả các ghế"
+// @Failure 504 {object} map[string]string "Cổng thanh toán không phản hồi"
+// @Router /bookings/{bookingId}/pay [post]
+func (ctl *PaymentController) PayBooking(c *gin.Context) {
+	bookingIDStr := c.Param("bookingId")
+	userIDStr, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	payment, err := ctl.payments.PayBooking(bookingIDStr, userIDStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Thanh toán thành công! Vé của bạn đã được xác nhận.",
+		"dữ_liệu":   payment,
+	})
+}
+
+// @Summary Nhận webhook từ cổng thanh toán
+// @Description Xác thực chữ ký HMAC-SHA256 trong header X-Webhook-Signature ("sha256=<hex>") và áp dụng sự kiện thanh toán. Mỗi sự kiện chỉ được xử lý một lần theo mã sự kiện.
+// @Tags Payments
+// @Accept  json
+// @Produce  json
+// @Param   X-Webhook-Signature header string true "Chữ ký HMAC-SHA256 của body"
+// @Param   event body payments.WebhookEvent true "Sự kiện thanh toán"
+// @Success 200 {object} map[string]string "Đã xử lý sự kiện (hoặc sự kiện đã được xử lý trước đó)"
+// @Failure 400 {object} map[string]string "Nội dung webhook không hợp lệ"
+// @Failure 401 {object} map[string]string "Chữ ký không hợp lệ"
+// @Failure 404 {object} map[string]string "Không tìm thấy giao dịch"
+// @Failure 409 {object} map[string]string "Sự kiện đang được xử lý"
+// @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ"
+// @Router /payments/webhook [post]
+func (ctl *PaymentController) PaymentWebhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.Error(apperrors.Wrap(apperrors.ErrInvalidInput, "Không đọc được nội dung webhook", err))
+		return
+	}
+
+	event, err := ctl.payments.HandlePaymentWebhook(payload, c.Request.Header)
+	if err != nil {
+		if errors.Is(err, services.ErrWebhookReplayed) {
+			// Trả 200 để cổng thanh toán ngừng gửi lại sự kiện đã xử lý.
+			c.JSON(http.StatusOK, gin.H{"thông báo": err.Error(), "eventId": event.ID})
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Đã xử lý sự kiện thanh toán.",
+		"eventId":   event.ID,
+	})
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+)
+
+// ScheduleController xử lý các endpoint quản lý lịch chạy lặp lại.
+type ScheduleController struct {
+	schedules *services.ScheduleService
+}
+
+func NewScheduleController(schedules *services.ScheduleService) *ScheduleController {
+	return &ScheduleController{schedules: schedules}
+}
+
+// @Summary Tạo lịch chạy
+// @Description Dành cho quản trị viên và nhân viên nhà xe (chỉ với nhà xe của mình). Các chuyến đi trong những ngày tới được sinh ngay sau khi tạo, sau đó bộ sinh chạy định kỳ. weekdays: 0 là Chủ nhật; ngày dạng YYYY-MM-DD và giờ dạng HH:MM theo múi giờ vận hành.
+// @Tags Admin - Schedules
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   schedule body services.ScheduleInput true "Thông tin lịch chạy"
+// @Success 201 {object} map[string]interface{} "Lịch chạy đã tạo. Body: {thông báo: string, dữ_liệu: models.Schedule}"
+// @Failure 400 {object} map[string]string "Dữ liệu đầu vào hoặc xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Failure 404 {object} map[string]string "Không tìm thấy nhà xe hoặc xe"
+// @Failure 409 {object} map[string]string "Nhà xe đã ngừng hoạt động"
+// @Router /admin/schedules [post]
+func (ctl *ScheduleController) CreateSchedule(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var input services.ScheduleInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	schedule, err := ctl.schedules.CreateSchedule(input, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"thông báo": "Tạo lịch chạy thành công!",
+		"dữ_liệu":   schedule,
+	})
+}
+
+// @Summary Cập nhật lịch chạy
+// @Description Ghi đè toàn bộ lịch chạy, trừ skippedDates (ngày có chuyến đã bị xóa hoặc dời ngày). Thay đổi chỉ áp dụng cho các chuyến được sinh sau đó, trừ giờ khởi hành và thời gian chạy: các chuyến sắp tới chưa có booking được dời theo giờ mới.
+// @Tags Admin - Schedules
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   scheduleId path string true "ID của lịch chạy" Format(ObjectID)
+// @Param   schedule body services.ScheduleInput true "Thông tin lịch chạy"
+// @Success 200 {object} map[string]interface{} "Lịch chạy sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Schedule}"
+// @Failure 400 {object} map[string]string "ID, dữ liệu đầu vào hoặc xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Failure 404 {object} map[string]string "Không tìm thấy lịch chạy, nhà xe hoặc xe"
+// @Failure 409 {object} map[string]string "Nhà xe đã ngừng hoạt động"
+// @Router /admin/schedules/{scheduleId} [put]
+func (ctl *ScheduleController) UpdateSchedule(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var input services.ScheduleInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	schedule, err := ctl.schedules.UpdateSchedule(c.Param("scheduleId"), input, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Cập nhật lịch chạy thành công!",
+		"dữ_liệu":   schedule,
+	})
+}
+
+// @Summary Ngừng lịch chạy
+// @Description Ngừng sinh chuyến đi mới từ lịch và xóa các chuyến sắp tới do lịch sinh ra mà chưa có booking. Chuyến đã có booking được giữ nguyên.
+// @Tags Admin - Schedules
+// @Produce  json
+// @Security BearerAuth
+// @Param   scheduleId path string true "ID của lịch chạy" Format(ObjectID)
+// @Success 200 {object} map[string]interface{} "Body: {thông báo: string, dữ_liệu: models.Schedule, removedTrips: int}"
+// @Failure 400 {object} map[string]string "ID lịch chạy không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 404 {object} map[string]string "Không tìm thấy lịch chạy"
+// @Router /admin/schedules/{scheduleId}/disable [post]
+func (ctl *ScheduleController) DisableSchedule(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	schedule, removed, err := ctl.schedules.DisableSchedule(c.Param("scheduleId"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo":    "Đã ngừng lịch chạy.",
+		"dữ_liệu":      schedule,
+		"removedTrips": removed,
+	})
+}
+
+// @Summary Danh sách lịch chạy
+// @Description Quản trị viên có thể lọc theo nhà xe; nhân viên nhà xe luôn chỉ thấy lịch của nhà xe mình.
+// @Tags Admin - Schedules
+// @Produce  json
+// @Security BearerAuth
+// @Param   companyId query string false "Lọc theo ID nhà xe" Format(ObjectID)
+// @Success 200 {object} map[string]interface{} "Body: {thông báo: string, dữ_liệu: []models.Schedule}"
+// @Failure 400 {object} map[string]string "ID nhà xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Router /admin/schedules [get]
+func (ctl *ScheduleController) ListSchedules(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	schedules, err := ctl.schedules.ListSchedules(c.Query("companyId"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Lấy danh sách lịch chạy thành công!",
+		"dữ_liệu":   schedules,
+	})
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+	"github.com/Go_final_exam/bus-booking-backend/src/tickets"
+	"github.com/gin-gonic/gin"
+)
+
+// TicketController xử lý kiểm tra vé và tải vé điện tử.
+type TicketController struct {
+	tickets *services.TicketService
+}
+
+func NewTicketController(tickets *services.TicketService) *TicketController {
+	return &TicketController{tickets: tickets}
+}
+
+// @Summary Kiểm tra vé theo mã vé
+// @Description Dành cho nhân viên nhà xe kiểm tra vé mà không cần token của hành khách. Chỉ trả về chuyến đi, ghế và trạng thái, không có thông tin cá nhân.
+// @Tags Tickets
+// @Produce  json
+// @Param   code path string true "Mã vé (ví dụ: 8Q5-B8M-B2E) hoặc nội dung mã QR trên vé"
+// @Success 200 {object} map[string]interface{} "Kết quả kiểm tra. Body: {thông báo: string, dữ_liệu: services.TicketVerification}"
+// @Failure 400 {object} map[string]string "Mã vé sai định dạng hoặc sai chữ số kiểm tra"
+// @Failure 404 {object} map[string]string "Không tìm thấy vé"
+// @Failure 500 {object} map[string]string "Lỗi máy chủ nội bộ"
+// @Router /tickets/{code}/verify [get]
+func (ctl *TicketController) VerifyTicket(c *gin.Context) {
+	ticket, err := ctl.tickets.VerifyTicket(c.Param("code"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	message := "Vé hợp lệ."
+	if !ticket.Valid {
+		message = "Vé không còn hiệu lực."
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": message,
+		"dữ_liệu":   ticket,
+	})
+}
+
+// @Summary Soát vé lên xe
+// @Description Nhân viên nhà xe (chỉ với chuyến của nhà xe mình) hoặc quản trị viên xác nhận hành khách đã lên xe; booking chuyển từ "confirmed" sang "checked_in".
+// @Tags Tickets
+// @Produce  json
+// @Security BearerAuth
+// @Param   code path string true "Mã vé hoặc nội dung mã QR trên vé"
+// @Success 200 {object} map[string]interface{} "Soát vé thành công. Body: {thông báo: string, dữ_liệu: services.TicketVerification}"
+// @Failure 400 {object} map[string]string "Mã vé sai định dạng"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không phải nhân viên nhà xe/quản trị viên, hoặc vé thuộc nhà xe khác"
+// @Failure 404 {object} map[string]string "Không tìm thấy vé"
+// @Failure 409 {object} map[string]string "Vé không ở trạng thái có thể soát (chưa thanh toán, đã soát, đã hủy...)"
+// @Router /tickets/{code}/check-in [post]
+func (ctl *TicketController) CheckIn(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ticket, err := ctl.tickets.CheckIn(c.Param("code"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Soát vé thành công!",
+		"dữ_liệu":   ticket,
+	})
+}
+
+// @Summary Tải vé điện tử dạng PDF
+// @Description Vé gồm tuyến đường, giờ khởi hành, ghế, hành khách, nhà xe và mã QR đã ký (chứa mã vé và ID booking). Chỉ có khi booking đã được xác nhận.
+// @Tags Tickets
+// @Produce  application/pdf
+// @Security BearerAuth
+// @Param   bookingId path string true "ID của Booking" Format(ObjectID)
+// @Success 200 {file} file "File PDF của vé"
+// @Failure 400 {object} map[string]string "ID booking không hợp lệ"
+// @Failure 404 {object} map[string]string "Không tìm thấy booking hoặc không có quyền xem"
+// @Failure 409 {object} map[string]string "Vé chưa được phát hành"
+// @Router /bookings/{bookingId}/ticket.pdf [get]
+func (ctl *TicketController) DownloadTicketPDF(c *gin.Context) {
+	ctl.renderTicket(c, "application/pdf", "pdf", tickets.RenderPDF)
+}
+
+// @Summary Tải vé điện tử dạng ảnh PNG
+// @Description Cùng nội dung với bản PDF, phù hợp để lưu vào điện thoại.
+// @Tags Tickets
+// @Produce  image/png
+// @Security BearerAuth
+// @Param   bookingId path string true "ID của Booking" Format(ObjectID)
+// @Success 200 {file} file "Ảnh PNG của vé"
+// @Failure 400 {object} map[string]string "ID booking không hợp lệ"
+// @Failure 404 {object} map[string]string "Không tìm thấy booking hoặc không có quyền xem"
+// @Failure 409 {object} map[string]string "Vé chưa được phát hành"
+// @Router /bookings/{bookingId}/ticket.png [get]
+func (ctl *TicketController) DownloadTicketPNG(c *gin.Context) {
+	ctl.renderTicket(c, "image/png", "png", tickets.RenderPNG)
+}
+
+func (ctl *TicketController) renderTicket(c *gin.Context, contentType, ext string, render func(*tickets.BoardingPass) ([]byte, error)) {
+	userIDStr, err := currentUserID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pass, err := ctl.tickets.GetBoardingPass(c.Param("bookingId"), userIDStr)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data, err := render(pass)
+	if err != nil {
+		c.Error(apperrors.Wrap(apperrors.ErrInternal, "Lỗi máy chủ khi tạo vé điện tử.", err))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="ve-%s.%s"`, pass.TicketCode, ext))
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -1,87 +1,170 @@
 package controllers
 
 import (
+	"io"
 	"net/http"
-	"strings"
+	"time"
 
-	"github.com/Go_final_exam/bus-booking-backend/src/models"
 	"github.com/Go_final_exam/bus-booking-backend/src/services"
 	"github.com/gin-gonic/gin"
 )
 
+// TripController xử lý các endpoint tra cứu chuyến đi và quản lý chuyến đi của nhà xe.
+type TripController struct {
+	trips *services.TripService
+}
+
+func NewTripController(trips *services.TripService) *TripController {
+	return &TripController{trips: trips}
+}
+
 // @Summary Tìm kiếm chuyến đi
-// @Description Tìm kiếm các chuyến đi dựa trên điểm đi, điểm đến và ngày khởi hành.
+// @Description Tìm kiếm chuyến đi với bộ lọc, sắp xếp và phân trang theo cursor; mọi tham số đều tùy chọn. Điểm đi và điểm đến có thể là điểm dừng bất kỳ trên tuyến (đúng chiều); khi có, kết quả kèm segmentInfo với giá và giờ của chặng. Ngày, khung giờ, giá và số ghế trống đều tính theo chặng đã chọn; ngày và giờ theo múi giờ vận hành (mặc định Asia/Ho_Chi_Minh) và chuyến đã qua giờ lên xe bị bỏ qua. facets đếm số chuyến theo nhà xe và loại xe. Số ghế trống được tính trong cơ sở dữ liệu; kết quả không kèm danh sách ghế (xem GET /trips/{tripId}).
 // @Tags Trips
 // @Accept  json
 // @Produce  json
-// @Param from query string true "Tên điểm đi (Ví dụ: 'TP. Hồ Chí Minh')"
-// @Param to query string true "Tên điểm đến (Ví dụ: 'Đà Lạt')"
-// @Param date query string true "Ngày đi theo định dạng YYYY-MM-DD (Ví dụ: '2024-05-25')"
-// @Success 200 {object} map[string]interface{} "Danh sách các chuyến đi phù hợp"
-// @Failure 400 {object} map[string]string "Các tham số query bắt buộc bị thiếu"
+// @Param from query string false "Tên điểm đi (Ví dụ: 'TP. Hồ Chí Minh')"
+// @Param to query string false "Tên điểm đến (Ví dụ: 'Đà Lạt')"
+// @Param date query string false "Ngày đi theo định dạng YYYY-MM-DD (Ví dụ: '2024-05-25')"
+// @Param companyId query []string false "ID nhà xe; lặp lại tham số hoặc phân tách bằng dấu phẩy" collectionFormat(multi)
+// @Param vehicleType query string false "Loại xe"
+// @Param minPrice query number false "Giá vé tối thiểu"
+// @Param maxPrice query number false "Giá vé tối đa"
+// @Param departAfter query string false "Giờ lên xe sớm nhất, dạng HH:MM"
+// @Param departBefore query string false "Giờ lên xe muộn nhất, dạng HH:MM"
+// @Param minSeats query int false "Số ghế trống tối thiểu"
+// @Param seatType query string false "Hạng ghế cần còn trống" Enums(seat, bed, vip)
+// @Param sort query string false "Cách sắp xếp (mặc định departure)" Enums(departure, price, duration, rating)
+// @Param cursor query string false "Cursor trang tiếp theo (nextCursor của lần gọi trước)"
+// @Param limit query int false "Số chuyến mỗi trang (mặc định 20, tối đa 100)"
+// @Param includePast query bool false "Bao gồm cả chuyến có giờ lên xe đã qua"
+// @Success 200 {object} map[string]interface{} "Danh sách chuyến đi, nextCursor và facets"
+// @Failure 400 {object} map[string]string "Tham số truy vấn hoặc cursor không hợp lệ"
 // @Failure 500 {object} map[string]string "Lỗi máy chủ"
 // @Router /trips [get]
-func SearchTripsController(c *gin.Context) {
-	from := c.Query("from")
-	to := c.Query("to")
-	date := c.Query("date")
-
-	var trips []models.Trip
-	var err error
-
-	if from != "" && to != "" && date != "" {
-		trips, err = services.SearchTrips(from, to, date)
-	} else if from == "" && to == "" && date == "" {
-		trips, err = services.GetAllTrips() 
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": "Cần cung cấp đủ các tham số 'from', 'to', 'date' cho việc tìm kiếm, hoặc không cung cấp tham số nào để lấy tất cả chuyến đi."})
+func (ctl *TripController) SearchTrips(c *gin.Context) {
+	var query services.TripSearchQuery
+	if err := bindQueryAndValidate(c, &query); err != nil {
+		c.Error(err)
 		return
 	}
 
+	result, err := ctl.trips.SearchTrips(query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
+	message := "Yêu cầu chuyến đi thành công!"
+	if len(result.Trips) == 0 {
+		message = "Không tìm thấy chuyến đi nào phù hợp."
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo":  message,
+		"dữ_liệu":    result.Trips,
+		"nextCursor": result.NextCursor,
+		"facets":     result.Facets,
+	})
+}
+
+// seatStreamHeartbeat là chu kỳ gửi dòng chú thích giữ kết nối SSE qua proxy khi không có sự kiện.
+const seatStreamHeartbeat = 25 * time.Second
+
+// @Summary Theo dõi trạng thái ghế theo thời gian thực
+// @Description Luồng Server-Sent Events: mỗi sự kiện "seat" chứa tripId, seatNumbers, status mới ("held", "booked" hoặc "available" khi ghế được trả lại) và segment (chặng bị ảnh hưởng, không có nghĩa là cả chuyến). Khi luồng bị đóng, client nên tải lại chi tiết chuyến đi rồi kết nối lại.
+// @Tags Trips
+// @Produce  text/event-stream
+// @Param tripId path string true "ID của chuyến đi"
+// @Success 200 {string} string "Luồng sự kiện"
+// @Failure 400 {object} map[string]string "ID chuyến đi không hợp lệ"
+// @Failure 404 {object} map[string]string "Không tìm thấy chuyến đi"
+// @Router /trips/{tripId}/seats/stream [get]
+func (ctl *TripController) StreamSeatEvents(c *gin.Context) {
+	events, unsubscribe, err := ctl.trips.SubscribeSeatEvents(c.Param("tripId"))
 	if err != nil {
-		if strings.Contains(err.Error(), "Lỗi phân tích ngày") {
-             c.JSON(http.StatusBadRequest, gin.H{"lỗi": "Định dạng ngày không hợp lệ. Vui lòng sử dụng YYYY-MM-DD."})
-             return
-        }
-		c.JSON(http.StatusInternalServerError, gin.H{"lỗi": "Đã có lỗi xảy ra ở máy chủ khi xử lý yêu cầu chuyến đi."})
+		c.Error(err)
+		return
+	}
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(seatStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Tắt bộ đệm của nginx để sự kiện tới client ngay.
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Content-Type", "text/event-stream")
+	// Gửi header ngay để client biết kết nối đã mở, không phải chờ tới sự kiện đầu tiên.
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("seat", event)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// @Summary Lịch giá vé theo ngày
+// @Description Với chặng from → to, trả về cho từng ngày trong khoảng [start, start+days) giá vé thấp nhất trong các chuyến còn ghế (minPrice, null nếu không còn chuyến bán được), số chuyến và tổng số ghế trống. Ngày tính theo giờ xe tới điểm lên, theo múi giờ vận hành.
+// @Tags Trips
+// @Accept  json
+// @Produce  json
+// @Param from query string true "Tên điểm đi"
+// @Param to query string true "Tên điểm đến"
+// @Param start query string false "Ngày bắt đầu YYYY-MM-DD (mặc định hôm nay)"
+// @Param days query int false "Số ngày (mặc định 7, tối đa 31)"
+// @Success 200 {object} map[string]interface{} "Thống kê theo từng ngày"
+// @Failure 400 {object} map[string]string "Tham số truy vấn không hợp lệ"
+// @Failure 500 {object} map[string]string "Lỗi máy chủ"
+// @Router /trips/calendar [get]
+func (ctl *TripController) GetFareCalendar(c *gin.Context) {
+	var query services.FareCalendarQuery
+	if err := bindQueryAndValidate(c, &query); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if len(trips) == 0 {
-		c.JSON(http.StatusOK, gin.H{
-			"thông báo": "Không tìm thấy chuyến đi nào phù hợp.",
-			"dữ_liệu":   []models.Trip{},
-		})
+	calendar, err := ctl.trips.GetFareCalendar(query)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"thông báo": "Yêu cầu chuyến đi thành công!", 
-		"dữ_liệu": trips,
+		"thông báo": "Lấy lịch giá vé thành công!",
+		"dữ_liệu":   calendar,
 	})
 }
 
 // @Summary Lấy thông tin chi tiết một chuyến đi
-// @Description Lấy toàn bộ thông tin chi tiết của một chuyến đi, bao gồm cả sơ đồ ghế.
+// @Description Lấy toàn bộ thông tin chi tiết của một chuyến đi, bao gồm cả sơ đồ ghế. Khi có from và to, trạng thái ghế, số ghế trống và segmentInfo được tính cho chặng đó.
 // @Tags Trips
 // @Accept  json
 // @Produce  json
 // @Param tripId path string true "ID của chuyến đi"
+// @Param from query string false "Điểm lên xe (tên điểm dừng)"
+// @Param to query string false "Điểm xuống xe (tên điểm dừng)"
 // @Success 200 {object} map[string]interface{} "Thông tin chi tiết của chuyến đi"
-// @Failure 400 {object} map[string]string "ID chuyến đi không hợp lệ"
+// @Failure 400 {object} map[string]string "ID chuyến đi không hợp lệ hoặc chuyến không bán vé chặng đã chọn"
 // @Failure 404 {object} map[string]string "Không tìm thấy chuyến đi"
 // @Router /trips/{tripId} [get]
-func GetTripDetailsController(c *gin.Context) {
+func (ctl *TripController) GetTripDetails(c *gin.Context) {
 	tripID := c.Param("tripId")
 
-	trip, err := services.GetTripByID(tripID)
+	trip, err := ctl.trips.GetTripByID(tripID, c.Query("from"), c.Query("to"))
 	if err != nil {
-		if err.Error() == "không tìm thấy chuyến đi" {
-			c.JSON(http.StatusNotFound, gin.H{"lỗi": err.Error()})
-			return
-		}
-		c.JSON(http.StatusBadRequest, gin.H{"lỗi": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -91,3 +174,108 @@ func GetTripDetailsController(c *gin.Context) {
 	})
 }
 
+
+// @Summary Tạo chuyến đi
+// @Description Dành cho quản trị viên và nhân viên nhà xe (chỉ với nhà xe của mình). Danh sách ghế được sinh từ sơ đồ ghế của xe, tất cả ở trạng thái "available".
+// @Tags Admin - Trips
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   trip body services.TripInput true "Thông tin chuyến đi"
+// @Success 201 {object} map[string]interface{} "Chuyến đi đã tạo. Body: {thông báo: string, dữ_liệu: models.Trip}"
+// @Failure 400 {object} map[string]string "Dữ liệu đầu vào, thời gian hoặc xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Failure 404 {object} map[string]string "Không tìm thấy nhà xe hoặc xe"
+// @Failure 409 {object} map[string]string "Nhà xe đã ngừng hoạt động"
+// @Router /admin/trips [post]
+func (ctl *TripController) CreateTrip(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var input services.TripInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	trip, err := ctl.trips.CreateTrip(input, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"thông báo": "Tạo chuyến đi thành công!",
+		"dữ_liệu":   trip,
+	})
+}
+
+// @Summary Cập nhật chuyến đi
+// @Description Ghi đè toàn bộ thông tin chuyến đi. Khi chuyến đi đang có booking còn hiệu lực (đang giữ chỗ chưa hết hạn hoặc đã xác nhận), đổi giá vé trả về mã trip_fares_locked, đổi giờ khởi hành hoặc giờ đến trả về trip_times_locked, đổi nhà xe, xe hoặc số điểm dừng trả về trip_has_bookings; các thay đổi khác như tên điểm dừng vẫn được lưu. Đổi xe sẽ sinh lại danh sách ghế.
+// @Tags Admin - Trips
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   tripId path string true "ID của chuyến đi" Format(ObjectID)
+// @Param   trip body services.TripInput true "Thông tin chuyến đi"
+// @Success 200 {object} map[string]interface{} "Chuyến đi sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Trip}"
+// @Failure 400 {object} map[string]string "ID, dữ liệu đầu vào, thời gian hoặc xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Failure 404 {object} map[string]string "Không tìm thấy chuyến đi, nhà xe hoặc xe"
+// @Failure 409 {object} map[string]string "Thay đổi bị khóa vì chuyến đi đang có booking (trip_fares_locked, trip_times_locked, trip_has_bookings) hoặc nhà xe đã ngừng hoạt động"
+// @Router /admin/trips/{tripId} [put]
+func (ctl *TripController) UpdateTrip(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var input services.TripInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	trip, err := ctl.trips.UpdateTrip(c.Param("tripId"), input, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Cập nhật chuyến đi thành công!",
+		"dữ_liệu":   trip,
+	})
+}
+
+// @Summary Xóa chuyến đi
+// @Description Chỉ xóa được chuyến đi chưa có booking nào, kể cả booking đã hủy. Chuyến sinh từ lịch chạy sẽ không được sinh lại vào ngày đó.
+// @Tags Admin - Trips
+// @Produce  json
+// @Security BearerAuth
+// @Param   tripId path string true "ID của chuyến đi" Format(ObjectID)
+// @Success 200 {object} map[string]string "Xóa chuyến đi thành công"
+// @Failure 400 {object} map[string]string "ID chuyến đi không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Failure 404 {object} map[string]string "Không tìm thấy chuyến đi"
+// @Failure 409 {object} map[string]string "Chuyến đi đã có booking"
+// @Router /admin/trips/{tripId} [delete]
+func (ctl *TripController) DeleteTrip(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctl.trips.DeleteTrip(c.Param("tripId"), claims); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"thông báo": "Xóa chuyến đi thành công!"})
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+)
+
+// VehicleController xử lý các endpoint quản lý xe và sơ đồ ghế.
+type VehicleController struct {
+	vehicles *services.VehicleService
+}
+
+func NewVehicleController(vehicles *services.VehicleService) *VehicleController {
+	return &VehicleController{vehicles: vehicles}
+}
+
+// @Summary Tạo xe
+// @Description Dành cho quản trị viên và nhân viên nhà xe (chỉ với nhà xe của mình). Số ô "seat" trong sơ đồ ghế phải bằng totalSeats và mã ghế phải duy nhất.
+// @Tags Admin - Vehicles
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   vehicle body services.VehicleInput true "Thông tin xe và sơ đồ ghế"
+// @Success 201 {object} map[string]interface{} "Xe đã tạo. Body: {thông báo: string, dữ_liệu: models.Vehicle}"
+// @Failure 400 {object} map[string]string "Dữ liệu đầu vào hoặc sơ đồ ghế không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Failure 404 {object} map[string]string "Không tìm thấy nhà xe"
+// @Failure 409 {object} map[string]string "Nhà xe đã ngừng hoạt động"
+// @Router /admin/vehicles [post]
+func (ctl *VehicleController) CreateVehicle(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var input services.VehicleInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	vehicle, err := ctl.vehicles.CreateVehicle(input, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"thông báo": "Tạo xe thành công!",
+		"dữ_liệu":   vehicle,
+	})
+}
+
+// @Summary Cập nhật xe
+// @Description Ghi đè toàn bộ thông tin xe. Không thể đổi sơ đồ ghế, số ghế hoặc nhà xe khi xe đã được gán cho chuyến đi.
+// @Tags Admin - Vehicles
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param   vehicleId path string true "ID của xe" Format(ObjectID)
+// @Param   vehicle body services.VehicleInput true "Thông tin xe và sơ đồ ghế"
+// @Success 200 {object} map[string]interface{} "Xe sau khi cập nhật. Body: {thông báo: string, dữ_liệu: models.Vehicle}"
+// @Failure 400 {object} map[string]string "ID, dữ liệu đầu vào hoặc sơ đồ ghế không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Failure 404 {object} map[string]string "Không tìm thấy xe hoặc nhà xe"
+// @Failure 409 {object} map[string]string "Xe đang được sử dụng hoặc nhà xe đã ngừng hoạt động"
+// @Router /admin/vehicles/{vehicleId} [put]
+func (ctl *VehicleController) UpdateVehicle(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var input services.VehicleInput
+	if err := bindAndValidate(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	vehicle, err := ctl.vehicles.UpdateVehicle(c.Param("vehicleId"), input, claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Cập nhật xe thành công!",
+		"dữ_liệu":   vehicle,
+	})
+}
+
+// @Summary Xóa xe
+// @Description Chỉ xóa được xe chưa được gán cho chuyến đi nào.
+// @Tags Admin - Vehicles
+// @Produce  json
+// @Security BearerAuth
+// @Param   vehicleId path string true "ID của xe" Format(ObjectID)
+// @Success 200 {object} map[string]string "Xóa xe thành công"
+// @Failure 400 {object} map[string]string "ID xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 404 {object} map[string]string "Không tìm thấy xe"
+// @Failure 409 {object} map[string]string "Xe đang được sử dụng"
+// @Router /admin/vehicles/{vehicleId} [delete]
+func (ctl *VehicleController) DeleteVehicle(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctl.vehicles.DeleteVehicle(c.Param("vehicleId"), claims); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"thông báo": "Xóa xe thành công!"})
+}
+
+// @Summary Chi tiết xe
+// @Description Trả về xe kèm sơ đồ ghế. Nhân viên nhà xe chỉ xem được xe của nhà xe mình.
+// @Tags Admin - Vehicles
+// @Produce  json
+// @Security BearerAuth
+// @Param   vehicleId path string true "ID của xe" Format(ObjectID)
+// @Success 200 {object} map[string]interface{} "Body: {thông báo: string, dữ_liệu: models.Vehicle}"
+// @Failure 400 {object} map[string]string "ID xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 404 {object} map[string]string "Không tìm thấy xe"
+// @Router /admin/vehicles/{vehicleId} [get]
+func (ctl *VehicleController) GetVehicle(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	vehicle, err := ctl.vehicles.GetVehicle(c.Param("vehicleId"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Lấy thông tin xe thành công!",
+		"dữ_liệu":   vehicle,
+	})
+}
+
+// @Summary Danh sách xe
+// @Description Quản trị viên có thể lọc theo nhà xe; nhân viên nhà xe luôn chỉ thấy xe của nhà xe mình.
+// @Tags Admin - Vehicles
+// @Produce  json
+// @Security BearerAuth
+// @Param   companyId query string false "Lọc theo ID nhà xe" Format(ObjectID)
+// @Success 200 {object} map[string]interface{} "Body: {thông báo: string, dữ_liệu: []models.Vehicle}"
+// @Failure 400 {object} map[string]string "ID nhà xe không hợp lệ"
+// @Failure 401 {object} map[string]string "Chưa xác thực"
+// @Failure 403 {object} map[string]string "Không có quyền với nhà xe này"
+// @Router /admin/vehicles [get]
+func (ctl *VehicleController) ListVehicles(c *gin.Context) {
+	claims, err := currentClaims(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	vehicles, err := ctl.vehicles.ListVehicles(c.Query("companyId"), claims)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thông báo": "Lấy danh sách xe thành công!",
+		"dữ_liệu":   vehicles,
+	})
+}
@@ -1,62 +1,51 @@
 package middlewares
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 
-	"github.com/Go_final_exam/bus-booking-backend/src/config"
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		cfg, err := config.LoadConfig()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"lỗi": "Không thể tải cấu hình hệ thống"})
-			c.Abort()
-			return
-		}
-		jwtSecretKey := cfg.JwtSecretKey
+var (
+	errMissingToken   = apperrors.New(apperrors.ErrUnauthorized, "Yêu cầu cần có token xác thực").WithCode("missing_token")
+	errMalformedToken = apperrors.New(apperrors.ErrUnauthorized, "Định dạng token không hợp lệ").WithCode("invalid_token")
+)
 
+// AuthMiddleware yêu cầu header "Authorization: Bearer <token>" hợp lệ. Lỗi xác thực được
+// ghi nhận qua c.Error để ErrorHandler trả về cùng định dạng với các lỗi khác. Token của phiên
+// đăng nhập đã bị thu hồi (đăng xuất, phát hiện dùng lại refresh token) bị từ chối.
+func AuthMiddleware(auth *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Yêu cầu cần có token xác thực"})
+			c.Error(errMissingToken)
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Định dạng token không hợp lệ"})
+			c.Error(errMalformedToken)
 			c.Abort()
 			return
 		}
 
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, http.ErrAbortHandler
-			}
-			return []byte(jwtSecretKey), nil
-		})
-
+		claims, err := auth.Authenticate(c.Request.Context(), tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Token không hợp lệ hoặc đã hết hạn"})
+			c.Error(err)
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("userId", claims["userId"])
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"lỗi": "Token không hợp lệ"})
-			c.Abort()
-			return
-		}
+		c.Set("userId", claims.UserID)
+		c.Set("sessionId", claims.SessionID)
+		c.Set("authClaims", claims)
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
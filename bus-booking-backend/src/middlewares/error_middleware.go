@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+)
+
+// ErrorHandler chuyển lỗi được handler ghi nhận qua c.Error thành phản hồi JSON
+// {"lỗi": thông báo, "code": mã lỗi} với mã HTTP tương ứng nhóm lỗi trong package apperrors.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		status, code := apperrors.Classify(err)
+		message := err.Error()
+		if status >= http.StatusInternalServerError {
+			log.Printf("Lỗi khi xử lý %s %s: %v", c.Request.Method, c.Request.URL.Path, errorChain(err))
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) {
+				message = "Lỗi máy chủ nội bộ."
+			}
+		}
+
+		c.JSON(status, gin.H{"lỗi": message, "code": code})
+	}
+}
+
+// errorChain ghép thông báo lỗi với lỗi gốc (nếu có) để ghi log đầy đủ.
+func errorChain(err error) string {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) && appErr.Err != nil {
+		return err.Error() + ": " + appErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/services"
+)
+
+var errForbiddenRole = apperrors.New(apperrors.ErrForbidden, "Bạn không có quyền thực hiện thao tác này").WithCode("forbidden_role")
+
+// RequireRole chỉ cho phép người dùng có một trong các vai trò roles đi tiếp. Phải đặt sau
+// AuthMiddleware; việc giới hạn theo nhà xe được kiểm tra ở service.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("authClaims")
+		claims, ok := value.(*services.AccessClaims)
+		if !ok {
+			c.Error(errMissingToken)
+			c.Abort()
+			return
+		}
+
+		if !claims.HasRole(roles...) {
+			c.Error(errForbiddenRole)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Location là một địa điểm trong danh mục (thành phố, thị xã, bến xe...) mà điểm đi, điểm đến
+// và điểm dừng của tuyến đường tham chiếu tới.
+type Location struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Slug        string             `json:"slug" bson:"slug"`
+	Province    string             `json:"province,omitempty" bson:"province,omitempty"`
+	Aliases     []string           `json:"aliases,omitempty" bson:"aliases,omitempty"`
+	Coordinates *GeoPoint          `json:"coordinates,omitempty" bson:"coordinates,omitempty"`
+	// Keys là tên, các tên gọi khác và slug đã bỏ dấu (utils.FoldText), dùng để nhận ra địa điểm
+	// từ chuỗi người dùng nhập. Mỗi key chỉ thuộc về một địa điểm.
+	Keys []string `json:"-" bson:"keys"`
+	// SearchTerms gồm các key và mọi phần đuôi của chúng bắt đầu từ một từ ("ho chi minh",
+	// "chi minh", "minh"), để gợi ý theo tiền tố của bất kỳ từ nào.
+	SearchTerms []string  `json:"-" bson:"searchTerms"`
+	CreatedAt   time.Time `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+// GeoPoint là tọa độ theo vĩ độ và kinh độ.
+type GeoPoint struct {
+	Lat float64 `json:"lat" bson:"lat" validate:"gte=-90,lte=90"`
+	Lng float64 `json:"lng" bson:"lng" validate:"gte=-180,lte=180"`
+}
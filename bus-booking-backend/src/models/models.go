@@ -6,14 +6,40 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Role là vai trò của tài khoản.
+type Role string
+
+const (
+	RoleCustomer      Role = "customer"
+	RoleOperatorStaff Role = "operator_staff"
+	RoleAdmin         Role = "admin"
+)
+
+// IsValid cho biết role có phải một vai trò được hỗ trợ hay không.
+func (r Role) IsValid() bool {
+	return r == RoleCustomer || r == RoleOperatorStaff || r == RoleAdmin
+}
+
 type User struct {
 	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
 	Email        string             `json:"email" bson:"email" validate:"required,email"`
 	Phone        string             `json:"phone" bson:"phone" validate:"required"`
-	PasswordHash string             `json:"-" bson:"passwordHash"` 
+	PasswordHash string             `json:"-" bson:"passwordHash"`
 	Name         string             `json:"name" bson:"name" validate:"required"`
-	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
+	// Role rỗng ở các tài khoản tạo trước khi có phân quyền và được coi là khách hàng.
+	Role Role `json:"role,omitempty" bson:"role,omitempty"`
+	// CompanyID là nhà xe mà nhân viên nhà xe trực thuộc.
+	CompanyID *primitive.ObjectID `json:"companyId,omitempty" bson:"companyId,omitempty"`
+	CreatedAt time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt" bson:"updatedAt"`
+}
+
+// EffectiveRole trả về vai trò của người dùng, mặc định là khách hàng.
+func (u *User) EffectiveRole() Role {
+	if u.Role == "" {
+		return RoleCustomer
+	}
+	return u.Role
 }
 
 type Company struct {
@@ -22,13 +48,41 @@ type Company struct {
 	Code        string             `json:"code" bson:"code"`
 	Description string             `json:"description,omitempty" bson:"description,omitempty"`
 	LogoURL     string             `json:"logoUrl,omitempty" bson:"logoUrl,omitempty"`
+	// RefundPolicy là chính sách hoàn tiền riêng của nhà xe; nil nghĩa là dùng chính sách mặc định.
+	RefundPolicy *RefundPolicy `json:"refundPolicy,omitempty" bson:"refundPolicy,omitempty"`
+	// Rating là điểm đánh giá trung bình của nhà xe (0-5); 0 nghĩa là chưa có đánh giá.
+	Rating float64 `json:"rating,omitempty" bson:"rating,omitempty"`
+	// DeactivatedAt khác nil khi nhà xe đã ngừng hoạt động; chuyến đi của nhà xe không còn được bán vé.
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty" bson:"deactivatedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+// IsActive cho biết nhà xe còn hoạt động hay không.
+func (c *Company) IsActive() bool {
+	return c.DeactivatedAt == nil
+}
+
+// RefundPolicy gồm các mức hoàn tiền theo số giờ trước giờ khởi hành.
+type RefundPolicy struct {
+	Tiers []RefundTier `json:"tiers" bson:"tiers" validate:"required,min=1,dive"`
+}
+
+// RefundTier: hủy vé sớm hơn MinHoursBeforeDeparture giờ trước giờ khởi hành
+// thì được hoàn Percent phần trăm số tiền đã thanh toán.
+type RefundTier struct {
+	MinHoursBeforeDeparture float64 `json:"minHoursBeforeDeparture" bson:"minHoursBeforeDeparture" validate:"gte=0"`
+	Percent                 float64 `json:"percent" bson:"percent" validate:"gte=0,lte=100"`
 }
 
 type Vehicle struct {
 	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID  primitive.ObjectID `json:"companyId" bson:"companyId"`
 	Type       string             `json:"type" bson:"type"`
 	TotalSeats int                `json:"totalSeats" bson:"totalSeats"`
-	SeatMap    interface{}        `json:"seatMap,omitempty" bson:"seatMap,omitempty"` 
+	SeatMap    *SeatMap           `json:"seatMap,omitempty" bson:"seatMap,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt  time.Time          `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
 }
 
 type Trip struct {
@@ -39,43 +93,141 @@ type Trip struct {
 	DepartureTime       time.Time          `json:"departureTime" bson:"departureTime"`
 	ExpectedArrivalTime time.Time          `json:"expectedArrivalTime" bson:"expectedArrivalTime"`
 	Price               float64            `json:"price" bson:"price"`
-	Seats               []Seat             `json:"seats" bson:"seats"`
+	Fares               []Fare             `json:"fares,omitempty" bson:"fares,omitempty"`
+	Seats               []Seat             `json:"seats,omitempty" bson:"seats"`
 	AvailableSeats      int                `json:"availableSeats" bson:"-"`
-	CompanyInfo *Company `json:"companyInfo,omitempty" bson:"-"` 
-	VehicleInfo *Vehicle `json:"vehicleInfo,omitempty" bson:"-"` 
+	CompanyInfo         *Company           `json:"companyInfo,omitempty" bson:"-"`
+	VehicleInfo         *Vehicle           `json:"vehicleInfo,omitempty" bson:"-"`
+	CreatedAt           time.Time          `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt           time.Time          `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	// ScheduleID là lịch chạy đã sinh ra chuyến đi, nil với chuyến được tạo thủ công.
+	ScheduleID *primitive.ObjectID `json:"scheduleId,omitempty" bson:"scheduleId,omitempty"`
+	// SegmentInfo là chặng khách tìm kiếm, chỉ có trong kết quả tìm kiếm và chi tiết chuyến đi.
+	SegmentInfo *SegmentInfo `json:"segmentInfo,omitempty" bson:"-"`
 }
 
 type Seat struct {
-	SeatNumber string `json:"seatNumber" bson:"seatNumber"`
-	Status     string `json:"status" bson:"status"` 
+	SeatNumber string     `json:"seatNumber" bson:"seatNumber"`
+	Status     SeatStatus `json:"status" bson:"status"`
+	SeatType   SeatType   `json:"seatType,omitempty" bson:"seatType,omitempty"`
+	// Legs là các chặng của ghế đang được giữ hoặc đã bán. Status chỉ còn dùng cho dữ liệu cũ,
+	// ghế bán theo chặng luôn có Status "available".
+	Legs []SeatLeg `json:"-" bson:"legs,omitempty"`
 }
 
 type Route struct {
-	From LocationPoint `json:"from" bson:"from"`
-	To   LocationPoint `json:"to" bson:"to"`
+	From LocationPoint `json:"from" bson:"from" validate:"required"`
+	To   LocationPoint `json:"to" bson:"to" validate:"required"`
+	// Stops là các điểm dừng theo thứ tự, gồm cả điểm đầu và điểm cuối. Rỗng với tuyến chỉ có
+	// điểm đi và điểm đến.
+	Stops []Stop `json:"stops,omitempty" bson:"stops,omitempty" validate:"omitempty,dive"`
 }
 
 type LocationPoint struct {
-	Name string `json:"name" bson:"name"`
+	Name string `json:"name" bson:"name" validate:"required,max=100"`
+	// LocationID trỏ tới địa điểm trong danh mục; nil với tên nhập tự do không khớp địa điểm nào.
+	LocationID *primitive.ObjectID `json:"locationId,omitempty" bson:"locationId,omitempty"`
 }
 
 type Booking struct {
-	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	UserID      primitive.ObjectID `json:"userId" bson:"userId"`
-	TripID      primitive.ObjectID `json:"tripId" bson:"tripId"`
-	BookingTime time.Time          `json:"bookingTime" bson:"bookingTime"`
-	Status      string             `json:"status" bson:"status"` 
-	TotalAmount float64            `json:"totalAmount" bson:"totalAmount"`
-	Passengers  []Passenger        `json:"passengers" bson:"passengers"`
-	TicketCode  string             `json:"ticketCode,omitempty" bson:"ticketCode,omitempty"`
-	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ID            primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID        primitive.ObjectID  `json:"userId" bson:"userId"`
+	TripID        primitive.ObjectID  `json:"tripId" bson:"tripId"`
+	BookingTime   time.Time           `json:"bookingTime" bson:"bookingTime"`
+	Status        BookingStatus       `json:"status" bson:"status"`
+	TotalAmount   float64             `json:"totalAmount" bson:"totalAmount"`
+	Passengers    []Passenger         `json:"passengers" bson:"passengers"`
+	TicketCode    string              `json:"ticketCode,omitempty" bson:"ticketCode,omitempty"`
+	PaymentStatus string              `json:"paymentStatus,omitempty" bson:"paymentStatus,omitempty"`
+	PaymentID     *primitive.ObjectID `json:"paymentId,omitempty" bson:"paymentId,omitempty"`
+	// Segment là chặng đã đặt; nil với booking cũ, được hiểu là trọn tuyến.
+	Segment *Segment `json:"segment,omitempty" bson:"segment,omitempty"`
+	// HoldExpiresAt là thời điểm ghế đang giữ sẽ được trả lại nếu chưa thanh toán.
+	HoldExpiresAt *time.Time     `json:"holdExpiresAt,omitempty" bson:"holdExpiresAt,omitempty"`
+	Cancellation  *Cancellation  `json:"cancellation,omitempty" bson:"cancellation,omitempty"`
+	StatusHistory []StatusChange `json:"statusHistory,omitempty" bson:"statusHistory,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt" bson:"updatedAt"`
 
 	TripInfo *Trip `json:"tripInfo,omitempty" bson:"tripInfo,omitempty"`
 }
 
+type Cancellation struct {
+	CancelledAt   time.Time `json:"cancelledAt" bson:"cancelledAt"`
+	Reason        string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	RefundPercent float64   `json:"refundPercent" bson:"refundPercent"`
+	RefundAmount  float64   `json:"refundAmount" bson:"refundAmount"`
+}
+
 type Passenger struct {
 	Name       string `json:"name" bson:"name"`
 	Phone      string `json:"phone" bson:"phone"`
 	SeatNumber string `json:"seatNumber" bson:"seatNumber"`
-}
\ No newline at end of file
+}
+
+type Payment struct {
+	ID             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	BookingID      primitive.ObjectID `json:"bookingId" bson:"bookingId"`
+	UserID         primitive.ObjectID `json:"userId" bson:"userId"`
+	Provider       string             `json:"provider" bson:"provider"`
+	IntentID       string             `json:"intentId" bson:"intentId"`
+	TransactionID  string             `json:"transactionId,omitempty" bson:"transactionId,omitempty"`
+	Amount         float64            `json:"amount" bson:"amount"`
+	Currency       string             `json:"currency" bson:"currency"`
+	Status         string             `json:"status" bson:"status"` // pending, succeeded, failed, partially_refunded, refunded
+	FailureReason  string             `json:"failureReason,omitempty" bson:"failureReason,omitempty"`
+	RefundedAmount float64            `json:"refundedAmount" bson:"refundedAmount"`
+	Refunds        []Refund           `json:"refunds,omitempty" bson:"refunds,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Trạng thái của một lần hoàn tiền.
+const (
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+// Refund là một lần hoàn tiền được ghi nhận trên giao dịch thanh toán. Lần hoàn tiền "failed"
+// được thử lại định kỳ; Attempts đếm số lần đã gọi cổng thanh toán.
+type Refund struct {
+	ID        string    `json:"id,omitempty" bson:"id,omitempty"` // mã hoàn tiền do cổng thanh toán cấp
+	Amount    float64   `json:"amount" bson:"amount"`
+	Percent   float64   `json:"percent" bson:"percent"`
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Status    string    `json:"status" bson:"status"` // succeeded, failed
+	Attempts  int       `json:"attempts,omitempty" bson:"attempts,omitempty"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+}
+
+// WebhookEvent lưu lại các sự kiện webhook đã nhận để chống xử lý lặp.
+// ID chính là mã sự kiện do cổng thanh toán cấp.
+type WebhookEvent struct {
+	ID          string     `json:"id" bson:"_id"`
+	Provider    string     `json:"provider" bson:"provider"`
+	Type        string     `json:"type" bson:"type"`
+	IntentID    string     `json:"intentId" bson:"intentId"`
+	Status      string     `json:"status" bson:"status"` // processing, processed
+	ReceivedAt  time.Time  `json:"receivedAt" bson:"receivedAt"`
+	ProcessedAt *time.Time `json:"processedAt,omitempty" bson:"processedAt,omitempty"`
+	// LockedUntil là hạn của request đang xử lý sự kiện. Sự kiện "processing" quá hạn (tiến trình
+	// bị dừng giữa chừng) được lần gửi lại tiếp theo nhận xử lý tiếp.
+	LockedUntil *time.Time `json:"lockedUntil,omitempty" bson:"lockedUntil,omitempty"`
+}
+
+// Session là một phiên đăng nhập. Refresh token chỉ được lưu dưới dạng băm SHA-256 và được
+// thay mới sau mỗi lần làm mới access token; access token tham chiếu phiên qua claim "jti".
+type Session struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id"`
+	UserID           primitive.ObjectID `json:"userId" bson:"userId"`
+	RefreshTokenHash string             `json:"-" bson:"refreshTokenHash"`
+	UserAgent        string             `json:"userAgent,omitempty" bson:"userAgent,omitempty"`
+	IP               string             `json:"ip,omitempty" bson:"ip,omitempty"`
+	CreatedAt        time.Time          `json:"createdAt" bson:"createdAt"`
+	RefreshedAt      time.Time          `json:"refreshedAt" bson:"refreshedAt"`
+	ExpiresAt        time.Time          `json:"expiresAt" bson:"expiresAt"`
+	RevokedAt        *time.Time         `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+	// RevokedReason: logout, logout_all, refresh_token_reused.
+	RevokedReason string `json:"revokedReason,omitempty" bson:"revokedReason,omitempty"`
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+)
+
+// Stop là một điểm dừng trên tuyến. OffsetMinutes là số phút từ giờ khởi hành của chuyến đến
+// khi xe tới điểm dừng; điểm đầu có OffsetMinutes bằng 0.
+type Stop struct {
+	Name          string `json:"name" bson:"name" validate:"required,max=100"`
+	OffsetMinutes int    `json:"offsetMinutes" bson:"offsetMinutes" validate:"gte=0"`
+	// LocationID trỏ tới địa điểm trong danh mục, giống LocationPoint.LocationID.
+	LocationID *primitive.ObjectID `json:"locationId,omitempty" bson:"locationId,omitempty"`
+}
+
+// Fare là giá vé cho một cặp điểm dừng (lên ở From, xuống ở To).
+type Fare struct {
+	From  string  `json:"from" bson:"from" validate:"required,max=100"`
+	To    string  `json:"to" bson:"to" validate:"required,max=100"`
+	Price float64 `json:"price" bson:"price" validate:"required,gt=0"`
+}
+
+// Segment là chặng từ điểm dừng thứ FromStop đến điểm dừng thứ ToStop (tính từ 0, FromStop < ToStop).
+type Segment struct {
+	FromStop int `json:"fromStop" bson:"fromStop"`
+	ToStop   int `json:"toStop" bson:"toStop"`
+}
+
+// Overlaps cho biết hai chặng có dùng chung một đoạn đường hay không. Chặng kết thúc tại điểm
+// dừng mà chặng kia bắt đầu không bị tính là chồng nhau.
+func (s Segment) Overlaps(other Segment) bool {
+	return s.FromStop < other.ToStop && other.FromStop < s.ToStop
+}
+
+// SeatLeg là một chặng của ghế đang được giữ hoặc đã bán cho booking BookingID.
+type SeatLeg struct {
+	Segment   `bson:",inline"`
+	Status    SeatStatus         `json:"status" bson:"status"`
+	BookingID primitive.ObjectID `json:"bookingId" bson:"bookingId"`
+}
+
+// StatusFor trả về trạng thái của ghế trên chặng seg: "booked" nếu có chặng đã bán chồng lên
+// seg, "held" nếu chỉ có chặng đang giữ, ngược lại "available". Status khác "available" của
+// dữ liệu cũ (trước khi có điểm dừng) được hiểu là chiếm cả chuyến.
+func (s *Seat) StatusFor(seg Segment) SeatStatus {
+	if s.Status != "" && s.Status != SeatAvailable {
+		return s.Status
+	}
+	status := SeatAvailable
+	for _, leg := range s.Legs {
+		if !leg.Overlaps(seg) {
+			continue
+		}
+		if leg.Status == SeatBooked {
+			return SeatBooked
+		}
+		status = SeatHeld
+	}
+	return status
+}
+
+// ErrInvalidRoute là lỗi gốc khi danh sách điểm dừng hoặc bảng giá không nhất quán.
+var ErrInvalidRoute = apperrors.New(apperrors.ErrInvalidInput, "tuyến đường không hợp lệ").WithCode("invalid_route")
+
+// NormalizeRoute bỏ khoảng trắng thừa trong tên địa điểm rồi kiểm tra danh sách điểm dừng và bảng
+// giá của tuyến. Khi có điểm dừng, From và To phải trùng điểm dừng đầu và cuối, các điểm dừng có
+// tên khác nhau, điểm đầu có offset 0 và offset tăng dần; mỗi giá vé phải là một cặp điểm dừng
+// theo đúng chiều đi.
+func NormalizeRoute(route *Route, fares []Fare) error {
+	var problems []string
+
+	route.From.Name = strings.TrimSpace(route.From.Name)
+	route.To.Name = strings.TrimSpace(route.To.Name)
+	for i := range route.Stops {
+		route.Stops[i].Name = strings.TrimSpace(route.Stops[i].Name)
+	}
+	if n := len(route.Stops); n > 0 {
+		if n < 2 {
+			problems = append(problems, "cần ít nhất hai điểm dừng")
+		}
+		if route.Stops[0].Name != route.From.Name || route.Stops[n-1].Name != route.To.Name {
+			problems = append(problems, "điểm dừng đầu và cuối phải trùng điểm đi và điểm đến")
+		}
+		if route.Stops[0].OffsetMinutes != 0 {
+			problems = append(problems, "điểm dừng đầu tiên phải có offsetMinutes bằng 0")
+		}
+		seen := map[string]bool{}
+		for i, stop := range route.Stops {
+			if seen[stop.Name] {
+				problems = append(problems, fmt.Sprintf("điểm dừng '%s' bị trùng", stop.Name))
+			}
+			seen[stop.Name] = true
+			if i > 0 && stop.OffsetMinutes <= route.Stops[i-1].OffsetMinutes {
+				problems = append(problems, fmt.Sprintf("offsetMinutes của '%s' phải lớn hơn điểm dừng trước", stop.Name))
+			}
+		}
+	}
+	if route.From.Name == route.To.Name {
+		problems = append(problems, "điểm đi và điểm đến phải khác nhau")
+	}
+
+	names := route.StopNames()
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+	pairs := map[[2]string]bool{}
+	for _, fare := range fares {
+		from, okFrom := index[fare.From]
+		to, okTo := index[fare.To]
+		switch {
+		case !okFrom || !okTo:
+			problems = append(problems, fmt.Sprintf("giá vé %s → %s dùng điểm dừng không có trên tuyến", fare.From, fare.To))
+		case from >= to:
+			problems = append(problems, fmt.Sprintf("giá vé %s → %s ngược chiều tuyến", fare.From, fare.To))
+		case pairs[[2]string{fare.From, fare.To}]:
+			problems = append(problems, fmt.Sprintf("giá vé %s → %s bị khai báo hai lần", fare.From, fare.To))
+		}
+		pairs[[2]string{fare.From, fare.To}] = true
+	}
+
+	if len(problems) > 0 {
+		return apperrors.New(ErrInvalidRoute.Kind, ErrInvalidRoute.Message+": "+strings.Join(problems, "; ")).WithCode(ErrInvalidRoute.Code)
+	}
+	return nil
+}
+
+// StopPoints trả về các điểm dừng theo thứ tự giống StopNames; tuyến không khai báo điểm dừng
+// có hai điểm dừng là điểm đi và điểm đến (OffsetMinutes bằng 0).
+func (r *Route) StopPoints() []Stop {
+	if len(r.Stops) == 0 {
+		return []Stop{
+			{Name: r.From.Name, LocationID: r.From.LocationID},
+			{Name: r.To.Name, LocationID: r.To.LocationID},
+		}
+	}
+	return r.Stops
+}
+
+// StopNames trả về tên các điểm dừng theo thứ tự; tuyến không khai báo điểm dừng chỉ có điểm
+// đi và điểm đến.
+func (r *Route) StopNames() []string {
+	if len(r.Stops) == 0 {
+		return []string{r.From.Name, r.To.Name}
+	}
+	names := make([]string, len(r.Stops))
+	for i, stop := range r.Stops {
+		names[i] = stop.Name
+	}
+	return names
+}
+
+// FullSegment là chặng từ điểm đầu đến điểm cuối của chuyến.
+func (t *Trip) FullSegment() Segment {
+	return Segment{FromStop: 0, ToStop: len(t.Route.StopNames()) - 1}
+}
+
+// FindSegment tìm chặng lên xe ở from và xuống ở một điểm dừng to phía sau.
+func (t *Trip) FindSegment(from, to string) (Segment, bool) {
+	names := t.Route.StopNames()
+	for i, name := range names {
+		if name != from {
+			continue
+		}
+		for j := i + 1; j < len(names); j++ {
+			if names[j] == to {
+				return Segment{FromStop: i, ToStop: j}, true
+			}
+		}
+	}
+	return Segment{}, false
+}
+
+// FareFor trả về giá vé của chặng seg. Chặng trọn tuyến mặc định có giá Price; các chặng khác
+// chỉ được bán khi có trong bảng giá.
+func (t *Trip) FareFor(seg Segment) (float64, bool) {
+	names := t.Route.StopNames()
+	for _, fare := range t.Fares {
+		if fare.From == names[seg.FromStop] && fare.To == names[seg.ToStop] {
+			return fare.Price, true
+		}
+	}
+	if seg == t.FullSegment() {
+		return t.Price, true
+	}
+	return 0, false
+}
+
+// SegmentTimes trả về giờ xe tới điểm lên và điểm xuống của chặng seg. Tuyến không khai báo
+// điểm dừng dùng giờ khởi hành và giờ đến dự kiến của chuyến.
+func (t *Trip) SegmentTimes(seg Segment) (departure, arrival time.Time) {
+	if len(t.Route.Stops) == 0 {
+		return t.DepartureTime, t.ExpectedArrivalTime
+	}
+	offset := func(i int) time.Duration {
+		return time.Duration(t.Route.Stops[i].OffsetMinutes) * time.Minute
+	}
+	return t.DepartureTime.Add(offset(seg.FromStop)), t.DepartureTime.Add(offset(seg.ToStop))
+}
+
+// SegmentInfo mô tả chặng khách chọn trên một chuyến đi trong kết quả tìm kiếm.
+type SegmentInfo struct {
+	Segment
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	DepartureTime time.Time `json:"departureTime"`
+	ArrivalTime   time.Time `json:"arrivalTime"`
+	Price         float64   `json:"price"`
+}
+
+// DescribeSegment dựng SegmentInfo cho chặng seg với giá price.
+func (t *Trip) DescribeSegment(seg Segment, price float64) *SegmentInfo {
+	names := t.Route.StopNames()
+	departure, arrival := t.SegmentTimes(seg)
+	return &SegmentInfo{
+		Segment:       seg,
+		From:          names[seg.FromStop],
+		To:            names[seg.ToStop],
+		DepartureTime: departure,
+		ArrivalTime:   arrival,
+		Price:         price,
+	}
+}
+
+// InLocation đổi giờ khởi hành, giờ đến dự kiến và giờ của segmentInfo sang múi giờ loc để
+// phản hồi JSON mang độ lệch múi giờ của nơi vận hành (ví dụ "+07:00") thay vì UTC.
+func (t *Trip) InLocation(loc *time.Location) {
+	t.DepartureTime = t.DepartureTime.In(loc)
+	t.ExpectedArrivalTime = t.ExpectedArrivalTime.In(loc)
+	if t.SegmentInfo != nil {
+		t.SegmentInfo.DepartureTime = t.SegmentInfo.DepartureTime.In(loc)
+		t.SegmentInfo.ArrivalTime = t.SegmentInfo.ArrivalTime.In(loc)
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Định dạng ngày và giờ trong ngày dùng cho lịch chạy. Ngày được lưu dạng chuỗi để luôn được
+// hiểu theo múi giờ vận hành, không phụ thuộc múi giờ của máy chủ.
+const (
+	DateLayout      = "2006-01-02"
+	TimeOfDayLayout = "15:04"
+)
+
+// Schedule là lịch chạy lặp lại của một tuyến. Bộ sinh chuyến đi dựa vào lịch để tạo trước
+// các Trip trong một số ngày tới.
+type Schedule struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CompanyID primitive.ObjectID `json:"companyId" bson:"companyId"`
+	VehicleID primitive.ObjectID `json:"vehicleId" bson:"vehicleId"`
+	Route     Route              `json:"route" bson:"route"`
+	Price     float64            `json:"price" bson:"price"`
+	Fares     []Fare             `json:"fares,omitempty" bson:"fares,omitempty"`
+	// DepartureTime là giờ khởi hành trong ngày, dạng "HH:MM".
+	DepartureTime   string `json:"departureTime" bson:"departureTime"`
+	DurationMinutes int    `json:"durationMinutes" bson:"durationMinutes"`
+	// Weekdays là các ngày trong tuần có chuyến, 0 là Chủ nhật.
+	Weekdays []time.Weekday `json:"weekdays" bson:"weekdays"`
+	// ValidFrom và ValidTo (dạng "YYYY-MM-DD", đều bao gồm) giới hạn khoảng ngày có chuyến;
+	// ValidTo rỗng nghĩa là không giới hạn.
+	ValidFrom string `json:"validFrom" bson:"validFrom"`
+	ValidTo   string `json:"validTo,omitempty" bson:"validTo,omitempty"`
+	// ExceptionDates là các ngày không chạy dù khớp lịch (ngày lễ, bảo dưỡng xe...).
+	ExceptionDates []string `json:"exceptionDates,omitempty" bson:"exceptionDates,omitempty"`
+	// SkippedDates là các ngày có chuyến do lịch sinh ra đã bị xóa hoặc dời sang ngày khác. Bộ
+	// sinh chuyến đi không tạo lại chuyến cho các ngày này; cập nhật lịch chạy không ghi đè danh sách.
+	SkippedDates []string   `json:"skippedDates,omitempty" bson:"skippedDates,omitempty"`
+	DisabledAt   *time.Time `json:"disabledAt,omitempty" bson:"disabledAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+func (s *Schedule) IsActive() bool {
+	return s.DisabledAt == nil
+}
+
+// RunsOn cho biết lịch có chuyến vào ngày date ("YYYY-MM-DD") hay không.
+func (s *Schedule) RunsOn(date string) bool {
+	day, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return false
+	}
+	// Chuỗi "YYYY-MM-DD" so sánh được theo thứ tự từ điển.
+	if date < s.ValidFrom || (s.ValidTo != "" && date > s.ValidTo) {
+		return false
+	}
+	return slices.Contains(s.Weekdays, day.Weekday()) && !slices.Contains(s.ExceptionDates, date) && !slices.Contains(s.SkippedDates, date)
+}
+
+// DepartureOn trả về giờ khởi hành và giờ đến dự kiến của chuyến chạy ngày date theo múi giờ loc.
+func (s *Schedule) DepartureOn(date string, loc *time.Location) (departure, arrival time.Time, err error) {
+	departure, err = time.ParseInLocation(DateLayout+" "+TimeOfDayLayout, date+" "+s.DepartureTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return departure, departure.Add(time.Duration(s.DurationMinutes) * time.Minute), nil
+}
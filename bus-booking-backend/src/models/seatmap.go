@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+)
+
+// CellKind là loại của một ô trên sơ đồ ghế.
+type CellKind string
+
+const (
+	CellSeat     CellKind = "seat"     // ghế bán được
+	CellAisle    CellKind = "aisle"    // lối đi
+	CellDisabled CellKind = "disabled" // vị trí có ghế nhưng không bán (ghế hỏng, ghế phụ xe...)
+)
+
+// SeatType là hạng ghế.
+type SeatType string
+
+const (
+	SeatTypeSeat SeatType = "seat"
+	SeatTypeBed  SeatType = "bed"
+	SeatTypeVIP  SeatType = "vip"
+)
+
+// SeatMap là sơ đồ ghế của xe. Xe giường nằm có hai tầng, các xe khác có một tầng.
+type SeatMap struct {
+	Decks []Deck `json:"decks" bson:"decks" validate:"required,min=1,max=2,dive"`
+}
+
+// Deck là một tầng xe dạng lưới Rows x Columns. Ô không được liệt kê trong Cells là ô trống.
+type Deck struct {
+	Name    string     `json:"name" bson:"name" validate:"max=50"`
+	Rows    int        `json:"rows" bson:"rows" validate:"min=1,max=30"`
+	Columns int        `json:"columns" bson:"columns" validate:"min=1,max=10"`
+	Cells   []SeatCell `json:"cells" bson:"cells" validate:"dive"`
+}
+
+// SeatCell là một ô trên lưới, Row và Column tính từ 0. Code là mã ghế (trùng với
+// Seat.SeatNumber trên chuyến đi) và chỉ bắt buộc với ô loại "seat".
+type SeatCell struct {
+	Row      int      `json:"row" bson:"row" validate:"gte=0"`
+	Column   int      `json:"column" bson:"column" validate:"gte=0"`
+	Kind     CellKind `json:"kind" bson:"kind" validate:"required,oneof=seat aisle disabled"`
+	Code     string   `json:"code,omitempty" bson:"code,omitempty" validate:"max=10"`
+	SeatType SeatType `json:"seatType,omitempty" bson:"seatType,omitempty" validate:"omitempty,oneof=seat bed vip"`
+}
+
+// ErrInvalidSeatMap là lỗi gốc khi sơ đồ ghế không nhất quán.
+var ErrInvalidSeatMap = apperrors.New(apperrors.ErrInvalidInput, "sơ đồ ghế không hợp lệ").WithCode("invalid_seat_map")
+
+// Validate kiểm tra các ràng buộc giữa các ô mà tag `validate` không diễn đạt được: ô nằm
+// trong lưới, không có hai ô cùng vị trí, mã ghế duy nhất trên toàn xe và số ô "seat"
+// bằng totalSeats. Mọi vi phạm được gộp vào một lỗi.
+func (m *SeatMap) Validate(totalSeats int) error {
+	var problems []string
+	codes := map[string]bool{}
+	seatCount := 0
+
+	for d, deck := range m.Decks {
+		positions := map[[2]int]bool{}
+		for _, cell := range deck.Cells {
+			where := fmt.Sprintf("tầng %d, ô (%d,%d)", d+1, cell.Row, cell.Column)
+			if cell.Row >= deck.Rows || cell.Column >= deck.Columns {
+				problems = append(problems, where+" nằm ngoài lưới")
+			}
+			pos := [2]int{cell.Row, cell.Column}
+			if positions[pos] {
+				problems = append(problems, where+" bị khai báo hai lần")
+			}
+			positions[pos] = true
+
+			if cell.Kind != CellSeat {
+				continue
+			}
+			seatCount++
+			switch {
+			case strings.TrimSpace(cell.Code) == "":
+				problems = append(problems, where+" là ghế nhưng thiếu mã ghế")
+			case codes[cell.Code]:
+				problems = append(problems, fmt.Sprintf("mã ghế '%s' bị trùng", cell.Code))
+			}
+			codes[cell.Code] = true
+		}
+	}
+
+	if seatCount != totalSeats {
+		problems = append(problems, fmt.Sprintf("sơ đồ có %d ghế nhưng tổng số ghế là %d", seatCount, totalSeats))
+	}
+
+	if len(problems) > 0 {
+		return apperrors.New(ErrInvalidSeatMap.Kind, ErrInvalidSeatMap.Message+": "+strings.Join(problems, "; ")).WithCode(ErrInvalidSeatMap.Code)
+	}
+	return nil
+}
+
+// Seats trả về các ô ghế bán được, theo thứ tự tầng rồi thứ tự khai báo trong tầng.
+func (m *SeatMap) Seats() []SeatCell {
+	var seats []SeatCell
+	for _, deck := range m.Decks {
+		for _, cell := range deck.Cells {
+			if cell.Kind == CellSeat {
+				seats = append(seats, cell)
+			}
+		}
+	}
+	return seats
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+)
+
+// BookingStatus là trạng thái vòng đời của một booking.
+type BookingStatus string
+
+const (
+	BookingHeld      BookingStatus = "held"
+	BookingConfirmed BookingStatus = "confirmed"
+	BookingCheckedIn BookingStatus = "checked_in"
+	BookingCompleted BookingStatus = "completed"
+	BookingExpired   BookingStatus = "expired"
+	BookingCancelled BookingStatus = "cancelled"
+	BookingRefunded  BookingStatus = "refunded"
+)
+
+// SeatStatus là trạng thái của một ghế trên chuyến đi.
+type SeatStatus string
+
+const (
+	SeatAvailable SeatStatus = "available"
+	SeatHeld      SeatStatus = "held"
+	SeatBooked    SeatStatus = "booked"
+)
+
+// bookingTransitions liệt kê các chuyển trạng thái hợp lệ của booking:
+//
+//	held → confirmed → checked_in → completed
+//	held → expired, held → cancelled
+//	confirmed → completed, confirmed → cancelled → refunded
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingHeld:      {BookingConfirmed, BookingExpired, BookingCancelled},
+	BookingConfirmed: {BookingCheckedIn, BookingCompleted, BookingCancelled},
+	BookingCheckedIn: {BookingCompleted},
+	BookingCancelled: {BookingRefunded},
+}
+
+var seatTransitions = map[SeatStatus][]SeatStatus{
+	SeatAvailable: {SeatHeld},
+	SeatHeld:      {SeatBooked, SeatAvailable},
+	SeatBooked:    {SeatAvailable},
+}
+
+// ErrInvalidTransition là lỗi gốc của mọi chuyển trạng thái không hợp lệ.
+var ErrInvalidTransition = apperrors.New(apperrors.ErrConflict, "chuyển trạng thái không hợp lệ").WithCode("invalid_transition")
+
+// InvalidTransitionError cho biết đối tượng và cặp trạng thái bị từ chối.
+type InvalidTransitionError struct {
+	Entity string
+	From   string
+	To     string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("%s: %s không thể chuyển từ '%s' sang '%s'", ErrInvalidTransition.Error(), e.Entity, e.From, e.To)
+}
+
+func (e *InvalidTransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// CanTransitionTo cho biết booking có được phép chuyển sang trạng thái to hay không.
+func (s BookingStatus) CanTransitionTo(to BookingStatus) bool {
+	for _, next := range bookingTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateBookingTransition trả về *InvalidTransitionError nếu chuyển trạng thái không hợp lệ.
+func ValidateBookingTransition(from, to BookingStatus) error {
+	if !from.CanTransitionTo(to) {
+		return &InvalidTransitionError{Entity: "booking", From: string(from), To: string(to)}
+	}
+	return nil
+}
+
+func (s SeatStatus) CanTransitionTo(to SeatStatus) bool {
+	for _, next := range seatTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateSeatTransition(from, to SeatStatus) error {
+	if !from.CanTransitionTo(to) {
+		return &InvalidTransitionError{Entity: "ghế", From: string(from), To: string(to)}
+	}
+	return nil
+}
+
+// StatusChange là một mục trong lịch sử trạng thái (chỉ được thêm, không sửa) của booking.
+type StatusChange struct {
+	From   BookingStatus `json:"from,omitempty" bson:"from,omitempty"`
+	To     BookingStatus `json:"to" bson:"to"`
+	At     time.Time     `json:"at" bson:"at"`
+	Actor  string        `json:"actor" bson:"actor"`
+	Reason string        `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// Các tác nhân hệ thống ghi vào lịch sử trạng thái. Người dùng được ghi dưới dạng "user:<id>".
+const (
+	ActorHoldExpiry = "system:hold-expiry"
+	ActorGateway    = "system:payment-gateway"
+)
+
+func UserActor(userID string) string {
+	return "user:" + userID
+}
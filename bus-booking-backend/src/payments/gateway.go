@@ -0,0 +1,92 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+)
+
+// Các trạng thái giao dịch do cổng thanh toán trả về.
+const (
+	StatusRequiresCapture = "requires_capture"
+	StatusSucceeded       = "succeeded"
+	StatusFailed          = "failed"
+	StatusRefunded        = "refunded"
+)
+
+var (
+	// ErrPaymentDeclined được trả về khi cổng thanh toán từ chối giao dịch.
+	ErrPaymentDeclined = errors.New("giao dịch bị cổng thanh toán từ chối")
+	// ErrGatewayTimeout được trả về khi cổng thanh toán không phản hồi kịp.
+	ErrGatewayTimeout = errors.New("cổng thanh toán không phản hồi")
+	// ErrIntentNotFound được trả về khi mã giao dịch không tồn tại trên cổng thanh toán.
+	ErrIntentNotFound = errors.New("không tìm thấy giao dịch trên cổng thanh toán")
+	// ErrInvalidRefund được trả về khi số tiền hoàn vượt quá số tiền đã thu.
+	ErrInvalidRefund = errors.New("yêu cầu hoàn tiền không hợp lệ")
+	// ErrInvalidWebhook được trả về khi nội dung webhook không đọc được.
+	ErrInvalidWebhook = apperrors.New(apperrors.ErrInvalidInput, "webhook không hợp lệ").WithCode("invalid_webhook")
+)
+
+// PaymentGateway là giao diện chung cho mọi nhà cung cấp thanh toán.
+// Mỗi nhà cung cấp (VNPay, MoMo, ...) chỉ cần cài đặt giao diện này.
+type PaymentGateway interface {
+	Name() string
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+	Capture(ctx context.Context, intentID string) (*CaptureResult, error)
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+	ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
+
+type IntentRequest struct {
+	BookingID primitive.ObjectID
+	Amount    float64
+	Currency  string
+}
+
+type Intent struct {
+	ID       string
+	Amount   float64
+	Currency string
+	Status   string
+}
+
+type CaptureResult struct {
+	IntentID      string
+	TransactionID string
+	Amount        float64
+	Status        string
+}
+
+type RefundRequest struct {
+	IntentID string
+	Amount   float64
+	Reason   string
+}
+
+type RefundResult struct {
+	RefundID string
+	IntentID string
+	Amount   float64
+	Status   string
+}
+
+// WebhookEvent là sự kiện đã được chuẩn hóa từ webhook của cổng thanh toán.
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	IntentID  string    `json:"intentId"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Các loại sự kiện webhook được hệ thống xử lý.
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventRefundSucceeded  = "refund.succeeded"
+)
@@ -0,0 +1,163 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// MockOutcome quyết định kết quả của bước Capture trên MockGateway; MockOutcomeTimeout cũng làm
+// các lần Refund thất bại như khi cổng thanh toán không phản hồi.
+type MockOutcome string
+
+const (
+	MockOutcomeSuccess MockOutcome = "success"
+	MockOutcomeFailure MockOutcome = "failure"
+	MockOutcomeTimeout MockOutcome = "timeout"
+)
+
+// ParseMockOutcome chuyển chuỗi cấu hình thành MockOutcome.
+func ParseMockOutcome(s string) (MockOutcome, error) {
+	switch o := MockOutcome(s); o {
+	case MockOutcomeSuccess, MockOutcomeFailure, MockOutcomeTimeout:
+		return o, nil
+	}
+	return "", fmt.Errorf("kết quả giả lập thanh toán không hợp lệ: '%s'", s)
+}
+
+// MockGateway là cổng thanh toán giả lập chạy hoàn toàn trong bộ nhớ.
+// Kết quả luôn xác định theo Outcome nên có thể dùng cho môi trường dev và kiểm thử.
+type MockGateway struct {
+	mu            sync.Mutex
+	outcome       MockOutcome
+	webhookSecret string
+	seq           int
+	intents       map[string]*mockIntent
+	refunded      map[string]float64
+}
+
+type mockIntent struct {
+	intent   Intent
+	captured float64
+}
+
+// NewMockGateway tạo cổng giả lập; webhookSecret dùng để xác thực chữ ký webhook gửi tới.
+func NewMockGateway(outcome MockOutcome, webhookSecret string) *MockGateway {
+	return &MockGateway{
+		outcome:       outcome,
+		webhookSecret: webhookSecret,
+		intents:       make(map[string]*mockIntent),
+		refunded:      make(map[string]float64),
+	}
+}
+
+// SetOutcome thay đổi kết quả của các lần Capture (và Refund) tiếp theo.
+func (g *MockGateway) SetOutcome(outcome MockOutcome) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.outcome = outcome
+}
+
+func (g *MockGateway) Name() string {
+	return "mock"
+}
+
+func (g *MockGateway) nextID(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("%s_%06d", prefix, g.seq)
+}
+
+func (g *MockGateway) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrGatewayTimeout
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent := Intent{
+		ID:       g.nextID("mock_pi"),
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Status:   StatusRequiresCapture,
+	}
+	g.intents[intent.ID] = &mockIntent{intent: intent}
+	return &intent, nil
+}
+
+func (g *MockGateway) Capture(ctx context.Context, intentID string) (*CaptureResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrGatewayTimeout
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	mi, ok := g.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	switch g.outcome {
+	case MockOutcomeTimeout:
+		return nil, ErrGatewayTimeout
+	case MockOutcomeFailure:
+		mi.intent.Status = StatusFailed
+		return nil, ErrPaymentDeclined
+	}
+
+	mi.intent.Status = StatusSucceeded
+	mi.captured = mi.intent.Amount
+	return &CaptureResult{
+		IntentID:      intentID,
+		TransactionID: g.nextID("mock_tx"),
+		Amount:        mi.captured,
+		Status:        StatusSucceeded,
+	}, nil
+}
+
+func (g *MockGateway) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrGatewayTimeout
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.outcome == MockOutcomeTimeout {
+		return nil, ErrGatewayTimeout
+	}
+	mi, ok := g.intents[req.IntentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if req.Amount <= 0 || g.refunded[req.IntentID]+req.Amount > mi.captured {
+		return nil, ErrInvalidRefund
+	}
+
+	g.refunded[req.IntentID] += req.Amount
+	if g.refunded[req.IntentID] == mi.captured {
+		mi.intent.Status = StatusRefunded
+	}
+	return &RefundResult{
+		RefundID: g.nextID("mock_re"),
+		IntentID: req.IntentID,
+		Amount:   req.Amount,
+		Status:   StatusSucceeded,
+	}, nil
+}
+
+// ParseWebhook xác thực chữ ký và đọc webhook giả lập ở dạng JSON của WebhookEvent.
+func (g *MockGateway) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	if err := VerifySignature(g.webhookSecret, payload, header.Get(SignatureHeader)); err != nil {
+		return nil, err
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, ErrInvalidWebhook
+	}
+	if event.ID == "" || event.Type == "" || event.IntentID == "" {
+		return nil, ErrInvalidWebhook
+	}
+	return &event, nil
+}
@@ -0,0 +1,36 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+)
+
+// SignatureHeader là header chứa chữ ký HMAC-SHA256 của nội dung webhook,
+// ở dạng "sha256=<hex>".
+const SignatureHeader = "X-Webhook-Signature"
+
+// ErrInvalidSignature được trả về khi chữ ký webhook không khớp với secret đã cấu hình.
+var ErrInvalidSignature = apperrors.New(apperrors.ErrUnauthorized, "chữ ký webhook không hợp lệ").WithCode("invalid_signature")
+
+// SignPayload tính chữ ký cho nội dung webhook với secret dùng chung.
+func SignPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature kiểm tra chữ ký webhook bằng phép so sánh thời gian hằng.
+func VerifySignature(secret string, payload []byte, signature string) error {
+	if secret == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+	expected := SignPayload(secret, payload)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package realtime
+
+import (
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySeatEventBus struct {
+	mu          sync.Mutex
+	buffer      int
+	subscribers map[primitive.ObjectID]map[chan SeatEvent]struct{}
+}
+
+// NewMemorySeatEventBus tạo SeatEventBus trong bộ nhớ; mỗi subscriber có hàng đợi buffer sự kiện.
+func NewMemorySeatEventBus(buffer int) SeatEventBus {
+	return &memorySeatEventBus{buffer: buffer, subscribers: map[primitive.ObjectID]map[chan SeatEvent]struct{}{}}
+}
+
+func (b *memorySeatEventBus) Publish(event SeatEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[event.TripID] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber đã bỏ lỡ sự kiện nên sơ đồ ghế của họ không còn đúng; đóng kênh để client
+			// kết nối lại và tải lại thay vì tiếp tục hiển thị trạng thái sai.
+			log.Printf("Subscriber của chuyến %s đọc sự kiện ghế quá chậm, ngắt kết nối", event.TripID.Hex())
+			b.remove(event.TripID, ch)
+		}
+	}
+}
+
+func (b *memorySeatEventBus) Subscribe(tripID primitive.ObjectID) (<-chan SeatEvent, func()) {
+	ch := make(chan SeatEvent, b.buffer)
+	b.mu.Lock()
+	if b.subscribers[tripID] == nil {
+		b.subscribers[tripID] = map[chan SeatEvent]struct{}{}
+	}
+	b.subscribers[tripID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.remove(tripID, ch)
+		})
+	}
+}
+
+// remove hủy đăng ký và đóng kênh ch nếu còn đăng ký; b.mu phải đang được giữ.
+func (b *memorySeatEventBus) remove(tripID primitive.ObjectID, ch chan SeatEvent) {
+	subscribers := b.subscribers[tripID]
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(b.subscribers, tripID)
+	}
+}
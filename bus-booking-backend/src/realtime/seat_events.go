@@ -0,0 +1,32 @@
+package realtime
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+// SeatEvent là một thay đổi trạng thái ghế trên chuyến đi. Status là trạng thái mới của các ghế
+// trên chặng Segment: "held" khi ghế vừa được giữ, "booked" khi booking được thanh toán và
+// "available" khi ghế được trả lại (hết hạn giữ chỗ, hủy vé). Segment nil nghĩa là cả chuyến.
+type SeatEvent struct {
+	TripID      primitive.ObjectID `json:"tripId"`
+	SeatNumbers []string           `json:"seatNumbers"`
+	Status      models.SeatStatus  `json:"status"`
+	Segment     *models.Segment    `json:"segment,omitempty"`
+	At          time.Time          `json:"at"`
+}
+
+// SeatEventBus phát các thay đổi trạng thái ghế tới những người đang xem sơ đồ ghế. Bản cài đặt
+// trong bộ nhớ chỉ phục vụ một tiến trình; khi chạy nhiều instance có thể thay bằng bản đọc từ
+// MongoDB change streams mà không đổi nơi phát hay nơi nhận sự kiện.
+type SeatEventBus interface {
+	// Publish gửi sự kiện tới mọi subscriber của chuyến đi; không bao giờ chặn người gọi.
+	Publish(event SeatEvent)
+	// Subscribe nhận các sự kiện của chuyến tripID cho tới khi gọi hàm hủy được trả về. Kênh bị
+	// đóng khi hủy đăng ký hoặc khi subscriber đọc quá chậm để bắt kịp; khi đó client cần tải
+	// lại sơ đồ ghế.
+	Subscribe(tripID primitive.ObjectID) (<-chan SeatEvent, func())
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BookingFields là các trường được cập nhật kèm theo một lần chuyển trạng thái booking.
+// Trường nil không bị thay đổi.
+type BookingFields struct {
+	PaymentStatus   *string
+	PaymentID       *primitive.ObjectID
+	TicketCode      *string
+	Cancellation    *models.Cancellation
+	ClearHoldExpiry bool
+}
+
+type BookingRepository interface {
+	Create(ctx context.Context, booking *models.Booking) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Booking, error)
+	// FindByIDs trả về các booking có ID trong ids; ID không tồn tại bị bỏ qua.
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Booking, error)
+	// FindByTrip trả về mọi booking (mọi trạng thái) của chuyến đi.
+	FindByTrip(ctx context.Context, tripID primitive.ObjectID) ([]models.Booking, error)
+	// FindByUser trả về các booking của người dùng, mới nhất trước.
+	FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Booking, error)
+	FindByTicketCode(ctx context.Context, ticketCode string) (*models.Booking, error)
+	// CountByTrip đếm số booking (mọi trạng thái) của chuyến đi.
+	CountByTrip(ctx context.Context, tripID primitive.ObjectID) (int64, error)
+	// CountActiveByTrip đếm các booking còn hiệu lực của chuyến đi: đã xác nhận, đã lên xe hoặc
+	// đang giữ chỗ mà chưa hết hạn lúc now. Booking cũ chưa có holdExpiresAt luôn được tính.
+	CountActiveByTrip(ctx context.Context, tripID primitive.ObjectID, now time.Time) (int64, error)
+	// FindExpiredHolds trả về các booking "held" có holdExpiresAt <= now. Booking cũ chưa có
+	// holdExpiresAt được tính hạn theo bookingTime <= legacyCutoff.
+	FindExpiredHolds(ctx context.Context, now, legacyCutoff time.Time) ([]models.Booking, error)
+	// UpdateStatus chuyển booking từ trạng thái from sang change.To và ghi change vào
+	// statusHistory trong cùng một lệnh. Trả về ErrConflict nếu booking không còn ở trạng
+	// thái from, ErrDuplicate nếu mã vé mới bị trùng.
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, from models.BookingStatus, change models.StatusChange, fields BookingFields) error
+	// UpdatePassengers thay danh sách hành khách nếu booking đang ở một trong các trạng thái
+	// allowed; ngược lại trả về ErrConflict.
+	UpdatePassengers(ctx context.Context, id primitive.ObjectID, allowed []models.BookingStatus, passengers []models.Passenger, updatedAt time.Time) error
+}
+
+type mongoBookingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoBookingRepository(db *mongo.Database) BookingRepository {
+	return &mongoBookingRepository{collection: db.Collection("bookings")}
+}
+
+func (r *mongoBookingRepository) Create(ctx context.Context, booking *models.Booking) error {
+	_, err := r.collection.InsertOne(ctx, booking)
+	return mongoError(err)
+}
+
+func (r *mongoBookingRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Booking, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoBookingRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Booking, error) {
+	if len(ids) == 0 {
+		return []models.Booking{}, nil
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *mongoBookingRepository) FindByTrip(ctx context.Context, tripID primitive.ObjectID) ([]models.Booking, error) {
+	return r.find(ctx, bson.M{"tripId": tripID})
+}
+
+func (r *mongoBookingRepository) FindByTicketCode(ctx context.Context, ticketCode string) (*models.Booking, error) {
+	return r.findOne(ctx, bson.M{"ticketCode": ticketCode})
+}
+
+func (r *mongoBookingRepository) findOne(ctx context.Context, filter bson.M) (*models.Booking, error) {
+	var booking models.Booking
+	if err := r.collection.FindOne(ctx, filter).Decode(&booking); err != nil {
+		return nil, mongoError(err)
+	}
+	return &booking, nil
+}
+
+func (r *mongoBookingRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Booking, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "bookingTime", Value: -1}})
+	return r.find(ctx, bson.M{"userId": userID}, findOptions)
+}
+
+func (r *mongoBookingRepository) CountByTrip(ctx context.Context, tripID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"tripId": tripID})
+}
+
+func (r *mongoBookingRepository) CountActiveByTrip(ctx context.Context, tripID primitive.ObjectID, now time.Time) (int64, error) {
+	filter := bson.M{
+		"tripId": tripID,
+		"$or": []bson.M{
+			{"status": bson.M{"$in": []models.BookingStatus{models.BookingConfirmed, models.BookingCheckedIn}}},
+			{"status": models.BookingHeld, "holdExpiresAt": bson.M{"$gt": now}},
+			{"status": models.BookingHeld, "holdExpiresAt": bson.M{"$exists": false}},
+		},
+	}
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+func (r *mongoBookingRepository) FindExpiredHolds(ctx context.Context, now, legacyCutoff time.Time) ([]models.Booking, error) {
+	filter := bson.M{
+		"status": models.BookingHeld,
+		"$or": []bson.M{
+			{"holdExpiresAt": bson.M{"$lte": now}},
+			{"holdExpiresAt": bson.M{"$exists": false}, "bookingTime": bson.M{"$lte": legacyCutoff}},
+		},
+	}
+	return r.find(ctx, filter)
+}
+
+func (r *mongoBookingRepository) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.Booking, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bookings := []models.Booking{}
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (r *mongoBookingRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, from models.BookingStatus, change models.StatusChange, fields BookingFields) error {
+	set := bson.M{"status": change.To, "updatedAt": change.At}
+	if fields.PaymentStatus != nil {
+		set["paymentStatus"] = *fields.PaymentStatus
+	}
+	if fields.PaymentID != nil {
+		set["paymentId"] = *fields.PaymentID
+	}
+	if fields.TicketCode != nil {
+		set["ticketCode"] = *fields.TicketCode
+	}
+	if fields.Cancellation != nil {
+		set["cancellation"] = *fields.Cancellation
+	}
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"statusHistory": change},
+	}
+	if fields.ClearHoldExpiry {
+		update["$unset"] = bson.M{"holdExpiresAt": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, update)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoBookingRepository) UpdatePassengers(ctx context.Context, id primitive.ObjectID, allowed []models.BookingStatus, passengers []models.Passenger, updatedAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": allowed}},
+		bson.M{"$set": bson.M{"passengers": passengers, "updatedAt": updatedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
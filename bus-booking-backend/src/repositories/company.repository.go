@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CompanyFilter là điều kiện liệt kê nhà xe.
+type CompanyFilter struct {
+	// IncludeInactive cho phép trả về cả các nhà xe đã ngừng hoạt động.
+	IncludeInactive bool
+}
+
+type CompanyRepository interface {
+	// Create thêm nhà xe mới, trả về ErrDuplicate nếu mã nhà xe đã tồn tại.
+	Create(ctx context.Context, company *models.Company) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Company, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Company, error)
+	// List trả về các nhà xe khớp filter, sắp xếp theo tên.
+	List(ctx context.Context, filter CompanyFilter) ([]models.Company, error)
+	// Update ghi đè toàn bộ nhà xe theo ID; trả về ErrNotFound nếu không tồn tại và
+	// ErrDuplicate nếu mã mới trùng với nhà xe khác.
+	Update(ctx context.Context, company *models.Company) error
+	// Deactivate đánh dấu nhà xe ngừng hoạt động; không làm gì nếu nhà xe đã ngừng hoạt động.
+	Deactivate(ctx context.Context, id primitive.ObjectID, deactivatedAt time.Time) error
+}
+
+type mongoCompanyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoCompanyRepository(db *mongo.Database) CompanyRepository {
+	return &mongoCompanyRepository{collection: db.Collection("companies")}
+}
+
+func (r *mongoCompanyRepository) Create(ctx context.Context, company *models.Company) error {
+	_, err := r.collection.InsertOne(ctx, company)
+	return mongoError(err)
+}
+
+func (r *mongoCompanyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Company, error) {
+	var company models.Company
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&company); err != nil {
+		return nil, mongoError(err)
+	}
+	return &company, nil
+}
+
+func (r *mongoCompanyRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Company, error) {
+	if len(ids) == 0 {
+		return []models.Company{}, nil
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *mongoCompanyRepository) List(ctx context.Context, filter CompanyFilter) ([]models.Company, error) {
+	query := bson.M{}
+	if !filter.IncludeInactive {
+		query["deactivatedAt"] = bson.M{"$exists": false}
+	}
+	return r.find(ctx, query)
+}
+
+func (r *mongoCompanyRepository) find(ctx context.Context, query bson.M) ([]models.Company, error) {
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	companies := []models.Company{}
+	if err := cursor.All(ctx, &companies); err != nil {
+		return nil, err
+	}
+	return companies, nil
+}
+
+func (r *mongoCompanyRepository) Update(ctx context.Context, company *models.Company) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": company.ID}, company)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoCompanyRepository) Deactivate(ctx context.Context, id primitive.ObjectID, deactivatedAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "deactivatedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deactivatedAt": deactivatedAt, "updatedAt": deactivatedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
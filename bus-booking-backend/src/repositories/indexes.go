@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes tạo các chỉ mục cần thiết khi khởi động server. CreateMany không làm gì
+// nếu chỉ mục đã tồn tại với cùng định nghĩa.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := map[string][]mongo.IndexModel{
+		"bookings": {
+			{
+				// Booking chưa xác nhận không có ticketCode nên chỉ áp dụng unique cho các mã đã sinh.
+				Keys: bson.D{{Key: "ticketCode", Value: 1}},
+				Options: options.Index().
+					SetName("ticketCode_unique").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"ticketCode": bson.M{"$type": "string"}}),
+			},
+			{
+				Keys:    bson.D{{Key: "tripId", Value: 1}},
+				Options: options.Index().SetName("tripId"),
+			},
+		},
+		"companies": {
+			{
+				Keys:    bson.D{{Key: "code", Value: 1}},
+				Options: options.Index().SetName("code_unique").SetUnique(true),
+			},
+		},
+		"vehicles": {
+			{
+				Keys:    bson.D{{Key: "companyId", Value: 1}},
+				Options: options.Index().SetName("companyId"),
+			},
+		},
+		"trips": {
+			{
+				Keys:    bson.D{{Key: "vehicleId", Value: 1}},
+				Options: options.Index().SetName("vehicleId"),
+			},
+			// Tìm kiếm lọc theo locationId hoặc tên điểm dừng ($or trên điểm đi, điểm đến và các
+			// điểm dừng, mỗi nhánh dùng một chỉ mục) kèm khoảng giờ khởi hành; tìm kiếm không có
+			// điểm đi/đến dùng chỉ mục departureTime.
+			{
+				Keys:    bson.D{{Key: "route.from.locationId", Value: 1}, {Key: "route.to.locationId", Value: 1}, {Key: "departureTime", Value: 1}},
+				Options: options.Index().SetName("route_from_to_locationId_departureTime"),
+			},
+			{
+				Keys:    bson.D{{Key: "route.to.locationId", Value: 1}, {Key: "departureTime", Value: 1}},
+				Options: options.Index().SetName("route_to_locationId_departureTime"),
+			},
+			{
+				Keys:    bson.D{{Key: "route.stops.locationId", Value: 1}, {Key: "departureTime", Value: 1}},
+				Options: options.Index().SetName("route_stops_locationId_departureTime"),
+			},
+			{
+				Keys:    bson.D{{Key: "route.from.name", Value: 1}, {Key: "route.to.name", Value: 1}, {Key: "departureTime", Value: 1}},
+				Options: options.Index().SetName("route_from_to_departureTime"),
+			},
+			{
+				Keys:    bson.D{{Key: "route.to.name", Value: 1}, {Key: "departureTime", Value: 1}},
+				Options: options.Index().SetName("route_to_departureTime"),
+			},
+			{
+				Keys:    bson.D{{Key: "route.stops.name", Value: 1}, {Key: "departureTime", Value: 1}},
+				Options: options.Index().SetName("route_stops_departureTime"),
+			},
+			{
+				Keys:    bson.D{{Key: "departureTime", Value: 1}},
+				Options: options.Index().SetName("departureTime"),
+			},
+			// Worker giữ chỗ tìm các chặng ghế còn "held" để đối chiếu với trạng thái booking.
+			{
+				Keys:    bson.D{{Key: "seats.legs.status", Value: 1}},
+				Options: options.Index().SetName("seats_legs_status"),
+			},
+			// Ghế mang trạng thái cũ (trọn chuyến) của booking tạo trước khi bán theo chặng.
+			{
+				Keys:    bson.D{{Key: "seats.status", Value: 1}},
+				Options: options.Index().SetName("seats_status"),
+			},
+			{
+				// Mỗi lịch chạy chỉ có một chuyến cho mỗi giờ khởi hành, giúp bộ sinh chuyến đi
+				// chạy lại nhiều lần mà không tạo trùng.
+				Keys: bson.D{{Key: "scheduleId", Value: 1}, {Key: "departureTime", Value: 1}},
+				Options: options.Index().
+					SetName("scheduleId_departureTime_unique").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"scheduleId": bson.M{"$type": "objectId"}}),
+			},
+		},
+		"locations": {
+			{
+				Keys:    bson.D{{Key: "slug", Value: 1}},
+				Options: options.Index().SetName("slug_unique").SetUnique(true),
+			},
+			{
+				// Chỉ mục unique trên mảng: một key (tên đã bỏ dấu, tên gọi khác) không thể thuộc về
+				// hai địa điểm, nhờ đó chuỗi người dùng nhập luôn chỉ trỏ tới một địa điểm.
+				Keys:    bson.D{{Key: "keys", Value: 1}},
+				Options: options.Index().SetName("keys_unique").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "searchTerms", Value: 1}},
+				Options: options.Index().SetName("searchTerms"),
+			},
+		},
+		"schedules": {
+			{
+				Keys:    bson.D{{Key: "companyId", Value: 1}},
+				Options: options.Index().SetName("companyId"),
+			},
+		},
+		"payments": {
+			{
+				Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "intentId", Value: 1}},
+				Options: options.Index().SetName("provider_intentId_unique").SetUnique(true),
+			},
+			{
+				// Worker giữ chỗ tìm các lần hoàn tiền "failed" để thử lại.
+				Keys:    bson.D{{Key: "refunds.status", Value: 1}},
+				Options: options.Index().SetName("refunds_status"),
+			},
+		},
+		"sessions": {
+			{
+				Keys:    bson.D{{Key: "userId", Value: 1}},
+				Options: options.Index().SetName("userId"),
+			},
+			{
+				// MongoDB tự xóa các phiên đã hết hạn refresh token.
+				Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+				Options: options.Index().SetName("expiresAt_ttl").SetExpireAfterSeconds(0),
+			},
+		},
+	}
+
+	for collection, collectionIndexes := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, collectionIndexes); err != nil {
+			return fmt.Errorf("không thể tạo chỉ mục cho collection %s: %w", collection, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocationRepository interface {
+	// Create thêm địa điểm, trả về ErrDuplicate nếu slug hoặc một key đã thuộc về địa điểm khác.
+	Create(ctx context.Context, location *models.Location) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Location, error)
+	// FindByKey tìm địa điểm có key (chuỗi đã bỏ dấu) trong Keys, trả về ErrNotFound nếu không có.
+	FindByKey(ctx context.Context, key string) (*models.Location, error)
+	// List trả về mọi địa điểm, sắp xếp theo tên.
+	List(ctx context.Context) ([]models.Location, error)
+	// Suggest trả về tối đa limit địa điểm có một SearchTerms bắt đầu bằng prefix, sắp xếp theo tên.
+	Suggest(ctx context.Context, prefix string, limit int) ([]models.Location, error)
+	// Update ghi đè toàn bộ địa điểm theo ID; trả về ErrNotFound nếu không tồn tại và
+	// ErrDuplicate nếu slug hoặc key mới trùng với địa điểm khác.
+	Update(ctx context.Context, location *models.Location) error
+}
+
+type mongoLocationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoLocationRepository(db *mongo.Database) LocationRepository {
+	return &mongoLocationRepository{collection: db.Collection("locations")}
+}
+
+func (r *mongoLocationRepository) Create(ctx context.Context, location *models.Location) error {
+	_, err := r.collection.InsertOne(ctx, location)
+	return mongoError(err)
+}
+
+func (r *mongoLocationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Location, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoLocationRepository) FindByKey(ctx context.Context, key string) (*models.Location, error) {
+	return r.findOne(ctx, bson.M{"keys": key})
+}
+
+func (r *mongoLocationRepository) findOne(ctx context.Context, query bson.M) (*models.Location, error) {
+	var location models.Location
+	if err := r.collection.FindOne(ctx, query).Decode(&location); err != nil {
+		return nil, mongoError(err)
+	}
+	return &location, nil
+}
+
+func (r *mongoLocationRepository) List(ctx context.Context) ([]models.Location, error) {
+	return r.find(ctx, bson.M{}, options.Find())
+}
+
+func (r *mongoLocationRepository) Suggest(ctx context.Context, prefix string, limit int) ([]models.Location, error) {
+	// Biểu thức chính quy neo ở đầu chuỗi và phân biệt hoa thường nên dùng được chỉ mục searchTerms.
+	query := bson.M{"searchTerms": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}}
+	return r.find(ctx, query, options.Find().SetLimit(int64(limit)))
+}
+
+func (r *mongoLocationRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]models.Location, error) {
+	cursor, err := r.collection.Find(ctx, query, opts.SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	locations := []models.Location{}
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+func (r *mongoLocationRepository) Update(ctx context.Context, location *models.Location) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": location.ID}, location)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
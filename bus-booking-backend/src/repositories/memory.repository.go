@@ -0,0 +1,1510 @@
+package repositories
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Các repository trong bộ nhớ lưu bản sao của dữ liệu và chỉ trả ra bản sao, nên caller có
+// thể sửa kết quả mà không ảnh hưởng tới dữ liệu đã lưu. Mỗi repository được bảo vệ bởi
+// một mutex, nhờ đó các thao tác có điều kiện (giữ ghế, chuyển trạng thái) là nguyên tử
+// giống như cập nhật một document trên MongoDB.
+
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]models.User
+}
+
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: map[primitive.ObjectID]models.User{}}
+}
+
+func (r *memoryUserRepository) Create(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.users[user.ID]; ok {
+		return ErrDuplicate
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(_ context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+type memoryCompanyRepository struct {
+	mu        sync.RWMutex
+	companies map[primitive.ObjectID]models.Company
+}
+
+// MemoryCompanyRepository cho phép nạp sẵn dữ liệu nhà xe vào repository trong bộ nhớ.
+type MemoryCompanyRepository interface {
+	CompanyRepository
+	Put(company models.Company)
+}
+
+func NewMemoryCompanyRepository() MemoryCompanyRepository {
+	return &memoryCompanyRepository{companies: map[primitive.ObjectID]models.Company{}}
+}
+
+func (r *memoryCompanyRepository) Put(company models.Company) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.companies[company.ID] = cloneCompany(company)
+}
+
+func (r *memoryCompanyRepository) Create(_ context.Context, company *models.Company) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if company.ID.IsZero() {
+		company.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.companies[company.ID]; ok || r.codeTaken(company.Code, company.ID) {
+		return ErrDuplicate
+	}
+	r.companies[company.ID] = cloneCompany(*company)
+	return nil
+}
+
+func (r *memoryCompanyRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	company, ok := r.companies[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	company = cloneCompany(company)
+	return &company, nil
+}
+
+func (r *memoryCompanyRepository) FindByIDs(_ context.Context, ids []primitive.ObjectID) ([]models.Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	companies := []models.Company{}
+	for _, id := range ids {
+		if company, ok := r.companies[id]; ok {
+			companies = append(companies, cloneCompany(company))
+		}
+	}
+	sortCompanies(companies)
+	return companies, nil
+}
+
+func (r *memoryCompanyRepository) List(_ context.Context, filter CompanyFilter) ([]models.Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	companies := []models.Company{}
+	for _, company := range r.companies {
+		if !filter.IncludeInactive && !company.IsActive() {
+			continue
+		}
+		companies = append(companies, cloneCompany(company))
+	}
+	sortCompanies(companies)
+	return companies, nil
+}
+
+func (r *memoryCompanyRepository) Update(_ context.Context, company *models.Company) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.companies[company.ID]; !ok {
+		return ErrNotFound
+	}
+	if r.codeTaken(company.Code, company.ID) {
+		return ErrDuplicate
+	}
+	r.companies[company.ID] = cloneCompany(*company)
+	return nil
+}
+
+func (r *memoryCompanyRepository) Deactivate(_ context.Context, id primitive.ObjectID, deactivatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	company, ok := r.companies[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if company.DeactivatedAt == nil {
+		company.DeactivatedAt = &deactivatedAt
+		company.UpdatedAt = deactivatedAt
+		r.companies[id] = company
+	}
+	return nil
+}
+
+// codeTaken cho biết mã nhà xe đã được nhà xe khác (khác ID exceptID) sử dụng hay chưa.
+func (r *memoryCompanyRepository) codeTaken(code string, exceptID primitive.ObjectID) bool {
+	for id, existing := range r.companies {
+		if id != exceptID && existing.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func sortCompanies(companies []models.Company) {
+	sort.Slice(companies, func(i, j int) bool {
+		return companies[i].Name < companies[j].Name
+	})
+}
+
+type memoryTripRepository struct {
+	mu    sync.RWMutex
+	trips map[primitive.ObjectID]models.Trip
+	// companies và vehicles thay cho $lookup khi tìm kiếm.
+	companies CompanyRepository
+	vehicles  VehicleRepository
+}
+
+// MemoryTripRepository cho phép nạp sẵn dữ liệu chuyến đi vào repository trong bộ nhớ.
+type MemoryTripRepository interface {
+	TripRepository
+	Put(trip models.Trip)
+}
+
+func NewMemoryTripRepository(companies CompanyRepository, vehicles VehicleRepository) MemoryTripRepository {
+	return &memoryTripRepository{trips: map[primitive.ObjectID]models.Trip{}, companies: companies, vehicles: vehicles}
+}
+
+func (r *memoryTripRepository) Put(trip models.Trip) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if trip.ID.IsZero() {
+		trip.ID = primitive.NewObjectID()
+	}
+	r.trips[trip.ID] = cloneTrip(trip)
+}
+
+func (r *memoryTripRepository) Create(_ context.Context, trip *models.Trip) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if trip.ID.IsZero() {
+		trip.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.trips[trip.ID]; ok || r.scheduleSlotTaken(trip) {
+		return ErrDuplicate
+	}
+	r.trips[trip.ID] = cloneTrip(*trip)
+	return nil
+}
+
+// scheduleSlotTaken giống chỉ mục unique (scheduleId, departureTime): lịch chạy của trip đã có
+// chuyến khác cùng giờ khởi hành.
+func (r *memoryTripRepository) scheduleSlotTaken(trip *models.Trip) bool {
+	if trip.ScheduleID == nil {
+		return false
+	}
+	for _, existing := range r.trips {
+		if existing.ID != trip.ID && existing.ScheduleID != nil && *existing.ScheduleID == *trip.ScheduleID && existing.DepartureTime.Equal(trip.DepartureTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryTripRepository) Replace(_ context.Context, trip *models.Trip) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkUnsold(trip.ID); err != nil {
+		return err
+	}
+	if r.scheduleSlotTaken(trip) {
+		return ErrDuplicate
+	}
+	r.trips[trip.ID] = cloneTrip(*trip)
+	return nil
+}
+
+func (r *memoryTripRepository) UpdateDetails(_ context.Context, trip *models.Trip) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.trips[trip.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	updated := cloneTrip(*trip)
+	updated.Seats = existing.Seats
+	updated.ScheduleID = existing.ScheduleID
+	updated.CreatedAt = existing.CreatedAt
+	r.trips[trip.ID] = updated
+	return nil
+}
+
+func (r *memoryTripRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkUnsold(id); err != nil {
+		return err
+	}
+	delete(r.trips, id)
+	return nil
+}
+
+func (r *memoryTripRepository) checkUnsold(id primitive.ObjectID) error {
+	trip, ok := r.trips[id]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, seat := range trip.Seats {
+		if seat.Status == models.SeatHeld || seat.Status == models.SeatBooked || len(seat.Legs) > 0 {
+			return ErrConflict
+		}
+	}
+	return nil
+}
+
+func (r *memoryTripRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Trip, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	trip, ok := r.trips[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	trip = cloneTrip(trip)
+	return &trip, nil
+}
+
+func (r *memoryTripRepository) FindByIDs(_ context.Context, ids []primitive.ObjectID) ([]models.Trip, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	trips := []models.Trip{}
+	for _, id := range ids {
+		if trip, ok := r.trips[id]; ok {
+			trips = append(trips, cloneTrip(trip))
+		}
+	}
+	sortTrips(trips)
+	return trips, nil
+}
+
+func (r *memoryTripRepository) Find(_ context.Context, filter TripFilter) ([]models.Trip, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	trips := []models.Trip{}
+	for _, trip := range r.trips {
+		if tripMatches(&trip, filter) {
+			trips = append(trips, cloneTrip(trip))
+		}
+	}
+	sortTrips(trips)
+	return trips, nil
+}
+
+func (r *memoryTripRepository) Search(ctx context.Context, search TripSearch) (*TripSearchPage, error) {
+	r.mu.RLock()
+	var matched []TripSummary
+	for _, trip := range r.trips {
+		if !tripMatches(&trip, search.TripFilter) {
+			continue
+		}
+		segment, ok := findFilterSegment(&trip, search.TripFilter)
+		if !ok {
+			continue
+		}
+		price, ok := trip.FareFor(segment)
+		if !ok || price < search.MinPrice || (search.MaxPrice > 0 && price > search.MaxPrice) {
+			continue
+		}
+		boarding, arrival := trip.SegmentTimes(segment)
+		if (!search.BoardingFrom.IsZero() && boarding.Before(search.BoardingFrom)) || (!search.BoardingTo.IsZero() && !boarding.Before(search.BoardingTo)) {
+			continue
+		}
+		clock := boarding.In(search.Location).Format(models.TimeOfDayLayout)
+		if (search.DepartAfter != "" && clock < search.DepartAfter) || (search.DepartBefore != "" && clock > search.DepartBefore) {
+			continue
+		}
+
+		summary := TripSummary{
+			Trip:         cloneTrip(trip),
+			Segment:      segment,
+			FarePrice:    price,
+			BoardingTime: boarding,
+			AvailableByType: map[models.SeatType]int{
+				models.SeatTypeSeat: 0,
+				models.SeatTypeBed:  0,
+				models.SeatTypeVIP:  0,
+			},
+		}
+		for i := range trip.Seats {
+			if trip.Seats[i].StatusFor(segment) != models.SeatAvailable {
+				continue
+			}
+			summary.AvailableSeats++
+			seatType := trip.Seats[i].SeatType
+			if seatType == "" {
+				seatType = models.SeatTypeSeat
+			}
+			summary.AvailableByType[seatType]++
+		}
+		if search.MinSeats > 0 || search.SeatType != "" {
+			available := summary.AvailableSeats
+			if search.SeatType != "" {
+				available = summary.AvailableByType[search.SeatType]
+			}
+			if available < max(search.MinSeats, 1) {
+				continue
+			}
+		}
+		switch search.Sort {
+		case TripSortPrice:
+			summary.SortKey = price
+		case TripSortDuration:
+			summary.SortKey = arrival.Sub(boarding).Minutes()
+		}
+		summary.Seats = nil
+		matched = append(matched, summary)
+	}
+	r.mu.RUnlock()
+
+	// Giống $lookup: tên và điểm đánh giá của nhà xe, loại xe của các chuyến đã lọc.
+	var companyIDs, vehicleIDs []primitive.ObjectID
+	for i := range matched {
+		companyIDs = append(companyIDs, matched[i].CompanyID)
+		vehicleIDs = append(vehicleIDs, matched[i].VehicleID)
+	}
+	companies, err := r.companies.FindByIDs(ctx, companyIDs)
+	if err != nil {
+		return nil, err
+	}
+	vehicles, err := r.vehicles.FindByIDs(ctx, vehicleIDs)
+	if err != nil {
+		return nil, err
+	}
+	companyByID := make(map[primitive.ObjectID]models.Company, len(companies))
+	for _, company := range companies {
+		companyByID[company.ID] = company
+	}
+	vehicleTypes := make(map[primitive.ObjectID]string, len(vehicles))
+	for _, vehicle := range vehicles {
+		vehicleTypes[vehicle.ID] = vehicle.Type
+	}
+
+	page := &TripSearchPage{Trips: []TripSummary{}, Companies: []CompanyCount{}, VehicleTypes: []VehicleTypeCount{}}
+	companyCounts := map[primitive.ObjectID]int{}
+	typeCounts := map[string]int{}
+	for _, summary := range matched {
+		company := companyByID[summary.CompanyID]
+		if search.Sort == TripSortRating {
+			summary.SortKey = -company.Rating
+		}
+		vehicleType := vehicleTypes[summary.VehicleID]
+		companyOK := len(search.CompanyIDs) == 0 || slices.Contains(search.CompanyIDs, summary.CompanyID)
+		typeOK := search.VehicleType == "" || strings.EqualFold(vehicleType, search.VehicleType)
+		if typeOK {
+			if _, ok := companyCounts[summary.CompanyID]; !ok {
+				page.Companies = append(page.Companies, CompanyCount{CompanyID: summary.CompanyID, Name: company.Name})
+			}
+			companyCounts[summary.CompanyID]++
+		}
+		if companyOK && vehicleType != "" {
+			if _, ok := typeCounts[vehicleType]; !ok {
+				page.VehicleTypes = append(page.VehicleTypes, VehicleTypeCount{VehicleType: vehicleType})
+			}
+			typeCounts[vehicleType]++
+		}
+		if companyOK && typeOK && (search.After == nil || compareSearchPosition(&summary, search.After) > 0) {
+			page.Trips = append(page.Trips, summary)
+		}
+	}
+	for i := range page.Companies {
+		page.Companies[i].Count = companyCounts[page.Companies[i].CompanyID]
+	}
+	for i := range page.VehicleTypes {
+		page.VehicleTypes[i].Count = typeCounts[page.VehicleTypes[i].VehicleType]
+	}
+
+	slices.SortFunc(page.Trips, func(a, b TripSummary) int {
+		return compareSearchPosition(&a, &TripSearchCursor{SortKey: b.SortKey, BoardingTime: b.BoardingTime, ID: b.ID})
+	})
+	if len(page.Trips) > search.Limit+1 {
+		page.Trips = page.Trips[:search.Limit+1]
+	}
+	return page, nil
+}
+
+// compareSearchPosition so sánh vị trí của chuyến với cursor theo thứ tự (SortKey, BoardingTime, ID).
+func compareSearchPosition(summary *TripSummary, cursor *TripSearchCursor) int {
+	return cmp.Or(
+		cmp.Compare(summary.SortKey, cursor.SortKey),
+		summary.BoardingTime.Compare(cursor.BoardingTime),
+		bytes.Compare(summary.ID[:], cursor.ID[:]),
+	)
+}
+
+// findFilterSegment tìm chặng từ điểm From tới điểm To của filter trên chuyến (From rỗng là điểm
+// đầu, To rỗng là điểm cuối), giống segmentStages.
+func findFilterSegment(trip *models.Trip, filter TripFilter) (models.Segment, bool) {
+	stops := trip.Route.StopPoints()
+	matches := func(i int, name string, locationID *primitive.ObjectID, def int) bool {
+		if name == "" {
+			return i == def
+		}
+		return StopMatch(stops[i], name, locationID)
+	}
+	for i := range stops {
+		if !matches(i, filter.From, filter.FromLocationID, 0) {
+			continue
+		}
+		for j := i + 1; j < len(stops); j++ {
+			if matches(j, filter.To, filter.ToLocationID, len(stops)-1) {
+				return models.Segment{FromStop: i, ToStop: j}, true
+			}
+		}
+	}
+	return models.Segment{}, false
+}
+
+func tripMatches(trip *models.Trip, filter TripFilter) bool {
+	stops := trip.Route.StopPoints()
+	passes := func(name string, locationID *primitive.ObjectID) bool {
+		return slices.ContainsFunc(stops, func(stop models.Stop) bool { return StopMatch(stop, name, locationID) })
+	}
+	if filter.From != "" && !passes(filter.From, filter.FromLocationID) {
+		return false
+	}
+	if filter.To != "" && !passes(filter.To, filter.ToLocationID) {
+		return false
+	}
+	if filter.ScheduleID != nil && (trip.ScheduleID == nil || *trip.ScheduleID != *filter.ScheduleID) {
+		return false
+	}
+	if !filter.DepartureFrom.IsZero() && trip.DepartureTime.Before(filter.DepartureFrom) {
+		return false
+	}
+	if !filter.DepartureTo.IsZero() && !trip.DepartureTime.Before(filter.DepartureTo) {
+		return false
+	}
+	return !slices.Contains(filter.ExcludeCompanyIDs, trip.CompanyID)
+}
+
+func (r *memoryTripRepository) FareCalendar(_ context.Context, query FareCalendarQuery) ([]FareCalendarDay, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	byDate := map[string]*FareCalendarDay{}
+	for _, trip := range r.trips {
+		if trip.DepartureTime.Before(query.DepartureFrom) || !trip.DepartureTime.Before(query.DepartureTo) || slices.Contains(query.ExcludeCompanyIDs, trip.CompanyID) {
+			continue
+		}
+		segment, ok := findFilterSegment(&trip, TripFilter{From: query.From, FromLocationID: query.FromLocationID, To: query.To, ToLocationID: query.ToLocationID})
+		if !ok {
+			continue
+		}
+		price, ok := trip.FareFor(segment)
+		if !ok {
+			continue
+		}
+		boarding, _ := trip.SegmentTimes(segment)
+		if boarding.Before(query.BoardingFrom) || !boarding.Before(query.BoardingTo) {
+			continue
+		}
+		available := 0
+		for i := range trip.Seats {
+			if trip.Seats[i].StatusFor(segment) == models.SeatAvailable {
+				available++
+			}
+		}
+		date := boarding.In(query.Location).Format(models.DateLayout)
+		day, ok := byDate[date]
+		if !ok {
+			day = &FareCalendarDay{Date: date}
+			byDate[date] = day
+		}
+		day.Trips++
+		day.AvailableSeats += available
+		if available > 0 && (day.MinPrice == nil || price < *day.MinPrice) {
+			day.MinPrice = &price
+		}
+	}
+	days := make([]FareCalendarDay, 0, len(byDate))
+	for _, day := range byDate {
+		days = append(days, *day)
+	}
+	slices.SortFunc(days, func(a, b FareCalendarDay) int { return strings.Compare(a.Date, b.Date) })
+	return days, nil
+}
+
+func (r *memoryTripRepository) HoldSeats(_ context.Context, tripID primitive.ObjectID, seatNumbers []string, leg models.SeatLeg) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return ErrConflict
+	}
+	requested := make(map[string]bool, len(seatNumbers))
+	for _, s := range seatNumbers {
+		requested[s] = true
+	}
+	available := 0
+	for i := range trip.Seats {
+		if requested[trip.Seats[i].SeatNumber] && trip.Seats[i].StatusFor(leg.Segment) == models.SeatAvailable {
+			available++
+		}
+	}
+	if available != len(requested) {
+		return ErrConflict
+	}
+	for i := range trip.Seats {
+		if requested[trip.Seats[i].SeatNumber] {
+			trip.Seats[i].Legs = append(trip.Seats[i].Legs, leg)
+		}
+	}
+	return nil
+}
+
+func (r *memoryTripRepository) UpdateSeatLegs(_ context.Context, tripID, bookingID primitive.ObjectID, from, to models.SeatStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return nil
+	}
+	for i := range trip.Seats {
+		legs := trip.Seats[i].Legs[:0]
+		for _, leg := range trip.Seats[i].Legs {
+			if leg.BookingID == bookingID && leg.Status == from {
+				if to == models.SeatAvailable {
+					continue
+				}
+				leg.Status = to
+			}
+			legs = append(legs, leg)
+		}
+		trip.Seats[i].Legs = legs
+	}
+	return nil
+}
+
+func (r *memoryTripRepository) FindHeldSeatLegs(_ context.Context) ([]SeatLegRef, error) {
+	return r.findSeatLegs(func(models.Trip) bool { return true }, models.SeatHeld), nil
+}
+
+func (r *memoryTripRepository) FindBookedSeatLegs(_ context.Context, departingAfter time.Time) ([]SeatLegRef, error) {
+	return r.findSeatLegs(func(trip models.Trip) bool { return trip.DepartureTime.After(departingAfter) }, models.SeatBooked), nil
+}
+
+func (r *memoryTripRepository) findSeatLegs(match func(models.Trip) bool, status models.SeatStatus) []SeatLegRef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := map[SeatLegRef]bool{}
+	legs := []SeatLegRef{}
+	for _, trip := range r.trips {
+		if !match(trip) {
+			continue
+		}
+		for _, seat := range trip.Seats {
+			for _, leg := range seat.Legs {
+				ref := SeatLegRef{TripID: trip.ID, BookingID: leg.BookingID}
+				if leg.Status != status || seen[ref] {
+					continue
+				}
+				seen[ref] = true
+				legs = append(legs, ref)
+			}
+		}
+	}
+	return legs
+}
+
+func (r *memoryTripRepository) FindLegacySeats(_ context.Context, status models.SeatStatus, departingAfter time.Time) ([]LegacySeatRef, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	refs := []LegacySeatRef{}
+	for _, trip := range r.trips {
+		if !trip.DepartureTime.After(departingAfter) {
+			continue
+		}
+		var seatNumbers []string
+		for _, seat := range trip.Seats {
+			if seat.Status == status {
+				seatNumbers = append(seatNumbers, seat.SeatNumber)
+			}
+		}
+		if len(seatNumbers) > 0 {
+			refs = append(refs, LegacySeatRef{TripID: trip.ID, SeatNumbers: seatNumbers})
+		}
+	}
+	return refs, nil
+}
+
+func (r *memoryTripRepository) UpdateSeatStatus(_ context.Context, tripID primitive.ObjectID, seatNumbers []string, from, to models.SeatStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trip, ok := r.trips[tripID]
+	if !ok {
+		return nil
+	}
+	requested := make(map[string]bool, len(seatNumbers))
+	for _, s := range seatNumbers {
+		requested[s] = true
+	}
+	for i := range trip.Seats {
+		if requested[trip.Seats[i].SeatNumber] && trip.Seats[i].Status == from {
+			trip.Seats[i].Status = to
+		}
+	}
+	return nil
+}
+
+func sortTrips(trips []models.Trip) {
+	sort.SliceStable(trips, func(i, j int) bool {
+		return trips[i].DepartureTime.Before(trips[j].DepartureTime)
+	})
+}
+
+func (r *memoryTripRepository) CountByVehicle(_ context.Context, vehicleID primitive.ObjectID) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var count int64
+	for _, trip := range r.trips {
+		if trip.VehicleID == vehicleID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type memoryVehicleRepository struct {
+	mu       sync.RWMutex
+	vehicles map[primitive.ObjectID]models.Vehicle
+}
+
+func NewMemoryVehicleRepository() VehicleRepository {
+	return &memoryVehicleRepository{vehicles: map[primitive.ObjectID]models.Vehicle{}}
+}
+
+func (r *memoryVehicleRepository) Create(_ context.Context, vehicle *models.Vehicle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if vehicle.ID.IsZero() {
+		vehicle.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.vehicles[vehicle.ID]; ok {
+		return ErrDuplicate
+	}
+	r.vehicles[vehicle.ID] = cloneVehicle(*vehicle)
+	return nil
+}
+
+func (r *memoryVehicleRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Vehicle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	vehicle, ok := r.vehicles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	vehicle = cloneVehicle(vehicle)
+	return &vehicle, nil
+}
+
+func (r *memoryVehicleRepository) FindByIDs(_ context.Context, ids []primitive.ObjectID) ([]models.Vehicle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	vehicles := []models.Vehicle{}
+	for _, id := range ids {
+		if vehicle, ok := r.vehicles[id]; ok {
+			vehicles = append(vehicles, cloneVehicle(vehicle))
+		}
+	}
+	return vehicles, nil
+}
+
+func (r *memoryVehicleRepository) List(_ context.Context, filter VehicleFilter) ([]models.Vehicle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	vehicles := []models.Vehicle{}
+	for _, vehicle := range r.vehicles {
+		if filter.CompanyID != nil && vehicle.CompanyID != *filter.CompanyID {
+			continue
+		}
+		vehicles = append(vehicles, cloneVehicle(vehicle))
+	}
+	sort.Slice(vehicles, func(i, j int) bool {
+		return vehicles[i].ID.Hex() > vehicles[j].ID.Hex()
+	})
+	return vehicles, nil
+}
+
+func (r *memoryVehicleRepository) Update(_ context.Context, vehicle *models.Vehicle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.vehicles[vehicle.ID]; !ok {
+		return ErrNotFound
+	}
+	r.vehicles[vehicle.ID] = cloneVehicle(*vehicle)
+	return nil
+}
+
+func (r *memoryVehicleRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.vehicles[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.vehicles, id)
+	return nil
+}
+
+type memoryScheduleRepository struct {
+	mu        sync.RWMutex
+	schedules map[primitive.ObjectID]models.Schedule
+}
+
+func NewMemoryScheduleRepository() ScheduleRepository {
+	return &memoryScheduleRepository{schedules: map[primitive.ObjectID]models.Schedule{}}
+}
+
+func (r *memoryScheduleRepository) Create(_ context.Context, schedule *models.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if schedule.ID.IsZero() {
+		schedule.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.schedules[schedule.ID]; ok {
+		return ErrDuplicate
+	}
+	r.schedules[schedule.ID] = cloneSchedule(*schedule)
+	return nil
+}
+
+func (r *memoryScheduleRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	schedule = cloneSchedule(schedule)
+	return &schedule, nil
+}
+
+func (r *memoryScheduleRepository) List(_ context.Context, filter ScheduleFilter) ([]models.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schedules := []models.Schedule{}
+	for _, schedule := range r.schedules {
+		if filter.CompanyID != nil && schedule.CompanyID != *filter.CompanyID {
+			continue
+		}
+		if filter.ActiveOnly && !schedule.IsActive() {
+			continue
+		}
+		schedules = append(schedules, cloneSchedule(schedule))
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ID.Hex() > schedules[j].ID.Hex()
+	})
+	return schedules, nil
+}
+
+func (r *memoryScheduleRepository) Update(_ context.Context, schedule *models.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.schedules[schedule.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	updated := cloneSchedule(*schedule)
+	updated.SkippedDates = stored.SkippedDates
+	r.schedules[schedule.ID] = updated
+	return nil
+}
+
+func (r *memoryScheduleRepository) AddSkippedDate(_ context.Context, id primitive.ObjectID, date string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if !slices.Contains(schedule.SkippedDates, date) {
+		schedule.SkippedDates = append(append([]string(nil), schedule.SkippedDates...), date)
+	}
+	schedule.UpdatedAt = time.Now()
+	r.schedules[id] = schedule
+	return nil
+}
+
+type memoryLocationRepository struct {
+	mu        sync.RWMutex
+	locations map[primitive.ObjectID]models.Location
+}
+
+func NewMemoryLocationRepository() LocationRepository {
+	return &memoryLocationRepository{locations: map[primitive.ObjectID]models.Location{}}
+}
+
+func (r *memoryLocationRepository) Create(_ context.Context, location *models.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if location.ID.IsZero() {
+		location.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.locations[location.ID]; ok || r.taken(location) {
+		return ErrDuplicate
+	}
+	r.locations[location.ID] = cloneLocation(*location)
+	return nil
+}
+
+func (r *memoryLocationRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	location, ok := r.locations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	location = cloneLocation(location)
+	return &location, nil
+}
+
+func (r *memoryLocationRepository) FindByKey(_ context.Context, key string) (*models.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, location := range r.locations {
+		if slices.Contains(location.Keys, key) {
+			location = cloneLocation(location)
+			return &location, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryLocationRepository) List(_ context.Context) ([]models.Location, error) {
+	return r.filter(func(models.Location) bool { return true }, 0), nil
+}
+
+func (r *memoryLocationRepository) Suggest(_ context.Context, prefix string, limit int) ([]models.Location, error) {
+	return r.filter(func(location models.Location) bool {
+		return slices.ContainsFunc(location.SearchTerms, func(term string) bool { return strings.HasPrefix(term, prefix) })
+	}, limit), nil
+}
+
+// filter trả về tối đa limit địa điểm (0 là không giới hạn) thỏa match, sắp xếp theo tên.
+func (r *memoryLocationRepository) filter(match func(models.Location) bool, limit int) []models.Location {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	locations := []models.Location{}
+	for _, location := range r.locations {
+		if match(location) {
+			locations = append(locations, cloneLocation(location))
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].Name < locations[j].Name })
+	if limit > 0 && len(locations) > limit {
+		locations = locations[:limit]
+	}
+	return locations
+}
+
+func (r *memoryLocationRepository) Update(_ context.Context, location *models.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.locations[location.ID]; !ok {
+		return ErrNotFound
+	}
+	if r.taken(location) {
+		return ErrDuplicate
+	}
+	r.locations[location.ID] = cloneLocation(*location)
+	return nil
+}
+
+// taken cho biết slug hoặc một key của location đã thuộc về địa điểm khác hay chưa.
+func (r *memoryLocationRepository) taken(location *models.Location) bool {
+	for id, existing := range r.locations {
+		if id == location.ID {
+			continue
+		}
+		if existing.Slug == location.Slug || slices.ContainsFunc(location.Keys, func(key string) bool { return slices.Contains(existing.Keys, key) }) {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryBookingRepository struct {
+	mu       sync.RWMutex
+	bookings map[primitive.ObjectID]models.Booking
+}
+
+func NewMemoryBookingRepository() BookingRepository {
+	return &memoryBookingRepository{bookings: map[primitive.ObjectID]models.Booking{}}
+}
+
+func (r *memoryBookingRepository) Create(_ context.Context, booking *models.Booking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if booking.ID.IsZero() {
+		booking.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.bookings[booking.ID]; ok {
+		return ErrDuplicate
+	}
+	if booking.TicketCode != "" && r.ticketCodeTaken(booking.TicketCode, booking.ID) {
+		return ErrDuplicate
+	}
+	stored := cloneBooking(*booking)
+	stored.TripInfo = nil
+	r.bookings[booking.ID] = stored
+	return nil
+}
+
+func (r *memoryBookingRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	booking, ok := r.bookings[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	booking = cloneBooking(booking)
+	return &booking, nil
+}
+
+func (r *memoryBookingRepository) FindByIDs(_ context.Context, ids []primitive.ObjectID) ([]models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bookings := []models.Booking{}
+	for _, id := range ids {
+		if booking, ok := r.bookings[id]; ok {
+			bookings = append(bookings, cloneBooking(booking))
+		}
+	}
+	return bookings, nil
+}
+
+func (r *memoryBookingRepository) FindByTrip(_ context.Context, tripID primitive.ObjectID) ([]models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bookings := []models.Booking{}
+	for _, booking := range r.bookings {
+		if booking.TripID == tripID {
+			bookings = append(bookings, cloneBooking(booking))
+		}
+	}
+	return bookings, nil
+}
+
+func (r *memoryBookingRepository) FindByUser(_ context.Context, userID primitive.ObjectID) ([]models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bookings := []models.Booking{}
+	for _, booking := range r.bookings {
+		if booking.UserID == userID {
+			bookings = append(bookings, cloneBooking(booking))
+		}
+	}
+	sort.SliceStable(bookings, func(i, j int) bool {
+		return bookings[i].BookingTime.After(bookings[j].BookingTime)
+	})
+	return bookings, nil
+}
+
+func (r *memoryBookingRepository) CountByTrip(_ context.Context, tripID primitive.ObjectID) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var count int64
+	for _, booking := range r.bookings {
+		if booking.TripID == tripID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryBookingRepository) FindByTicketCode(_ context.Context, ticketCode string) (*models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, booking := range r.bookings {
+		if booking.TicketCode == ticketCode {
+			booking = cloneBooking(booking)
+			return &booking, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryBookingRepository) CountActiveByTrip(_ context.Context, tripID primitive.ObjectID, now time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var count int64
+	for _, booking := range r.bookings {
+		if booking.TripID != tripID {
+			continue
+		}
+		switch booking.Status {
+		case models.BookingConfirmed, models.BookingCheckedIn:
+			count++
+		case models.BookingHeld:
+			if booking.HoldExpiresAt == nil || booking.HoldExpiresAt.After(now) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryBookingRepository) FindExpiredHolds(_ context.Context, now, legacyCutoff time.Time) ([]models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bookings := []models.Booking{}
+	for _, booking := range r.bookings {
+		if booking.Status != models.BookingHeld {
+			continue
+		}
+		expired := booking.HoldExpiresAt != nil && !booking.HoldExpiresAt.After(now)
+		legacy := booking.HoldExpiresAt == nil && !booking.BookingTime.After(legacyCutoff)
+		if expired || legacy {
+			bookings = append(bookings, cloneBooking(booking))
+		}
+	}
+	return bookings, nil
+}
+
+func (r *memoryBookingRepository) UpdateStatus(_ context.Context, id primitive.ObjectID, from models.BookingStatus, change models.StatusChange, fields BookingFields) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	booking, ok := r.bookings[id]
+	if !ok || booking.Status != from {
+		return ErrConflict
+	}
+	if fields.TicketCode != nil && r.ticketCodeTaken(*fields.TicketCode, id) {
+		return ErrDuplicate
+	}
+
+	booking.Status = change.To
+	booking.UpdatedAt = change.At
+	booking.StatusHistory = append(booking.StatusHistory, change)
+	if fields.PaymentStatus != nil {
+		booking.PaymentStatus = *fields.PaymentStatus
+	}
+	if fields.PaymentID != nil {
+		paymentID := *fields.PaymentID
+		booking.PaymentID = &paymentID
+	}
+	if fields.TicketCode != nil {
+		booking.TicketCode = *fields.TicketCode
+	}
+	if fields.Cancellation != nil {
+		cancellation := *fields.Cancellation
+		booking.Cancellation = &cancellation
+	}
+	if fields.ClearHoldExpiry {
+		booking.HoldExpiresAt = nil
+	}
+	r.bookings[id] = booking
+	return nil
+}
+
+func (r *memoryBookingRepository) UpdatePassengers(_ context.Context, id primitive.ObjectID, allowed []models.BookingStatus, passengers []models.Passenger, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	booking, ok := r.bookings[id]
+	if !ok || !containsStatus(allowed, booking.Status) {
+		return ErrConflict
+	}
+	booking.Passengers = append([]models.Passenger(nil), passengers...)
+	booking.UpdatedAt = updatedAt
+	r.bookings[id] = booking
+	return nil
+}
+
+func (r *memoryBookingRepository) ticketCodeTaken(code string, except primitive.ObjectID) bool {
+	for id, booking := range r.bookings {
+		if id != except && booking.TicketCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStatus(statuses []models.BookingStatus, status models.BookingStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryPaymentRepository struct {
+	mu       sync.RWMutex
+	payments map[primitive.ObjectID]models.Payment
+}
+
+func NewMemoryPaymentRepository() PaymentRepository {
+	return &memoryPaymentRepository{payments: map[primitive.ObjectID]models.Payment{}}
+}
+
+func (r *memoryPaymentRepository) Create(_ context.Context, payment *models.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if payment.ID.IsZero() {
+		payment.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.payments[payment.ID]; ok {
+		return ErrDuplicate
+	}
+	for _, existing := range r.payments {
+		if existing.Provider == payment.Provider && existing.IntentID == payment.IntentID {
+			return ErrDuplicate
+		}
+	}
+	r.payments[payment.ID] = clonePayment(*payment)
+	return nil
+}
+
+func (r *memoryPaymentRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	payment = clonePayment(payment)
+	return &payment, nil
+}
+
+func (r *memoryPaymentRepository) FindByIntent(_ context.Context, provider, intentID string) (*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, payment := range r.payments {
+		if payment.Provider == provider && payment.IntentID == intentID {
+			payment = clonePayment(payment)
+			return &payment, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryPaymentRepository) UpdateStatus(_ context.Context, payment *models.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.payments[payment.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = payment.Status
+	stored.TransactionID = payment.TransactionID
+	stored.FailureReason = payment.FailureReason
+	stored.UpdatedAt = payment.UpdatedAt
+	r.payments[payment.ID] = stored
+	return nil
+}
+
+func (r *memoryPaymentRepository) AddRefund(_ context.Context, id primitive.ObjectID, refund models.Refund, refundedDelta float64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.payments[id]
+	if !ok {
+		return nil
+	}
+	stored.Refunds = append(stored.Refunds, refund)
+	stored.RefundedAmount += refundedDelta
+	if status != "" {
+		stored.Status = status
+	}
+	stored.UpdatedAt = time.Now()
+	r.payments[id] = stored
+	return nil
+}
+
+func (r *memoryPaymentRepository) FindFailedRefunds(_ context.Context, maxAttempts int) ([]models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	payments := []models.Payment{}
+	for _, payment := range r.payments {
+		for _, refund := range payment.Refunds {
+			if refund.Status == models.RefundFailed && refund.Attempts < maxAttempts {
+				payments = append(payments, clonePayment(payment))
+				break
+			}
+		}
+	}
+	return payments, nil
+}
+
+func (r *memoryPaymentRepository) ResolveRefund(_ context.Context, id primitive.ObjectID, index, attempts int, refund models.Refund, refundedDelta float64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.payments[id]
+	if !ok || index < 0 || index >= len(stored.Refunds) {
+		return ErrConflict
+	}
+	if current := stored.Refunds[index]; current.Status != models.RefundFailed || current.Attempts != attempts {
+		return ErrConflict
+	}
+	stored = clonePayment(stored)
+	stored.Refunds[index] = refund
+	stored.RefundedAmount += refundedDelta
+	if status != "" {
+		stored.Status = status
+	}
+	stored.UpdatedAt = time.Now()
+	r.payments[id] = stored
+	return nil
+}
+
+type memoryWebhookEventRepository struct {
+	mu     sync.Mutex
+	events map[string]models.WebhookEvent
+}
+
+func NewMemoryWebhookEventRepository() WebhookEventRepository {
+	return &memoryWebhookEventRepository{events: map[string]models.WebhookEvent{}}
+}
+
+func (r *memoryWebhookEventRepository) Create(_ context.Context, event *models.WebhookEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.events[event.ID]; ok {
+		return ErrDuplicate
+	}
+	r.events[event.ID] = *event
+	return nil
+}
+
+func (r *memoryWebhookEventRepository) FindByID(_ context.Context, id string) (*models.WebhookEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &event, nil
+}
+
+func (r *memoryWebhookEventRepository) TakeOver(_ context.Context, id string, now, lockedUntil time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if event.Status != "processing" || (event.LockedUntil != nil && !event.LockedUntil.Before(now)) {
+		return ErrConflict
+	}
+	event.LockedUntil = &lockedUntil
+	r.events[id] = event
+	return nil
+}
+
+func (r *memoryWebhookEventRepository) MarkProcessed(_ context.Context, id string, processedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event, ok := r.events[id]; ok {
+		event.Status = "processed"
+		event.ProcessedAt = &processedAt
+		r.events[id] = event
+	}
+	return nil
+}
+
+func (r *memoryWebhookEventRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.events, id)
+	return nil
+}
+
+type memorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]models.Session
+}
+
+func NewMemorySessionRepository() SessionRepository {
+	return &memorySessionRepository{sessions: map[primitive.ObjectID]models.Session{}}
+}
+
+func (r *memorySessionRepository) Create(_ context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sessions[session.ID]; ok {
+		return ErrDuplicate
+	}
+	r.sessions[session.ID] = cloneSession(*session)
+	return nil
+}
+
+func (r *memorySessionRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	session = cloneSession(session)
+	return &session, nil
+}
+
+func (r *memorySessionRepository) Rotate(_ context.Context, id primitive.ObjectID, oldHash, newHash string, refreshedAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok || session.RevokedAt != nil || session.RefreshTokenHash != oldHash {
+		return ErrConflict
+	}
+	session.RefreshTokenHash = newHash
+	session.RefreshedAt = refreshedAt
+	session.ExpiresAt = expiresAt
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *memorySessionRepository) Revoke(_ context.Context, id primitive.ObjectID, revokedAt time.Time, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		session.RevokedAt = &revokedAt
+		session.RevokedReason = reason
+		r.sessions[id] = session
+	}
+	return nil
+}
+
+func (r *memorySessionRepository) RevokeAllForUser(_ context.Context, userID primitive.ObjectID, revokedAt time.Time, reason string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var revoked int64
+	for id, session := range r.sessions {
+		if session.UserID != userID || session.RevokedAt != nil {
+			continue
+		}
+		session.RevokedAt = &revokedAt
+		session.RevokedReason = reason
+		r.sessions[id] = session
+		revoked++
+	}
+	return revoked, nil
+}
+
+func cloneTrip(trip models.Trip) models.Trip {
+	trip.Seats = append([]models.Seat(nil), trip.Seats...)
+	for i := range trip.Seats {
+		trip.Seats[i].Legs = append([]models.SeatLeg(nil), trip.Seats[i].Legs...)
+	}
+	trip.Route.Stops = append([]models.Stop(nil), trip.Route.Stops...)
+	trip.Fares = append([]models.Fare(nil), trip.Fares...)
+	trip.SegmentInfo = nil
+	if trip.ScheduleID != nil {
+		scheduleID := *trip.ScheduleID
+		trip.ScheduleID = &scheduleID
+	}
+	trip.CompanyInfo = nil
+	trip.VehicleInfo = nil
+	return trip
+}
+
+func cloneBooking(booking models.Booking) models.Booking {
+	booking.Passengers = append([]models.Passenger(nil), booking.Passengers...)
+	booking.StatusHistory = append([]models.StatusChange(nil), booking.StatusHistory...)
+	if booking.PaymentID != nil {
+		paymentID := *booking.PaymentID
+		booking.PaymentID = &paymentID
+	}
+	if booking.HoldExpiresAt != nil {
+		holdExpiresAt := *booking.HoldExpiresAt
+		booking.HoldExpiresAt = &holdExpiresAt
+	}
+	if booking.Cancellation != nil {
+		cancellation := *booking.Cancellation
+		booking.Cancellation = &cancellation
+	}
+	return booking
+}
+
+func clonePayment(payment models.Payment) models.Payment {
+	payment.Refunds = append([]models.Refund(nil), payment.Refunds...)
+	return payment
+}
+
+func cloneCompany(company models.Company) models.Company {
+	if company.RefundPolicy != nil {
+		policy := *company.RefundPolicy
+		policy.Tiers = append([]models.RefundTier(nil), policy.Tiers...)
+		company.RefundPolicy = &policy
+	}
+	if company.DeactivatedAt != nil {
+		deactivatedAt := *company.DeactivatedAt
+		company.DeactivatedAt = &deactivatedAt
+	}
+	return company
+}
+
+func cloneVehicle(vehicle models.Vehicle) models.Vehicle {
+	if vehicle.SeatMap != nil {
+		seatMap := models.SeatMap{Decks: make([]models.Deck, len(vehicle.SeatMap.Decks))}
+		for i, deck := range vehicle.SeatMap.Decks {
+			deck.Cells = append([]models.SeatCell(nil), deck.Cells...)
+			seatMap.Decks[i] = deck
+		}
+		vehicle.SeatMap = &seatMap
+	}
+	return vehicle
+}
+
+func cloneSchedule(schedule models.Schedule) models.Schedule {
+	schedule.Route.Stops = append([]models.Stop(nil), schedule.Route.Stops...)
+	schedule.Fares = append([]models.Fare(nil), schedule.Fares...)
+	schedule.Weekdays = append([]time.Weekday(nil), schedule.Weekdays...)
+	schedule.ExceptionDates = append([]string(nil), schedule.ExceptionDates...)
+	schedule.SkippedDates = append([]string(nil), schedule.SkippedDates...)
+	if schedule.DisabledAt != nil {
+		disabledAt := *schedule.DisabledAt
+		schedule.DisabledAt = &disabledAt
+	}
+	return schedule
+}
+
+func cloneLocation(location models.Location) models.Location {
+	location.Aliases = append([]string(nil), location.Aliases...)
+	location.Keys = append([]string(nil), location.Keys...)
+	location.SearchTerms = append([]string(nil), location.SearchTerms...)
+	if location.Coordinates != nil {
+		coordinates := *location.Coordinates
+		location.Coordinates = &coordinates
+	}
+	return location
+}
+
+func cloneSession(session models.Session) models.Session {
+	if session.RevokedAt != nil {
+		revokedAt := *session.RevokedAt
+		session.RevokedAt = &revokedAt
+	}
+	return session
+}
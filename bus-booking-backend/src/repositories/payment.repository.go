@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Payment, error)
+	FindByIntent(ctx context.Context, provider, intentID string) (*models.Payment, error)
+	// UpdateStatus ghi trạng thái, mã giao dịch và lý do thất bại hiện tại của payment.
+	UpdateStatus(ctx context.Context, payment *models.Payment) error
+	// AddRefund ghi thêm một lần hoàn tiền, cộng refundedDelta vào refundedAmount và đổi
+	// trạng thái giao dịch sang status (nếu khác rỗng).
+	AddRefund(ctx context.Context, id primitive.ObjectID, refund models.Refund, refundedDelta float64, status string) error
+	// FindFailedRefunds trả về các giao dịch có lần hoàn tiền "failed" chưa thử lại đủ maxAttempts lần.
+	FindFailedRefunds(ctx context.Context, maxAttempts int) ([]models.Payment, error)
+	// ResolveRefund ghi đè lần hoàn tiền thứ index nếu nó vẫn "failed" với đúng attempts lần thử,
+	// cộng refundedDelta vào refundedAmount và đổi trạng thái giao dịch sang status (nếu khác
+	// rỗng). Trả về ErrConflict nếu lần hoàn tiền đó đã được xử lý hoặc đang được thử lại nơi khác.
+	ResolveRefund(ctx context.Context, id primitive.ObjectID, index, attempts int, refund models.Refund, refundedDelta float64, status string) error
+}
+
+type mongoPaymentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPaymentRepository(db *mongo.Database) PaymentRepository {
+	return &mongoPaymentRepository{collection: db.Collection("payments")}
+}
+
+func (r *mongoPaymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	_, err := r.collection.InsertOne(ctx, payment)
+	return mongoError(err)
+}
+
+func (r *mongoPaymentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Payment, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoPaymentRepository) FindByIntent(ctx context.Context, provider, intentID string) (*models.Payment, error) {
+	return r.findOne(ctx, bson.M{"intentId": intentID, "provider": provider})
+}
+
+func (r *mongoPaymentRepository) findOne(ctx context.Context, filter bson.M) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.collection.FindOne(ctx, filter).Decode(&payment); err != nil {
+		return nil, mongoError(err)
+	}
+	return &payment, nil
+}
+
+func (r *mongoPaymentRepository) UpdateStatus(ctx context.Context, payment *models.Payment) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": payment.ID},
+		bson.M{"$set": bson.M{
+			"status":        payment.Status,
+			"transactionId": payment.TransactionID,
+			"failureReason": payment.FailureReason,
+			"updatedAt":     payment.UpdatedAt,
+		}},
+	)
+	return err
+}
+
+func (r *mongoPaymentRepository) AddRefund(ctx context.Context, id primitive.ObjectID, refund models.Refund, refundedDelta float64, status string) error {
+	set := bson.M{"updatedAt": time.Now()}
+	if status != "" {
+		set["status"] = status
+	}
+	update := bson.M{"$push": bson.M{"refunds": refund}, "$set": set}
+	if refundedDelta != 0 {
+		update["$inc"] = bson.M{"refundedAmount": refundedDelta}
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *mongoPaymentRepository) FindFailedRefunds(ctx context.Context, maxAttempts int) ([]models.Payment, error) {
+	filter := bson.M{"refunds": bson.M{"$elemMatch": bson.M{
+		"status":   models.RefundFailed,
+		"attempts": bson.M{"$not": bson.M{"$gte": maxAttempts}},
+	}}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []models.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *mongoPaymentRepository) ResolveRefund(ctx context.Context, id primitive.ObjectID, index, attempts int, refund models.Refund, refundedDelta float64, status string) error {
+	key := fmt.Sprintf("refunds.%d", index)
+	set := bson.M{key: refund, "updatedAt": time.Now()}
+	if status != "" {
+		set["status"] = status
+	}
+	update := bson.M{"$set": set}
+	if refundedDelta != 0 {
+		update["$inc"] = bson.M{"refundedAmount": refundedDelta}
+	}
+	filter := bson.M{"_id": id, key + ".status": models.RefundFailed, key + ".attempts": attempts}
+	if attempts == 0 {
+		// Bản ghi cũ chưa có trường attempts.
+		filter[key+".attempts"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
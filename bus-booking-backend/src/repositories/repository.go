@@ -0,0 +1,82 @@
+// Package repositories tách phần truy cập dữ liệu khỏi service. Mỗi repository có một bản
+// cài đặt MongoDB dùng khi chạy thật và một bản cài đặt trong bộ nhớ, an toàn khi dùng đồng
+// thời, để chạy service mà không cần MongoDB.
+package repositories
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrNotFound được trả về khi không có bản ghi khớp điều kiện truy vấn.
+	ErrNotFound = errors.New("không tìm thấy bản ghi")
+	// ErrDuplicate được trả về khi bản ghi vi phạm ràng buộc duy nhất (email, mã vé, mã sự kiện...).
+	ErrDuplicate = errors.New("bản ghi bị trùng")
+	// ErrConflict được trả về khi cập nhật có điều kiện không khớp, ví dụ ghế không còn trống
+	// hoặc trạng thái booking đã bị thao tác khác thay đổi.
+	ErrConflict = errors.New("dữ liệu đã bị thay đổi hoặc không thỏa điều kiện cập nhật")
+)
+
+// Repositories gom tất cả repository mà các service cần.
+type Repositories struct {
+	Users         UserRepository
+	Trips         TripRepository
+	Bookings      BookingRepository
+	Payments      PaymentRepository
+	Companies     CompanyRepository
+	Vehicles      VehicleRepository
+	Schedules     ScheduleRepository
+	Locations     LocationRepository
+	WebhookEvents WebhookEventRepository
+	Sessions      SessionRepository
+}
+
+// NewMongoRepositories tạo các repository dùng MongoDB database db.
+func NewMongoRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Users:         NewMongoUserRepository(db),
+		Trips:         NewMongoTripRepository(db),
+		Bookings:      NewMongoBookingRepository(db),
+		Payments:      NewMongoPaymentRepository(db),
+		Companies:     NewMongoCompanyRepository(db),
+		Vehicles:      NewMongoVehicleRepository(db),
+		Schedules:     NewMongoScheduleRepository(db),
+		Locations:     NewMongoLocationRepository(db),
+		WebhookEvents: NewMongoWebhookEventRepository(db),
+		Sessions:      NewMongoSessionRepository(db),
+	}
+}
+
+// NewMemoryRepositories tạo các repository lưu dữ liệu trong bộ nhớ.
+func NewMemoryRepositories() *Repositories {
+	companies := NewMemoryCompanyRepository()
+	vehicles := NewMemoryVehicleRepository()
+	return &Repositories{
+		Users:         NewMemoryUserRepository(),
+		Trips:         NewMemoryTripRepository(companies, vehicles),
+		Bookings:      NewMemoryBookingRepository(),
+		Payments:      NewMemoryPaymentRepository(),
+		Companies:     companies,
+		Vehicles:      vehicles,
+		Schedules:     NewMemoryScheduleRepository(),
+		Locations:     NewMemoryLocationRepository(),
+		WebhookEvents: NewMemoryWebhookEventRepository(),
+		Sessions:      NewMemorySessionRepository(),
+	}
+}
+
+// mongoError chuyển lỗi của driver MongoDB sang các lỗi chung của package.
+func mongoError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return errors.Join(ErrDuplicate, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ScheduleFilter là điều kiện liệt kê lịch chạy. CompanyID nil nghĩa là mọi nhà xe.
+type ScheduleFilter struct {
+	CompanyID  *primitive.ObjectID
+	ActiveOnly bool
+}
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *models.Schedule) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Schedule, error)
+	// List trả về các lịch chạy khớp filter, mới tạo trước.
+	List(ctx context.Context, filter ScheduleFilter) ([]models.Schedule, error)
+	// Update ghi đè lịch chạy theo ID, trừ SkippedDates luôn giữ giá trị đang lưu. Trả về
+	// ErrNotFound nếu không tồn tại.
+	Update(ctx context.Context, schedule *models.Schedule) error
+	// AddSkippedDate thêm date ("YYYY-MM-DD") vào SkippedDates của lịch mà không ghi đè các thay
+	// đổi khác, trả về ErrNotFound nếu lịch không tồn tại.
+	AddSkippedDate(ctx context.Context, id primitive.ObjectID, date string) error
+}
+
+type mongoScheduleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoScheduleRepository(db *mongo.Database) ScheduleRepository {
+	return &mongoScheduleRepository{collection: db.Collection("schedules")}
+}
+
+func (r *mongoScheduleRepository) Create(ctx context.Context, schedule *models.Schedule) error {
+	_, err := r.collection.InsertOne(ctx, schedule)
+	return mongoError(err)
+}
+
+func (r *mongoScheduleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Schedule, error) {
+	var schedule models.Schedule
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&schedule); err != nil {
+		return nil, mongoError(err)
+	}
+	return &schedule, nil
+}
+
+func (r *mongoScheduleRepository) List(ctx context.Context, filter ScheduleFilter) ([]models.Schedule, error) {
+	query := bson.M{}
+	if filter.CompanyID != nil {
+		query["companyId"] = *filter.CompanyID
+	}
+	if filter.ActiveOnly {
+		query["disabledAt"] = bson.M{"$exists": false}
+	}
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	schedules := []models.Schedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *mongoScheduleRepository) Update(ctx context.Context, schedule *models.Schedule) error {
+	// Thay document bằng schedule nhưng lấy skippedDates từ document đang lưu, để ngày được thêm
+	// bởi AddSkippedDate trong lúc người dùng sửa lịch không bị mất.
+	replacement := bson.M{"$mergeObjects": bson.A{
+		bson.M{"$literal": schedule},
+		bson.M{"skippedDates": "$skippedDates"},
+	}}
+	update := mongo.Pipeline{{{Key: "$replaceWith", Value: replacement}}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": schedule.ID}, update)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoScheduleRepository) AddSkippedDate(ctx context.Context, id primitive.ObjectID, date string) error {
+	update := bson.M{
+		"$addToSet": bson.M{"skippedDates": date},
+		"$set":      bson.M{"updatedAt": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	// Rotate thay refresh token của phiên nếu phiên chưa bị thu hồi và token hiện tại vẫn là
+	// oldHash; ngược lại trả về ErrConflict.
+	Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, refreshedAt, expiresAt time.Time) error
+	// Revoke thu hồi phiên nếu phiên chưa bị thu hồi.
+	Revoke(ctx context.Context, id primitive.ObjectID, revokedAt time.Time, reason string) error
+	// RevokeAllForUser thu hồi mọi phiên còn hiệu lực của người dùng, trả về số phiên bị thu hồi.
+	RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, revokedAt time.Time, reason string) (int64, error)
+}
+
+type mongoSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSessionRepository(db *mongo.Database) SessionRepository {
+	return &mongoSessionRepository{collection: db.Collection("sessions")}
+}
+
+func (r *mongoSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return mongoError(err)
+}
+
+func (r *mongoSessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session); err != nil {
+		return nil, mongoError(err)
+	}
+	return &session, nil
+}
+
+func (r *mongoSessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, refreshedAt, expiresAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "refreshTokenHash": oldHash, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"refreshTokenHash": newHash, "refreshedAt": refreshedAt, "expiresAt": expiresAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoSessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, revokedAt time.Time, reason string) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": revokedAt, "revokedReason": reason}},
+	)
+	return err
+}
+
+func (r *mongoSessionRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, revokedAt time.Time, reason string) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": revokedAt, "revokedReason": reason}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
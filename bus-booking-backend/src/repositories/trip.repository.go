@@ -0,0 +1,817 @@
+package repositories
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TripFilter là điều kiện tìm chuyến đi. Trường rỗng (hoặc thời điểm zero) không được dùng để lọc.
+// From và To là tên điểm dừng: chuyến khớp khi đi qua cả hai điểm, thứ tự giữa chúng do service
+// kiểm tra. FromLocationID và ToLocationID là địa điểm trong danh mục mà From và To trỏ tới (nil
+// nếu tên không khớp địa điểm nào); khi có, điểm dừng mang locationId được so khớp theo ID và chỉ
+// điểm dừng không có locationId mới được so theo tên (xem StopMatch).
+type TripFilter struct {
+	From           string
+	FromLocationID *primitive.ObjectID
+	To             string
+	ToLocationID   *primitive.ObjectID
+	ScheduleID     *primitive.ObjectID
+	DepartureFrom  time.Time // bao gồm
+	DepartureTo    time.Time // không bao gồm
+	// ExcludeCompanyIDs loại các chuyến của những nhà xe này (thường là nhà xe đã ngừng hoạt động).
+	ExcludeCompanyIDs []primitive.ObjectID
+}
+
+// TripSummary là chuyến đi trong danh sách tìm kiếm: không kèm danh sách ghế, số ghế trống của
+// chặng Segment (tổng và theo hạng ghế), giá chặng, giờ lên xe và khóa sắp xếp được tính sẵn
+// trong cơ sở dữ liệu.
+type TripSummary struct {
+	models.Trip     `bson:",inline"`
+	Segment         models.Segment          `bson:",inline"`
+	AvailableSeats  int                     `bson:"availableSeats"`
+	AvailableByType map[models.SeatType]int `bson:"availableByType"`
+	FarePrice       float64                 `bson:"farePrice"`
+	BoardingTime    time.Time               `bson:"boardingTime"`
+	SortKey         float64                 `bson:"sortKey"`
+}
+
+// Các cách sắp xếp kết quả tìm kiếm. Giá và thời gian chạy tính theo chặng khách chọn; đánh
+// giá là điểm của nhà xe, cao trước.
+const (
+	TripSortDeparture = "departure"
+	TripSortPrice     = "price"
+	TripSortDuration  = "duration"
+	TripSortRating    = "rating"
+)
+
+// TripSearch là điều kiện tìm kiếm chuyến đi cho chặng From → To của TripFilter. Giờ lên xe
+// (giờ xe tới điểm From) phải nằm trong [BoardingFrom, BoardingTo), mốc zero là không giới hạn,
+// và giờ trong ngày của nó theo múi giờ Location ("HH:MM") nằm trong [DepartAfter, DepartBefore].
+// Giá chặng nằm trong [MinPrice, MaxPrice], MaxPrice 0 là không giới hạn. Chuyến hết ghế chỉ bị
+// loại khi có MinSeats hoặc SeatType.
+type TripSearch struct {
+	TripFilter
+	BoardingFrom time.Time
+	BoardingTo   time.Time
+	Location     *time.Location
+	CompanyIDs   []primitive.ObjectID
+	// VehicleType so khớp loại xe không phân biệt hoa thường.
+	VehicleType  string
+	MinPrice     float64
+	MaxPrice     float64
+	DepartAfter  string
+	DepartBefore string
+	MinSeats     int
+	SeatType     models.SeatType
+	// Sort là một trong các hằng TripSort*; rỗng là theo giờ lên xe.
+	Sort string
+	// After là vị trí của chuyến cuối cùng ở trang trước, nil với trang đầu.
+	After *TripSearchCursor
+	Limit int
+}
+
+// TripSearchCursor là vị trí của một chuyến theo thứ tự (SortKey, BoardingTime, ID) của kết quả
+// tìm kiếm. Thứ tự này luôn xác định nên cursor không bỏ sót hay lặp lại chuyến nào.
+type TripSearchCursor struct {
+	SortKey      float64
+	BoardingTime time.Time
+	ID           primitive.ObjectID
+}
+
+// TripSearchPage là một trang kết quả tìm kiếm. Trips có tối đa Limit+1 chuyến, chuyến thừa
+// chỉ để biết còn trang sau. Companies và VehicleTypes đếm số chuyến khớp tìm kiếm theo nhà xe
+// và loại xe, mỗi nhóm bỏ qua bộ lọc của chính nhóm đó và không phụ thuộc cursor.
+type TripSearchPage struct {
+	Trips        []TripSummary      `bson:"trips"`
+	Companies    []CompanyCount     `bson:"companies"`
+	VehicleTypes []VehicleTypeCount `bson:"vehicleTypes"`
+}
+
+type CompanyCount struct {
+	CompanyID primitive.ObjectID `bson:"_id"`
+	Name      string             `bson:"name"`
+	Count     int                `bson:"count"`
+}
+
+type VehicleTypeCount struct {
+	VehicleType string `bson:"_id"`
+	Count       int    `bson:"count"`
+}
+
+// SeatLegRef là một booking có ít nhất một chặng ghế (ở trạng thái đang tìm) trên chuyến TripID.
+type SeatLegRef struct {
+	TripID    primitive.ObjectID `bson:"tripId"`
+	BookingID primitive.ObjectID `bson:"bookingId"`
+}
+
+// LegacySeatRef là các ghế mang trạng thái cũ (trọn chuyến) trên chuyến TripID.
+type LegacySeatRef struct {
+	TripID      primitive.ObjectID `bson:"_id"`
+	SeatNumbers []string           `bson:"seatNumbers"`
+}
+
+// FareCalendarQuery là điều kiện thống kê giá vé theo ngày cho chặng From → To. Ngày được tính
+// theo giờ xe tới điểm lên From, trong múi giờ Location. Chuyến được lọc thô theo giờ khởi hành
+// trong [DepartureFrom, DepartureTo) rồi lọc chính xác theo giờ lên xe trong [BoardingFrom, BoardingTo).
+type FareCalendarQuery struct {
+	From              string
+	FromLocationID    *primitive.ObjectID
+	To                string
+	ToLocationID      *primitive.ObjectID
+	DepartureFrom     time.Time
+	DepartureTo       time.Time
+	BoardingFrom      time.Time
+	BoardingTo        time.Time
+	Location          *time.Location
+	ExcludeCompanyIDs []primitive.ObjectID
+}
+
+// FareCalendarDay là thống kê của một ngày: giá thấp nhất trong các chuyến còn ghế trống trên
+// chặng (nil nếu không còn chuyến nào bán được), số chuyến và tổng số ghế trống.
+type FareCalendarDay struct {
+	Date           string   `json:"date" bson:"_id"`
+	MinPrice       *float64 `json:"minPrice" bson:"minPrice"`
+	Trips          int      `json:"trips" bson:"trips"`
+	AvailableSeats int      `json:"availableSeats" bson:"availableSeats"`
+}
+
+type TripRepository interface {
+	// Create thêm chuyến đi mới. Trả về ErrDuplicate nếu lịch chạy đã có chuyến cùng giờ khởi hành.
+	Create(ctx context.Context, trip *models.Trip) error
+	// Replace ghi đè toàn bộ chuyến đi, kể cả danh sách ghế, nếu chưa có ghế nào đang được giữ
+	// hoặc đã bán; ngược lại trả về ErrConflict. Trả về ErrDuplicate nếu lịch chạy đã có chuyến
+	// khác cùng giờ khởi hành.
+	Replace(ctx context.Context, trip *models.Trip) error
+	// UpdateDetails ghi nhà xe, xe, tuyến đường, thời gian và giá của chuyến đi mà không đụng tới
+	// danh sách ghế, dùng khi sửa chuyến đã bán vé. Trả về ErrNotFound nếu chuyến đi không tồn tại.
+	UpdateDetails(ctx context.Context, trip *models.Trip) error
+	// Delete xóa chuyến đi nếu chưa có ghế nào đang được giữ hoặc đã bán; ngược lại trả về ErrConflict.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Trip, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Trip, error)
+	// Find trả về các chuyến đi khớp filter, sắp xếp theo giờ khởi hành tăng dần.
+	Find(ctx context.Context, filter TripFilter) ([]models.Trip, error)
+	// Search trả về một trang chuyến đi khớp search dưới dạng TripSummary cho chặng From → To
+	// (From rỗng là điểm đầu, To rỗng là điểm cuối tuyến) cùng số đếm theo nhà xe và loại xe.
+	// Chuyến không đi qua From trước To hoặc không bán vé chặng này bị bỏ qua.
+	Search(ctx context.Context, search TripSearch) (*TripSearchPage, error)
+	// FareCalendar thống kê giá vé và số ghế trống theo ngày, sắp xếp theo ngày tăng dần; ngày
+	// không có chuyến nào không có trong kết quả. Chuyến không bán vé chặng From → To bị bỏ qua.
+	FareCalendar(ctx context.Context, query FareCalendarQuery) ([]FareCalendarDay, error)
+	// CountByVehicle đếm số chuyến đi dùng xe vehicleID.
+	CountByVehicle(ctx context.Context, vehicleID primitive.ObjectID) (int64, error)
+	// HoldSeats thêm chặng leg vào TẤT CẢ các ghế một cách nguyên tử nếu không ghế nào đã bị
+	// chiếm trên một đoạn chồng lên chặng đó; ngược lại không ghế nào thay đổi và trả về ErrConflict.
+	HoldSeats(ctx context.Context, tripID primitive.ObjectID, seatNumbers []string, leg models.SeatLeg) error
+	// UpdateSeatLegs chuyển các chặng của booking bookingID đang ở trạng thái from sang to;
+	// to là "available" nghĩa là gỡ các chặng đó khỏi ghế.
+	UpdateSeatLegs(ctx context.Context, tripID, bookingID primitive.ObjectID, from, to models.SeatStatus) error
+	// FindHeldSeatLegs liệt kê các cặp (chuyến đi, booking) còn chặng ghế ở trạng thái "held".
+	FindHeldSeatLegs(ctx context.Context) ([]SeatLegRef, error)
+	// FindBookedSeatLegs liệt kê các cặp (chuyến đi, booking) có chặng ghế "booked" trên các
+	// chuyến khởi hành sau departingAfter.
+	FindBookedSeatLegs(ctx context.Context, departingAfter time.Time) ([]SeatLegRef, error)
+	// FindLegacySeats liệt kê, theo từng chuyến khởi hành sau departingAfter, các ghế mang trạng
+	// thái cũ status của booking tạo trước khi ghế được bán theo chặng.
+	FindLegacySeats(ctx context.Context, status models.SeatStatus, departingAfter time.Time) ([]LegacySeatRef, error)
+	// UpdateSeatStatus chuyển trạng thái cũ (trọn chuyến) của các ghế đang ở from sang to; chỉ
+	// dùng cho booking tạo trước khi ghế được bán theo chặng. Ghế ở trạng thái khác không bị ảnh hưởng.
+	UpdateSeatStatus(ctx context.Context, tripID primitive.ObjectID, seatNumbers []string, from, to models.SeatStatus) error
+}
+
+type mongoTripRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTripRepository(db *mongo.Database) TripRepository {
+	return &mongoTripRepository{collection: db.Collection("trips")}
+}
+
+func (r *mongoTripRepository) Create(ctx context.Context, trip *models.Trip) error {
+	_, err := r.collection.InsertOne(ctx, trip)
+	return mongoError(err)
+}
+
+// unsoldFilter khớp chuyến đi id khi không ghế nào mang trạng thái cũ "held"/"booked" hoặc có chặng đã bị chiếm.
+func unsoldFilter(id primitive.ObjectID) bson.M {
+	return bson.M{
+		"_id":          id,
+		"seats.status": bson.M{"$nin": []models.SeatStatus{models.SeatHeld, models.SeatBooked}},
+		"seats.legs.0": bson.M{"$exists": false},
+	}
+}
+
+func (r *mongoTripRepository) Replace(ctx context.Context, trip *models.Trip) error {
+	result, err := r.collection.ReplaceOne(ctx, unsoldFilter(trip.ID), trip)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return r.missingOrConflict(ctx, trip.ID)
+	}
+	return nil
+}
+
+func (r *mongoTripRepository) UpdateDetails(ctx context.Context, trip *models.Trip) error {
+	set := bson.M{
+		"companyId":           trip.CompanyID,
+		"vehicleId":           trip.VehicleID,
+		"route":               trip.Route,
+		"departureTime":       trip.DepartureTime,
+		"expectedArrivalTime": trip.ExpectedArrivalTime,
+		"price":               trip.Price,
+		"updatedAt":           trip.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if len(trip.Fares) > 0 {
+		set["fares"] = trip.Fares
+	} else {
+		update["$unset"] = bson.M{"fares": ""}
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": trip.ID}, update)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTripRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, unsoldFilter(id))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+	return nil
+}
+
+// missingOrConflict phân biệt lệnh có điều kiện không khớp vì chuyến đi không tồn tại hay vì
+// đã có ghế được giữ hoặc bán.
+func (r *mongoTripRepository) missingOrConflict(ctx context.Context, id primitive.ObjectID) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
+func (r *mongoTripRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Trip, error) {
+	var trip models.Trip
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&trip); err != nil {
+		return nil, mongoError(err)
+	}
+	return &trip, nil
+}
+
+func (r *mongoTripRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Trip, error) {
+	if len(ids) == 0 {
+		return []models.Trip{}, nil
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *mongoTripRepository) Find(ctx context.Context, filter TripFilter) ([]models.Trip, error) {
+	return r.find(ctx, tripQuery(filter))
+}
+
+// StopMatch cho biết điểm dừng stop có khớp điểm name (địa điểm locationID, nil nếu không có)
+// trong điều kiện tìm kiếm hay không: điểm dừng mang locationId chỉ khớp theo ID, điểm dừng không
+// có locationId (hoặc tìm kiếm không có locationID) khớp theo tên.
+func StopMatch(stop models.Stop, name string, locationID *primitive.ObjectID) bool {
+	if locationID != nil && stop.LocationID != nil {
+		return *stop.LocationID == *locationID
+	}
+	return stop.Name == name
+}
+
+// stopQuery dựng điều kiện MongoDB cho chuyến đi qua điểm name (xem StopMatch). Mỗi nhánh của
+// $or dùng một chỉ mục trên tên hoặc locationId của điểm đi, điểm đến hay các điểm dừng.
+func stopQuery(name string, locationID *primitive.ObjectID) bson.M {
+	if locationID == nil {
+		return bson.M{"$or": []bson.M{{"route.stops.name": name}, {"route.from.name": name}, {"route.to.name": name}}}
+	}
+	return bson.M{"$or": []bson.M{
+		{"route.stops.locationId": *locationID},
+		{"route.from.locationId": *locationID},
+		{"route.to.locationId": *locationID},
+		// So khớp null cũng khớp trường không tồn tại.
+		{"route.stops": bson.M{"$elemMatch": bson.M{"name": name, "locationId": nil}}},
+		{"route.from.name": name, "route.from.locationId": nil},
+		{"route.to.name": name, "route.to.locationId": nil},
+	}}
+}
+
+// tripQuery dựng điều kiện lọc MongoDB cho filter.
+func tripQuery(filter TripFilter) bson.M {
+	query := bson.M{}
+	var stops []bson.M
+	if filter.From != "" {
+		stops = append(stops, stopQuery(filter.From, filter.FromLocationID))
+	}
+	if filter.To != "" {
+		stops = append(stops, stopQuery(filter.To, filter.ToLocationID))
+	}
+	if len(stops) > 0 {
+		query["$and"] = stops
+	}
+	if filter.ScheduleID != nil {
+		query["scheduleId"] = *filter.ScheduleID
+	}
+	departure := bson.M{}
+	if !filter.DepartureFrom.IsZero() {
+		departure["$gte"] = filter.DepartureFrom
+	}
+	if !filter.DepartureTo.IsZero() {
+		departure["$lt"] = filter.DepartureTo
+	}
+	if len(departure) > 0 {
+		query["departureTime"] = departure
+	}
+	if len(filter.ExcludeCompanyIDs) > 0 {
+		query["companyId"] = bson.M{"$nin": filter.ExcludeCompanyIDs}
+	}
+	return query
+}
+
+// segmentStages thêm vào mỗi chuyến fromStop và toStop là vị trí của điểm lên và điểm xuống
+// của filter trên tuyến (From rỗng là điểm đầu, To rỗng là điểm cuối, so khớp giống StopMatch),
+// rồi bỏ các chuyến không đi qua điểm lên trước điểm xuống.
+func segmentStages(filter TripFilter) mongo.Pipeline {
+	stopIndex := func(name string, locationID *primitive.ObjectID, def interface{}) interface{} {
+		switch {
+		case name == "":
+			return def
+		case locationID == nil:
+			return bson.M{"$indexOfArray": bson.A{"$stopNames", name}}
+		}
+		stopAt := func(field string) bson.M {
+			return bson.M{"$arrayElemAt": bson.A{field, "$$i"}}
+		}
+		matches := bson.M{"$map": bson.M{
+			"input": bson.M{"$range": bson.A{0, bson.M{"$size": "$stopNames"}}},
+			"as":    "i",
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$ne": bson.A{stopAt("$stopLocationIds"), nil}},
+				bson.M{"$eq": bson.A{stopAt("$stopLocationIds"), *locationID}},
+				bson.M{"$eq": bson.A{stopAt("$stopNames"), name}},
+			}},
+		}}
+		return bson.M{"$indexOfArray": bson.A{matches, true}}
+	}
+	hasStops := bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$route.stops", bson.A{}}}}, 0}}
+	// "$route.stops.locationId" bỏ qua điểm dừng không có locationId nên phải $map để giữ đúng vị trí.
+	orNull := func(field string) bson.M { return bson.M{"$ifNull": bson.A{field, nil}} }
+	return mongo.Pipeline{
+		{{Key: "$addFields", Value: bson.M{
+			"stopNames": bson.M{"$cond": bson.A{
+				hasStops,
+				"$route.stops.name",
+				bson.A{"$route.from.name", "$route.to.name"},
+			}},
+			"stopLocationIds": bson.M{"$cond": bson.A{
+				hasStops,
+				bson.M{"$map": bson.M{"input": "$route.stops", "as": "stop", "in": orNull("$$stop.locationId")}},
+				bson.A{orNull("$route.from.locationId"), orNull("$route.to.locationId")},
+			}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"fromStop": stopIndex(filter.From, filter.FromLocationID, 0),
+			"toStop":   stopIndex(filter.To, filter.ToLocationID, bson.M{"$subtract": bson.A{bson.M{"$size": "$stopNames"}, 1}}),
+		}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+			bson.M{"$gte": bson.A{"$fromStop", 0}},
+			bson.M{"$gt": bson.A{"$toStop", "$fromStop"}},
+		}}}}},
+	}
+}
+
+// freeSeatsExpr là danh sách ghế còn trống trên chặng [fromStop, toStop] của chuyến: ghế không
+// mang trạng thái cũ "held"/"booked" và không có chặng đang giữ hoặc đã bán nào chồng lên
+// (giống Seat.StatusFor).
+var freeSeatsExpr = bson.M{"$filter": bson.M{
+	"input": bson.M{"$ifNull": bson.A{"$seats", bson.A{}}},
+	"as":    "seat",
+	"cond": bson.M{"$and": bson.A{
+		bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{"$$seat.status", ""}}, bson.A{"", models.SeatAvailable}}},
+		bson.M{"$eq": bson.A{bson.M{"$size": bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$$seat.legs", bson.A{}}},
+			"as":    "leg",
+			"cond":  bson.M{"$and": bson.A{bson.M{"$lt": bson.A{"$$leg.fromStop", "$toStop"}}, bson.M{"$gt": bson.A{"$$leg.toStop", "$fromStop"}}}},
+		}}}, 0}},
+	}},
+}}
+
+// segmentFareStage thêm giờ xe tới điểm lên và điểm xuống (boardingTime, arrivalTime, giống
+// Trip.SegmentTimes) và giá (farePrice, giống Trip.FareFor) của chặng [fromStop, toStop] do
+// segmentStages tính. Giá lấy từ bảng giá; chặng trọn tuyến không có trong bảng giá dùng giá của
+// chuyến. Chặng không có giá cho farePrice null.
+func segmentFareStage() bson.D {
+	offset := func(stop string) bson.M {
+		return bson.M{"$multiply": bson.A{
+			bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$route.stops.offsetMinutes", stop}}, 0}},
+			60 * 1000,
+		}}
+	}
+	fare := bson.M{"$arrayElemAt": bson.A{
+		bson.M{"$map": bson.M{
+			"input": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$fares", bson.A{}}},
+				"as":    "fare",
+				"cond": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$$fare.from", bson.M{"$arrayElemAt": bson.A{"$stopNames", "$fromStop"}}}},
+					bson.M{"$eq": bson.A{"$$fare.to", bson.M{"$arrayElemAt": bson.A{"$stopNames", "$toStop"}}}},
+				}},
+			}},
+			"as": "fare",
+			"in": "$$fare.price",
+		}},
+		0,
+	}}
+	lastStop := bson.M{"$subtract": bson.A{bson.M{"$size": "$stopNames"}, 1}}
+	fullRoute := bson.M{"$and": bson.A{bson.M{"$eq": bson.A{"$fromStop", 0}}, bson.M{"$eq": bson.A{"$toStop", lastStop}}}}
+	hasStops := bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$route.stops", bson.A{}}}}, 0}}
+
+	return bson.D{{Key: "$addFields", Value: bson.M{
+		"boardingTime": bson.M{"$add": bson.A{"$departureTime", offset("$fromStop")}},
+		"arrivalTime": bson.M{"$cond": bson.A{
+			hasStops,
+			bson.M{"$add": bson.A{"$departureTime", offset("$toStop")}},
+			"$expectedArrivalTime",
+		}},
+		"farePrice": bson.M{"$ifNull": bson.A{fare, bson.M{"$cond": bson.A{fullRoute, "$price", nil}}}},
+	}}}
+}
+
+// searchSortKey là khóa sắp xếp chính của sortBy, tăng dần. Đánh giá được đổi dấu để nhà xe đánh
+// giá cao đứng trước; sắp xếp theo giờ lên xe dùng khóa hằng.
+func searchSortKey(sortBy string) interface{} {
+	switch sortBy {
+	case TripSortPrice:
+		return "$farePrice"
+	case TripSortDuration:
+		return bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{"$arrivalTime", "$boardingTime"}}, 60 * 1000}}
+	case TripSortRating:
+		return bson.M{"$multiply": bson.A{"$rating", -1}}
+	default:
+		return bson.M{"$literal": 0}
+	}
+}
+
+func (r *mongoTripRepository) Search(ctx context.Context, search TripSearch) (*TripSearchPage, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: tripQuery(search.TripFilter)}}}
+	pipeline = append(pipeline, segmentStages(search.TripFilter)...)
+	pipeline = append(pipeline,
+		segmentFareStage(),
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"boardingClock": bson.M{"$dateToString": bson.M{"format": "%H:%M", "date": "$boardingTime", "timezone": search.Location.String()}},
+		}}},
+	)
+
+	// Các bộ lọc chung cho trang kết quả và mọi nhóm đếm.
+	price := bson.M{"$ne": nil}
+	if search.MinPrice > 0 {
+		price["$gte"] = search.MinPrice
+	}
+	if search.MaxPrice > 0 {
+		price["$lte"] = search.MaxPrice
+	}
+	match := bson.M{"farePrice": price}
+	boarding := bson.M{}
+	if !search.BoardingFrom.IsZero() {
+		boarding["$gte"] = search.BoardingFrom
+	}
+	if !search.BoardingTo.IsZero() {
+		boarding["$lt"] = search.BoardingTo
+	}
+	if len(boarding) > 0 {
+		match["boardingTime"] = boarding
+	}
+	clock := bson.M{}
+	if search.DepartAfter != "" {
+		clock["$gte"] = search.DepartAfter
+	}
+	if search.DepartBefore != "" {
+		clock["$lte"] = search.DepartBefore
+	}
+	if len(clock) > 0 {
+		match["boardingClock"] = clock
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+
+	countType := func(seatType models.SeatType) bson.M {
+		return bson.M{"$size": bson.M{"$filter": bson.M{
+			"input": "$freeSeatTypes",
+			"cond":  bson.M{"$eq": bson.A{"$$this", seatType}},
+		}}}
+	}
+	pipeline = append(pipeline,
+		// Chỉ giữ hạng của các ghế còn trống (ghế không ghi hạng là ghế ngồi) để đếm; danh sách
+		// ghế không được gửi về.
+		bson.D{{Key: "$addFields", Value: bson.M{"freeSeatTypes": bson.M{"$map": bson.M{
+			"input": freeSeatsExpr,
+			"as":    "seat",
+			"in":    bson.M{"$ifNull": bson.A{"$$seat.seatType", models.SeatTypeSeat}},
+		}}}}},
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"availableSeats": bson.M{"$size": "$freeSeatTypes"},
+			"availableByType": bson.M{
+				string(models.SeatTypeSeat): countType(models.SeatTypeSeat),
+				string(models.SeatTypeBed):  countType(models.SeatTypeBed),
+				string(models.SeatTypeVIP):  countType(models.SeatTypeVIP),
+			},
+		}}},
+	)
+	if search.MinSeats > 0 || search.SeatType != "" {
+		field := "availableSeats"
+		if search.SeatType != "" {
+			field = "availableByType." + string(search.SeatType)
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{field: bson.M{"$gte": max(search.MinSeats, 1)}}}})
+	}
+
+	// Tên và điểm đánh giá của nhà xe, loại xe dùng cho nhóm đếm và sắp xếp.
+	lookup := func(collection, localField, as string, fields bson.M) bson.D {
+		return bson.D{{Key: "$lookup", Value: bson.M{
+			"from": collection,
+			"let":  bson.M{"id": "$" + localField},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$id"}}}},
+				bson.M{"$project": fields},
+			},
+			"as": as,
+		}}}
+	}
+	pipeline = append(pipeline,
+		lookup("companies", "companyId", "company", bson.M{"name": 1, "rating": 1}),
+		lookup("vehicles", "vehicleId", "vehicle", bson.M{"type": 1}),
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"companyName": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$company.name", 0}}, ""}},
+			"rating":      bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$company.rating", 0}}, 0}},
+			"vehicleType": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$vehicle.type", 0}}, ""}},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{"sortKey": searchSortKey(search.Sort)}}},
+	)
+
+	companyMatch := bson.M{}
+	if len(search.CompanyIDs) > 0 {
+		companyMatch["companyId"] = bson.M{"$in": search.CompanyIDs}
+	}
+	typeMatch := bson.M{}
+	if search.VehicleType != "" {
+		typeMatch["vehicleType"] = bson.M{"$regex": "^" + regexp.QuoteMeta(search.VehicleType) + "$", "$options": "i"}
+	}
+	pageMatch := bson.M{}
+	maps.Copy(pageMatch, companyMatch)
+	maps.Copy(pageMatch, typeMatch)
+	knownType := bson.M{"vehicleType": bson.M{"$ne": ""}}
+	maps.Copy(knownType, companyMatch)
+
+	trips := bson.A{bson.M{"$match": pageMatch}}
+	if after := search.After; after != nil {
+		trips = append(trips, bson.M{"$match": bson.M{"$or": bson.A{
+			bson.M{"sortKey": bson.M{"$gt": after.SortKey}},
+			bson.M{"sortKey": after.SortKey, "boardingTime": bson.M{"$gt": after.BoardingTime}},
+			bson.M{"sortKey": after.SortKey, "boardingTime": after.BoardingTime, "_id": bson.M{"$gt": after.ID}},
+		}}})
+	}
+	trips = append(trips,
+		bson.M{"$sort": bson.D{{Key: "sortKey", Value: 1}, {Key: "boardingTime", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": search.Limit + 1},
+		bson.M{"$project": bson.M{
+			"seats": 0, "freeSeatTypes": 0, "stopNames": 0, "stopLocationIds": 0, "arrivalTime": 0, "boardingClock": 0,
+			"company": 0, "vehicle": 0, "companyName": 0, "rating": 0, "vehicleType": 0,
+		}},
+	)
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: bson.M{
+		"trips": trips,
+		"companies": bson.A{
+			bson.M{"$match": typeMatch},
+			bson.M{"$group": bson.M{"_id": "$companyId", "name": bson.M{"$first": "$companyName"}, "count": bson.M{"$sum": 1}}},
+		},
+		"vehicleTypes": bson.A{
+			bson.M{"$match": knownType},
+			bson.M{"$group": bson.M{"_id": "$vehicleType", "count": bson.M{"$sum": 1}}},
+		},
+	}}})
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// $facet luôn trả về đúng một document.
+	page := &TripSearchPage{}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(page); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *mongoTripRepository) FareCalendar(ctx context.Context, query FareCalendarQuery) ([]FareCalendarDay, error) {
+	filter := TripFilter{
+		From:              query.From,
+		FromLocationID:    query.FromLocationID,
+		To:                query.To,
+		ToLocationID:      query.ToLocationID,
+		DepartureFrom:     query.DepartureFrom,
+		DepartureTo:       query.DepartureTo,
+		ExcludeCompanyIDs: query.ExcludeCompanyIDs,
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: tripQuery(filter)}}}
+	pipeline = append(pipeline, segmentStages(filter)...)
+	pipeline = append(pipeline,
+		segmentFareStage(),
+		bson.D{{Key: "$match", Value: bson.M{
+			"farePrice":    bson.M{"$ne": nil},
+			"boardingTime": bson.M{"$gte": query.BoardingFrom, "$lt": query.BoardingTo},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"day":            bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$boardingTime", "timezone": query.Location.String()}},
+			"farePrice":      1,
+			"availableSeats": bson.M{"$size": freeSeatsExpr},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": "$day",
+			// $min bỏ qua null, nên chuyến đã hết ghế không được tính vào giá thấp nhất.
+			"minPrice":       bson.M{"$min": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$availableSeats", 0}}, "$farePrice", nil}}},
+			"trips":          bson.M{"$sum": 1},
+			"availableSeats": bson.M{"$sum": "$availableSeats"},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	)
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	days := []FareCalendarDay{}
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+func (r *mongoTripRepository) find(ctx context.Context, query bson.M) ([]models.Trip, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "departureTime", Value: 1}})
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	trips := []models.Trip{}
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, err
+	}
+	return trips, nil
+}
+
+func (r *mongoTripRepository) CountByVehicle(ctx context.Context, vehicleID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"vehicleId": vehicleID})
+}
+
+func (r *mongoTripRepository) HoldSeats(ctx context.Context, tripID primitive.ObjectID, seatNumbers []string, leg models.SeatLeg) error {
+	// Bộ lọc yêu cầu TẤT CẢ các ghế được chọn đều chưa bị chiếm trên chặng của leg: không mang
+	// trạng thái cũ "held"/"booked" và không có chặng nào chồng lên. MongoDB cập nhật một
+	// document một cách nguyên tử, nên khi hai người cùng tranh một ghế trên cùng đoạn đường
+	// chỉ có đúng một lệnh khớp bộ lọc.
+	overlapping := bson.M{"fromStop": bson.M{"$lt": leg.ToStop}, "toStop": bson.M{"$gt": leg.FromStop}}
+	matchers := make([]interface{}, 0, len(seatNumbers))
+	for _, seatNum := range seatNumbers {
+		matchers = append(matchers, bson.M{"$elemMatch": bson.M{
+			"seatNumber": seatNum,
+			"status":     bson.M{"$nin": []models.SeatStatus{models.SeatHeld, models.SeatBooked}},
+			"legs":       bson.M{"$not": bson.M{"$elemMatch": overlapping}},
+		}})
+	}
+	filter := bson.M{
+		"_id":   tripID,
+		"seats": bson.M{"$all": matchers},
+	}
+	update := bson.M{
+		"$push": bson.M{"seats.$[elem].legs": leg},
+	}
+	arrayFilters := options.ArrayFilters{
+		Filters: []interface{}{bson.M{"elem.seatNumber": bson.M{"$in": seatNumbers}}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, &options.UpdateOptions{ArrayFilters: &arrayFilters})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoTripRepository) UpdateSeatLegs(ctx context.Context, tripID, bookingID primitive.ObjectID, from, to models.SeatStatus) error {
+	// Chỉ duyệt các ghế có chặng của booking; cập nhật mảng lồng nhau qua ghế chưa có trường
+	// "legs" sẽ bị MongoDB từ chối.
+	seatFilter := bson.M{"seat.legs.bookingId": bookingID}
+	var update bson.M
+	var filters []interface{}
+	if to == models.SeatAvailable {
+		update = bson.M{"$pull": bson.M{"seats.$[seat].legs": bson.M{"bookingId": bookingID, "status": from}}}
+		filters = []interface{}{seatFilter}
+	} else {
+		update = bson.M{"$set": bson.M{"seats.$[seat].legs.$[leg].status": to}}
+		filters = []interface{}{seatFilter, bson.M{"leg.bookingId": bookingID, "leg.status": from}}
+	}
+	arrayFilters := options.ArrayFilters{Filters: filters}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": tripID}, update, &options.UpdateOptions{ArrayFilters: &arrayFilters})
+	return err
+}
+
+func (r *mongoTripRepository) FindHeldSeatLegs(ctx context.Context) ([]SeatLegRef, error) {
+	return r.findSeatLegs(ctx, bson.M{}, models.SeatHeld)
+}
+
+func (r *mongoTripRepository) FindBookedSeatLegs(ctx context.Context, departingAfter time.Time) ([]SeatLegRef, error) {
+	return r.findSeatLegs(ctx, bson.M{"departureTime": bson.M{"$gt": departingAfter}}, models.SeatBooked)
+}
+
+// findSeatLegs gom các cặp (chuyến đi, booking) có chặng ghế ở trạng thái status trên các chuyến khớp filter.
+func (r *mongoTripRepository) findSeatLegs(ctx context.Context, filter bson.M, status models.SeatStatus) ([]SeatLegRef, error) {
+	filter["seats.legs.status"] = status
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$unwind", Value: "$seats"}},
+		{{Key: "$unwind", Value: "$seats.legs"}},
+		{{Key: "$match", Value: bson.M{"seats.legs.status": status}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"tripId": "$_id", "bookingId": "$seats.legs.bookingId"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$_id"}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	legs := []SeatLegRef{}
+	if err := cursor.All(ctx, &legs); err != nil {
+		return nil, err
+	}
+	return legs, nil
+}
+
+func (r *mongoTripRepository) FindLegacySeats(ctx context.Context, status models.SeatStatus, departingAfter time.Time) ([]LegacySeatRef, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"seats.status": status, "departureTime": bson.M{"$gt": departingAfter}}}},
+		{{Key: "$unwind", Value: "$seats"}},
+		{{Key: "$match", Value: bson.M{"seats.status": status}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id", "seatNumbers": bson.M{"$push": "$seats.seatNumber"}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	refs := []LegacySeatRef{}
+	if err := cursor.All(ctx, &refs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+func (r *mongoTripRepository) UpdateSeatStatus(ctx context.Context, tripID primitive.ObjectID, seatNumbers []string, from, to models.SeatStatus) error {
+	if len(seatNumbers) == 0 {
+		return nil
+	}
+	update := bson.M{
+		"$set": bson.M{"seats.$[elem].status": to},
+	}
+	arrayFilters := options.ArrayFilters{
+		Filters: []interface{}{bson.M{
+			"elem.seatNumber": bson.M{"$in": seatNumbers},
+			"elem.status":     from,
+		}},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": tripID}, update, &options.UpdateOptions{ArrayFilters: &arrayFilters})
+	return err
+}
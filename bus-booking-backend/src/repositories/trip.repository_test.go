@@ -0,0 +1,375 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+// Các kiểm thử trong file này chạy cùng một kịch bản trên repository trong bộ nhớ và trên
+// MongoDB để bảo đảm hai bản cài đặt cho cùng kết quả. Phần MongoDB chỉ chạy khi MONGO_URI được
+// thiết lập, mỗi lần chạy dùng một database riêng và xóa nó khi kết thúc.
+
+// forEachBackend chạy test trên repository trong bộ nhớ và trên MongoDB.
+func forEachBackend(t *testing.T, test func(t *testing.T, repos *Repositories)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryRepositories())
+	})
+	t.Run("mongo", func(t *testing.T) {
+		test(t, newMongoTestRepositories(t))
+	})
+}
+
+func newMongoTestRepositories(t *testing.T) *Repositories {
+	t.Helper()
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI chưa được thiết lập, bỏ qua kiểm thử tích hợp MongoDB")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("kết nối MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+	db := client.Database("bus_booking_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := db.Drop(ctx); err != nil {
+			t.Errorf("xóa database kiểm thử: %v", err)
+		}
+		client.Disconnect(ctx)
+	})
+	if err := EnsureIndexes(ctx, db); err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+	return NewMongoRepositories(db)
+}
+
+func testLocation(t *testing.T) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Fatalf("đọc múi giờ: %v", err)
+	}
+	return location
+}
+
+// addCompanyVehicle nạp nhà xe name có điểm đánh giá rating cùng một xe loại vehicleType.
+func addCompanyVehicle(t *testing.T, repos *Repositories, name, code string, rating float64, vehicleType string) models.Vehicle {
+	t.Helper()
+	company := models.Company{ID: primitive.NewObjectID(), Name: name, Code: code, Rating: rating}
+	if err := repos.Companies.Create(t.Context(), &company); err != nil {
+		t.Fatalf("tạo nhà xe: %v", err)
+	}
+	vehicle := models.Vehicle{ID: primitive.NewObjectID(), CompanyID: company.ID, Type: vehicleType}
+	if err := repos.Vehicles.Create(t.Context(), &vehicle); err != nil {
+		t.Fatalf("tạo xe: %v", err)
+	}
+	return vehicle
+}
+
+// addTrip nạp chuyến Hà Nội → Hải Phòng bằng xe vehicle với hai ghế A1, A2; ghế trong sold đã
+// được bán trọn tuyến.
+func addTrip(t *testing.T, repos *Repositories, vehicle models.Vehicle, departure time.Time, duration time.Duration, price float64, sold ...string) models.Trip {
+	t.Helper()
+	trip := newTrip(vehicle, departure, duration, price, sold...)
+	createTrip(t, repos, &trip)
+	return trip
+}
+
+// newTrip dựng chuyến đi của addTrip mà chưa lưu.
+func newTrip(vehicle models.Vehicle, departure time.Time, duration time.Duration, price float64, sold ...string) models.Trip {
+	trip := models.Trip{
+		ID:                  primitive.NewObjectID(),
+		CompanyID:           vehicle.CompanyID,
+		VehicleID:           vehicle.ID,
+		Route:               models.Route{From: models.LocationPoint{Name: "Hà Nội"}, To: models.LocationPoint{Name: "Hải Phòng"}},
+		DepartureTime:       departure,
+		ExpectedArrivalTime: departure.Add(duration),
+		Price:               price,
+	}
+	for _, seatNumber := range []string{"A1", "A2"} {
+		seat := models.Seat{SeatNumber: seatNumber, Status: models.SeatAvailable}
+		if slices.Contains(sold, seatNumber) {
+			seat.Legs = []models.SeatLeg{{Segment: trip.FullSegment(), Status: models.SeatBooked, BookingID: primitive.NewObjectID()}}
+		}
+		trip.Seats = append(trip.Seats, seat)
+	}
+	return trip
+}
+
+func createTrip(t *testing.T, repos *Repositories, trip *models.Trip) {
+	t.Helper()
+	if err := repos.Trips.Create(t.Context(), trip); err != nil {
+		t.Fatalf("tạo chuyến đi: %v", err)
+	}
+}
+
+// searchFixture là năm chuyến Hà Nội → Hải Phòng trong một ngày của hai nhà xe.
+type searchFixture struct {
+	bac, nam           models.Vehicle
+	t06, t08, t10, t12 models.Trip
+	// t14 đã bán hết ghế.
+	t14 models.Trip
+}
+
+func addSearchFixture(t *testing.T, repos *Repositories, day time.Time) searchFixture {
+	t.Helper()
+	f := searchFixture{
+		bac: addCompanyVehicle(t, repos, "Nhà xe Bắc", "BAC", 4.5, "Giường nằm"),
+		nam: addCompanyVehicle(t, repos, "Nhà xe Nam", "NAM", 3.8, "Limousine"),
+	}
+	f.t06 = addTrip(t, repos, f.bac, day.Add(6*time.Hour), 150*time.Minute, 300000)
+	f.t08 = addTrip(t, repos, f.nam, day.Add(8*time.Hour), 120*time.Minute, 200000)
+	f.t10 = addTrip(t, repos, f.bac, day.Add(10*time.Hour), 180*time.Minute, 250000)
+	f.t12 = addTrip(t, repos, f.nam, day.Add(12*time.Hour), 90*time.Minute, 200000)
+	f.t14 = addTrip(t, repos, f.bac, day.Add(14*time.Hour), 120*time.Minute, 350000, "A1", "A2")
+	return f
+}
+
+func TestSearchPagesInSortOrder(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		location := testLocation(t)
+		f := addSearchFixture(t, repos, time.Date(2030, 3, 10, 0, 0, 0, 0, location))
+
+		tests := []struct {
+			sort string
+			want []models.Trip
+		}{
+			{sort: TripSortDeparture, want: []models.Trip{f.t06, f.t08, f.t10, f.t12, f.t14}},
+			// Hai chuyến cùng giá được xếp theo giờ lên xe.
+			{sort: TripSortPrice, want: []models.Trip{f.t08, f.t12, f.t10, f.t06, f.t14}},
+			{sort: TripSortDuration, want: []models.Trip{f.t12, f.t08, f.t14, f.t06, f.t10}},
+			{sort: TripSortRating, want: []models.Trip{f.t06, f.t10, f.t14, f.t08, f.t12}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.sort, func(t *testing.T) {
+				search := TripSearch{
+					TripFilter: TripFilter{From: "Hà Nội", To: "Hải Phòng"},
+					Location:   location,
+					Sort:       tt.sort,
+					Limit:      2,
+				}
+				var got []primitive.ObjectID
+				for pages := 0; ; pages++ {
+					if pages > len(tt.want) {
+						t.Fatalf("cursor không kết thúc sau %d trang", pages)
+					}
+					page, err := repos.Trips.Search(t.Context(), search)
+					if err != nil {
+						t.Fatalf("Search: %v", err)
+					}
+					trips := page.Trips
+					if len(trips) > search.Limit {
+						trips = trips[:search.Limit]
+					}
+					for _, trip := range trips {
+						got = append(got, trip.ID)
+					}
+					if len(page.Trips) <= search.Limit {
+						break
+					}
+					last := trips[len(trips)-1]
+					search.After = &TripSearchCursor{SortKey: last.SortKey, BoardingTime: last.BoardingTime, ID: last.ID}
+				}
+
+				want := make([]primitive.ObjectID, len(tt.want))
+				for i, trip := range tt.want {
+					want[i] = trip.ID
+				}
+				if !slices.Equal(got, want) {
+					t.Fatalf("thứ tự = %v, muốn %v", got, want)
+				}
+			})
+		}
+	})
+}
+
+func TestSearchCountsFacets(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		location := testLocation(t)
+		f := addSearchFixture(t, repos, time.Date(2030, 3, 10, 0, 0, 0, 0, location))
+
+		tests := []struct {
+			name         string
+			search       TripSearch
+			trips        []models.Trip
+			companies    map[string]int
+			vehicleTypes map[string]int
+		}{
+			{
+				name:         "không lọc",
+				trips:        []models.Trip{f.t06, f.t08, f.t10, f.t12, f.t14},
+				companies:    map[string]int{"Nhà xe Bắc": 3, "Nhà xe Nam": 2},
+				vehicleTypes: map[string]int{"Giường nằm": 3, "Limousine": 2},
+			},
+			{
+				// Nhóm đếm theo loại xe bỏ qua bộ lọc loại xe để client vẫn hiện đủ các lựa chọn.
+				name:         "lọc loại xe",
+				search:       TripSearch{VehicleType: "limousine"},
+				trips:        []models.Trip{f.t08, f.t12},
+				companies:    map[string]int{"Nhà xe Nam": 2},
+				vehicleTypes: map[string]int{"Giường nằm": 3, "Limousine": 2},
+			},
+			{
+				name:         "lọc nhà xe",
+				search:       TripSearch{CompanyIDs: []primitive.ObjectID{f.bac.CompanyID}},
+				trips:        []models.Trip{f.t06, f.t10, f.t14},
+				companies:    map[string]int{"Nhà xe Bắc": 3, "Nhà xe Nam": 2},
+				vehicleTypes: map[string]int{"Giường nằm": 3},
+			},
+			{
+				name:         "còn ghế",
+				search:       TripSearch{MinSeats: 1},
+				trips:        []models.Trip{f.t06, f.t08, f.t10, f.t12},
+				companies:    map[string]int{"Nhà xe Bắc": 2, "Nhà xe Nam": 2},
+				vehicleTypes: map[string]int{"Giường nằm": 2, "Limousine": 2},
+			},
+			{
+				name:         "khoảng giá",
+				search:       TripSearch{MinPrice: 210000, MaxPrice: 320000},
+				trips:        []models.Trip{f.t06, f.t10},
+				companies:    map[string]int{"Nhà xe Bắc": 2},
+				vehicleTypes: map[string]int{"Giường nằm": 2},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				search := tt.search
+				search.TripFilter = TripFilter{From: "Hà Nội", To: "Hải Phòng"}
+				search.Location = location
+				search.Limit = 10
+				page, err := repos.Trips.Search(t.Context(), search)
+				if err != nil {
+					t.Fatalf("Search: %v", err)
+				}
+
+				var got, want []primitive.ObjectID
+				for _, trip := range page.Trips {
+					got = append(got, trip.ID)
+				}
+				for _, trip := range tt.trips {
+					want = append(want, trip.ID)
+				}
+				if !slices.Equal(got, want) {
+					t.Fatalf("chuyến = %v, muốn %v", got, want)
+				}
+
+				companies := map[string]int{}
+				for _, count := range page.Companies {
+					companies[count.Name] = count.Count
+				}
+				vehicleTypes := map[string]int{}
+				for _, count := range page.VehicleTypes {
+					vehicleTypes[count.VehicleType] = count.Count
+				}
+				if !maps.Equal(companies, tt.companies) {
+					t.Fatalf("đếm theo nhà xe = %v, muốn %v", companies, tt.companies)
+				}
+				if !maps.Equal(vehicleTypes, tt.vehicleTypes) {
+					t.Fatalf("đếm theo loại xe = %v, muốn %v", vehicleTypes, tt.vehicleTypes)
+				}
+			})
+		}
+	})
+}
+
+func TestFareCalendarCountsBoardingDays(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos *Repositories) {
+		location := testLocation(t)
+		day := time.Date(2030, 3, 10, 0, 0, 0, 0, location)
+		vehicle := addCompanyVehicle(t, repos, "Nhà xe Bắc", "BAC", 4.5, "Giường nằm")
+
+		// Ngày 10: chuyến rẻ nhất đã hết ghế nên không được tính vào giá thấp nhất.
+		addTrip(t, repos, vehicle, day.Add(7*time.Hour), 2*time.Hour, 200000, "A1", "A2")
+		addTrip(t, repos, vehicle, day.Add(9*time.Hour), 2*time.Hour, 260000)
+
+		// Ngày 11: chuyến Bắc Ninh → Hà Nội → Hải Phòng khởi hành tối ngày 10 nhưng tới Hà Nội sau
+		// nửa đêm nên thuộc ngày 11. Ghế A1 chỉ bán chặng Bắc Ninh → Hà Nội nên vẫn trống trên chặng
+		// Hà Nội → Hải Phòng; ghế A2 đã bán chặng này.
+		overnight := newTrip(vehicle, day.Add(23*time.Hour+30*time.Minute), 3*time.Hour, 220000)
+		overnight.Route = models.Route{
+			From: models.LocationPoint{Name: "Bắc Ninh"},
+			To:   models.LocationPoint{Name: "Hải Phòng"},
+			Stops: []models.Stop{
+				{Name: "Bắc Ninh"},
+				{Name: "Hà Nội", OffsetMinutes: 60},
+				{Name: "Hải Phòng", OffsetMinutes: 180},
+			},
+		}
+		overnight.Fares = []models.Fare{{From: "Hà Nội", To: "Hải Phòng", Price: 150000}}
+		overnight.Seats[0].Legs = []models.SeatLeg{{Segment: models.Segment{FromStop: 0, ToStop: 1}, Status: models.SeatBooked, BookingID: primitive.NewObjectID()}}
+		overnight.Seats[1].Legs = []models.SeatLeg{{Segment: models.Segment{FromStop: 1, ToStop: 2}, Status: models.SeatBooked, BookingID: primitive.NewObjectID()}}
+		createTrip(t, repos, &overnight)
+
+		// Ngày 12: chỉ có chuyến đã hết ghế và một chuyến đi ngược chiều.
+		addTrip(t, repos, vehicle, day.Add(56*time.Hour), 2*time.Hour, 180000, "A1", "A2")
+		reverse := newTrip(vehicle, day.Add(58*time.Hour), 2*time.Hour, 100000)
+		reverse.Route = models.Route{From: models.LocationPoint{Name: "Hải Phòng"}, To: models.LocationPoint{Name: "Hà Nội"}}
+		createTrip(t, repos, &reverse)
+
+		// Chuyến ngoài khoảng thống kê.
+		addTrip(t, repos, vehicle, day.Add(-2*time.Hour), 2*time.Hour, 90000)
+		addTrip(t, repos, vehicle, day.Add(72*time.Hour), 2*time.Hour, 90000)
+
+		start, end := day, day.AddDate(0, 0, 3)
+		got, err := repos.Trips.FareCalendar(t.Context(), FareCalendarQuery{
+			From:          "Hà Nội",
+			To:            "Hải Phòng",
+			DepartureFrom: start.Add(-24 * time.Hour),
+			DepartureTo:   end,
+			BoardingFrom:  start,
+			BoardingTo:    end,
+			Location:      location,
+		})
+		if err != nil {
+			t.Fatalf("FareCalendar: %v", err)
+		}
+
+		price := func(p float64) *float64 { return &p }
+		want := []FareCalendarDay{
+			{Date: "2030-03-10", MinPrice: price(260000), Trips: 2, AvailableSeats: 2},
+			{Date: "2030-03-11", MinPrice: price(150000), Trips: 1, AvailableSeats: 1},
+			{Date: "2030-03-12", MinPrice: nil, Trips: 1, AvailableSeats: 0},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("FareCalendar = %s, muốn %s", formatDays(got), formatDays(want))
+		}
+		for i := range want {
+			same := got[i].Date == want[i].Date && got[i].Trips == want[i].Trips && got[i].AvailableSeats == want[i].AvailableSeats &&
+				(got[i].MinPrice == nil) == (want[i].MinPrice == nil) && (got[i].MinPrice == nil || *got[i].MinPrice == *want[i].MinPrice)
+			if !same {
+				t.Fatalf("FareCalendar = %s, muốn %s", formatDays(got), formatDays(want))
+			}
+		}
+	})
+}
+
+func formatDays(days []FareCalendarDay) string {
+	var parts []string
+	for _, day := range days {
+		minPrice := "nil"
+		if day.MinPrice != nil {
+			minPrice = strconv.FormatFloat(*day.MinPrice, 'f', -1, 64)
+		}
+		parts = append(parts, fmt.Sprintf("{%s giá %s, %d chuyến, %d ghế}", day.Date, minPrice, day.Trips, day.AvailableSeats))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
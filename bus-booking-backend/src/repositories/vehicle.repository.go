@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VehicleFilter là điều kiện liệt kê xe. CompanyID nil nghĩa là mọi nhà xe.
+type VehicleFilter struct {
+	CompanyID *primitive.ObjectID
+}
+
+type VehicleRepository interface {
+	Create(ctx context.Context, vehicle *models.Vehicle) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Vehicle, error)
+	// FindByIDs trả về các xe có ID trong ids; ID không tồn tại bị bỏ qua.
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Vehicle, error)
+	// List trả về các xe khớp filter, mới tạo trước.
+	List(ctx context.Context, filter VehicleFilter) ([]models.Vehicle, error)
+	// Update ghi đè toàn bộ xe theo ID, trả về ErrNotFound nếu không tồn tại.
+	Update(ctx context.Context, vehicle *models.Vehicle) error
+	// Delete xóa xe theo ID, trả về ErrNotFound nếu không tồn tại.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type mongoVehicleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoVehicleRepository(db *mongo.Database) VehicleRepository {
+	return &mongoVehicleRepository{collection: db.Collection("vehicles")}
+}
+
+func (r *mongoVehicleRepository) Create(ctx context.Context, vehicle *models.Vehicle) error {
+	_, err := r.collection.InsertOne(ctx, vehicle)
+	return mongoError(err)
+}
+
+func (r *mongoVehicleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&vehicle); err != nil {
+		return nil, mongoError(err)
+	}
+	return &vehicle, nil
+}
+
+func (r *mongoVehicleRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Vehicle, error) {
+	if len(ids) == 0 {
+		return []models.Vehicle{}, nil
+	}
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *mongoVehicleRepository) List(ctx context.Context, filter VehicleFilter) ([]models.Vehicle, error) {
+	query := bson.M{}
+	if filter.CompanyID != nil {
+		query["companyId"] = *filter.CompanyID
+	}
+	return r.find(ctx, query)
+}
+
+func (r *mongoVehicleRepository) find(ctx context.Context, query bson.M) ([]models.Vehicle, error) {
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	vehicles := []models.Vehicle{}
+	if err := cursor.All(ctx, &vehicles); err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
+
+func (r *mongoVehicleRepository) Update(ctx context.Context, vehicle *models.Vehicle) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": vehicle.ID}, vehicle)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoVehicleRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type WebhookEventRepository interface {
+	// Create ghi nhận sự kiện, trả về ErrDuplicate nếu mã sự kiện đã tồn tại.
+	Create(ctx context.Context, event *models.WebhookEvent) error
+	FindByID(ctx context.Context, id string) (*models.WebhookEvent, error)
+	// TakeOver nhận xử lý tiếp sự kiện đang "processing" mà lease đã hết hạn trước now (hoặc không
+	// có lease), đặt lease mới tới lockedUntil. Trả về ErrConflict nếu sự kiện đã xử lý xong hoặc
+	// lease còn hiệu lực, ErrNotFound nếu sự kiện không tồn tại.
+	TakeOver(ctx context.Context, id string, now, lockedUntil time.Time) error
+	MarkProcessed(ctx context.Context, id string, processedAt time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
+type mongoWebhookEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoWebhookEventRepository(db *mongo.Database) WebhookEventRepository {
+	return &mongoWebhookEventRepository{collection: db.Collection("webhook_events")}
+}
+
+func (r *mongoWebhookEventRepository) Create(ctx context.Context, event *models.WebhookEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return mongoError(err)
+}
+
+func (r *mongoWebhookEventRepository) FindByID(ctx context.Context, id string) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&event); err != nil {
+		return nil, mongoError(err)
+	}
+	return &event, nil
+}
+
+func (r *mongoWebhookEventRepository) TakeOver(ctx context.Context, id string, now, lockedUntil time.Time) error {
+	filter := bson.M{
+		"_id":    id,
+		"status": "processing",
+		"$or": bson.A{
+			bson.M{"lockedUntil": bson.M{"$lt": now}},
+			bson.M{"lockedUntil": bson.M{"$exists": false}},
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lockedUntil": lockedUntil}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoWebhookEventRepository) MarkProcessed(ctx context.Context, id string, processedAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": "processed", "processedAt": processedAt}},
+	)
+	return err
+}
+
+func (r *mongoWebhookEventRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
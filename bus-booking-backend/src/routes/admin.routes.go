@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"github.com/Go_final_exam/bus-booking-backend/src/controllers"
+	"github.com/Go_final_exam/bus-booking-backend/src/middlewares"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoutes chứa các endpoint quản trị; mỗi nhóm tự khai báo vai trò được phép truy cập.
+func AdminRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, companyController *controllers.CompanyController, vehicleController *controllers.VehicleController, tripController *controllers.TripController, scheduleController *controllers.ScheduleController, locationController *controllers.LocationController) {
+	adminGroup := router.Group("/admin")
+	adminGroup.Use(authMiddleware)
+
+	companyGroup := adminGroup.Group("/companies")
+	companyGroup.Use(middlewares.RequireRole(models.RoleAdmin))
+	{
+		companyGroup.GET("", companyController.ListCompanies)
+		companyGroup.POST("", companyController.CreateCompany)
+		companyGroup.PUT("/:companyId", companyController.UpdateCompany)
+		companyGroup.POST("/:companyId/deactivate", companyController.DeactivateCompany)
+	}
+
+	locationGroup := adminGroup.Group("/locations")
+	locationGroup.Use(middlewares.RequireRole(models.RoleAdmin))
+	{
+		locationGroup.GET("", locationController.ListLocations)
+		locationGroup.POST("", locationController.CreateLocation)
+		locationGroup.PUT("/:locationId", locationController.UpdateLocation)
+	}
+
+	vehicleGroup := adminGroup.Group("/vehicles")
+	vehicleGroup.Use(middlewares.RequireRole(models.RoleAdmin, models.RoleOperatorStaff))
+	{
+		vehicleGroup.GET("", vehicleController.ListVehicles)
+		vehicleGroup.GET("/:vehicleId", vehicleController.GetVehicle)
+		vehicleGroup.POST("", vehicleController.CreateVehicle)
+		vehicleGroup.PUT("/:vehicleId", vehicleController.UpdateVehicle)
+		vehicleGroup.DELETE("/:vehicleId", vehicleController.DeleteVehicle)
+	}
+
+	tripGroup := adminGroup.Group("/trips")
+	tripGroup.Use(middlewares.RequireRole(models.RoleAdmin, models.RoleOperatorStaff))
+	{
+		tripGroup.POST("", tripController.CreateTrip)
+		tripGroup.PUT("/:tripId", tripController.UpdateTrip)
+		tripGroup.DELETE("/:tripId", tripController.DeleteTrip)
+	}
+
+	scheduleGroup := adminGroup.Group("/schedules")
+	scheduleGroup.Use(middlewares.RequireRole(models.RoleAdmin, models.RoleOperatorStaff))
+	{
+		scheduleGroup.GET("", scheduleController.ListSchedules)
+		scheduleGroup.POST("", scheduleController.CreateSchedule)
+		scheduleGroup.PUT("/:scheduleId", scheduleController.UpdateSchedule)
+		scheduleGroup.POST("/:scheduleId/disable", scheduleController.DisableSchedule)
+	}
+}
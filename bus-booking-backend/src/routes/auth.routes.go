@@ -5,10 +5,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(router *gin.RouterGroup) {
+func AuthRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, authController *controllers.AuthController) {
 	authGroup := router.Group("/auth")
 	{
-		authGroup.POST("/register", controllers.RegisterController)
-		authGroup.POST("/login", controllers.LoginController)
+		authGroup.POST("/register", authController.Register)
+		authGroup.POST("/login", authController.Login)
+		authGroup.POST("/refresh", authController.Refresh)
+		authGroup.POST("/logout", authController.Logout)
+		authGroup.POST("/logout-all", authMiddleware, authController.LogoutAll)
 	}
 }
\ No newline at end of file
@@ -2,16 +2,20 @@ package routes
 
 import (
 	"github.com/Go_final_exam/bus-booking-backend/src/controllers"
-	"github.com/Go_final_exam/bus-booking-backend/src/middlewares"
 	"github.com/gin-gonic/gin"
 )
 
-func BookingRoutes(router *gin.RouterGroup) {
+func BookingRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, bookingController *controllers.BookingController, paymentController *controllers.PaymentController, ticketController *controllers.TicketController) {
 	bookingGroup := router.Group("/bookings")
-  	bookingGroup.Use(middlewares.AuthMiddleware())
+  	bookingGroup.Use(authMiddleware)
   	{
-  		bookingGroup.POST("", controllers.CreateBookingController)
-  		bookingGroup.GET("/:bookingId", controllers.GetBookingDetailsController) 
-  		bookingGroup.GET("/my", controllers.GetMyBookingsController)         
+  		bookingGroup.POST("", bookingController.CreateBooking)
+  		bookingGroup.GET("/:bookingId", bookingController.GetBookingDetails) 
+  		bookingGroup.GET("/my", bookingController.GetMyBookings)         
+  		bookingGroup.POST("/:bookingId/pay", paymentController.PayBooking)
+  		bookingGroup.POST("/:bookingId/cancel", bookingController.CancelBooking)
+  		bookingGroup.PUT("/:bookingId/passengers", bookingController.UpdatePassengers)
+  		bookingGroup.GET("/:bookingId/ticket.pdf", ticketController.DownloadTicketPDF)
+  		bookingGroup.GET("/:bookingId/ticket.png", ticketController.DownloadTicketPNG)
   	}
 }
\ No newline at end of file
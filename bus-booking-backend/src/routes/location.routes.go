@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Go_final_exam/bus-booking-backend/src/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// LocationRoutes chứa các endpoint công khai của danh mục địa điểm.
+func LocationRoutes(router *gin.RouterGroup, locationController *controllers.LocationController) {
+	locationGroup := router.Group("/locations")
+	{
+		locationGroup.GET("/suggest", locationController.SuggestLocations)
+	}
+}
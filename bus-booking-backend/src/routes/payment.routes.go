@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Go_final_exam/bus-booking-backend/src/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentRoutes chứa các endpoint do cổng thanh toán gọi tới, không dùng JWT
+// mà được xác thực bằng chữ ký HMAC.
+func PaymentRoutes(router *gin.RouterGroup, paymentController *controllers.PaymentController) {
+	paymentGroup := router.Group("/payments")
+	{
+		paymentGroup.POST("/webhook", paymentController.PaymentWebhook)
+	}
+}
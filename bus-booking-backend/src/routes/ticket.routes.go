@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/Go_final_exam/bus-booking-backend/src/controllers"
+	"github.com/Go_final_exam/bus-booking-backend/src/middlewares"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TicketRoutes(router *gin.RouterGroup, authMiddleware gin.HandlerFunc, ticketController *controllers.TicketController) {
+	ticketGroup := router.Group("/tickets")
+	{
+		ticketGroup.GET("/:code/verify", ticketController.VerifyTicket)
+		ticketGroup.POST("/:code/check-in", authMiddleware, middlewares.RequireRole(models.RoleOperatorStaff, models.RoleAdmin), ticketController.CheckIn)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func TripRoutes(router *gin.RouterGroup) {
+func TripRoutes(router *gin.RouterGroup, tripController *controllers.TripController) {
 	tripGroup := router.Group("/trips")
 	{
-		tripGroup.GET("", controllers.SearchTripsController)
+		tripGroup.GET("", tripController.SearchTrips)
+		tripGroup.GET("/calendar", tripController.GetFareCalendar)
 		
-		tripGroup.GET("/:tripId", controllers.GetTripDetailsController)
+		tripGroup.GET("/:tripId", tripController.GetTripDetails)
+		tripGroup.GET("/:tripId/seats/stream", tripController.StreamSeatEvents)
 	}
 }
\ No newline at end of file
@@ -5,12 +5,11 @@ import (
 	"errors"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 
-	"github.com/Go_final_exam/bus-booking-backend/src/config"
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
 	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
 	"github.com/Go_final_exam/bus-booking-backend/src/utils"
 )
 
@@ -26,20 +25,31 @@ type LoginInput struct {
 	Password string `json:"password" validate:"required"`
 }
 
-func Register(input RegisterInput) (*models.User, error) {
-	userCollection := config.DB.Collection("users")
+var (
+	ErrEmailTaken         = apperrors.New(apperrors.ErrConflict, "email đã được sử dụng").WithCode("email_taken")
+	ErrInvalidCredentials = apperrors.New(apperrors.ErrUnauthorized, "email hoặc mật khẩu không chính xác").WithCode("invalid_credentials")
+)
 
-	count, err := userCollection.CountDocuments(context.TODO(), bson.M{"email": input.Email})
-	if err != nil {
-		return nil, errors.New("lỗi khi kiểm tra email")
-	}
-	if count > 0 {
-		return nil, errors.New("email đã được sử dụng")
-	}
+// AuthService xử lý đăng ký, đăng nhập và các phiên đăng nhập.
+type AuthService struct {
+	users    repositories.UserRepository
+	sessions repositories.SessionRepository
+	tokens   *utils.TokenIssuer
+	// refreshTTL là thời hạn của refresh token.
+	refreshTTL time.Duration
+}
+
+func NewAuthService(users repositories.UserRepository, sessions repositories.SessionRepository, tokens *utils.TokenIssuer, refreshTTL time.Duration) *AuthService {
+	return &AuthService{users: users, sessions: sessions, tokens: tokens, refreshTTL: refreshTTL}
+}
+
+func (s *AuthService) Register(input RegisterInput) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
 	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
-		return nil, errors.New("không thể băm mật khẩu")
+		return nil, internalError("không thể băm mật khẩu", err)
 	}
 
 	newUser := models.User{
@@ -48,39 +58,38 @@ func Register(input RegisterInput) (*models.User, error) {
 		Phone:        input.Phone,
 		PasswordHash: hashedPassword,
 		Name:         input.Name,
+		Role:         models.RoleCustomer,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	_, err = userCollection.InsertOne(context.TODO(), newUser)
-	if err != nil {
-		return nil, errors.New("không thể tạo người dùng")
+	if err := s.users.Create(ctx, &newUser); err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrEmailTaken
+		}
+		return nil, internalError("không thể tạo người dùng", err)
 	}
 
 	return &newUser, nil
 }
 
-func Login(input LoginInput) (string, error) {
-	userCollection := config.DB.Collection("users")
-	var user models.User
+// Login kiểm tra thông tin đăng nhập và mở một phiên mới cho thiết bị client.
+func (s *AuthService) Login(input LoginInput, client ClientInfo) (*AuthTokens, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	err := userCollection.FindOne(context.TODO(), bson.M{"email": input.Email}).Decode(&user)
+	user, err := s.users.FindByEmail(ctx, input.Email)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return "", errors.New("email hoặc mật khẩu không chính xác")
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrInvalidCredentials
 		}
-		return "", errors.New("lỗi hệ thống khi tìm kiếm người dùng")
+		return nil, internalError("lỗi hệ thống khi tìm kiếm người dùng", err)
 	}
 
 	err = utils.CheckPasswordHash(input.Password, user.PasswordHash)
 	if err != nil {
-		return "", errors.New("email hoặc mật khẩu không chính xác")
+		return nil, ErrInvalidCredentials
 	}
 
-	token, err := utils.GenerateToken(user.ID)
-	if err != nil {
-		return "", errors.New("không thể tạo token xác thực")
-	}
-
-	return token, nil
-}
\ No newline at end of file
+	return s.startSession(ctx, user, client)
+}
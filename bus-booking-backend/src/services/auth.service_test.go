@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"github.com/Go_final_exam/bus-booking-backend/src/utils"
+)
+
+func newTestAuthService() *AuthService {
+	repos := repositories.NewMemoryRepositories()
+	return NewAuthService(repos.Users, repos.Sessions, utils.NewTokenIssuer("test-secret", 15*time.Minute), 24*time.Hour)
+}
+
+func registerAndLogin(t *testing.T, auth *AuthService) *AuthTokens {
+	t.Helper()
+	input := RegisterInput{Email: "a@example.com", Phone: "0901234567", Password: "matkhau123", Name: "Nguyễn Văn A"}
+	if _, err := auth.Register(input); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	tokens, err := auth.Login(LoginInput{Email: input.Email, Password: input.Password}, ClientInfo{UserAgent: "test"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return tokens
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	auth := newTestAuthService()
+	tokens := registerAndLogin(t, auth)
+
+	if _, err := auth.Authenticate(t.Context(), tokens.AccessToken); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if _, err := auth.Register(RegisterInput{Email: "a@example.com", Phone: "0901234567", Password: "matkhau123", Name: "B"}); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("đăng ký trùng email: lỗi = %v, muốn ErrEmailTaken", err)
+	}
+	if _, err := auth.Login(LoginInput{Email: "a@example.com", Password: "saimatkhau"}, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("sai mật khẩu: lỗi = %v, muốn ErrInvalidCredentials", err)
+	}
+	if _, err := auth.Login(LoginInput{Email: "b@example.com", Password: "matkhau123"}, ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("email không tồn tại: lỗi = %v, muốn ErrInvalidCredentials", err)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	auth := newTestAuthService()
+	first := registerAndLogin(t, auth)
+
+	second, err := auth.Refresh(RefreshInput{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token không được xoay vòng")
+	}
+	if _, err := auth.Authenticate(t.Context(), second.AccessToken); err != nil {
+		t.Fatalf("Authenticate token mới: %v", err)
+	}
+
+	// Dùng lại refresh token cũ là dấu hiệu bị lộ token: cả phiên bị thu hồi.
+	if _, err := auth.Refresh(RefreshInput{RefreshToken: first.RefreshToken}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("dùng lại refresh token: lỗi = %v, muốn ErrRefreshTokenReused", err)
+	}
+	if _, err := auth.Refresh(RefreshInput{RefreshToken: second.RefreshToken}); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("refresh sau khi thu hồi: lỗi = %v, muốn ErrSessionRevoked", err)
+	}
+	if _, err := auth.Authenticate(t.Context(), second.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("access token sau khi thu hồi: lỗi = %v, muốn ErrSessionRevoked", err)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	auth := newTestAuthService()
+	tokens := registerAndLogin(t, auth)
+
+	if err := auth.Logout(RefreshInput{RefreshToken: tokens.RefreshToken}); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := auth.Authenticate(t.Context(), tokens.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("access token sau khi đăng xuất: lỗi = %v, muốn ErrSessionRevoked", err)
+	}
+	if _, err := auth.Refresh(RefreshInput{RefreshToken: tokens.RefreshToken}); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("refresh sau khi đăng xuất: lỗi = %v, muốn ErrSessionRevoked", err)
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+// ErrCompanyForbidden được trả về khi nhân viên thao tác trên dữ liệu của nhà xe khác.
+var ErrCompanyForbidden = apperrors.New(apperrors.ErrForbidden, "bạn không có quyền quản lý dữ liệu của nhà xe này").WithCode("company_forbidden")
+
+// HasRole cho biết người dùng có một trong các vai trò roles hay không.
+func (c *AccessClaims) HasRole(roles ...models.Role) bool {
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageCompany cho biết người dùng có được quản lý chuyến đi và booking của nhà xe
+// companyID hay không: quản trị viên quản lý mọi nhà xe, nhân viên chỉ quản lý nhà xe của mình.
+func (c *AccessClaims) CanManageCompany(companyID primitive.ObjectID) bool {
+	switch c.Role {
+	case models.RoleAdmin:
+		return true
+	case models.RoleOperatorStaff:
+		return c.CompanyID != "" && c.CompanyID == companyID.Hex()
+	default:
+		return false
+	}
+}
+
+// authorizeCompany trả về ErrCompanyForbidden nếu người dùng không được quản lý nhà xe companyID.
+func authorizeCompany(claims *AccessClaims, companyID primitive.ObjectID) error {
+	if claims == nil || !claims.CanManageCompany(companyID) {
+		return ErrCompanyForbidden
+	}
+	return nil
+}
@@ -5,239 +5,302 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
-	"github.com/Go_final_exam/bus-booking-backend/src/config"
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
 	"github.com/Go_final_exam/bus-booking-backend/src/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+	"github.com/Go_final_exam/bus-booking-backend/src/realtime"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// CreateBookingInput là dữ liệu giữ chỗ. From và To là tên điểm lên và điểm xuống; để trống cả
+// hai để đi trọn tuyến.
 type CreateBookingInput struct {
 	TripID      string   `json:"tripId" validate:"required"`
 	SeatNumbers []string `json:"seatNumbers" validate:"required,min=1"`
+	From        string   `json:"from" validate:"omitempty,max=100"`
+	To          string   `json:"to" validate:"omitempty,max=100"`
 }
 
-func CreateBooking(input CreateBookingInput, userIDStr string) (*models.Booking, error) {
-    ctx := context.Background()
-    tripCollection := config.DB.Collection("trips")
-    bookingCollection := config.DB.Collection("bookings")
-
-    tripID, err := primitive.ObjectIDFromHex(input.TripID)
-    if err != nil {
-        return nil, errors.New("ID chuyến đi không hợp lệ")
-    }
-    userID, err := primitive.ObjectIDFromHex(userIDStr)
-    if err != nil {
-        return nil, errors.New("ID người dùng không hợp lệ")
-    }
-
-    var trip models.Trip
-    err = tripCollection.FindOne(ctx, bson.M{"_id": tripID}).Decode(&trip)
-    if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return nil, errors.New("không tìm thấy chuyến đi")
-        }
-        log.Printf("Lỗi khi FindOne trip: %v", err)
-        return nil, errors.New("lỗi khi tìm kiếm chuyến đi")
-    }
-
-    seatPrice := trip.Price
-    seatsToBook := make(map[string]bool)
-    for _, s := range input.SeatNumbers {
-        seatsToBook[s] = true
-    }
-
-    for _, seat := range trip.Seats {
-        if seatsToBook[seat.SeatNumber] {
-            if seat.Status != "available" {
-                return nil, fmt.Errorf("ghế '%s' đã được chọn hoặc không còn trống", seat.SeatNumber)
-            }
-        }
-    }
-
-    newBooking := models.Booking{
-        ID:          primitive.NewObjectID(),
-        UserID:      userID,
-        TripID:      tripID,
-        BookingTime: time.Now(),
-        Status:      "held",
-        TotalAmount: float64(len(input.SeatNumbers)) * seatPrice,
-        Passengers:  []models.Passenger{},
-        CreatedAt:   time.Now(),
-        UpdatedAt:   time.Now(),
-    }
-    for _, seatNum := range input.SeatNumbers {
-        newBooking.Passengers = append(newBooking.Passengers, models.Passenger{SeatNumber: seatNum})
-    }
-
-    _, err = bookingCollection.InsertOne(ctx, newBooking)
-    if err != nil {
-        return nil, errors.New("không thể tạo booking mới")
-    }
-
-    filter := bson.M{
-        "_id":              tripID,
-        "seats.seatNumber": bson.M{"$in": input.SeatNumbers},
-    }
-    update := bson.M{
-        "$set": bson.M{"seats.$[elem].status": "held"},
-    }
-    arrayFilters := options.ArrayFilters{
-        Filters: []interface{}{bson.M{"elem.seatNumber": bson.M{"$in": input.SeatNumbers}}},
-    }
-    updateOptions := options.UpdateOptions{ArrayFilters: &arrayFilters}
-
-    _, err = tripCollection.UpdateOne(ctx, filter, update, &updateOptions)
-    if err != nil {
-        return nil, errors.New("lỗi khi cập nhật trạng thái ghế")
-    }
-
-    return &newBooking, nil
+// SeatConflictError cho biết cụ thể những ghế nào đã bị người khác giữ hoặc đặt.
+type SeatConflictError struct {
+	SeatNumbers []string
 }
 
-func GetBookingDetailsByID(bookingIDStr string, userIDStr string) (*models.Booking, error) {
-	bookingCollection := config.DB.Collection("bookings")
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second) // Tăng timeout một chút cho aggregation
+func (e *SeatConflictError) Error() string {
+	return fmt.Sprintf("%s: %s", apperrors.ErrSeatConflict.Error(), strings.Join(e.SeatNumbers, ", "))
+}
+
+func (e *SeatConflictError) Unwrap() error {
+	return apperrors.ErrSeatConflict
+}
+
+// BookingService quản lý vòng đời booking: giữ chỗ, hành khách, hủy vé và hết hạn giữ chỗ.
+type BookingService struct {
+	bookings  repositories.BookingRepository
+	trips     repositories.TripRepository
+	users     repositories.UserRepository
+	payments  repositories.PaymentRepository
+	companies repositories.CompanyRepository
+	gateway   payments.PaymentGateway
+	// holdDuration là thời gian một booking "held" được giữ ghế trước khi bị hủy tự động.
+	holdDuration time.Duration
+	// location là múi giờ vận hành, dùng cho giờ của chuyến đi trả về kèm booking.
+	location *time.Location
+	// seatEvents nhận các thay đổi trạng thái ghế để đẩy tới người đang xem sơ đồ ghế.
+	seatEvents realtime.SeatEventBus
+}
+
+func NewBookingService(
+	bookings repositories.BookingRepository,
+	trips repositories.TripRepository,
+	users repositories.UserRepository,
+	paymentRepo repositories.PaymentRepository,
+	companies repositories.CompanyRepository,
+	gateway payments.PaymentGateway,
+	holdDuration time.Duration,
+	location *time.Location,
+	seatEvents realtime.SeatEventBus,
+) *BookingService {
+	return &BookingService{
+		bookings:     bookings,
+		trips:        trips,
+		users:        users,
+		payments:     paymentRepo,
+		companies:    companies,
+		gateway:      gateway,
+		holdDuration: holdDuration,
+		location:     location,
+		seatEvents:   seatEvents,
+	}
+}
+
+// CreateBooking giữ các ghế được chọn trên chặng From → To rồi tạo booking "held". Giữ ghế và ghi
+// booking là hai lệnh ghi trên hai collection, không nằm trong một transaction: nếu ghi booking
+// thất bại, ghế được trả lại ngay; nếu lệnh trả ghế cũng thất bại hoặc tiến trình dừng giữa hai
+// lệnh ghi, chặng ghế không có booking được ReconcileHeldSeats trả lại khi quá thời gian giữ chỗ.
+func (s *BookingService) CreateBooking(input CreateBookingInput, userIDStr string) (*models.Booking, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Bắt đầu cho bookingID: %s, userID: %s", bookingIDStr, userIDStr)
+	tripID, err := primitive.ObjectIDFromHex(input.TripID)
+	if err != nil {
+		return nil, ErrInvalidTripID
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	seatNumbers := uniqueSeatNumbers(input.SeatNumbers)
+
+	trip, err := s.trips.FindByID(ctx, tripID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrTripNotFound
+		}
+		log.Printf("Lỗi khi FindOne trip: %v", err)
+		return nil, internalError("lỗi khi tìm kiếm chuyến đi", err)
+	}
+
+	if company, err := s.companies.FindByID(ctx, trip.CompanyID); err == nil && !company.IsActive() {
+		return nil, ErrCompanyInactive
+	} else if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		log.Printf("Lỗi khi tìm nhà xe %s của trip %s: %v", trip.CompanyID.Hex(), tripID.Hex(), err)
+		return nil, internalError("lỗi khi tìm kiếm nhà xe", err)
+	}
+
+	segment, fare, err := resolveSegment(trip, input.From, input.To)
+	if err != nil {
+		return nil, err
+	}
+
+	tripSeats := make(map[string]bool, len(trip.Seats))
+	for _, seat := range trip.Seats {
+		tripSeats[seat.SeatNumber] = true
+	}
+	for _, seatNum := range seatNumbers {
+		if !tripSeats[seatNum] {
+			return nil, apperrors.New(apperrors.ErrInvalidInput, fmt.Sprintf("ghế '%s' không tồn tại trên chuyến đi này", seatNum)).WithCode("seat_not_found")
+		}
+	}
+
+	// Giữ ghế bằng một thao tác nguyên tử: hoặc tất cả các ghế được chọn được giữ cho chặng này,
+	// hoặc không ghế nào thay đổi. Khi hai người cùng tranh một ghế trên các chặng chồng nhau chỉ
+	// có đúng một người thành công. ID booking được sinh trước để chặng ghế trỏ tới booking và
+	// mang thời điểm giữ ghế, nhờ đó ReconcileHeldSeats biết khi nào được trả chặng ghế mồ côi.
+	bookingID := primitive.NewObjectID()
+	leg := models.SeatLeg{Segment: segment, Status: models.SeatHeld, BookingID: bookingID}
+	if err := s.trips.HoldSeats(ctx, tripID, seatNumbers, leg); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return nil, &SeatConflictError{SeatNumbers: s.unavailableSeats(ctx, tripID, seatNumbers, segment)}
+		}
+		log.Printf("Lỗi khi giữ ghế cho trip %s: %v", tripID.Hex(), err)
+		return nil, internalError("lỗi khi cập nhật trạng thái ghế", err)
+	}
+
+	now := time.Now()
+	holdExpiresAt := now.Add(s.holdDuration)
+	newBooking := models.Booking{
+		ID:            bookingID,
+		UserID:        userID,
+		TripID:        tripID,
+		BookingTime:   now,
+		Status:        models.BookingHeld,
+		TotalAmount:   float64(len(seatNumbers)) * fare,
+		Segment:       &segment,
+		Passengers:    []models.Passenger{},
+		HoldExpiresAt: &holdExpiresAt,
+		StatusHistory: []models.StatusChange{
+			{To: models.BookingHeld, At: now, Actor: models.UserActor(userIDStr), Reason: "giữ chỗ"},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, seatNum := range seatNumbers {
+		newBooking.Passengers = append(newBooking.Passengers, models.Passenger{SeatNumber: seatNum})
+	}
+
+	if err := s.bookings.Create(ctx, &newBooking); err != nil {
+		// Nếu không trả được ghế, ReconcileHeldSeats sẽ trả lại khi hết thời gian giữ chỗ.
+		log.Printf("Lỗi khi tạo booking, hoàn trả ghế cho trip %s: %v", tripID.Hex(), err)
+		if releaseErr := updateSeatStatus(ctx, s.trips, &newBooking, models.SeatHeld, models.SeatAvailable); releaseErr != nil {
+			log.Printf("Lỗi khi hoàn trả ghế cho trip %s: %v", tripID.Hex(), releaseErr)
+		}
+		return nil, internalError("không thể tạo booking mới", err)
+	}
+	publishSeatEvent(s.seatEvents, &newBooking, models.SeatHeld)
+
+	return &newBooking, nil
+}
+
+// resolveSegment tìm chặng from → to trên chuyến đi và giá vé của chặng. Để trống cả hai điểm
+// nghĩa là đi trọn tuyến.
+func resolveSegment(trip *models.Trip, from, to string) (models.Segment, float64, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	segment := trip.FullSegment()
+	if from != "" || to != "" {
+		var ok bool
+		if segment, ok = trip.FindSegment(from, to); !ok {
+			return models.Segment{}, 0, ErrSegmentNotSold
+		}
+	}
+	fare, ok := trip.FareFor(segment)
+	if !ok {
+		return models.Segment{}, 0, ErrSegmentNotSold
+	}
+	return segment, fare, nil
+}
 
-	objBookingID, err := primitive.ObjectIDFromHex(bookingIDStr)
+func uniqueSeatNumbers(seatNumbers []string) []string {
+	seen := make(map[string]bool, len(seatNumbers))
+	result := make([]string, 0, len(seatNumbers))
+	for _, s := range seatNumbers {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		result = append(result, s)
+	}
+	return result
+}
+
+// unavailableSeats đọc lại trip để báo cho người dùng biết ghế nào đã bị chiếm trên chặng segment.
+func (s *BookingService) unavailableSeats(ctx context.Context, tripID primitive.ObjectID, seatNumbers []string, segment models.Segment) []string {
+	trip, err := s.trips.FindByID(ctx, tripID)
 	if err != nil {
-		log.Printf("BACKEND ERROR: GetBookingDetailsByID - ID booking không hợp lệ: %s, lỗi: %v", bookingIDStr, err)
-		return nil, errors.New("ID booking không hợp lệ")
+		return seatNumbers
+	}
+	requested := make(map[string]bool, len(seatNumbers))
+	for _, s := range seatNumbers {
+		requested[s] = true
+	}
+	var taken []string
+	for i := range trip.Seats {
+		seat := &trip.Seats[i]
+		if requested[seat.SeatNumber] && seat.StatusFor(segment) != models.SeatAvailable {
+			taken = append(taken, seat.SeatNumber)
+		}
+	}
+	if len(taken) == 0 {
+		return seatNumbers
+	}
+	return taken
+}
+
+// GetBookingDetailsByID trả về booking của người dùng kèm thông tin chuyến đi (tripInfo).
+func (s *BookingService) GetBookingDetailsByID(bookingIDStr string, userIDStr string) (*models.Booking, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	booking, err := findUserBooking(ctx, s.bookings, bookingIDStr, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.trips.FindByID(ctx, booking.TripID)
+	switch {
+	case err == nil:
+		trips := []models.Trip{*trip}
+		if err := attachCompanies(ctx, s.companies, trips); err != nil {
+			log.Printf("Lỗi khi tìm nhà xe của booking %s: %v", bookingIDStr, err)
+			return nil, internalError("lỗi hệ thống khi truy vấn chi tiết booking", err)
+		}
+		trips[0].InLocation(s.location)
+		// Sơ đồ ghế chỉ có trong chi tiết chuyến đi; ghế của booking nằm trong passengers.
+		trips[0].Seats = nil
+		booking.TripInfo = &trips[0]
+	case !errors.Is(err, repositories.ErrNotFound):
+		log.Printf("Lỗi khi tìm chuyến đi %s của booking %s: %v", booking.TripID.Hex(), bookingIDStr, err)
+		return nil, internalError("lỗi hệ thống khi truy vấn chi tiết booking", err)
 	}
+
+	return booking, nil
+}
+
+// GetBookingsByUserID trả về lịch sử booking của người dùng (mới nhất trước) kèm thông tin chuyến đi.
+func (s *BookingService) GetBookingsByUserID(userIDStr string) ([]models.Booking, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
 	objUserID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
-		// Lỗi này không nên xảy ra nếu userID từ JWT là hợp lệ
-		log.Printf("BACKEND ERROR: GetBookingDetailsByID - Lỗi chuyển đổi userID từ JWT: %s, lỗi: %v", userIDStr, err)
-		return nil, errors.New("ID người dùng không hợp lệ")
-	}
-
-	// Sử dụng Aggregation Pipeline để lookup thông tin Trip
-	pipeline := mongo.Pipeline{
-		{{"$match", bson.D{{"_id", objBookingID}, {"userId", objUserID}}}}, // Khớp bookingId và userId
-		{{"$lookup", bson.D{
-			{"from", "trips"},         // Tên collection trips (đảm bảo đúng tên)
-			{"localField", "tripId"},  // Trường trong collection bookings (đảm bảo đúng tên và kiểu)
-			{"foreignField", "_id"},   // Trường trong collection trips (đảm bảo đúng tên và kiểu)
-			{"as", "tripInfoArr"},     // Tên mảng kết quả của lookup
-		}}},
-		// Bước $unwind: chuyển mảng tripInfoArr (thường chỉ có 1 phần tử nếu tìm thấy) thành object
-		// preserveNullAndEmptyArrays: true sẽ giữ lại document booking ngay cả khi không có trip nào khớp (tripInfoArr sẽ là mảng rỗng)
-		{{"$unwind", bson.D{
-			{"path", "$tripInfoArr"},
-			{"preserveNullAndEmptyArrays", true},
-		}}},
-		// Bước $addFields: Tạo trường mới "tripInfo" từ trường "tripInfoArr" (sau khi unwind, tripInfoArr là object hoặc null)
-		// Nếu tripInfoArr là null (do preserveNullAndEmptyArrays và không tìm thấy trip), tripInfo cũng sẽ là null
-		{{"$addFields", bson.D{
-			{"tripInfo", "$tripInfoArr"},
-		}}},
-		// Bước $project: Loại bỏ trường "tripInfoArr" không cần thiết nữa
-		{{"$project", bson.D{
-			{"tripInfoArr", 0},
-		}}},
-	}
-
-	log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Pipeline được xây dựng: %+v", pipeline)
-
-	cursor, err := bookingCollection.Aggregate(ctx, pipeline)
+		log.Printf("Lỗi chuyển đổi userID từ JWT (GetBookingsByUserID): %v", err)
+		return nil, ErrInvalidUserID
+	}
+
+	bookings, err := s.bookings.FindByUser(ctx, objUserID)
 	if err != nil {
-		log.Printf("BACKEND ERROR: GetBookingDetailsByID - Lỗi thực thi aggregation: %v", err)
-		return nil, errors.New("lỗi hệ thống khi truy vấn chi tiết booking")
-	}
-	defer cursor.Close(ctx)
-
-	var results []models.Booking // Kết quả aggregate luôn là một mảng
-	if err = cursor.All(ctx, &results); err != nil {
-		log.Printf("BACKEND ERROR: GetBookingDetailsByID - Lỗi đọc tất cả kết quả từ cursor aggregation: %v", err)
-		return nil, errors.New("lỗi hệ thống khi đọc dữ liệu booking")
-	}
-
-	log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Số lượng results từ aggregation: %d", len(results))
-
-	if len(results) == 0 {
-		log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Không tìm thấy booking nào sau aggregation (có thể do bookingId sai, userId không khớp, hoặc tripId không tìm thấy và preserveNullAndEmptyArrays là false ở bước nào đó - nhưng hiện tại là true).")
-		// Kiểm tra xem booking có tồn tại nhưng không thuộc user này không (nếu $match không lọc được)
-		// Tuy nhiên, $match đã nên xử lý việc này.
-		// var tempBooking models.Booking
-		// errCheck := bookingCollection.FindOne(ctx, bson.M{"_id": objBookingID}).Decode(&tempBooking)
-		// if errCheck == nil { // Booking tồn tại
-		// 	log.Printf("BACKEND WARNING: GetBookingDetailsByID - Booking %s tồn tại nhưng không thuộc user %s hoặc không qua được pipeline.", bookingIDStr, userIDStr)
-		// 	return nil, errors.New("bạn không có quyền xem booking này hoặc booking không hợp lệ")
-		// }
-		return nil, errors.New("không tìm thấy booking hoặc bạn không có quyền xem")
-	}
-
-	// Log chi tiết từng document trong results (nếu có nhiều hơn 1, dù $match theo _id thường chỉ ra 1)
-	// for i, res := range results {
-	//     log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Result[%d]: %+v", i, res)
-	//     if res.TripInfo != nil {
-	//          log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Result[%d].TripInfo: %+v", i, *res.TripInfo)
-	//     } else {
-	//          log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Result[%d].TripInfo LÀ NIL", i)
-	//     }
-	// }
-
-	log.Printf("BACKEND DEBUG: GetBookingDetailsByID - Booking sẽ trả về cho client: %+v", results[0])
-	if results[0].TripInfo != nil {
-		log.Printf("BACKEND DEBUG: GetBookingDetailsByID - TripInfo trong booking sẽ trả về: %+v", *results[0].TripInfo)
-        log.Printf("BACKEND DEBUG: GetBookingDetailsByID - TripInfo.CompanyName: %s", results[0].TripInfo)
-        log.Printf("BACKEND DEBUG: GetBookingDetailsByID - TripInfo.Route.From.Name: %s", results[0].TripInfo.Route.From.Name)
-	} else {
-		log.Printf("BACKEND DEBUG: GetBookingDetailsByID - TripInfo trong booking sẽ trả về LÀ NIL")
-	}
-
-	return &results[0], nil
+		log.Printf("Lỗi khi lấy danh sách booking: %v", err)
+		return nil, internalError("lỗi hệ thống khi truy vấn danh sách booking", err)
+	}
+
+	tripIDs := make([]primitive.ObjectID, 0, len(bookings))
+	for _, booking := range bookings {
+		tripIDs = append(tripIDs, booking.TripID)
+	}
+	trips, err := s.trips.FindByIDs(ctx, tripIDs)
+	if err != nil {
+		log.Printf("Lỗi khi lấy chuyến đi của danh sách booking: %v", err)
+		return nil, internalError("lỗi hệ thống khi đọc dữ liệu booking", err)
+	}
+	if err := attachCompanies(ctx, s.companies, trips); err != nil {
+		log.Printf("Lỗi khi lấy nhà xe của danh sách booking: %v", err)
+		return nil, internalError("lỗi hệ thống khi đọc dữ liệu booking", err)
+	}
+	tripsByID := make(map[primitive.ObjectID]*models.Trip, len(trips))
+	for i := range trips {
+		trips[i].InLocation(s.location)
+		trips[i].Seats = nil
+		tripsByID[trips[i].ID] = &trips[i]
+	}
+	for i := range bookings {
+		bookings[i].TripInfo = tripsByID[bookings[i].TripID]
+	}
+
+	return bookings, nil
 }
-  func GetBookingsByUserID(userIDStr string) ([]models.Booking, error) {
-  	bookingCollection := config.DB.Collection("bookings")
-  	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-  	defer cancel()
-
-  	objUserID, err := primitive.ObjectIDFromHex(userIDStr)
-  	if err != nil {
-  		log.Printf("Lỗi chuyển đổi userID từ JWT (GetBookingsByUserID): %v", err)
-  		return nil, errors.New("ID người dùng không hợp lệ")
-  	}
-
-  	pipeline := mongo.Pipeline{
-  		{{"$match", bson.D{{"userId", objUserID}}}},
-        {{"$sort", bson.D{{"bookingTime", -1}}}}, 
-  		{{"$lookup", bson.D{
-  			{"from", "trips"},
-  			{"localField", "tripId"},
-  			{"foreignField", "_id"},
-  			{"as", "tripInfoArr"},
-  		}}},
-  		{{"$unwind", bson.D{
-  			{"path", "$tripInfoArr"},
-  			{"preserveNullAndEmptyArrays", true},
-  		}}},
-        {{"$addFields", bson.D{
-            {"tripInfo", "$tripInfoArr"},
-        }}},
-        {{"$project", bson.D{
-            {"tripInfoArr", 0},
-        }}},
-  	}
-
-  	cursor, err := bookingCollection.Aggregate(ctx, pipeline, options.Aggregate()) 
-  	if err != nil {
-  		log.Printf("Lỗi aggregation khi lấy danh sách booking: %v", err)
-  		return nil, errors.New("lỗi hệ thống khi truy vấn danh sách booking")
-  	}
-  	defer cursor.Close(ctx)
-
-  	var bookings []models.Booking
-  	if err = cursor.All(ctx, &bookings); err != nil {
-  		log.Printf("Lỗi đọc cursor aggregation (danh sách booking): %v", err)
-  		return nil, errors.New("lỗi hệ thống khi đọc dữ liệu booking")
-  	}
-
-  	return bookings, nil
-  }
\ No newline at end of file
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateBookingConcurrentSameSeat(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1", "A2")
+
+	const attempts = 20
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		winners   int
+		conflicts int
+		others    []error
+	)
+	start := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		user := env.addUser(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				winners++
+			case errors.Is(err, apperrors.ErrSeatConflict):
+				conflicts++
+			default:
+				others = append(others, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if winners != 1 || conflicts != attempts-1 || len(others) != 0 {
+		t.Fatalf("thắng = %d, xung đột = %d, lỗi khác = %v; muốn đúng 1 booking thắng", winners, conflicts, others)
+	}
+	statuses := env.seatStatuses(t, trip.ID)
+	if statuses["A1"] != models.SeatHeld || statuses["A2"] != models.SeatAvailable {
+		t.Fatalf("trạng thái ghế = %v", statuses)
+	}
+}
+
+func TestCreateBookingReportsTakenSeats(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1", "A2", "A3")
+	first, second := env.addUser(t), env.addUser(t)
+
+	if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A2"}}, first.ID.Hex()); err != nil {
+		t.Fatalf("booking đầu tiên: %v", err)
+	}
+
+	_, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1", "A2"}}, second.ID.Hex())
+	var conflict *SeatConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("lỗi = %v, muốn SeatConflictError", err)
+	}
+	if len(conflict.SeatNumbers) != 1 || conflict.SeatNumbers[0] != "A2" {
+		t.Fatalf("ghế bị chiếm = %v, muốn [A2]", conflict.SeatNumbers)
+	}
+	// Giữ chỗ là tất cả hoặc không: ghế A1 không bị giữ dở dang.
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatAvailable {
+		t.Fatalf("ghế A1 = %s, muốn available", status)
+	}
+}
+
+func TestCreateBookingUnknownSeat(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+	user := env.addUser(t)
+
+	_, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"Z9"}}, user.ID.Hex())
+	if !errors.Is(err, apperrors.ErrInvalidInput) {
+		t.Fatalf("lỗi = %v, muốn ErrInvalidInput", err)
+	}
+}
+
+func TestExpireHeldBookingsReleasesSeats(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1", "A2")
+	user := env.addUser(t)
+
+	booking, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+	if err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+
+	n, err := env.bookings.ExpireHeldBookings(t.Context(), time.Now())
+	if err != nil || n != 0 {
+		t.Fatalf("quét trước hạn: n = %d, err = %v; muốn chưa hủy booking nào", n, err)
+	}
+
+	n, err = env.bookings.ExpireHeldBookings(t.Context(), time.Now().Add(testHoldDuration+time.Minute))
+	if err != nil || n != 1 {
+		t.Fatalf("quét sau hạn: n = %d, err = %v; muốn hủy 1 booking", n, err)
+	}
+	stored, err := env.repos.Bookings.FindByID(t.Context(), booking.ID)
+	if err != nil {
+		t.Fatalf("đọc booking: %v", err)
+	}
+	if stored.Status != models.BookingExpired {
+		t.Fatalf("trạng thái booking = %s, muốn expired", stored.Status)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatAvailable {
+		t.Fatalf("ghế A1 = %s, muốn available", status)
+	}
+}
+
+func TestReconcileHeldSeatsReleasesExpiredBooking(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+	user := env.addUser(t)
+
+	if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+
+	// Booking chuyển sang "expired" nhưng lệnh trả ghế thất bại.
+	failing := *env.bookings
+	failing.trips = failingSeatLegs{env.repos.Trips}
+	expiredAt := time.Now().Add(testHoldDuration + time.Minute)
+	if n, err := failing.ExpireHeldBookings(t.Context(), expiredAt); err != nil || n != 0 {
+		t.Fatalf("ExpireHeldBookings: n = %d, err = %v", n, err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatHeld {
+		t.Fatalf("ghế A1 = %s, muốn vẫn held sau lỗi ghi", status)
+	}
+
+	n, err := env.bookings.ReconcileHeldSeats(t.Context(), expiredAt)
+	if err != nil || n != 1 {
+		t.Fatalf("ReconcileHeldSeats: n = %d, err = %v; muốn đồng bộ 1 booking", n, err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatAvailable {
+		t.Fatalf("ghế A1 = %s, muốn available", status)
+	}
+}
+
+func TestReconcileHeldSeatsReleasesOrphanedHold(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1", "A2")
+	now := time.Now()
+
+	// Chặng ghế không có booking: một cái đã quá thời gian giữ chỗ, một cái vừa được giữ.
+	stale := primitive.NewObjectIDFromTimestamp(now.Add(-2 * testHoldDuration))
+	fresh := primitive.NewObjectID()
+	for seat, bookingID := range map[string]primitive.ObjectID{"A1": stale, "A2": fresh} {
+		leg := models.SeatLeg{Segment: trip.FullSegment(), Status: models.SeatHeld, BookingID: bookingID}
+		if err := env.repos.Trips.HoldSeats(t.Context(), trip.ID, []string{seat}, leg); err != nil {
+			t.Fatalf("HoldSeats %s: %v", seat, err)
+		}
+	}
+
+	if n, err := env.bookings.ReconcileHeldSeats(t.Context(), now); err != nil || n != 1 {
+		t.Fatalf("ReconcileHeldSeats: n = %d, err = %v; muốn trả 1 chặng", n, err)
+	}
+	statuses := env.seatStatuses(t, trip.ID)
+	if statuses["A1"] != models.SeatAvailable || statuses["A2"] != models.SeatHeld {
+		t.Fatalf("trạng thái ghế = %v, muốn A1 available, A2 held", statuses)
+	}
+}
+
+func TestReconcileLegacySeats(t *testing.T) {
+	tests := []struct {
+		name    string
+		seat    models.SeatStatus
+		booking models.BookingStatus // rỗng là ghế không có booking nào
+		want    models.SeatStatus
+	}{
+		{name: "ghế giữ của booking đang giữ chỗ", seat: models.SeatHeld, booking: models.BookingHeld, want: models.SeatHeld},
+		{name: "ghế giữ của booking đã thanh toán", seat: models.SeatHeld, booking: models.BookingConfirmed, want: models.SeatBooked},
+		{name: "ghế giữ của booking hết hạn", seat: models.SeatHeld, booking: models.BookingExpired, want: models.SeatAvailable},
+		{name: "ghế giữ không có booking", seat: models.SeatHeld, want: models.SeatAvailable},
+		{name: "ghế đã bán của booking đã xác nhận", seat: models.SeatBooked, booking: models.BookingConfirmed, want: models.SeatBooked},
+		{name: "ghế đã bán của booking đã hủy", seat: models.SeatBooked, booking: models.BookingCancelled, want: models.SeatAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+			// Dữ liệu tạo trước khi ghế được bán theo chặng: trạng thái nằm trên cả ghế và booking
+			// không có Segment.
+			trip.Seats[0].Status = tt.seat
+			env.putTrip(trip)
+			if tt.booking != "" {
+				booking := models.Booking{
+					ID:          primitive.NewObjectID(),
+					UserID:      env.addUser(t).ID,
+					TripID:      trip.ID,
+					BookingTime: time.Now().Add(-time.Hour),
+					Status:      tt.booking,
+					Passengers:  []models.Passenger{{SeatNumber: "A1"}},
+				}
+				if err := env.repos.Bookings.Create(t.Context(), &booking); err != nil {
+					t.Fatalf("tạo booking: %v", err)
+				}
+			}
+
+			if _, err := env.bookings.ReconcileHeldSeats(t.Context(), time.Now()); err != nil {
+				t.Fatalf("ReconcileHeldSeats: %v", err)
+			}
+			if _, err := env.bookings.ReconcileBookedSeats(t.Context(), time.Now()); err != nil {
+				t.Fatalf("ReconcileBookedSeats: %v", err)
+			}
+			if got := env.seatStatuses(t, trip.ID)["A1"]; got != tt.want {
+				t.Fatalf("ghế A1 = %s, muốn %s", got, tt.want)
+			}
+		})
+	}
+}
+
+var errBookingWrite = errors.New("lỗi ghi booking giả lập")
+
+// failingBookingCreate giả lập lỗi ghi booking sau khi ghế đã được giữ.
+type failingBookingCreate struct {
+	repositories.BookingRepository
+}
+
+func (failingBookingCreate) Create(context.Context, *models.Booking) error {
+	return errBookingWrite
+}
+
+func TestCreateBookingRecoversFromBookingWriteFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		// releaseFails giả lập cả lệnh trả ghế ngay sau lỗi ghi booking cũng thất bại.
+		releaseFails bool
+	}{
+		{name: "trả ghế ngay"},
+		{name: "trả ghế khi đối chiếu", releaseFails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+			user := env.addUser(t)
+
+			failing := *env.bookings
+			failing.bookings = failingBookingCreate{env.repos.Bookings}
+			if tt.releaseFails {
+				failing.trips = failingSeatLegs{env.repos.Trips}
+			}
+			_, err := failing.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+			if !errors.Is(err, apperrors.ErrInternal) {
+				t.Fatalf("CreateBooking: err = %v, muốn lỗi máy chủ", err)
+			}
+
+			if !tt.releaseFails {
+				if got := env.seatStatuses(t, trip.ID)["A1"]; got != models.SeatAvailable {
+					t.Fatalf("ghế A1 = %s, muốn được trả ngay", got)
+				}
+				return
+			}
+
+			// Chặng ghế mồ côi vẫn được giữ trong thời gian giữ chỗ vì booking có thể chỉ chưa kịp ghi.
+			now := time.Now()
+			if n, err := env.bookings.ReconcileHeldSeats(t.Context(), now); err != nil || n != 0 {
+				t.Fatalf("ReconcileHeldSeats trong thời gian giữ chỗ: n = %d, err = %v; muốn chưa trả", n, err)
+			}
+			if got := env.seatStatuses(t, trip.ID)["A1"]; got != models.SeatHeld {
+				t.Fatalf("ghế A1 = %s, muốn vẫn held", got)
+			}
+			if n, err := env.bookings.ReconcileHeldSeats(t.Context(), now.Add(testHoldDuration+time.Minute)); err != nil || n != 1 {
+				t.Fatalf("ReconcileHeldSeats sau thời gian giữ chỗ: n = %d, err = %v; muốn trả 1 chặng", n, err)
+			}
+			if got := env.seatStatuses(t, trip.ID)["A1"]; got != models.SeatAvailable {
+				t.Fatalf("ghế A1 = %s, muốn available", got)
+			}
+
+			// Ghế đã trả được đặt lại bình thường.
+			if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+				t.Fatalf("CreateBooking sau khi đối chiếu: %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateBookingSegmentsShareSeat(t *testing.T) {
+	stops := []models.Stop{
+		{Name: "Hà Nội"},
+		{Name: "Phủ Lý", OffsetMinutes: 60},
+		{Name: "Ninh Bình", OffsetMinutes: 120},
+		{Name: "Thanh Hóa", OffsetMinutes: 210},
+	}
+	// Giá mỗi cặp điểm dừng: 50.000đ cho mỗi chặng đi qua.
+	var fares []models.Fare
+	for i := range stops {
+		for j := i + 1; j < len(stops); j++ {
+			fares = append(fares, models.Fare{From: stops[i].Name, To: stops[j].Name, Price: float64(50000 * (j - i))})
+		}
+	}
+
+	type leg struct{ from, to string }
+	tests := []struct {
+		name     string
+		held     leg
+		next     leg
+		conflict bool
+	}{
+		{name: "nối tiếp phía sau", held: leg{"Hà Nội", "Phủ Lý"}, next: leg{"Phủ Lý", "Thanh Hóa"}},
+		{name: "nối tiếp phía trước", held: leg{"Ninh Bình", "Thanh Hóa"}, next: leg{"Hà Nội", "Ninh Bình"}},
+		{name: "tách rời", held: leg{"Hà Nội", "Phủ Lý"}, next: leg{"Ninh Bình", "Thanh Hóa"}},
+		{name: "trùng một phần", held: leg{"Hà Nội", "Ninh Bình"}, next: leg{"Phủ Lý", "Thanh Hóa"}, conflict: true},
+		{name: "trùng khớp", held: leg{"Phủ Lý", "Ninh Bình"}, next: leg{"Phủ Lý", "Ninh Bình"}, conflict: true},
+		{name: "nằm trong chặng đã giữ", held: leg{"Hà Nội", "Thanh Hóa"}, next: leg{"Phủ Lý", "Ninh Bình"}, conflict: true},
+		{name: "bao trùm chặng đã giữ", held: leg{"Phủ Lý", "Ninh Bình"}, next: leg{"Hà Nội", "Thanh Hóa"}, conflict: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			trip := env.addTrip(t, "Hà Nội", "Thanh Hóa", time.Now().Add(48*time.Hour), "A1")
+			trip.Route.Stops = stops
+			trip.Fares = fares
+			env.putTrip(trip)
+
+			book := func(l leg) (*models.Booking, error) {
+				user := env.addUser(t)
+				return env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}, From: l.from, To: l.to}, user.ID.Hex())
+			}
+			if _, err := book(tt.held); err != nil {
+				t.Fatalf("giữ chặng %s → %s: %v", tt.held.from, tt.held.to, err)
+			}
+
+			booking, err := book(tt.next)
+			if tt.conflict {
+				var conflict *SeatConflictError
+				if !errors.As(err, &conflict) || !errors.Is(err, apperrors.ErrSeatConflict) || len(conflict.SeatNumbers) != 1 || conflict.SeatNumbers[0] != "A1" {
+					t.Fatalf("chặng %s → %s: lỗi = %v, muốn ErrSeatConflict cho ghế A1", tt.next.from, tt.next.to, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("chặng %s → %s: %v", tt.next.from, tt.next.to, err)
+			}
+			segment, _ := trip.FindSegment(tt.next.from, tt.next.to)
+			if want, _ := trip.FareFor(segment); booking.TotalAmount != want {
+				t.Fatalf("tổng tiền = %v, muốn giá chặng %v", booking.TotalAmount, want)
+			}
+		})
+	}
+}
+
+func TestSeatEventsFollowBookingLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1", "A2")
+	user := env.addUser(t)
+
+	events, unsubscribe, err := env.trips.SubscribeSeatEvents(trip.ID.Hex())
+	if err != nil {
+		t.Fatalf("SubscribeSeatEvents: %v", err)
+	}
+	defer unsubscribe()
+
+	if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+	if _, err := env.bookings.ExpireHeldBookings(t.Context(), time.Now().Add(testHoldDuration+time.Minute)); err != nil {
+		t.Fatalf("ExpireHeldBookings: %v", err)
+	}
+
+	for _, want := range []models.SeatStatus{models.SeatHeld, models.SeatAvailable} {
+		select {
+		case event := <-events:
+			if event.TripID != trip.ID || event.Status != want || len(event.SeatNumbers) != 1 || event.SeatNumbers[0] != "A1" {
+				t.Fatalf("sự kiện = %+v, muốn ghế A1 chuyển sang %s", event, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("không nhận được sự kiện %s", want)
+		}
+	}
+
+	if _, _, err := env.trips.SubscribeSeatEvents(primitive.NewObjectID().Hex()); !errors.Is(err, ErrTripNotFound) {
+		t.Fatalf("chuyến không tồn tại: lỗi = %v, muốn ErrTripNotFound", err)
+	}
+}
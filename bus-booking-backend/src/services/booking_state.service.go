@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/realtime"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrBookingStateChanged được trả về khi trạng thái booking đã bị một thao tác khác
+// thay đổi giữa lúc đọc và lúc cập nhật.
+var ErrBookingStateChanged = apperrors.New(apperrors.ErrConflict, "trạng thái booking vừa bị thay đổi bởi một thao tác khác").WithCode("booking_state_changed")
+
+// bookingTransition mô tả một lần chuyển trạng thái booking cùng các trường cập nhật kèm theo.
+type bookingTransition struct {
+	To     models.BookingStatus
+	Actor  string
+	Reason string
+	Fields repositories.BookingFields
+}
+
+// transitionBooking kiểm tra tính hợp lệ theo máy trạng thái rồi cập nhật booking có điều kiện
+// theo trạng thái hiện tại, đồng thời ghi thêm một mục vào statusHistory trong cùng một lệnh.
+// Khi thành công, booking trong bộ nhớ cũng được cập nhật tương ứng.
+func transitionBooking(ctx context.Context, bookings repositories.BookingRepository, booking *models.Booking, t bookingTransition) error {
+	if err := models.ValidateBookingTransition(booking.Status, t.To); err != nil {
+		return err
+	}
+
+	change := models.StatusChange{
+		From:   booking.Status,
+		To:     t.To,
+		At:     time.Now(),
+		Actor:  t.Actor,
+		Reason: t.Reason,
+	}
+	err := bookings.UpdateStatus(ctx, booking.ID, booking.Status, change, t.Fields)
+	if err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return ErrBookingStateChanged
+		}
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return err
+		}
+		log.Printf("Lỗi khi chuyển booking %s từ %s sang %s: %v", booking.ID.Hex(), booking.Status, t.To, err)
+		return internalError("lỗi hệ thống khi cập nhật trạng thái booking", err)
+	}
+
+	booking.Status = t.To
+	booking.UpdatedAt = change.At
+	booking.StatusHistory = append(booking.StatusHistory, change)
+	if t.Fields.PaymentStatus != nil {
+		booking.PaymentStatus = *t.Fields.PaymentStatus
+	}
+	if t.Fields.PaymentID != nil {
+		booking.PaymentID = t.Fields.PaymentID
+	}
+	if t.Fields.TicketCode != nil {
+		booking.TicketCode = *t.Fields.TicketCode
+	}
+	if t.Fields.Cancellation != nil {
+		booking.Cancellation = t.Fields.Cancellation
+	}
+	if t.Fields.ClearHoldExpiry {
+		booking.HoldExpiresAt = nil
+	}
+	return nil
+}
+
+// updateSeatStatus chuyển các ghế của booking đang ở trạng thái from sang to. Ghế ở trạng thái
+// khác (ví dụ đã được booking khác giữ hoặc đặt) không bị ảnh hưởng. Booking theo chặng chỉ
+// cập nhật các chặng ghế của chính nó; booking cũ (không có Segment) cập nhật trạng thái cả ghế.
+func updateSeatStatus(ctx context.Context, trips repositories.TripRepository, booking *models.Booking, from, to models.SeatStatus) error {
+	if err := models.ValidateSeatTransition(from, to); err != nil {
+		return err
+	}
+	if booking.Segment != nil {
+		return trips.UpdateSeatLegs(ctx, booking.TripID, booking.ID, from, to)
+	}
+	return trips.UpdateSeatStatus(ctx, booking.TripID, bookingSeatNumbers(booking), from, to)
+}
+
+// publishSeatEvent báo cho người đang xem sơ đồ ghế rằng các ghế của booking vừa chuyển sang to.
+func publishSeatEvent(bus realtime.SeatEventBus, booking *models.Booking, to models.SeatStatus) {
+	bus.Publish(realtime.SeatEvent{
+		TripID:      booking.TripID,
+		SeatNumbers: bookingSeatNumbers(booking),
+		Status:      to,
+		Segment:     booking.Segment,
+		At:          time.Now(),
+	})
+}
+
+// bookingSegment trả về chặng của booking trên trip; booking cũ không có chặng là trọn tuyến.
+func bookingSegment(trip *models.Trip, booking *models.Booking) models.Segment {
+	if booking.Segment != nil {
+		return *booking.Segment
+	}
+	return trip.FullSegment()
+}
+
+func bookingSeatNumbers(booking *models.Booking) []string {
+	seatNumbers := make([]string, 0, len(booking.Passengers))
+	for _, p := range booking.Passengers {
+		seatNumbers = append(seatNumbers, p.SeatNumber)
+	}
+	return seatNumbers
+}
+
+// findUserBooking tìm booking theo ID và kiểm tra booking thuộc về người dùng userIDStr.
+func findUserBooking(ctx context.Context, bookings repositories.BookingRepository, bookingIDStr, userIDStr string) (*models.Booking, error) {
+	bookingID, err := primitive.ObjectIDFromHex(bookingIDStr)
+	if err != nil {
+		return nil, ErrInvalidBookingID
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	booking, err := bookings.FindByID(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrBookingNotFound
+		}
+		log.Printf("Lỗi khi tìm booking %s: %v", bookingIDStr, err)
+		return nil, internalError("lỗi hệ thống khi truy vấn booking", err)
+	}
+	if booking.UserID != userID {
+		return nil, ErrBookingNotFound
+	}
+	return booking, nil
+}
+
+// findBookingTrip đọc chuyến đi của booking.
+func findBookingTrip(ctx context.Context, trips repositories.TripRepository, booking *models.Booking) (*models.Trip, error) {
+	trip, err := trips.FindByID(ctx, booking.TripID)
+	if err != nil {
+		log.Printf("Lỗi khi tìm chuyến đi %s của booking %s: %v", booking.TripID.Hex(), booking.ID.Hex(), err)
+		return nil, internalError("lỗi hệ thống khi truy vấn chuyến đi", err)
+	}
+	return trip, nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CancelBookingInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// CancelBookingResult mô tả kết quả hủy vé, gồm cả phần hoàn tiền (nếu có).
+type CancelBookingResult struct {
+	Booking *models.Booking `json:"booking"`
+	Refund  *models.Refund  `json:"refund,omitempty"`
+}
+
+var ErrTripAlreadyDeparted = apperrors.New(apperrors.ErrConflict, "chuyến đi đã khởi hành, không thể hủy vé").WithCode("trip_departed")
+
+// maxRefundAttempts là số lần gọi cổng thanh toán tối đa cho một lần hoàn tiền; sau đó lần hoàn
+// tiền giữ trạng thái "failed" để xử lý thủ công.
+const maxRefundAttempts = 10
+
+// CancelBooking hủy một booking "held" hoặc "confirmed" của người dùng, trả ghế lại cho
+// chuyến đi và hoàn tiền theo chính sách của nhà xe nếu booking đã được thanh toán. Hạn hủy
+// và mức hoàn tiền tính theo giờ xe tới điểm lên của chặng đã đặt.
+func (s *BookingService) CancelBooking(bookingIDStr, userIDStr string, input CancelBookingInput) (*CancelBookingResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	booking, err := findUserBooking(ctx, s.bookings, bookingIDStr, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.ValidateBookingTransition(booking.Status, models.BookingCancelled); err != nil {
+		return nil, err
+	}
+
+	trip, err := findBookingTrip(ctx, s.trips, booking)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	boarding, _ := trip.SegmentTimes(bookingSegment(trip, booking))
+	if !boarding.After(now) {
+		return nil, ErrTripAlreadyDeparted
+	}
+
+	refundPercent, refundAmount := 0.0, 0.0
+	if booking.Status == models.BookingConfirmed {
+		policy := s.companyRefundPolicy(ctx, trip.CompanyID)
+		refundPercent, refundAmount = ComputeRefund(policy, booking.TotalAmount, boarding, now)
+	}
+
+	cancellation := models.Cancellation{
+		CancelledAt:   now,
+		Reason:        input.Reason,
+		RefundPercent: refundPercent,
+		RefundAmount:  refundAmount,
+	}
+	previousStatus := booking.Status
+	err = transitionBooking(ctx, s.bookings, booking, bookingTransition{
+		To:     models.BookingCancelled,
+		Actor:  models.UserActor(userIDStr),
+		Reason: input.Reason,
+		Fields: repositories.BookingFields{Cancellation: &cancellation, ClearHoldExpiry: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seatStatus := models.SeatHeld
+	if previousStatus == models.BookingConfirmed {
+		seatStatus = models.SeatBooked
+	}
+	// Booking đã bị hủy; nếu không trả được ghế, ReconcileHeldSeats/ReconcileBookedSeats sẽ trả
+	// lại ở lần quét sau.
+	if err := updateSeatStatus(ctx, s.trips, booking, seatStatus, models.SeatAvailable); err != nil {
+		log.Printf("Lỗi khi trả ghế của booking %s: %v", bookingIDStr, err)
+	} else {
+		publishSeatEvent(s.seatEvents, booking, models.SeatAvailable)
+	}
+
+	var refund *models.Refund
+	if previousStatus == models.BookingConfirmed && booking.PaymentID != nil {
+		refund = s.refundPayment(ctx, *booking.PaymentID, refundAmount, refundPercent, "hủy vé")
+		if refund != nil && refund.Status == models.RefundSucceeded && refund.Amount > 0 {
+			s.markBookingRefunded(ctx, booking, *refund)
+		}
+	}
+
+	return &CancelBookingResult{Booking: booking, Refund: refund}, nil
+}
+
+// markBookingRefunded chuyển booking đã hủy sang "refunded" sau khi hoàn tiền thành công.
+func (s *BookingService) markBookingRefunded(ctx context.Context, booking *models.Booking, refund models.Refund) {
+	err := transitionBooking(ctx, s.bookings, booking, bookingTransition{
+		To:     models.BookingRefunded,
+		Actor:  models.ActorGateway,
+		Reason: fmt.Sprintf("hoàn %.0f%% (%.0f %s)", refund.Percent, refund.Amount, paymentCurrency),
+	})
+	if err != nil {
+		log.Printf("Lỗi khi chuyển booking %s sang refunded: %v", booking.ID.Hex(), err)
+	}
+}
+
+func (s *BookingService) companyRefundPolicy(ctx context.Context, companyID primitive.ObjectID) models.RefundPolicy {
+	company, err := s.companies.FindByID(ctx, companyID)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrNotFound) {
+			log.Printf("Lỗi khi tìm chính sách hoàn tiền của nhà xe %s: %v", companyID.Hex(), err)
+		}
+		return DefaultRefundPolicy
+	}
+	if company.RefundPolicy == nil {
+		return DefaultRefundPolicy
+	}
+	return *company.RefundPolicy
+}
+
+// refundPayment hoàn tiền qua cổng thanh toán và ghi nhận kết quả vào giao dịch.
+// Lỗi từ cổng thanh toán không làm hủy vé thất bại; bản ghi hoàn tiền có trạng thái "failed"
+// và được RetryFailedRefunds thử lại.
+func (s *BookingService) refundPayment(ctx context.Context, paymentID primitive.ObjectID, amount, percent float64, reason string) *models.Refund {
+	payment, err := s.payments.FindByID(ctx, paymentID)
+	if err != nil {
+		log.Printf("Lỗi khi tìm giao dịch %s để hoàn tiền: %v", paymentID.Hex(), err)
+		return nil
+	}
+
+	refund := models.Refund{
+		Amount:    amount,
+		Percent:   percent,
+		Reason:    reason,
+		Status:    models.RefundSucceeded,
+		CreatedAt: time.Now(),
+	}
+	if amount > 0 {
+		refund.Attempts = 1
+		s.callRefund(ctx, payment, &refund)
+	}
+
+	refundedDelta, status := refundOutcome(payment, refund)
+	if err := s.payments.AddRefund(ctx, payment.ID, refund, refundedDelta, status); err != nil {
+		log.Printf("Lỗi khi ghi nhận hoàn tiền cho giao dịch %s: %v", payment.ID.Hex(), err)
+	}
+	return &refund
+}
+
+// callRefund gọi cổng thanh toán hoàn refund.Amount và ghi kết quả vào refund.
+func (s *BookingService) callRefund(ctx context.Context, payment *models.Payment, refund *models.Refund) {
+	refund.UpdatedAt = time.Now()
+	result, err := s.gateway.Refund(ctx, payments.RefundRequest{
+		IntentID: payment.IntentID,
+		Amount:   refund.Amount,
+		Reason:   refund.Reason,
+	})
+	if err != nil {
+		log.Printf("Lỗi khi hoàn tiền giao dịch %s (lần %d): %v", payment.IntentID, refund.Attempts, err)
+		refund.Status = models.RefundFailed
+		return
+	}
+	refund.Status = models.RefundSucceeded
+	refund.ID = result.RefundID
+}
+
+// refundOutcome trả về số tiền cộng thêm vào refundedAmount và trạng thái mới của giao dịch
+// sau lần hoàn tiền refund; trạng thái rỗng nghĩa là giữ nguyên.
+func refundOutcome(payment *models.Payment, refund models.Refund) (float64, string) {
+	if refund.Status != models.RefundSucceeded || refund.Amount <= 0 {
+		return 0, ""
+	}
+	if payment.RefundedAmount+refund.Amount >= payment.Amount {
+		return refund.Amount, "refunded"
+	}
+	return refund.Amount, "partially_refunded"
+}
+
+// RetryFailedRefunds gọi lại cổng thanh toán cho các lần hoàn tiền "failed" chưa quá
+// maxRefundAttempts lần và chuyển booking sang "refunded" khi hoàn tiền thành công.
+// Trả về số lần hoàn tiền đã thành công trong lần quét này.
+func (s *BookingService) RetryFailedRefunds(ctx context.Context) (int, error) {
+	failed, err := s.payments.FindFailedRefunds(ctx, maxRefundAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range failed {
+		payment := &failed[i]
+		for index, refund := range payment.Refunds {
+			if refund.Status != models.RefundFailed || refund.Attempts >= maxRefundAttempts {
+				continue
+			}
+			// Nhận lần hoàn tiền bằng cách tăng attempts trước khi gọi cổng thanh toán để hai
+			// worker không cùng hoàn một khoản; nếu tiến trình dừng giữa chừng, lần quét sau thử lại.
+			claimed := refund
+			claimed.Attempts++
+			claimed.UpdatedAt = time.Now()
+			if err := s.payments.ResolveRefund(ctx, payment.ID, index, refund.Attempts, claimed, 0, ""); err != nil {
+				if !errors.Is(err, repositories.ErrConflict) {
+					log.Printf("Lỗi khi nhận lần hoàn tiền của giao dịch %s: %v", payment.ID.Hex(), err)
+				}
+				continue
+			}
+			refund = claimed
+			s.callRefund(ctx, payment, &refund)
+			refundedDelta, status := refundOutcome(payment, refund)
+			if err := s.payments.ResolveRefund(ctx, payment.ID, index, claimed.Attempts, refund, refundedDelta, status); err != nil {
+				log.Printf("Lỗi khi ghi nhận hoàn tiền cho giao dịch %s: %v", payment.ID.Hex(), err)
+				continue
+			}
+			if refund.Status != models.RefundSucceeded {
+				continue
+			}
+			succeeded++
+			payment.RefundedAmount += refundedDelta
+
+			booking, err := s.bookings.FindByID(ctx, payment.BookingID)
+			if err != nil {
+				log.Printf("Lỗi khi tìm booking %s của giao dịch %s: %v", payment.BookingID.Hex(), payment.ID.Hex(), err)
+				continue
+			}
+			if booking.Status == models.BookingCancelled {
+				s.markBookingRefunded(ctx, booking, refund)
+			}
+		}
+	}
+	return succeeded, nil
+}
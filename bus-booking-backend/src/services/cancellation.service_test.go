@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+)
+
+// confirmedBooking đặt và thanh toán ghế A1 trên chặng from → to (để trống là trọn tuyến).
+func (e *testEnv) confirmedBooking(t *testing.T, tripID primitive.ObjectID, from, to string) (*models.Booking, models.User) {
+	t.Helper()
+	user := e.addUser(t)
+	booking, err := e.bookings.CreateBooking(CreateBookingInput{TripID: tripID.Hex(), SeatNumbers: []string{"A1"}, From: from, To: to}, user.ID.Hex())
+	if err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+	if _, err := e.bookings.UpdatePassengers(booking.ID.Hex(), user.ID.Hex(), UpdatePassengersInput{}); err != nil {
+		t.Fatalf("UpdatePassengers: %v", err)
+	}
+	if _, err := e.payments.PayBooking(booking.ID.Hex(), user.ID.Hex()); err != nil {
+		t.Fatalf("PayBooking: %v", err)
+	}
+	return booking, user
+}
+
+func TestComputeRefundTiers(t *testing.T) {
+	departure := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)
+	custom := models.RefundPolicy{Tiers: []models.RefundTier{
+		{MinHoursBeforeDeparture: 2, Percent: 30},
+		{MinHoursBeforeDeparture: 24, Percent: 80},
+	}}
+	tests := []struct {
+		name        string
+		policy      models.RefundPolicy
+		before      time.Duration
+		wantPercent float64
+		wantAmount  float64
+	}{
+		{"mặc định, trước 72 giờ", DefaultRefundPolicy, 72 * time.Hour, 100, 300000},
+		{"mặc định, đúng 48 giờ", DefaultRefundPolicy, 48 * time.Hour, 50, 150000},
+		{"mặc định, trước 10 giờ", DefaultRefundPolicy, 10 * time.Hour, 50, 150000},
+		{"mặc định, đúng 6 giờ", DefaultRefundPolicy, 6 * time.Hour, 0, 0},
+		{"mặc định, trước 1 giờ", DefaultRefundPolicy, time.Hour, 0, 0},
+		{"tùy chỉnh không sắp xếp, trước 30 giờ", custom, 30 * time.Hour, 80, 240000},
+		{"tùy chỉnh không sắp xếp, trước 3 giờ", custom, 3 * time.Hour, 30, 90000},
+		{"chính sách rỗng", models.RefundPolicy{}, 72 * time.Hour, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			percent, amount := ComputeRefund(tt.policy, 300000, departure, departure.Add(-tt.before))
+			if percent != tt.wantPercent || amount != tt.wantAmount {
+				t.Fatalf("hoàn = %v%% (%v), muốn %v%% (%v)", percent, amount, tt.wantPercent, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestCancelBookingUsesBoardingTime(t *testing.T) {
+	env := newTestEnv(t)
+	// Xe rời Hà Nội sau 47 giờ và tới Ninh Bình 2 giờ sau đó, tức sau 49 giờ.
+	departure := time.Now().Add(47 * time.Hour)
+	trip := env.addTrip(t, "Hà Nội", "Thanh Hóa", departure, "A1")
+	trip.Route.Stops = []models.Stop{
+		{Name: "Hà Nội"},
+		{Name: "Ninh Bình", OffsetMinutes: 120},
+		{Name: "Thanh Hóa", OffsetMinutes: 210},
+	}
+	trip.Fares = []models.Fare{{From: "Ninh Bình", To: "Thanh Hóa", Price: 100000}}
+	env.putTrip(trip)
+
+	booking, user := env.confirmedBooking(t, trip.ID, "Ninh Bình", "Thanh Hóa")
+	result, err := env.bookings.CancelBooking(booking.ID.Hex(), user.ID.Hex(), CancelBookingInput{})
+	if err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	if result.Refund == nil || result.Refund.Percent != 100 || result.Refund.Amount != 100000 {
+		t.Fatalf("hoàn tiền = %+v, muốn 100%% vì lên xe sau hơn 48 giờ", result.Refund)
+	}
+
+	// Xe đã rời điểm đầu nhưng chưa tới điểm lên: vẫn được hủy.
+	trip.DepartureTime = time.Now().Add(-time.Hour)
+	env.putTrip(trip)
+	booking, user = env.confirmedBooking(t, trip.ID, "Ninh Bình", "Thanh Hóa")
+	if _, err := env.bookings.CancelBooking(booking.ID.Hex(), user.ID.Hex(), CancelBookingInput{}); err != nil {
+		t.Fatalf("hủy trước giờ lên xe: %v", err)
+	}
+
+	// Xe đã qua điểm lên.
+	booking, user = env.confirmedBooking(t, trip.ID, "Ninh Bình", "Thanh Hóa")
+	stored, err := env.repos.Trips.FindByID(t.Context(), trip.ID)
+	if err != nil {
+		t.Fatalf("đọc chuyến đi: %v", err)
+	}
+	stored.DepartureTime = time.Now().Add(-3 * time.Hour)
+	env.putTrip(*stored)
+	if _, err := env.bookings.CancelBooking(booking.ID.Hex(), user.ID.Hex(), CancelBookingInput{}); !errors.Is(err, ErrTripAlreadyDeparted) {
+		t.Fatalf("hủy sau giờ lên xe: lỗi = %v, muốn ErrTripAlreadyDeparted", err)
+	}
+}
+
+func TestReconcileBookedSeatsReleasesCancelledBooking(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(72*time.Hour), "A1")
+	booking, user := env.confirmedBooking(t, trip.ID, "", "")
+
+	// Booking chuyển sang "cancelled" nhưng lệnh trả ghế thất bại.
+	failing := *env.bookings
+	failing.trips = failingSeatLegs{env.repos.Trips}
+	if _, err := failing.CancelBooking(booking.ID.Hex(), user.ID.Hex(), CancelBookingInput{}); err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatBooked {
+		t.Fatalf("ghế A1 = %s, muốn vẫn booked sau lỗi ghi", status)
+	}
+
+	n, err := env.bookings.ReconcileBookedSeats(t.Context(), time.Now())
+	if err != nil || n != 1 {
+		t.Fatalf("ReconcileBookedSeats: n = %d, err = %v; muốn trả ghế của 1 booking", n, err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatAvailable {
+		t.Fatalf("ghế A1 = %s, muốn available", status)
+	}
+
+	// Ghế của booking còn hiệu lực không bị đụng tới.
+	env.confirmedBooking(t, trip.ID, "", "")
+	if n, err := env.bookings.ReconcileBookedSeats(t.Context(), time.Now()); err != nil || n != 0 {
+		t.Fatalf("quét lại: n = %d, err = %v; muốn không trả ghế nào", n, err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatBooked {
+		t.Fatalf("ghế A1 = %s, muốn booked", status)
+	}
+}
+
+func TestRetryFailedRefunds(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(72*time.Hour), "A1")
+	booking, user := env.confirmedBooking(t, trip.ID, "", "")
+
+	env.gateway.SetOutcome(payments.MockOutcomeTimeout)
+	result, err := env.bookings.CancelBooking(booking.ID.Hex(), user.ID.Hex(), CancelBookingInput{})
+	if err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	if result.Refund == nil || result.Refund.Status != models.RefundFailed || result.Booking.Status != models.BookingCancelled {
+		t.Fatalf("kết quả = %+v, muốn hoàn tiền failed và booking cancelled", result)
+	}
+
+	if n, err := env.bookings.RetryFailedRefunds(t.Context()); err != nil || n != 0 {
+		t.Fatalf("thử lại khi cổng lỗi: n = %d, err = %v", n, err)
+	}
+	env.gateway.SetOutcome(payments.MockOutcomeSuccess)
+	if n, err := env.bookings.RetryFailedRefunds(t.Context()); err != nil || n != 1 {
+		t.Fatalf("thử lại khi cổng hoạt động: n = %d, err = %v; muốn hoàn 1 lần", n, err)
+	}
+	if n, err := env.bookings.RetryFailedRefunds(t.Context()); err != nil || n != 0 {
+		t.Fatalf("quét lại: n = %d, err = %v; muốn không hoàn thêm", n, err)
+	}
+
+	stored, err := env.repos.Bookings.FindByID(t.Context(), booking.ID)
+	if err != nil || stored.Status != models.BookingRefunded {
+		t.Fatalf("booking = %+v, err = %v; muốn refunded", stored, err)
+	}
+	payment, err := env.repos.Payments.FindByID(t.Context(), *stored.PaymentID)
+	if err != nil {
+		t.Fatalf("đọc giao dịch: %v", err)
+	}
+	refund := payment.Refunds[0]
+	if len(payment.Refunds) != 1 || refund.Status != models.RefundSucceeded || refund.Attempts != 3 || refund.ID == "" {
+		t.Fatalf("hoàn tiền = %+v, muốn 1 lần succeeded sau 3 lần gọi", payment.Refunds)
+	}
+	if payment.Status != "refunded" || payment.RefundedAmount != payment.Amount {
+		t.Fatalf("giao dịch = %s (%v/%v), muốn refunded toàn bộ", payment.Status, payment.RefundedAmount, payment.Amount)
+	}
+}
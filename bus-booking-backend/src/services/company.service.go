@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+var (
+	ErrCompanyCodeTaken = apperrors.New(apperrors.ErrConflict, "mã nhà xe đã được sử dụng").WithCode("company_code_taken")
+	// ErrCompanyInactive được trả về khi đặt chỗ trên chuyến đi của nhà xe đã ngừng hoạt động.
+	ErrCompanyInactive = apperrors.New(apperrors.ErrConflict, "nhà xe đã ngừng hoạt động, không thể đặt chỗ").WithCode("company_inactive")
+)
+
+// CompanyInput là dữ liệu tạo hoặc cập nhật nhà xe. Mã nhà xe được chuyển thành chữ in hoa;
+// bỏ trống refundPolicy để dùng chính sách hoàn tiền mặc định. rating là điểm đánh giá trung
+// bình (0-5) dùng để sắp xếp kết quả tìm kiếm chuyến đi.
+type CompanyInput struct {
+	Name         string               `json:"name" validate:"required,min=2,max=100"`
+	Code         string               `json:"code" validate:"required,min=2,max=10,alphanum"`
+	Description  string               `json:"description" validate:"max=1000"`
+	LogoURL      string               `json:"logoUrl" validate:"omitempty,url"`
+	RefundPolicy *models.RefundPolicy `json:"refundPolicy"`
+	Rating       float64              `json:"rating" validate:"gte=0,lte=5"`
+}
+
+// CompanyService quản lý danh sách nhà xe.
+type CompanyService struct {
+	companies repositories.CompanyRepository
+}
+
+func NewCompanyService(companies repositories.CompanyRepository) *CompanyService {
+	return &CompanyService{companies: companies}
+}
+
+func (s *CompanyService) CreateCompany(input CompanyInput) (*models.Company, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	company := models.Company{ID: primitive.NewObjectID(), CreatedAt: now}
+	applyCompanyInput(&company, input, now)
+
+	if err := s.companies.Create(ctx, &company); err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrCompanyCodeTaken
+		}
+		log.Printf("Lỗi khi tạo nhà xe %s: %v", company.Code, err)
+		return nil, internalError("không thể tạo nhà xe", err)
+	}
+	return &company, nil
+}
+
+func (s *CompanyService) UpdateCompany(companyIDStr string, input CompanyInput) (*models.Company, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	company, err := s.findCompany(ctx, companyIDStr)
+	if err != nil {
+		return nil, err
+	}
+	applyCompanyInput(company, input, time.Now())
+
+	if err := s.companies.Update(ctx, company); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrDuplicate):
+			return nil, ErrCompanyCodeTaken
+		case errors.Is(err, repositories.ErrNotFound):
+			return nil, ErrCompanyNotFound
+		}
+		log.Printf("Lỗi khi cập nhật nhà xe %s: %v", companyIDStr, err)
+		return nil, internalError("không thể cập nhật nhà xe", err)
+	}
+	return company, nil
+}
+
+// DeactivateCompany ngừng hoạt động nhà xe. Các booking đã có vẫn giữ nguyên, nhưng chuyến đi
+// của nhà xe không còn xuất hiện khi tìm kiếm và không nhận đặt chỗ mới.
+func (s *CompanyService) DeactivateCompany(companyIDStr string) (*models.Company, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companyID, err := primitive.ObjectIDFromHex(companyIDStr)
+	if err != nil {
+		return nil, ErrInvalidCompanyID
+	}
+	if err := s.companies.Deactivate(ctx, companyID, time.Now()); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrCompanyNotFound
+		}
+		log.Printf("Lỗi khi ngừng hoạt động nhà xe %s: %v", companyIDStr, err)
+		return nil, internalError("không thể ngừng hoạt động nhà xe", err)
+	}
+	return s.findCompany(ctx, companyIDStr)
+}
+
+func (s *CompanyService) ListCompanies(includeInactive bool) ([]models.Company, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companies, err := s.companies.List(ctx, repositories.CompanyFilter{IncludeInactive: includeInactive})
+	if err != nil {
+		log.Printf("Lỗi khi liệt kê nhà xe: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn nhà xe", err)
+	}
+	return companies, nil
+}
+
+func (s *CompanyService) findCompany(ctx context.Context, companyIDStr string) (*models.Company, error) {
+	companyID, err := primitive.ObjectIDFromHex(companyIDStr)
+	if err != nil {
+		return nil, ErrInvalidCompanyID
+	}
+	company, err := s.companies.FindByID(ctx, companyID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrCompanyNotFound
+		}
+		log.Printf("Lỗi khi tìm nhà xe %s: %v", companyIDStr, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn nhà xe", err)
+	}
+	return company, nil
+}
+
+func applyCompanyInput(company *models.Company, input CompanyInput, now time.Time) {
+	company.Name = strings.TrimSpace(input.Name)
+	company.Code = strings.ToUpper(input.Code)
+	company.Description = strings.TrimSpace(input.Description)
+	company.LogoURL = input.LogoURL
+	company.RefundPolicy = input.RefundPolicy
+	company.Rating = input.Rating
+	company.UpdatedAt = now
+}
+
+// attachCompanies gắn CompanyInfo cho các chuyến đi bằng một truy vấn duy nhất.
+func attachCompanies(ctx context.Context, companies repositories.CompanyRepository, trips []models.Trip) error {
+	ids := make([]primitive.ObjectID, 0, len(trips))
+	seen := map[primitive.ObjectID]bool{}
+	for _, trip := range trips {
+		if !seen[trip.CompanyID] {
+			seen[trip.CompanyID] = true
+			ids = append(ids, trip.CompanyID)
+		}
+	}
+
+	found, err := companies.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[primitive.ObjectID]*models.Company, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+	for i := range trips {
+		trips[i].CompanyInfo = byID[trips[i].CompanyID]
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+func TestCompanyCodeIsUnique(t *testing.T) {
+	env := newTestEnv(t)
+	companies := NewCompanyService(env.repos.Companies)
+	bac, err := companies.CreateCompany(CompanyInput{Name: "Nhà xe Bắc", Code: "bac"})
+	if err != nil {
+		t.Fatalf("CreateCompany: %v", err)
+	}
+	if bac.Code != "BAC" {
+		t.Fatalf("mã nhà xe = %q, muốn chữ in hoa BAC", bac.Code)
+	}
+	nam, err := companies.CreateCompany(CompanyInput{Name: "Nhà xe Nam", Code: "NAM"})
+	if err != nil {
+		t.Fatalf("CreateCompany: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		write func() error
+		want  error
+	}{
+		{name: "tạo trùng mã khác hoa thường", write: func() error {
+			_, err := companies.CreateCompany(CompanyInput{Name: "Nhà xe Bắc 2", Code: "Bac"})
+			return err
+		}, want: ErrCompanyCodeTaken},
+		{name: "đổi sang mã đã dùng", write: func() error {
+			_, err := companies.UpdateCompany(nam.ID.Hex(), CompanyInput{Name: "Nhà xe Nam", Code: "BAC"})
+			return err
+		}, want: ErrCompanyCodeTaken},
+		{name: "giữ nguyên mã của chính mình", write: func() error {
+			_, err := companies.UpdateCompany(bac.ID.Hex(), CompanyInput{Name: "Nhà xe Bắc mới", Code: "BAC"})
+			return err
+		}},
+		{name: "nhà xe không tồn tại", write: func() error {
+			_, err := companies.UpdateCompany("64b7f0f0f0f0f0f0f0f0f0f0", CompanyInput{Name: "Nhà xe Tây", Code: "TAY"})
+			return err
+		}, want: ErrCompanyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.write(); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, muốn %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeactivatedCompanyIsScopedOut(t *testing.T) {
+	env := newTestEnv(t)
+	companies := NewCompanyService(env.repos.Companies)
+	day := time.Date(2030, 5, 10, 0, 0, 0, 0, env.location)
+	user := env.addUser(t)
+
+	tests := []struct {
+		name        string
+		deactivated bool
+		wantBooking error
+	}{
+		{name: "nhà xe đang hoạt động"},
+		{name: "nhà xe đã ngừng hoạt động", deactivated: true, wantBooking: ErrCompanyInactive},
+	}
+	type fixture struct {
+		company *models.Company
+		trip    models.Trip
+	}
+	fixtures := make([]fixture, len(tests))
+	for i, tt := range tests {
+		company, err := companies.CreateCompany(CompanyInput{Name: tt.name, Code: []string{"ACTIVE", "INACTIVE"}[i]})
+		if err != nil {
+			t.Fatalf("CreateCompany: %v", err)
+		}
+		if tt.deactivated {
+			if company, err = companies.DeactivateCompany(company.ID.Hex()); err != nil {
+				t.Fatalf("DeactivateCompany: %v", err)
+			}
+		}
+		trip := env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(time.Duration(8+i)*time.Hour), "A1")
+		trip.CompanyID = company.ID
+		env.putTrip(trip)
+		fixtures[i] = fixture{company: company, trip: trip}
+	}
+
+	result, err := env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", Date: "2030-05-10"})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	active, err := companies.ListCompanies(false)
+	if err != nil {
+		t.Fatalf("ListCompanies: %v", err)
+	}
+	all, err := companies.ListCompanies(true)
+	if err != nil {
+		t.Fatalf("ListCompanies: %v", err)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := fixtures[i]
+			hasTrip := slices.ContainsFunc(result.Trips, func(trip models.Trip) bool { return trip.ID == f.trip.ID })
+			if hasTrip == tt.deactivated {
+				t.Fatalf("chuyến có trong kết quả tìm kiếm = %v, muốn %v", hasTrip, !tt.deactivated)
+			}
+			hasCompany := func(list []models.Company) bool {
+				return slices.ContainsFunc(list, func(c models.Company) bool { return c.ID == f.company.ID })
+			}
+			if hasCompany(active) == tt.deactivated || !hasCompany(all) {
+				t.Fatalf("nhà xe trong danh sách đang hoạt động = %v, trong danh sách đầy đủ = %v", hasCompany(active), hasCompany(all))
+			}
+
+			_, err := env.bookings.CreateBooking(CreateBookingInput{TripID: f.trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+			if !errors.Is(err, tt.wantBooking) {
+				t.Fatalf("CreateBooking: err = %v, muốn %v", err, tt.wantBooking)
+			}
+
+			// Chi tiết chuyến đi vẫn xem được để khách đã đặt vé tra cứu, kèm thông tin nhà xe.
+			trip, err := env.trips.GetTripByID(f.trip.ID.Hex(), "", "")
+			if err != nil {
+				t.Fatalf("GetTripByID: %v", err)
+			}
+			if trip.CompanyInfo == nil || trip.CompanyInfo.ID != f.company.ID || trip.CompanyInfo.IsActive() == tt.deactivated {
+				t.Fatalf("companyInfo = %+v, muốn nhà xe %s", trip.CompanyInfo, f.company.ID.Hex())
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package services
+
+import "github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+
+// Các lỗi dùng chung giữa nhiều service.
+var (
+	ErrInvalidTripID    = apperrors.New(apperrors.ErrInvalidID, "ID chuyến đi không hợp lệ").WithCode("invalid_trip_id")
+	ErrInvalidBookingID = apperrors.New(apperrors.ErrInvalidID, "ID booking không hợp lệ").WithCode("invalid_booking_id")
+	ErrInvalidUserID    = apperrors.New(apperrors.ErrInvalidID, "ID người dùng không hợp lệ").WithCode("invalid_user_id")
+	ErrInvalidCompanyID = apperrors.New(apperrors.ErrInvalidID, "ID nhà xe không hợp lệ").WithCode("invalid_company_id")
+	ErrTripNotFound     = apperrors.New(apperrors.ErrNotFound, "không tìm thấy chuyến đi").WithCode("trip_not_found")
+	ErrBookingNotFound  = apperrors.New(apperrors.ErrNotFound, "không tìm thấy booking hoặc bạn không có quyền xem").WithCode("booking_not_found")
+	ErrCompanyNotFound  = apperrors.New(apperrors.ErrNotFound, "không tìm thấy nhà xe").WithCode("company_not_found")
+
+	// ErrSegmentNotSold được trả về khi chuyến đi không đi qua cặp điểm dừng đã chọn theo đúng
+	// chiều hoặc không bán vé cho chặng đó.
+	ErrSegmentNotSold = apperrors.New(apperrors.ErrInvalidInput, "chuyến đi không bán vé cho chặng đã chọn").WithCode("segment_not_sold")
+)
+
+// internalError bọc lỗi hạ tầng (thường là lỗi MongoDB) thành lỗi máy chủ nội bộ với thông báo
+// an toàn để trả về cho client.
+func internalError(message string, err error) error {
+	return apperrors.Wrap(apperrors.ErrInternal, message, err)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+const defaultCalendarDays = 7
+
+// FareCalendarQuery là tham số của lịch giá vé: chặng from → to trong days ngày kể từ start
+// (mặc định hôm nay theo múi giờ vận hành).
+type FareCalendarQuery struct {
+	From  string `form:"from" validate:"required,max=100"`
+	To    string `form:"to" validate:"required,max=100"`
+	Start string `form:"start" validate:"omitempty,datetime=2006-01-02"`
+	Days  int    `form:"days" validate:"gte=0,lte=31"`
+}
+
+// GetFareCalendar trả về, cho từng ngày trong khoảng, giá vé thấp nhất của chặng from → to trong
+// các chuyến còn ghế trống, số chuyến và tổng số ghế trống. Ngày không có chuyến nào vẫn có mặt
+// với trips bằng 0. Việc thống kê được thực hiện trong cơ sở dữ liệu; chuyến đã qua giờ lên xe và
+// chuyến của nhà xe đã ngừng hoạt động không được tính.
+func (s *TripService) GetFareCalendar(query FareCalendarQuery) ([]repositories.FareCalendarDay, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	days := query.Days
+	if days < 1 {
+		days = defaultCalendarDays
+	}
+	now := time.Now().In(s.location)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	if query.Start != "" {
+		var err error
+		start, err = time.ParseInLocation(models.DateLayout, query.Start, s.location)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrInvalidInput, "định dạng ngày không hợp lệ, vui lòng sử dụng YYYY-MM-DD", err).WithCode("invalid_date")
+		}
+	}
+	end := start.AddDate(0, 0, days)
+
+	from, fromID, err := resolveLocationQuery(ctx, s.locations, query.From)
+	if err != nil {
+		return nil, err
+	}
+	to, toID, err := resolveLocationQuery(ctx, s.locations, query.To)
+	if err != nil {
+		return nil, err
+	}
+	inactive, err := s.inactiveCompanyIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	calendarQuery := repositories.FareCalendarQuery{
+		From:              from,
+		FromLocationID:    fromID,
+		To:                to,
+		ToLocationID:      toID,
+		DepartureFrom:     start.Add(-boardingLookback),
+		DepartureTo:       end,
+		BoardingFrom:      start,
+		BoardingTo:        end,
+		Location:          s.location,
+		ExcludeCompanyIDs: inactive,
+	}
+	if calendarQuery.BoardingFrom.Before(now) {
+		calendarQuery.BoardingFrom = now
+	}
+	found, err := s.trips.FareCalendar(ctx, calendarQuery)
+	if err != nil {
+		log.Printf("Lỗi khi thống kê lịch giá vé %s → %s: %v", from, to, err)
+		return nil, internalError("lỗi máy chủ khi thống kê lịch giá vé", err)
+	}
+
+	byDate := make(map[string]repositories.FareCalendarDay, len(found))
+	for _, day := range found {
+		byDate[day.Date] = day
+	}
+	calendar := make([]repositories.FareCalendarDay, 0, days)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(models.DateLayout)
+		if stats, ok := byDate[date]; ok {
+			calendar = append(calendar, stats)
+		} else {
+			calendar = append(calendar, repositories.FareCalendarDay{Date: date})
+		}
+	}
+	return calendar, nil
+}
+
+// inactiveCompanyIDs trả về ID các nhà xe đã ngừng hoạt động.
+func (s *TripService) inactiveCompanyIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	companies, err := s.companies.List(ctx, repositories.CompanyFilter{IncludeInactive: true})
+	if err != nil {
+		log.Printf("Lỗi khi lấy danh sách nhà xe: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn nhà xe", err)
+	}
+	var ids []primitive.ObjectID
+	for _, company := range companies {
+		if !company.IsActive() {
+			ids = append(ids, company.ID)
+		}
+	}
+	return ids, nil
+}
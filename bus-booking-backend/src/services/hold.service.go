@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/realtime"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StartHoldExpiryWorker chạy nền, định kỳ hủy các booking "held" đã quá hạn giữ chỗ, trả ghế
+// về trạng thái "available", đối chiếu lại ghế với booking (xem ReconcileHeldSeats và
+// ReconcileBookedSeats) và thử lại các lần hoàn tiền thất bại (xem RetryFailedRefunds).
+// Worker dừng khi ctx bị hủy.
+func (s *BookingService) StartHoldExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Worker hủy giữ chỗ đã khởi động (thời gian giữ: %s, chu kỳ quét: %s)", s.holdDuration, interval)
+	for {
+		if n, err := s.ExpireHeldBookings(ctx, time.Now()); err != nil {
+			log.Printf("Lỗi khi hủy các booking hết hạn giữ chỗ: %v", err)
+		} else if n > 0 {
+			log.Printf("Đã hủy %d booking hết hạn giữ chỗ", n)
+		}
+		if n, err := s.ReconcileHeldSeats(ctx, time.Now()); err != nil {
+			log.Printf("Lỗi khi đối chiếu ghế đang giữ: %v", err)
+		} else if n > 0 {
+			log.Printf("Đã đồng bộ lại ghế của %d booking hoặc ghế cũ", n)
+		}
+		if n, err := s.ReconcileBookedSeats(ctx, time.Now()); err != nil {
+			log.Printf("Lỗi khi đối chiếu ghế đã bán: %v", err)
+		} else if n > 0 {
+			log.Printf("Đã trả lại ghế của %d booking đã hủy hoặc ghế cũ", n)
+		}
+		if n, err := s.RetryFailedRefunds(ctx); err != nil {
+			log.Printf("Lỗi khi thử lại hoàn tiền: %v", err)
+		} else if n > 0 {
+			log.Printf("Đã hoàn tiền thành công %d lần sau khi thử lại", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireHeldBookings chuyển các booking "held" đã hết hạn sang "expired" và trả ghế lại cho chuyến đi.
+// Trả về số booking đã được hủy trong lần quét này.
+func (s *BookingService) ExpireHeldBookings(ctx context.Context, now time.Time) (int, error) {
+	// Booking cũ chưa có holdExpiresAt được tính hạn theo bookingTime.
+	bookings, err := s.bookings.FindExpiredHolds(ctx, now, now.Add(-s.holdDuration))
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, booking := range bookings {
+		ok, err := s.expireBooking(ctx, booking)
+		if err != nil {
+			log.Printf("Lỗi khi hủy booking %s: %v", booking.ID.Hex(), err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (s *BookingService) expireBooking(ctx context.Context, booking models.Booking) (bool, error) {
+	// Chuyển trạng thái có điều kiện đảm bảo booking vừa được thanh toán (hoặc đã bị
+	// một worker khác xử lý) sẽ không bị hủy nhầm.
+	err := transitionBooking(ctx, s.bookings, &booking, bookingTransition{
+		To:     models.BookingExpired,
+		Actor:  models.ActorHoldExpiry,
+		Reason: "hết thời gian giữ chỗ",
+		Fields: repositories.BookingFields{ClearHoldExpiry: true},
+	})
+	if err != nil {
+		if errors.Is(err, ErrBookingStateChanged) || errors.Is(err, models.ErrInvalidTransition) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Nếu không trả được ghế, ReconcileHeldSeats sẽ trả lại ở lần quét sau vì booking đã "expired".
+	if err := updateSeatStatus(ctx, s.trips, &booking, models.SeatHeld, models.SeatAvailable); err != nil {
+		return true, err
+	}
+	publishSeatEvent(s.seatEvents, &booking, models.SeatAvailable)
+	return true, nil
+}
+
+// ReconcileHeldSeats đối chiếu các chặng ghế còn "held" với booking giữ chúng. Chuyển trạng thái
+// booking và cập nhật ghế là hai lệnh ghi riêng; nếu lệnh ghi ghế thất bại sau khi booking đã hết
+// hạn, bị hủy hoặc đã thanh toán, lần quét này sửa lại: ghế của booking đã thanh toán chuyển sang
+// "booked", ghế của booking không còn hiệu lực được trả lại. Chặng ghế không có booking (giữ ghế
+// xong nhưng không ghi được booking) được trả lại khi đã quá thời gian giữ chỗ. Ghế "held" cũ
+// (trọn chuyến, không có chặng) được đối chiếu theo reconcileLegacySeats.
+// Trả về số booking và ghế cũ đã được đồng bộ lại.
+func (s *BookingService) ReconcileHeldSeats(ctx context.Context, now time.Time) (int, error) {
+	legs, err := s.trips.FindHeldSeatLegs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	fixed := 0
+	for _, held := range legs {
+		ok, err := s.reconcileHeldSeat(ctx, held, now)
+		if err != nil {
+			log.Printf("Lỗi khi đối chiếu ghế của booking %s trên chuyến %s: %v", held.BookingID.Hex(), held.TripID.Hex(), err)
+			continue
+		}
+		if ok {
+			fixed++
+		}
+	}
+
+	legacy, err := s.reconcileLegacySeats(ctx, models.SeatHeld, now)
+	return fixed + legacy, err
+}
+
+func (s *BookingService) reconcileHeldSeat(ctx context.Context, held repositories.SeatLegRef, now time.Time) (bool, error) {
+	booking, err := s.bookings.FindByID(ctx, held.BookingID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		// ID booking được sinh ngay trước khi giữ ghế nên mang thời điểm giữ ghế; booking mới
+		// giữ ghế có thể chưa kịp được ghi.
+		if held.BookingID.Timestamp().After(now.Add(-s.holdDuration)) {
+			return false, nil
+		}
+		return true, s.trips.UpdateSeatLegs(ctx, held.TripID, held.BookingID, models.SeatHeld, models.SeatAvailable)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var to models.SeatStatus
+	switch booking.Status {
+	case models.BookingHeld:
+		return false, nil
+	case models.BookingConfirmed, models.BookingCheckedIn, models.BookingCompleted:
+		to = models.SeatBooked
+	default:
+		to = models.SeatAvailable
+	}
+	if err := s.trips.UpdateSeatLegs(ctx, held.TripID, held.BookingID, models.SeatHeld, to); err != nil {
+		return false, err
+	}
+	log.Printf("Đồng bộ ghế của booking %s (%s) sang %s", booking.ID.Hex(), booking.Status, to)
+	publishSeatEvent(s.seatEvents, booking, to)
+	return true, nil
+}
+
+// ReconcileBookedSeats trả lại các chặng ghế "booked" của booking đã hủy, hoàn tiền hoặc hết hạn
+// trên các chuyến chưa khởi hành. Hủy vé chuyển trạng thái booking trước rồi mới trả ghế; nếu
+// lệnh trả ghế thất bại, lần quét này trả lại ghế. Ghế "booked" cũ (trọn chuyến, không có chặng)
+// được đối chiếu theo reconcileLegacySeats. Trả về số booking và ghế cũ đã được trả lại.
+func (s *BookingService) ReconcileBookedSeats(ctx context.Context, now time.Time) (int, error) {
+	legs, err := s.trips.FindBookedSeatLegs(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	fixed, err := s.releaseBookedSeatLegs(ctx, legs)
+	if err != nil {
+		return fixed, err
+	}
+
+	legacy, err := s.reconcileLegacySeats(ctx, models.SeatBooked, now)
+	return fixed + legacy, err
+}
+
+func (s *BookingService) releaseBookedSeatLegs(ctx context.Context, legs []repositories.SeatLegRef) (int, error) {
+	if len(legs) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(legs))
+	for _, booked := range legs {
+		ids = append(ids, booked.BookingID)
+	}
+	bookings, err := s.bookings.FindByIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Booking, len(bookings))
+	for i := range bookings {
+		byID[bookings[i].ID] = &bookings[i]
+	}
+
+	fixed := 0
+	for _, booked := range legs {
+		booking, ok := byID[booked.BookingID]
+		if !ok {
+			continue
+		}
+		switch booking.Status {
+		case models.BookingCancelled, models.BookingRefunded, models.BookingExpired:
+		default:
+			continue
+		}
+		if err := s.trips.UpdateSeatLegs(ctx, booked.TripID, booked.BookingID, models.SeatBooked, models.SeatAvailable); err != nil {
+			log.Printf("Lỗi khi trả ghế của booking %s trên chuyến %s: %v", booked.BookingID.Hex(), booked.TripID.Hex(), err)
+			continue
+		}
+		log.Printf("Trả lại ghế của booking %s (%s)", booking.ID.Hex(), booking.Status)
+		publishSeatEvent(s.seatEvents, booking, models.SeatAvailable)
+		fixed++
+	}
+	return fixed, nil
+}
+
+// reconcileLegacySeats đối chiếu các ghế mang trạng thái cũ status ("held" hoặc "booked" cả ghế,
+// do booking tạo trước khi ghế được bán theo chặng) trên các chuyến chưa khởi hành với booking cũ
+// (không có Segment) của chuyến: ghế "held" của booking đã thanh toán chuyển sang "booked", ghế
+// không còn booking nào giữ hoặc đã mua được trả lại. Trả về số ghế đã được đồng bộ lại.
+func (s *BookingService) reconcileLegacySeats(ctx context.Context, status models.SeatStatus, now time.Time) (int, error) {
+	refs, err := s.trips.FindLegacySeats(ctx, status, now)
+	if err != nil {
+		return 0, err
+	}
+
+	fixed := 0
+	for _, ref := range refs {
+		n, err := s.reconcileLegacyTrip(ctx, ref, status)
+		if err != nil {
+			log.Printf("Lỗi khi đối chiếu ghế cũ trên chuyến %s: %v", ref.TripID.Hex(), err)
+		}
+		fixed += n
+	}
+	return fixed, nil
+}
+
+func (s *BookingService) reconcileLegacyTrip(ctx context.Context, ref repositories.LegacySeatRef, status models.SeatStatus) (int, error) {
+	bookings, err := s.bookings.FindByTrip(ctx, ref.TripID)
+	if err != nil {
+		return 0, err
+	}
+	// holders là trạng thái ghế mà các booking cũ còn hiệu lực đòi hỏi; booking đã thanh toán
+	// được ưu tiên hơn booking đang giữ chỗ trên cùng một ghế.
+	holders := map[string]models.SeatStatus{}
+	for i := range bookings {
+		booking := &bookings[i]
+		if booking.Segment != nil {
+			continue
+		}
+		var held models.SeatStatus
+		switch booking.Status {
+		case models.BookingHeld:
+			held = models.SeatHeld
+		case models.BookingConfirmed, models.BookingCheckedIn, models.BookingCompleted:
+			held = models.SeatBooked
+		default:
+			continue
+		}
+		for _, seatNumber := range bookingSeatNumbers(booking) {
+			if holders[seatNumber] != models.SeatBooked {
+				holders[seatNumber] = held
+			}
+		}
+	}
+
+	moves := map[models.SeatStatus][]string{}
+	for _, seatNumber := range ref.SeatNumbers {
+		to, ok := holders[seatNumber]
+		switch {
+		case !ok:
+			to = models.SeatAvailable
+		case to == status || status == models.SeatBooked:
+			// Ghế đã khớp với booking, hoặc ghế "booked" vẫn còn booking giữ chỗ: để nguyên.
+			continue
+		}
+		moves[to] = append(moves[to], seatNumber)
+	}
+
+	fixed := 0
+	for to, seatNumbers := range moves {
+		if err := s.trips.UpdateSeatStatus(ctx, ref.TripID, seatNumbers, status, to); err != nil {
+			return fixed, err
+		}
+		log.Printf("Đồng bộ ghế cũ %v trên chuyến %s từ %s sang %s", seatNumbers, ref.TripID.Hex(), status, to)
+		s.seatEvents.Publish(realtime.SeatEvent{TripID: ref.TripID, SeatNumbers: seatNumbers, Status: to, At: time.Now()})
+		fixed += len(seatNumbers)
+	}
+	return fixed, nil
+}
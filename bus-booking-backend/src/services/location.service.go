@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"github.com/Go_final_exam/bus-booking-backend/src/utils"
+)
+
+var (
+	ErrInvalidLocationID = apperrors.New(apperrors.ErrInvalidID, "ID địa điểm không hợp lệ").WithCode("invalid_location_id")
+	ErrLocationNotFound  = apperrors.New(apperrors.ErrNotFound, "không tìm thấy địa điểm").WithCode("location_not_found")
+	// ErrLocationTaken được trả về khi slug, tên hoặc tên gọi khác (sau khi bỏ dấu) đã thuộc về
+	// địa điểm khác.
+	ErrLocationTaken = apperrors.New(apperrors.ErrConflict, "slug, tên hoặc tên gọi khác đã được địa điểm khác sử dụng").WithCode("location_taken")
+)
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 20
+)
+
+// LocationInput là dữ liệu tạo hoặc cập nhật địa điểm. Bỏ trống slug để sinh từ tên.
+type LocationInput struct {
+	Name        string           `json:"name" validate:"required,max=100"`
+	Slug        string           `json:"slug" validate:"omitempty,max=100"`
+	Province    string           `json:"province" validate:"max=100"`
+	Aliases     []string         `json:"aliases" validate:"omitempty,max=20,dive,required,max=100"`
+	Coordinates *models.GeoPoint `json:"coordinates"`
+}
+
+// LocationService quản lý danh mục địa điểm và gợi ý địa điểm khi người dùng gõ tìm kiếm.
+type LocationService struct {
+	locations repositories.LocationRepository
+}
+
+func NewLocationService(locations repositories.LocationRepository) *LocationService {
+	return &LocationService{locations: locations}
+}
+
+func (s *LocationService) CreateLocation(input LocationInput) (*models.Location, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	location := models.Location{ID: primitive.NewObjectID(), CreatedAt: now}
+	if err := applyLocationInput(&location, input, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.locations.Create(ctx, &location); err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrLocationTaken
+		}
+		log.Printf("Lỗi khi tạo địa điểm %s: %v", location.Slug, err)
+		return nil, internalError("không thể tạo địa điểm", err)
+	}
+	return &location, nil
+}
+
+// UpdateLocation ghi đè địa điểm. Tên mới chỉ áp dụng cho chuyến đi tạo hoặc cập nhật sau đó;
+// chuyến đi đã có vẫn giữ tên cũ cùng LocationID.
+func (s *LocationService) UpdateLocation(locationIDStr string, input LocationInput) (*models.Location, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	locationID, err := primitive.ObjectIDFromHex(locationIDStr)
+	if err != nil {
+		return nil, ErrInvalidLocationID
+	}
+	location, err := s.locations.FindByID(ctx, locationID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrLocationNotFound
+		}
+		log.Printf("Lỗi khi tìm địa điểm %s: %v", locationIDStr, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn địa điểm", err)
+	}
+	if err := applyLocationInput(location, input, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.locations.Update(ctx, location); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrDuplicate):
+			return nil, ErrLocationTaken
+		case errors.Is(err, repositories.ErrNotFound):
+			return nil, ErrLocationNotFound
+		}
+		log.Printf("Lỗi khi cập nhật địa điểm %s: %v", locationIDStr, err)
+		return nil, internalError("không thể cập nhật địa điểm", err)
+	}
+	return location, nil
+}
+
+func (s *LocationService) ListLocations() ([]models.Location, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	locations, err := s.locations.List(ctx)
+	if err != nil {
+		log.Printf("Lỗi khi liệt kê địa điểm: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn địa điểm", err)
+	}
+	return locations, nil
+}
+
+// SuggestLocations gợi ý địa điểm cho ô tìm kiếm: query được bỏ dấu rồi so khớp theo tiền tố
+// với tên, tên gọi khác và slug, bắt đầu từ bất kỳ từ nào ("chi minh" khớp "TP. Hồ Chí Minh").
+// limit ngoài khoảng 1..20 được thay bằng 10.
+func (s *LocationService) SuggestLocations(query string, limit int) ([]models.Location, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prefix := utils.FoldText(query)
+	if prefix == "" {
+		return []models.Location{}, nil
+	}
+	if limit < 1 || limit > maxSuggestLimit {
+		limit = defaultSuggestLimit
+	}
+
+	locations, err := s.locations.Suggest(ctx, prefix, limit)
+	if err != nil {
+		log.Printf("Lỗi khi gợi ý địa điểm cho '%s': %v", query, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn địa điểm", err)
+	}
+	return locations, nil
+}
+
+func applyLocationInput(location *models.Location, input LocationInput, now time.Time) error {
+	location.Name = strings.TrimSpace(input.Name)
+	location.Slug = utils.Slugify(input.Slug)
+	if location.Slug == "" {
+		location.Slug = utils.Slugify(location.Name)
+	}
+	if location.Slug == "" {
+		return apperrors.New(apperrors.ErrInvalidInput, "tên địa điểm phải có ít nhất một chữ cái hoặc chữ số").WithCode("invalid_location_name")
+	}
+	location.Province = strings.TrimSpace(input.Province)
+	location.Aliases = nil
+	for _, alias := range input.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" && !slices.Contains(location.Aliases, alias) {
+			location.Aliases = append(location.Aliases, alias)
+		}
+	}
+	location.Coordinates = input.Coordinates
+	location.Keys, location.SearchTerms = locationKeys(location)
+	location.UpdatedAt = now
+	return nil
+}
+
+// locationKeys tính Keys và SearchTerms của địa điểm từ tên, tên gọi khác và slug.
+func locationKeys(location *models.Location) (keys, terms []string) {
+	sources := append([]string{location.Name, location.Slug}, location.Aliases...)
+	for _, source := range sources {
+		key := utils.FoldText(source)
+		if key == "" || slices.Contains(keys, key) {
+			continue
+		}
+		keys = append(keys, key)
+		words := strings.Fields(key)
+		for i := range words {
+			if term := strings.Join(words[i:], " "); !slices.Contains(terms, term) {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return keys, terms
+}
+
+// resolveLocationQuery tìm địa điểm khớp chuỗi người dùng nhập (không phân biệt dấu, tên gọi
+// khác hoặc slug) và trả về tên chuẩn cùng ID của nó; chuỗi không khớp địa điểm nào được trả lại
+// sau khi bỏ khoảng trắng thừa, với ID nil.
+func resolveLocationQuery(ctx context.Context, locations repositories.LocationRepository, name string) (string, *primitive.ObjectID, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return name, nil, nil
+	}
+	location, err := locations.FindByKey(ctx, utils.FoldText(name))
+	switch {
+	case err == nil:
+		return location.Name, &location.ID, nil
+	case errors.Is(err, repositories.ErrNotFound):
+		return name, nil, nil
+	}
+	log.Printf("Lỗi khi tìm địa điểm '%s': %v", name, err)
+	return "", nil, internalError("lỗi máy chủ khi truy vấn địa điểm", err)
+}
+
+// resolveRouteLocations gắn điểm đi, điểm đến và các điểm dừng của tuyến với danh mục địa điểm.
+// Điểm có locationId lấy tên chuẩn của địa điểm đó; điểm chỉ có tên được so khớp không phân biệt
+// dấu với tên, tên gọi khác và slug, khớp thì lấy tên chuẩn và locationId, không khớp thì giữ
+// nguyên tên. Tên trong bảng giá được đổi theo tên chuẩn tương ứng của điểm dừng.
+func resolveRouteLocations(ctx context.Context, locations repositories.LocationRepository, route *models.Route, fares []models.Fare) error {
+	renamed := map[string]string{}
+	resolve := func(name *string, locationID **primitive.ObjectID) error {
+		var location *models.Location
+		var err error
+		if *locationID != nil {
+			location, err = locations.FindByID(ctx, **locationID)
+		} else {
+			location, err = locations.FindByKey(ctx, utils.FoldText(*name))
+			if errors.Is(err, repositories.ErrNotFound) {
+				return nil
+			}
+		}
+		if err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				return apperrors.New(ErrLocationNotFound.Kind, fmt.Sprintf("%s: %s", ErrLocationNotFound.Message, (*locationID).Hex())).WithCode(ErrLocationNotFound.Code)
+			}
+			log.Printf("Lỗi khi tìm địa điểm của tuyến đường: %v", err)
+			return internalError("lỗi máy chủ khi truy vấn địa điểm", err)
+		}
+		renamed[utils.FoldText(*name)] = location.Name
+		*name = location.Name
+		id := location.ID
+		*locationID = &id
+		return nil
+	}
+
+	if err := resolve(&route.From.Name, &route.From.LocationID); err != nil {
+		return err
+	}
+	if err := resolve(&route.To.Name, &route.To.LocationID); err != nil {
+		return err
+	}
+	for i := range route.Stops {
+		if err := resolve(&route.Stops[i].Name, &route.Stops[i].LocationID); err != nil {
+			return err
+		}
+	}
+	for i := range fares {
+		if name, ok := renamed[utils.FoldText(fares[i].From)]; ok {
+			fares[i].From = name
+		}
+		if name, ok := renamed[utils.FoldText(fares[i].To)]; ok {
+			fares[i].To = name
+		}
+	}
+	return nil
+}
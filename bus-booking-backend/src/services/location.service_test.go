@@ -0,0 +1,146 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+func TestLocationKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		location  models.Location
+		wantKeys  []string
+		wantTerms []string
+	}{
+		{
+			name:      "tên một từ",
+			location:  models.Location{Name: "Huế", Slug: "hue"},
+			wantKeys:  []string{"hue"},
+			wantTerms: []string{"hue"},
+		},
+		{
+			name:      "tên nhiều từ và tên gọi khác",
+			location:  models.Location{Name: "TP. Hồ Chí Minh", Slug: "ho-chi-minh", Aliases: []string{"Sài Gòn"}},
+			wantKeys:  []string{"tp ho chi minh", "ho chi minh", "sai gon"},
+			wantTerms: []string{"tp ho chi minh", "ho chi minh", "chi minh", "minh", "sai gon", "gon"},
+		},
+		{
+			name:      "tên gọi khác trùng tên sau khi bỏ dấu",
+			location:  models.Location{Name: "Đà Lạt", Slug: "da-lat", Aliases: []string{"da lat", "ĐÀ LẠT"}},
+			wantKeys:  []string{"da lat"},
+			wantTerms: []string{"da lat", "lat"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, terms := locationKeys(&tt.location)
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("keys = %q, muốn %q", keys, tt.wantKeys)
+			}
+			if !slices.Equal(terms, tt.wantTerms) {
+				t.Errorf("terms = %q, muốn %q", terms, tt.wantTerms)
+			}
+		})
+	}
+}
+
+// addLocations tạo các địa điểm dùng chung cho kiểm thử gợi ý và tìm kiếm theo địa điểm.
+func (e *testEnv) addLocations(t *testing.T) (*LocationService, map[string]*models.Location) {
+	t.Helper()
+	service := NewLocationService(e.repos.Locations)
+	created := map[string]*models.Location{}
+	for _, input := range []LocationInput{
+		{Name: "TP. Hồ Chí Minh", Aliases: []string{"Sài Gòn", "TP HCM"}},
+		{Name: "Hà Nội"},
+		{Name: "Hải Phòng"},
+		{Name: "Đà Lạt"},
+	} {
+		location, err := service.CreateLocation(input)
+		if err != nil {
+			t.Fatalf("CreateLocation %s: %v", input.Name, err)
+		}
+		created[location.Name] = location
+	}
+	return service, created
+}
+
+func TestSuggestLocations(t *testing.T) {
+	env := newTestEnv(t)
+	service, _ := env.addLocations(t)
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"ha", 0, []string{"Hà Nội", "Hải Phòng"}},
+		{"HÀ N", 0, []string{"Hà Nội"}},
+		{"chi minh", 0, []string{"TP. Hồ Chí Minh"}},
+		{"sai", 0, []string{"TP. Hồ Chí Minh"}},
+		{"hcm", 0, []string{"TP. Hồ Chí Minh"}},
+		{"lat", 0, []string{"Đà Lạt"}},
+		{"h", 1, []string{"Hà Nội"}},
+		{"  ", 0, []string{}},
+		{"xyz", 0, []string{}},
+	}
+	for _, tt := range tests {
+		locations, err := service.SuggestLocations(tt.query, tt.limit)
+		if err != nil {
+			t.Fatalf("SuggestLocations(%q): %v", tt.query, err)
+		}
+		names := []string{}
+		for _, location := range locations {
+			names = append(names, location.Name)
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("SuggestLocations(%q, %d) = %q, muốn %q", tt.query, tt.limit, names, tt.want)
+		}
+	}
+}
+
+func TestSearchTripsMatchesLocationID(t *testing.T) {
+	env := newTestEnv(t)
+	_, locations := env.addLocations(t)
+	hcm, dalat := locations["TP. Hồ Chí Minh"], locations["Đà Lạt"]
+	otherID := primitive.NewObjectID()
+
+	departure := time.Date(2030, 5, 10, 8, 0, 0, 0, env.location)
+	addRoute := func(from models.LocationPoint) models.Trip {
+		trip := env.addTrip(t, from.Name, dalat.Name, departure, "A1")
+		trip.Route.From = from
+		trip.Route.To.LocationID = &dalat.ID
+		env.putTrip(trip)
+		return trip
+	}
+	// Chuyến tạo trước khi địa điểm đổi tên vẫn mang tên cũ cùng locationId.
+	renamed := addRoute(models.LocationPoint{Name: "Sài Gòn", LocationID: &hcm.ID})
+	// Điểm nhập tự do không có locationId được so theo tên.
+	byName := addRoute(models.LocationPoint{Name: "TP. Hồ Chí Minh"})
+	// Cùng tên nhưng trỏ tới địa điểm khác.
+	addRoute(models.LocationPoint{Name: "TP. Hồ Chí Minh", LocationID: &otherID})
+
+	result, err := env.trips.SearchTrips(TripSearchQuery{From: "tp hcm", To: "da lat", Date: "2030-05-10"})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	var found []primitive.ObjectID
+	for _, trip := range result.Trips {
+		found = append(found, trip.ID)
+	}
+	if len(found) != 2 || !slices.Contains(found, renamed.ID) || !slices.Contains(found, byName.ID) {
+		t.Fatalf("kết quả = %v, muốn hai chuyến %s và %s", found, renamed.ID.Hex(), byName.ID.Hex())
+	}
+
+	days, err := env.trips.GetFareCalendar(FareCalendarQuery{From: "Sài Gòn", To: "Đà Lạt", Start: "2030-05-10", Days: 1})
+	if err != nil {
+		t.Fatalf("GetFareCalendar: %v", err)
+	}
+	if len(days) != 1 || days[0].Trips != 2 {
+		t.Fatalf("lịch giá vé = %+v, muốn 2 chuyến trong ngày", days)
+	}
+}
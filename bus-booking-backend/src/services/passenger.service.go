@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+type PassengerInput struct {
+	SeatNumber string `json:"seatNumber" validate:"required"`
+	Name       string `json:"name"`
+	Phone      string `json:"phone"`
+}
+
+// UpdatePassengersInput chứa thông tin hành khách theo từng ghế. Ghế không được gửi lên
+// (hoặc để trống tên) sẽ mặc định là chủ tài khoản.
+type UpdatePassengersInput struct {
+	Passengers []PassengerInput `json:"passengers" validate:"dive"`
+}
+
+var (
+	ErrInvalidPassengers       = apperrors.New(apperrors.ErrInvalidInput, "thông tin hành khách không hợp lệ").WithCode("invalid_passengers")
+	ErrPassengersLocked        = apperrors.New(apperrors.ErrConflict, "không thể cập nhật hành khách: booking không còn hiệu lực hoặc chuyến đi đã khởi hành").WithCode("passengers_locked")
+	ErrPassengerDetailsMissing = apperrors.New(apperrors.ErrUnprocessable, "vui lòng cập nhật họ tên hành khách cho tất cả các ghế trước khi thanh toán").WithCode("passenger_details_missing")
+)
+
+// Số điện thoại di động Việt Nam: 0xxxxxxxxx hoặc +84xxxxxxxxx với đầu số 3, 5, 7, 8, 9.
+var phonePattern = regexp.MustCompile(`^(0|\+84)[35789][0-9]{8}$`)
+
+func normalizePhone(phone string) string {
+	return strings.NewReplacer(" ", "", ".", "", "-", "").Replace(strings.TrimSpace(phone))
+}
+
+// UpdatePassengers cập nhật họ tên và số điện thoại hành khách cho từng ghế của booking.
+// Chỉ được phép khi booking đang "held" hoặc "confirmed" và chuyến đi chưa khởi hành.
+func (s *BookingService) UpdatePassengers(bookingIDStr, userIDStr string, input UpdatePassengersInput) (*models.Booking, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, err := findUserBooking(ctx, s.bookings, bookingIDStr, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingHeld && booking.Status != models.BookingConfirmed {
+		return nil, ErrPassengersLocked
+	}
+
+	trip, err := findBookingTrip(ctx, s.trips, booking)
+	if err != nil {
+		return nil, err
+	}
+	if boarding, _ := trip.SegmentTimes(bookingSegment(trip, booking)); !boarding.After(time.Now()) {
+		return nil, ErrPassengersLocked
+	}
+
+	user, err := s.users.FindByID(ctx, booking.UserID)
+	if err != nil {
+		log.Printf("Lỗi khi tìm người dùng %s: %v", userIDStr, err)
+		return nil, internalError("lỗi hệ thống khi truy vấn người dùng", err)
+	}
+
+	passengers, err := buildPassengers(booking.Passengers, input.Passengers, *user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	editable := []models.BookingStatus{models.BookingHeld, models.BookingConfirmed}
+	if err := s.bookings.UpdatePassengers(ctx, booking.ID, editable, passengers, now); err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			return nil, ErrPassengersLocked
+		}
+		log.Printf("Lỗi khi cập nhật hành khách cho booking %s: %v", bookingIDStr, err)
+		return nil, internalError("lỗi hệ thống khi cập nhật hành khách", err)
+	}
+
+	booking.Passengers = passengers
+	booking.UpdatedAt = now
+	return booking, nil
+}
+
+// buildPassengers ghép dữ liệu gửi lên vào danh sách ghế của booking: mỗi ghế đúng một hành khách,
+// ghế thiếu thông tin dùng họ tên và số điện thoại của chủ tài khoản.
+func buildPassengers(current []models.Passenger, inputs []PassengerInput, user models.User) ([]models.Passenger, error) {
+	bySeat := make(map[string]PassengerInput, len(inputs))
+	seats := make(map[string]bool, len(current))
+	for _, p := range current {
+		seats[p.SeatNumber] = true
+	}
+	for _, in := range inputs {
+		seat := strings.TrimSpace(in.SeatNumber)
+		if !seats[seat] {
+			return nil, fmt.Errorf("%w: ghế '%s' không thuộc booking này", ErrInvalidPassengers, seat)
+		}
+		if _, dup := bySeat[seat]; dup {
+			return nil, fmt.Errorf("%w: ghế '%s' có nhiều hơn một hành khách", ErrInvalidPassengers, seat)
+		}
+		bySeat[seat] = in
+	}
+
+	passengers := make([]models.Passenger, 0, len(current))
+	for _, p := range current {
+		in, ok := bySeat[p.SeatNumber]
+		name := strings.Join(strings.Fields(in.Name), " ")
+		phone := normalizePhone(in.Phone)
+		if !ok || name == "" {
+			name = user.Name
+			if phone == "" {
+				phone = normalizePhone(user.Phone)
+			}
+		}
+		if phone == "" {
+			phone = normalizePhone(user.Phone)
+		}
+
+		if n := utf8.RuneCountInString(name); n < 2 || n > 100 {
+			return nil, fmt.Errorf("%w: họ tên hành khách ghế '%s' phải có từ 2 đến 100 ký tự", ErrInvalidPassengers, p.SeatNumber)
+		}
+		if !phonePattern.MatchString(phone) {
+			return nil, fmt.Errorf("%w: số điện thoại '%s' của ghế '%s' không đúng định dạng", ErrInvalidPassengers, phone, p.SeatNumber)
+		}
+		passengers = append(passengers, models.Passenger{Name: name, Phone: phone, SeatNumber: p.SeatNumber})
+	}
+	return passengers, nil
+}
+
+func passengersComplete(passengers []models.Passenger) bool {
+	if len(passengers) == 0 {
+		return false
+	}
+	for _, p := range passengers {
+		if strings.TrimSpace(p.Name) == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+		valid bool
+	}{
+		{phone: "0901234567", want: "0901234567", valid: true},
+		{phone: " 090 123 45 67 ", want: "0901234567", valid: true},
+		{phone: "090.123.4567", want: "0901234567", valid: true},
+		{phone: "090-123-4567", want: "0901234567", valid: true},
+		{phone: "+84 912 345 678", want: "+84912345678", valid: true},
+		// Đầu số 1, 2, 4, 6 không phải số di động.
+		{phone: "0123456789", want: "0123456789"},
+		{phone: "+84123456789", want: "+84123456789"},
+		{phone: "090123456", want: "090123456"},
+		{phone: "09012345678", want: "09012345678"},
+		{phone: "84901234567", want: "84901234567"},
+		{phone: "0901 abc 567", want: "0901abc567"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			got := normalizePhone(tt.phone)
+			if got != tt.want {
+				t.Fatalf("normalizePhone(%q) = %q, muốn %q", tt.phone, got, tt.want)
+			}
+			if valid := phonePattern.MatchString(got); valid != tt.valid {
+				t.Fatalf("phonePattern(%q) = %v, muốn %v", got, valid, tt.valid)
+			}
+		})
+	}
+}
+
+func TestUpdatePassengers(t *testing.T) {
+	// Chủ tài khoản do addUser tạo.
+	holder := models.Passenger{Name: "Nguyễn Văn A", Phone: "0901234567"}
+
+	tests := []struct {
+		name   string
+		inputs []PassengerInput
+		// want là hành khách theo ghế sau khi cập nhật; nil khi muốn lỗi ErrInvalidPassengers.
+		want map[string]models.Passenger
+	}{
+		{
+			name: "mặc định là chủ tài khoản",
+			want: map[string]models.Passenger{"A1": holder, "A2": holder},
+		},
+		{
+			name: "chuẩn hóa họ tên và số điện thoại",
+			inputs: []PassengerInput{
+				{SeatNumber: "A1", Name: "  Trần   Thị  B ", Phone: "090 765.43-21"},
+				{SeatNumber: " A2 ", Name: "Lê Văn C", Phone: "+84 912 345 678"},
+			},
+			want: map[string]models.Passenger{
+				"A1": {Name: "Trần Thị B", Phone: "0907654321"},
+				"A2": {Name: "Lê Văn C", Phone: "+84912345678"},
+			},
+		},
+		{
+			name:   "ghế không gửi lên dùng chủ tài khoản",
+			inputs: []PassengerInput{{SeatNumber: "A1", Name: "Trần Thị B", Phone: "0907654321"}},
+			want: map[string]models.Passenger{
+				"A1": {Name: "Trần Thị B", Phone: "0907654321"},
+				"A2": holder,
+			},
+		},
+		{
+			name:   "ghế để trống tên dùng chủ tài khoản",
+			inputs: []PassengerInput{{SeatNumber: "A1", Name: "  ", Phone: "0907654321"}},
+			want: map[string]models.Passenger{
+				"A1": {Name: holder.Name, Phone: "0907654321"},
+				"A2": holder,
+			},
+		},
+		{
+			name:   "thiếu số điện thoại dùng số của chủ tài khoản",
+			inputs: []PassengerInput{{SeatNumber: "A1", Name: "Trần Thị B"}},
+			want: map[string]models.Passenger{
+				"A1": {Name: "Trần Thị B", Phone: holder.Phone},
+				"A2": holder,
+			},
+		},
+		{
+			name: "một ghế hai hành khách",
+			inputs: []PassengerInput{
+				{SeatNumber: "A1", Name: "Trần Thị B", Phone: "0907654321"},
+				{SeatNumber: "A1", Name: "Lê Văn C", Phone: "0912345678"},
+			},
+		},
+		{
+			name:   "ghế không thuộc booking",
+			inputs: []PassengerInput{{SeatNumber: "A3", Name: "Trần Thị B", Phone: "0907654321"}},
+		},
+		{
+			name:   "số điện thoại sai định dạng",
+			inputs: []PassengerInput{{SeatNumber: "A1", Name: "Trần Thị B", Phone: "0123456789"}},
+		},
+		{
+			name:   "họ tên quá ngắn",
+			inputs: []PassengerInput{{SeatNumber: "A1", Name: "B", Phone: "0907654321"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1", "A2", "A3")
+			user := env.addUser(t)
+			booking, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1", "A2"}}, user.ID.Hex())
+			if err != nil {
+				t.Fatalf("CreateBooking: %v", err)
+			}
+
+			updated, err := env.bookings.UpdatePassengers(booking.ID.Hex(), user.ID.Hex(), UpdatePassengersInput{Passengers: tt.inputs})
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidPassengers) {
+					t.Fatalf("UpdatePassengers: err = %v, muốn %v", err, ErrInvalidPassengers)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdatePassengers: %v", err)
+			}
+
+			stored, err := env.repos.Bookings.FindByID(t.Context(), booking.ID)
+			if err != nil {
+				t.Fatalf("đọc booking: %v", err)
+			}
+			for _, passengers := range [][]models.Passenger{updated.Passengers, stored.Passengers} {
+				if len(passengers) != len(tt.want) {
+					t.Fatalf("hành khách = %+v, muốn mỗi ghế một hành khách %+v", passengers, tt.want)
+				}
+				for _, p := range passengers {
+					want := tt.want[p.SeatNumber]
+					if p.Name != want.Name || p.Phone != want.Phone {
+						t.Fatalf("ghế %s: %q %q, muốn %q %q", p.SeatNumber, p.Name, p.Phone, want.Name, want.Phone)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+	"github.com/Go_final_exam/bus-booking-backend/src/realtime"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"github.com/Go_final_exam/bus-booking-backend/src/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const paymentCurrency = "VND"
+
+const maxTicketCodeAttempts = 5
+
+// Số lần thử và khoảng chờ tăng dần giữa các lần ghi trạng thái ghế sau khi booking đã được xác nhận.
+const (
+	seatWriteAttempts = 3
+	seatWriteBackoff  = 100 * time.Millisecond
+)
+
+var (
+	ErrBookingNotPayable = apperrors.New(apperrors.ErrConflict, "booking không còn ở trạng thái chờ thanh toán").WithCode("booking_not_payable")
+	ErrPaymentFailed     = apperrors.New(apperrors.ErrPaymentRequired, "thanh toán thất bại").WithCode("payment_failed")
+	ErrPaymentTimeout    = apperrors.New(apperrors.ErrTimeout, "cổng thanh toán không phản hồi, vui lòng thử lại sau").WithCode("payment_timeout")
+	ErrSeatSyncPending   = apperrors.New(apperrors.ErrInternal, "thanh toán đã được ghi nhận nhưng chưa cập nhật được ghế, hệ thống sẽ tự đồng bộ lại trong ít phút").WithCode("seat_sync_pending")
+)
+
+// PaymentService thu tiền cho booking qua cổng thanh toán và xử lý webhook của cổng.
+type PaymentService struct {
+	bookings repositories.BookingRepository
+	trips    repositories.TripRepository
+	payments repositories.PaymentRepository
+	events   repositories.WebhookEventRepository
+	gateway  payments.PaymentGateway
+	// seatEvents nhận các thay đổi trạng thái ghế để đẩy tới người đang xem sơ đồ ghế.
+	seatEvents realtime.SeatEventBus
+}
+
+func NewPaymentService(
+	bookings repositories.BookingRepository,
+	trips repositories.TripRepository,
+	paymentRepo repositories.PaymentRepository,
+	events repositories.WebhookEventRepository,
+	gateway payments.PaymentGateway,
+	seatEvents realtime.SeatEventBus,
+) *PaymentService {
+	return &PaymentService{
+		bookings:   bookings,
+		trips:      trips,
+		payments:   paymentRepo,
+		events:     events,
+		gateway:    gateway,
+		seatEvents: seatEvents,
+	}
+}
+
+// PayBooking thu tiền cho một booking đang "held" của người dùng. Khi thu tiền thành công,
+// booking chuyển sang "confirmed" và các ghế chuyển từ "held" sang "booked".
+func (s *PaymentService) PayBooking(bookingIDStr, userIDStr string) (*models.Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	booking, err := findUserBooking(ctx, s.bookings, bookingIDStr, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := models.ValidateBookingTransition(booking.Status, models.BookingConfirmed); err != nil {
+		return nil, err
+	}
+	if booking.HoldExpiresAt != nil && !booking.HoldExpiresAt.After(now) {
+		return nil, ErrBookingNotPayable
+	}
+	if !passengersComplete(booking.Passengers) {
+		return nil, ErrPassengerDetailsMissing
+	}
+
+	intent, err := s.gateway.CreateIntent(ctx, payments.IntentRequest{
+		BookingID: booking.ID,
+		Amount:    booking.TotalAmount,
+		Currency:  paymentCurrency,
+	})
+	if err != nil {
+		log.Printf("Lỗi khi tạo giao dịch cho booking %s: %v", bookingIDStr, err)
+		if errors.Is(err, payments.ErrGatewayTimeout) {
+			return nil, ErrPaymentTimeout
+		}
+		return nil, ErrPaymentFailed
+	}
+
+	payment := models.Payment{
+		ID:        primitive.NewObjectID(),
+		BookingID: booking.ID,
+		UserID:    booking.UserID,
+		Provider:  s.gateway.Name(),
+		IntentID:  intent.ID,
+		Amount:    booking.TotalAmount,
+		Currency:  paymentCurrency,
+		Status:    "pending",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.payments.Create(ctx, &payment); err != nil {
+		log.Printf("Lỗi khi lưu giao dịch cho booking %s: %v", bookingIDStr, err)
+		return nil, internalError("lỗi hệ thống khi tạo giao dịch thanh toán", err)
+	}
+
+	capture, err := s.gateway.Capture(ctx, intent.ID)
+	if err != nil {
+		if errors.Is(err, payments.ErrGatewayTimeout) {
+			// Giao dịch giữ nguyên "pending": kết quả cuối cùng sẽ do cổng thanh toán báo về sau.
+			return nil, ErrPaymentTimeout
+		}
+		s.setPaymentStatus(ctx, &payment, "failed", err.Error())
+		return nil, ErrPaymentFailed
+	}
+
+	payment.TransactionID = capture.TransactionID
+	if err := s.confirmBookingPayment(ctx, booking, &payment, models.UserActor(userIDStr)); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// confirmBookingPayment ghi nhận một giao dịch đã thu tiền thành công vào booking và ghế.
+// Nếu booking không còn ở trạng thái "held" (ví dụ đã hết hạn giữ chỗ trong lúc thanh toán),
+// số tiền sẽ được hoàn lại và hàm trả về ErrBookingNotPayable. Nếu booking đã được xác nhận
+// nhưng không chuyển được ghế sang "booked", hàm trả về ErrSeatSyncPending.
+func (s *PaymentService) confirmBookingPayment(ctx context.Context, booking *models.Booking, payment *models.Payment, actor string) error {
+	paid := "paid"
+	var err error
+	// Ràng buộc unique trên ticketCode là bảo đảm cuối cùng; khi trùng mã (rất hiếm) thì sinh mã khác.
+	for attempt := 0; attempt < maxTicketCodeAttempts; attempt++ {
+		ticketCode, genErr := utils.GenerateTicketCode()
+		if genErr != nil {
+			log.Printf("Lỗi khi sinh mã vé cho booking %s: %v", booking.ID.Hex(), genErr)
+			return internalError("lỗi hệ thống khi sinh mã vé", genErr)
+		}
+		err = transitionBooking(ctx, s.bookings, booking, bookingTransition{
+			To:     models.BookingConfirmed,
+			Actor:  actor,
+			Reason: "thanh toán thành công qua " + payment.Provider,
+			Fields: repositories.BookingFields{
+				PaymentStatus:   &paid,
+				PaymentID:       &payment.ID,
+				TicketCode:      &ticketCode,
+				ClearHoldExpiry: true,
+			},
+		})
+		if !errors.Is(err, repositories.ErrDuplicate) {
+			break
+		}
+	}
+	if err != nil {
+		if !errors.Is(err, ErrBookingStateChanged) && !errors.Is(err, models.ErrInvalidTransition) {
+			return err
+		}
+		log.Printf("Booking %s không còn ở trạng thái held khi thanh toán, tiến hành hoàn tiền", booking.ID.Hex())
+		if _, refundErr := s.gateway.Refund(ctx, payments.RefundRequest{
+			IntentID: payment.IntentID,
+			Amount:   payment.Amount,
+			Reason:   "booking không còn chờ thanh toán",
+		}); refundErr != nil {
+			log.Printf("Lỗi khi hoàn tiền giao dịch %s: %v", payment.IntentID, refundErr)
+			s.setPaymentStatus(ctx, payment, "succeeded", "")
+		} else {
+			s.setPaymentStatus(ctx, payment, "refunded", "booking không còn chờ thanh toán")
+		}
+		return ErrBookingNotPayable
+	}
+
+	// Tiền đã thu nên giao dịch luôn được ghi nhận; nếu vẫn không ghi được ghế sau khi thử lại,
+	// báo lỗi thay vì báo thanh toán thành công, và để worker giữ chỗ đồng bộ ghế sau.
+	s.setPaymentStatus(ctx, payment, "succeeded", "")
+	if err := s.bookSeats(ctx, booking); err != nil {
+		return ErrSeatSyncPending
+	}
+	return nil
+}
+
+// bookSeats chuyển ghế của booking đã thanh toán từ "held" sang "booked", thử lại khi gặp lỗi.
+// Lệnh ghi chỉ đổi các chặng còn "held" nên chạy lại nhiều lần vẫn an toàn.
+func (s *PaymentService) bookSeats(ctx context.Context, booking *models.Booking) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = updateSeatStatus(ctx, s.trips, booking, models.SeatHeld, models.SeatBooked); err == nil {
+			publishSeatEvent(s.seatEvents, booking, models.SeatBooked)
+			return nil
+		}
+		log.Printf("Lỗi khi chuyển ghế sang booked cho booking %s (lần %d): %v", booking.ID.Hex(), attempt, err)
+		if attempt == seatWriteAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * seatWriteBackoff):
+		}
+	}
+}
+
+func (s *PaymentService) setPaymentStatus(ctx context.Context, payment *models.Payment, status, reason string) {
+	payment.Status = status
+	payment.FailureReason = reason
+	payment.UpdatedAt = time.Now()
+
+	if err := s.payments.UpdateStatus(ctx, payment); err != nil {
+		log.Printf("Lỗi khi cập nhật giao dịch %s: %v", payment.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+)
+
+func TestPayBookingOutcomes(t *testing.T) {
+	tests := []struct {
+		outcome       payments.MockOutcome
+		wantErr       error
+		wantBooking   models.BookingStatus
+		wantSeat      models.SeatStatus
+		wantPayment   string
+		wantTicketSet bool
+	}{
+		{payments.MockOutcomeSuccess, nil, models.BookingConfirmed, models.SeatBooked, "succeeded", true},
+		{payments.MockOutcomeFailure, ErrPaymentFailed, models.BookingHeld, models.SeatHeld, "failed", false},
+		{payments.MockOutcomeTimeout, ErrPaymentTimeout, models.BookingHeld, models.SeatHeld, "pending", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.outcome), func(t *testing.T) {
+			env := newTestEnv(t)
+			env.gateway.SetOutcome(tt.outcome)
+			trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+			user := env.addUser(t)
+
+			booking, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+			if err != nil {
+				t.Fatalf("CreateBooking: %v", err)
+			}
+			if _, err := env.bookings.UpdatePassengers(booking.ID.Hex(), user.ID.Hex(), UpdatePassengersInput{}); err != nil {
+				t.Fatalf("UpdatePassengers: %v", err)
+			}
+
+			_, err = env.payments.PayBooking(booking.ID.Hex(), user.ID.Hex())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PayBooking: lỗi = %v, muốn %v", err, tt.wantErr)
+			}
+
+			stored, err := env.repos.Bookings.FindByID(t.Context(), booking.ID)
+			if err != nil {
+				t.Fatalf("đọc booking: %v", err)
+			}
+			if stored.Status != tt.wantBooking || (stored.TicketCode != "") != tt.wantTicketSet {
+				t.Fatalf("booking: trạng thái = %s, mã vé = %q", stored.Status, stored.TicketCode)
+			}
+			if status := env.seatStatuses(t, trip.ID)["A1"]; status != tt.wantSeat {
+				t.Fatalf("ghế A1 = %s, muốn %s", status, tt.wantSeat)
+			}
+			// Cổng giả lập đánh số giao dịch tuần tự nên giao dịch đầu tiên luôn là mock_pi_000001.
+			recorded, err := env.repos.Payments.FindByIntent(t.Context(), env.gateway.Name(), "mock_pi_000001")
+			if err != nil || recorded.Status != tt.wantPayment || recorded.BookingID != booking.ID {
+				t.Fatalf("giao dịch = %+v, err = %v; muốn giao dịch %s của booking", recorded, err, tt.wantPayment)
+			}
+		})
+	}
+}
+
+func TestPayBookingRequiresPassengers(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+	user := env.addUser(t)
+
+	booking, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+	if err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+	if _, err := env.payments.PayBooking(booking.ID.Hex(), user.ID.Hex()); !errors.Is(err, ErrPassengerDetailsMissing) {
+		t.Fatalf("lỗi = %v, muốn ErrPassengerDetailsMissing", err)
+	}
+}
+
+func TestPayBookingSeatWriteFailure(t *testing.T) {
+	env := newTestEnv(t)
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+	user := env.addUser(t)
+
+	booking, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+	if err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+	if _, err := env.bookings.UpdatePassengers(booking.ID.Hex(), user.ID.Hex(), UpdatePassengersInput{}); err != nil {
+		t.Fatalf("UpdatePassengers: %v", err)
+	}
+
+	// Booking được xác nhận nhưng mọi lần ghi ghế đều thất bại: không được báo thanh toán thành công.
+	failing := *env.payments
+	failing.trips = failingSeatLegs{env.repos.Trips}
+	if _, err := failing.PayBooking(booking.ID.Hex(), user.ID.Hex()); !errors.Is(err, ErrSeatSyncPending) {
+		t.Fatalf("PayBooking: lỗi = %v, muốn ErrSeatSyncPending", err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatHeld {
+		t.Fatalf("ghế A1 = %s, muốn vẫn held sau lỗi ghi", status)
+	}
+
+	// Worker giữ chỗ đồng bộ ghế theo booking đã xác nhận, sau đó hủy vé trả được ghế.
+	if n, err := env.bookings.ReconcileHeldSeats(t.Context(), time.Now()); err != nil || n != 1 {
+		t.Fatalf("ReconcileHeldSeats: n = %d, err = %v", n, err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatBooked {
+		t.Fatalf("ghế A1 = %s, muốn booked", status)
+	}
+	if _, err := env.bookings.CancelBooking(booking.ID.Hex(), user.ID.Hex(), CancelBookingInput{}); err != nil {
+		t.Fatalf("CancelBooking: %v", err)
+	}
+	if status := env.seatStatuses(t, trip.ID)["A1"]; status != models.SeatAvailable {
+		t.Fatalf("ghế A1 = %s, muốn available sau khi hủy", status)
+	}
+}
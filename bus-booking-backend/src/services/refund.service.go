@@ -0,0 +1,56 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+// DefaultRefundPolicy được áp dụng cho các nhà xe chưa cấu hình chính sách riêng:
+// hoàn 100% nếu hủy trước 48 giờ, 50% nếu trước 6 giờ, sau đó không hoàn tiền.
+var DefaultRefundPolicy = models.RefundPolicy{
+	Tiers: []models.RefundTier{
+		{MinHoursBeforeDeparture: 48, Percent: 100},
+		{MinHoursBeforeDeparture: 6, Percent: 50},
+	},
+}
+
+// ValidateRefundPolicy kiểm tra các mức hoàn tiền có giá trị hợp lệ và không bị trùng.
+func ValidateRefundPolicy(policy models.RefundPolicy) error {
+	seen := make(map[float64]bool, len(policy.Tiers))
+	for _, tier := range policy.Tiers {
+		if tier.MinHoursBeforeDeparture < 0 {
+			return apperrors.New(apperrors.ErrInvalidInput, "số giờ trước khởi hành của chính sách hoàn tiền không được âm")
+		}
+		if tier.Percent < 0 || tier.Percent > 100 {
+			return apperrors.New(apperrors.ErrInvalidInput, "tỉ lệ hoàn tiền phải nằm trong khoảng 0-100")
+		}
+		if seen[tier.MinHoursBeforeDeparture] {
+			return apperrors.New(apperrors.ErrInvalidInput, "chính sách hoàn tiền có các mức trùng số giờ")
+		}
+		seen[tier.MinHoursBeforeDeparture] = true
+	}
+	return nil
+}
+
+// ComputeRefund trả về tỉ lệ và số tiền được hoàn khi hủy vé vào thời điểm now.
+// Mức được chọn là mức có số giờ lớn nhất mà thời gian còn lại tới giờ khởi hành vượt quá.
+func ComputeRefund(policy models.RefundPolicy, paidAmount float64, departure, now time.Time) (float64, float64) {
+	tiers := make([]models.RefundTier, len(policy.Tiers))
+	copy(tiers, policy.Tiers)
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinHoursBeforeDeparture > tiers[j].MinHoursBeforeDeparture
+	})
+
+	hoursBefore := departure.Sub(now).Hours()
+	for _, tier := range tiers {
+		if hoursBefore > tier.MinHoursBeforeDeparture {
+			// Làm tròn tới đồng để tránh sai số dấu phẩy động khi cộng dồn.
+			return tier.Percent, math.Round(paidAmount * tier.Percent / 100)
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+var (
+	ErrInvalidScheduleID    = apperrors.New(apperrors.ErrInvalidID, "ID lịch chạy không hợp lệ").WithCode("invalid_schedule_id")
+	ErrScheduleNotFound     = apperrors.New(apperrors.ErrNotFound, "không tìm thấy lịch chạy").WithCode("schedule_not_found")
+	ErrInvalidScheduleDates = apperrors.New(apperrors.ErrInvalidInput, "validTo phải cùng ngày hoặc sau validFrom").WithCode("invalid_schedule_dates")
+)
+
+// ScheduleInput là dữ liệu tạo hoặc cập nhật lịch chạy. Ngày dạng "YYYY-MM-DD" và giờ dạng
+// "HH:MM" được hiểu theo múi giờ vận hành.
+type ScheduleInput struct {
+	CompanyID       string         `json:"companyId" validate:"required"`
+	VehicleID       string         `json:"vehicleId" validate:"required"`
+	Route           models.Route   `json:"route" validate:"required"`
+	Price           float64        `json:"price" validate:"required,gt=0"`
+	Fares           []models.Fare  `json:"fares" validate:"omitempty,dive"`
+	DepartureTime   string         `json:"departureTime" validate:"required,datetime=15:04"`
+	DurationMinutes int            `json:"durationMinutes" validate:"required,min=1,max=4320"`
+	Weekdays        []time.Weekday `json:"weekdays" validate:"required,min=1,max=7,dive,gte=0,lte=6"`
+	ValidFrom       string         `json:"validFrom" validate:"required,datetime=2006-01-02"`
+	ValidTo         string         `json:"validTo" validate:"omitempty,datetime=2006-01-02"`
+	ExceptionDates  []string       `json:"exceptionDates" validate:"omitempty,dive,datetime=2006-01-02"`
+}
+
+// ScheduleService quản lý lịch chạy lặp lại và sinh trước các chuyến đi từ lịch.
+type ScheduleService struct {
+	schedules repositories.ScheduleRepository
+	trips     repositories.TripRepository
+	vehicles  repositories.VehicleRepository
+	companies repositories.CompanyRepository
+	bookings  repositories.BookingRepository
+	locations repositories.LocationRepository
+	// location là múi giờ dùng để hiểu ngày và giờ khởi hành của lịch.
+	location *time.Location
+	// horizonDays là số ngày (tính cả hôm nay) được sinh chuyến đi trước.
+	horizonDays int
+}
+
+func NewScheduleService(
+	schedules repositories.ScheduleRepository,
+	trips repositories.TripRepository,
+	vehicles repositories.VehicleRepository,
+	companies repositories.CompanyRepository,
+	bookings repositories.BookingRepository,
+	locations repositories.LocationRepository,
+	location *time.Location,
+	horizonDays int,
+) *ScheduleService {
+	return &ScheduleService{
+		schedules:   schedules,
+		trips:       trips,
+		vehicles:    vehicles,
+		companies:   companies,
+		bookings:    bookings,
+		locations:   locations,
+		location:    location,
+		horizonDays: horizonDays,
+	}
+}
+
+// CreateSchedule tạo lịch chạy và sinh ngay các chuyến đi trong khoảng ngày sinh trước.
+func (s *ScheduleService) CreateSchedule(input ScheduleInput, actor *AccessClaims) (*models.Schedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vehicle, err := s.checkScheduleInput(ctx, &input, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule := models.Schedule{ID: primitive.NewObjectID(), CreatedAt: now}
+	applyScheduleInput(&schedule, input, vehicle, now)
+	if err := s.schedules.Create(ctx, &schedule); err != nil {
+		log.Printf("Lỗi khi tạo lịch chạy cho nhà xe %s: %v", input.CompanyID, err)
+		return nil, internalError("không thể tạo lịch chạy", err)
+	}
+
+	if _, err := s.generateForSchedule(ctx, &schedule, now); err != nil {
+		log.Printf("Lỗi khi sinh chuyến đi cho lịch chạy mới %s: %v", schedule.ID.Hex(), err)
+	}
+	return &schedule, nil
+}
+
+// UpdateSchedule ghi đè lịch chạy. Thay đổi chỉ áp dụng cho các chuyến được sinh sau đó, trừ giờ
+// khởi hành và thời gian chạy: các chuyến sắp tới chưa có booking được dời theo giờ mới để mỗi
+// ngày vẫn chỉ có một chuyến.
+func (s *ScheduleService) UpdateSchedule(scheduleIDStr string, input ScheduleInput, actor *AccessClaims) (*models.Schedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	schedule, err := s.findManagedSchedule(ctx, scheduleIDStr, actor)
+	if err != nil {
+		return nil, err
+	}
+	vehicle, err := s.checkScheduleInput(ctx, &input, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	timesChanged := schedule.DepartureTime != input.DepartureTime || schedule.DurationMinutes != input.DurationMinutes
+	now := time.Now()
+	applyScheduleInput(schedule, input, vehicle, now)
+	if err := s.schedules.Update(ctx, schedule); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		log.Printf("Lỗi khi cập nhật lịch chạy %s: %v", scheduleIDStr, err)
+		return nil, internalError("không thể cập nhật lịch chạy", err)
+	}
+
+	if timesChanged {
+		if n, err := s.retimeTrips(ctx, schedule, now); err != nil {
+			log.Printf("Lỗi khi dời giờ các chuyến của lịch chạy %s: %v", scheduleIDStr, err)
+		} else if n > 0 {
+			log.Printf("Đã dời giờ %d chuyến của lịch chạy %s", n, scheduleIDStr)
+		}
+	}
+	return schedule, nil
+}
+
+// retimeTrips dời các chuyến sắp tới chưa có booking của lịch sang giờ khởi hành và thời gian chạy
+// hiện tại của lịch. Chuyến có booking, chuyến đã được sửa tay sang ngày khác và chuyến mà giờ
+// mới đã qua được giữ nguyên. Trả về số chuyến đã dời.
+func (s *ScheduleService) retimeTrips(ctx context.Context, schedule *models.Schedule, now time.Time) (int, error) {
+	upcoming, err := s.trips.Find(ctx, repositories.TripFilter{ScheduleID: &schedule.ID, DepartureFrom: now})
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	for _, trip := range upcoming {
+		date := trip.DepartureTime.In(s.location).Format(models.DateLayout)
+		if slices.Contains(schedule.SkippedDates, date) {
+			continue
+		}
+		departure, arrival, err := schedule.DepartureOn(date, s.location)
+		if err != nil {
+			return moved, err
+		}
+		if !departure.After(now) || (departure.Equal(trip.DepartureTime) && arrival.Equal(trip.ExpectedArrivalTime)) {
+			continue
+		}
+		count, err := s.bookings.CountByTrip(ctx, trip.ID)
+		if err != nil {
+			return moved, err
+		}
+		if count > 0 {
+			continue
+		}
+		trip.DepartureTime, trip.ExpectedArrivalTime, trip.UpdatedAt = departure, arrival, now
+		// Replace từ chối nếu có người vừa giữ ghế sau lần đếm booking ở trên.
+		if err := s.trips.Replace(ctx, &trip); err != nil {
+			if errors.Is(err, repositories.ErrConflict) || errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrDuplicate) {
+				continue
+			}
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// DisableSchedule ngừng sinh chuyến đi từ lịch và xóa các chuyến sắp tới do lịch sinh ra mà
+// chưa có booking. Chuyến đã có booking được giữ nguyên. Trả về lịch chạy và số chuyến đã xóa.
+func (s *ScheduleService) DisableSchedule(scheduleIDStr string, actor *AccessClaims) (*models.Schedule, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	schedule, err := s.findManagedSchedule(ctx, scheduleIDStr, actor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	if schedule.IsActive() {
+		schedule.DisabledAt = &now
+		schedule.UpdatedAt = now
+		if err := s.schedules.Update(ctx, schedule); err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				return nil, 0, ErrScheduleNotFound
+			}
+			log.Printf("Lỗi khi ngừng lịch chạy %s: %v", scheduleIDStr, err)
+			return nil, 0, internalError("không thể ngừng lịch chạy", err)
+		}
+	}
+
+	upcoming, err := s.trips.Find(ctx, repositories.TripFilter{ScheduleID: &schedule.ID, DepartureFrom: now})
+	if err != nil {
+		log.Printf("Lỗi khi tìm chuyến đi của lịch chạy %s: %v", scheduleIDStr, err)
+		return nil, 0, internalError("lỗi máy chủ khi truy vấn chuyến đi", err)
+	}
+	removed := 0
+	for _, trip := range upcoming {
+		count, err := s.bookings.CountByTrip(ctx, trip.ID)
+		if err != nil {
+			log.Printf("Lỗi khi đếm booking của chuyến đi %s: %v", trip.ID.Hex(), err)
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		// Delete từ chối nếu có người vừa giữ ghế sau lần đếm booking ở trên.
+		if err := s.trips.Delete(ctx, trip.ID); err != nil {
+			if !errors.Is(err, repositories.ErrConflict) && !errors.Is(err, repositories.ErrNotFound) {
+				log.Printf("Lỗi khi xóa chuyến đi %s của lịch chạy %s: %v", trip.ID.Hex(), scheduleIDStr, err)
+			}
+			continue
+		}
+		removed++
+	}
+	return schedule, removed, nil
+}
+
+// ListSchedules liệt kê lịch chạy. Nhân viên nhà xe luôn chỉ thấy lịch của nhà xe mình; quản
+// trị viên có thể lọc theo companyIDStr hoặc để trống để xem tất cả.
+func (s *ScheduleService) ListSchedules(companyIDStr string, actor *AccessClaims) ([]models.Schedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if actor.Role == models.RoleOperatorStaff {
+		companyIDStr = actor.CompanyID
+	}
+
+	filter := repositories.ScheduleFilter{}
+	if companyIDStr != "" {
+		companyID, err := primitive.ObjectIDFromHex(companyIDStr)
+		if err != nil {
+			return nil, ErrInvalidCompanyID
+		}
+		if err := authorizeCompany(actor, companyID); err != nil {
+			return nil, err
+		}
+		filter.CompanyID = &companyID
+	}
+
+	schedules, err := s.schedules.List(ctx, filter)
+	if err != nil {
+		log.Printf("Lỗi khi liệt kê lịch chạy: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn lịch chạy", err)
+	}
+	return schedules, nil
+}
+
+// StartGeneratorWorker chạy nền, định kỳ sinh chuyến đi từ các lịch chạy đang hoạt động.
+// Worker dừng khi ctx bị hủy.
+func (s *ScheduleService) StartGeneratorWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Worker sinh chuyến đi từ lịch chạy đã khởi động (sinh trước %d ngày, chu kỳ: %s)", s.horizonDays, interval)
+	for {
+		if n, err := s.GenerateTrips(ctx, time.Now()); err != nil {
+			log.Printf("Lỗi khi sinh chuyến đi từ lịch chạy: %v", err)
+		} else if n > 0 {
+			log.Printf("Đã sinh %d chuyến đi từ lịch chạy", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GenerateTrips sinh chuyến đi cho mọi lịch chạy đang hoạt động trong horizonDays ngày kể từ
+// ngày của now. Chạy lại nhiều lần không tạo chuyến trùng. Trả về số chuyến mới được tạo.
+func (s *ScheduleService) GenerateTrips(ctx context.Context, now time.Time) (int, error) {
+	schedules, err := s.schedules.List(ctx, repositories.ScheduleFilter{ActiveOnly: true})
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for i := range schedules {
+		n, err := s.generateForSchedule(ctx, &schedules[i], now)
+		if err != nil {
+			log.Printf("Lỗi khi sinh chuyến đi cho lịch chạy %s: %v", schedules[i].ID.Hex(), err)
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// generateForSchedule tạo các chuyến còn thiếu của một lịch chạy. Lịch của nhà xe đã ngừng
+// hoạt động hoặc có xe không còn hợp lệ bị bỏ qua.
+func (s *ScheduleService) generateForSchedule(ctx context.Context, schedule *models.Schedule, now time.Time) (int, error) {
+	company, err := s.companies.FindByID(ctx, schedule.CompanyID)
+	if err != nil {
+		return 0, err
+	}
+	if !company.IsActive() {
+		return 0, nil
+	}
+	vehicle, err := s.vehicles.FindByID(ctx, schedule.VehicleID)
+	if err != nil {
+		return 0, err
+	}
+	if vehicle.SeatMap == nil || vehicle.CompanyID != schedule.CompanyID {
+		return 0, ErrVehicleWithoutSeatMap
+	}
+
+	// Mỗi ngày chỉ có một chuyến của lịch, kể cả khi chuyến đã được dời giờ hoặc lịch đã đổi giờ
+	// khởi hành. Đọc từ đầu ngày để chuyến đã chạy hôm nay cũng được tính; chỉ mục unique
+	// (scheduleId, departureTime) vẫn chặn trùng khi hai worker chạy cùng lúc.
+	today := now.In(s.location)
+	startOfToday := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, s.location)
+	existing, err := s.trips.Find(ctx, repositories.TripFilter{ScheduleID: &schedule.ID, DepartureFrom: startOfToday})
+	if err != nil {
+		return 0, err
+	}
+	generated := make(map[string]bool, len(existing))
+	for _, trip := range existing {
+		generated[trip.DepartureTime.In(s.location).Format(models.DateLayout)] = true
+	}
+
+	created := 0
+	for i := 0; i < s.horizonDays; i++ {
+		date := time.Date(today.Year(), today.Month(), today.Day()+i, 0, 0, 0, 0, s.location).Format(models.DateLayout)
+		if !schedule.RunsOn(date) {
+			continue
+		}
+		departure, arrival, err := schedule.DepartureOn(date, s.location)
+		if err != nil {
+			return created, err
+		}
+		if !departure.After(now) || generated[date] {
+			continue
+		}
+
+		scheduleID := schedule.ID
+		trip := models.Trip{
+			ID:                  primitive.NewObjectID(),
+			CompanyID:           schedule.CompanyID,
+			VehicleID:           schedule.VehicleID,
+			ScheduleID:          &scheduleID,
+			Route:               schedule.Route,
+			DepartureTime:       departure,
+			ExpectedArrivalTime: arrival,
+			Price:               schedule.Price,
+			Fares:               schedule.Fares,
+			Seats:               seatsFromMap(vehicle.SeatMap),
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		}
+		if err := s.trips.Create(ctx, &trip); err != nil {
+			if errors.Is(err, repositories.ErrDuplicate) {
+				continue
+			}
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// checkScheduleInput kiểm tra khoảng ngày, chuẩn hóa tuyến đường của input rồi kiểm tra nhà xe và xe.
+func (s *ScheduleService) checkScheduleInput(ctx context.Context, input *ScheduleInput, actor *AccessClaims) (*models.Vehicle, error) {
+	if input.ValidTo != "" && input.ValidTo < input.ValidFrom {
+		return nil, ErrInvalidScheduleDates
+	}
+	route, fares, err := normalizeRoute(ctx, s.locations, input.Route, input.Fares, time.Duration(input.DurationMinutes)*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	input.Route, input.Fares = route, fares
+	return checkCompanyVehicle(ctx, s.companies, s.vehicles, input.CompanyID, input.VehicleID, actor)
+}
+
+func applyScheduleInput(schedule *models.Schedule, input ScheduleInput, vehicle *models.Vehicle, now time.Time) {
+	schedule.CompanyID = vehicle.CompanyID
+	schedule.VehicleID = vehicle.ID
+	schedule.Route = input.Route
+	schedule.Price = input.Price
+	schedule.Fares = input.Fares
+	schedule.DepartureTime = input.DepartureTime
+	schedule.DurationMinutes = input.DurationMinutes
+	schedule.Weekdays = input.Weekdays
+	schedule.ValidFrom = input.ValidFrom
+	schedule.ValidTo = input.ValidTo
+	schedule.ExceptionDates = input.ExceptionDates
+	schedule.UpdatedAt = now
+}
+
+// findManagedSchedule tìm lịch chạy của nhà xe mà người dùng được quản lý; lịch của nhà xe khác
+// được báo là không tồn tại.
+func (s *ScheduleService) findManagedSchedule(ctx context.Context, scheduleIDStr string, actor *AccessClaims) (*models.Schedule, error) {
+	scheduleID, err := primitive.ObjectIDFromHex(scheduleIDStr)
+	if err != nil {
+		return nil, ErrInvalidScheduleID
+	}
+	schedule, err := s.schedules.FindByID(ctx, scheduleID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		log.Printf("Lỗi khi tìm lịch chạy %s: %v", scheduleIDStr, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn lịch chạy", err)
+	}
+	if authorizeCompany(actor, schedule.CompanyID) != nil {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
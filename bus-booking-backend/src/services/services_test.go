@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+	"github.com/Go_final_exam/bus-booking-backend/src/realtime"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+// Các hàm dựng dữ liệu dùng chung cho kiểm thử service trên repository trong bộ nhớ.
+
+const testHoldDuration = 15 * time.Minute
+
+type testEnv struct {
+	// location là múi giờ vận hành của các service, khác UTC để lộ lỗi lẫn múi giờ.
+	location *time.Location
+	repos    *repositories.Repositories
+	gateway  *payments.MockGateway
+	bookings *BookingService
+	payments *PaymentService
+	trips    *TripService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	repos := repositories.NewMemoryRepositories()
+	gateway := payments.NewMockGateway(payments.MockOutcomeSuccess, "test-webhook-secret")
+	seatEvents := realtime.NewMemorySeatEventBus(16)
+	location, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Fatalf("đọc múi giờ: %v", err)
+	}
+	return &testEnv{
+		location: location,
+		repos:    repos,
+		gateway:  gateway,
+		bookings: NewBookingService(repos.Bookings, repos.Trips, repos.Users, repos.Payments, repos.Companies, gateway, testHoldDuration, location, seatEvents),
+		payments: NewPaymentService(repos.Bookings, repos.Trips, repos.Payments, repos.WebhookEvents, gateway, seatEvents),
+		trips:    NewTripService(repos.Trips, repos.Companies, repos.Vehicles, repos.Bookings, repos.Locations, repos.Schedules, location, seatEvents),
+	}
+}
+
+// addTrip nạp một chuyến đi còn trống tất cả các ghế seatNumbers.
+func (e *testEnv) addTrip(t *testing.T, from, to string, departure time.Time, seatNumbers ...string) models.Trip {
+	t.Helper()
+	trip := models.Trip{
+		ID:                  primitive.NewObjectID(),
+		CompanyID:           primitive.NewObjectID(),
+		Route:               models.Route{From: models.LocationPoint{Name: from}, To: models.LocationPoint{Name: to}},
+		DepartureTime:       departure,
+		ExpectedArrivalTime: departure.Add(6 * time.Hour),
+		Price:               250000,
+	}
+	for _, seatNumber := range seatNumbers {
+		trip.Seats = append(trip.Seats, models.Seat{SeatNumber: seatNumber, Status: models.SeatAvailable})
+	}
+	e.putTrip(trip)
+	return trip
+}
+
+// putTrip ghi đè chuyến đi trong repository, dùng sau khi sửa dữ liệu do addTrip tạo.
+func (e *testEnv) putTrip(trip models.Trip) {
+	e.repos.Trips.(repositories.MemoryTripRepository).Put(trip)
+}
+
+// addUser tạo một người dùng có họ tên và số điện thoại hợp lệ.
+func (e *testEnv) addUser(t *testing.T) models.User {
+	t.Helper()
+	user := models.User{
+		ID:    primitive.NewObjectID(),
+		Email: primitive.NewObjectID().Hex() + "@example.com",
+		Phone: "0901234567",
+		Name:  "Nguyễn Văn A",
+	}
+	if err := e.repos.Users.Create(t.Context(), &user); err != nil {
+		t.Fatalf("tạo người dùng: %v", err)
+	}
+	return user
+}
+
+// seatStatuses trả về trạng thái hiện tại của từng ghế trên toàn tuyến của chuyến đi.
+func (e *testEnv) seatStatuses(t *testing.T, tripID primitive.ObjectID) map[string]models.SeatStatus {
+	t.Helper()
+	trip, err := e.repos.Trips.FindByID(t.Context(), tripID)
+	if err != nil {
+		t.Fatalf("đọc chuyến đi: %v", err)
+	}
+	statuses := make(map[string]models.SeatStatus, len(trip.Seats))
+	for _, seat := range trip.Seats {
+		statuses[seat.SeatNumber] = seat.StatusFor(trip.FullSegment())
+	}
+	return statuses
+}
+
+var errSeatWrite = errors.New("lỗi ghi ghế giả lập")
+
+// failingSeatLegs giả lập lỗi ghi ghế sau khi booking đã đổi trạng thái: mọi lệnh UpdateSeatLegs
+// đều thất bại.
+type failingSeatLegs struct {
+	repositories.TripRepository
+}
+
+func (failingSeatLegs) UpdateSeatLegs(context.Context, primitive.ObjectID, primitive.ObjectID, models.SeatStatus, models.SeatStatus) error {
+	return errSeatWrite
+}
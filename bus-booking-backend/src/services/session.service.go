@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+// Lý do thu hồi phiên đăng nhập.
+const (
+	revokeReasonLogout    = "logout"
+	revokeReasonLogoutAll = "logout_all"
+	revokeReasonReused    = "refresh_token_reused"
+)
+
+var (
+	ErrInvalidAccessToken  = apperrors.New(apperrors.ErrUnauthorized, "Token không hợp lệ hoặc đã hết hạn").WithCode("invalid_token")
+	ErrInvalidRefreshToken = apperrors.New(apperrors.ErrUnauthorized, "refresh token không hợp lệ").WithCode("invalid_refresh_token")
+	// ErrRefreshTokenReused được trả về khi một refresh token đã được thay thế bị dùng lại;
+	// phiên tương ứng bị thu hồi vì token có thể đã bị lộ.
+	ErrRefreshTokenReused = apperrors.New(apperrors.ErrUnauthorized, "refresh token đã được sử dụng, phiên đăng nhập đã bị thu hồi").WithCode("refresh_token_reused")
+	ErrSessionRevoked     = apperrors.New(apperrors.ErrUnauthorized, "phiên đăng nhập đã bị thu hồi, vui lòng đăng nhập lại").WithCode("session_revoked")
+	ErrSessionExpired     = apperrors.New(apperrors.ErrUnauthorized, "phiên đăng nhập đã hết hạn, vui lòng đăng nhập lại").WithCode("session_expired")
+)
+
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// AuthTokens là cặp token trả về khi đăng nhập hoặc làm mới phiên.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn là thời hạn còn lại của access token.
+	ExpiresIn time.Duration
+}
+
+// ClientInfo mô tả thiết bị tạo phiên đăng nhập, chỉ dùng để hiển thị và điều tra.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// AccessClaims là thông tin đã được xác thực từ một access token.
+type AccessClaims struct {
+	UserID    string
+	SessionID string
+	Role      models.Role
+	// CompanyID là nhà xe của nhân viên nhà xe, rỗng với các vai trò khác.
+	CompanyID string
+}
+
+// Authenticate kiểm tra access token và phiên đăng nhập mà token tham chiếu qua claim "jti".
+// Token của phiên đã bị thu hồi bị từ chối dù chưa hết hạn.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*AccessClaims, error) {
+	claims, err := s.tokens.ParseToken(accessToken)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrUnauthorized, ErrInvalidAccessToken.Message, err).WithCode(ErrInvalidAccessToken.Code)
+	}
+
+	userID, _ := claims["userId"].(string)
+	jti, _ := claims["jti"].(string)
+	companyID, _ := claims["companyId"].(string)
+	role := models.RoleCustomer
+	if r, ok := claims["role"].(string); ok {
+		role = models.Role(r)
+	}
+	sessionID, err := primitive.ObjectIDFromHex(jti)
+	if userID == "" || err != nil || !role.IsValid() {
+		return nil, ErrInvalidAccessToken
+	}
+
+	session, err := s.sessions.FindByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		return nil, internalError("lỗi hệ thống khi kiểm tra phiên đăng nhập", err)
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+	if session.UserID.Hex() != userID {
+		return nil, ErrInvalidAccessToken
+	}
+
+	return &AccessClaims{UserID: userID, SessionID: jti, Role: role, CompanyID: companyID}, nil
+}
+
+// Refresh đổi refresh token lấy một cặp token mới. Refresh token cũ hết hiệu lực ngay; nếu nó
+// bị dùng lại thì toàn bộ phiên bị thu hồi.
+func (s *AuthService) Refresh(input RefreshInput) (*AuthTokens, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := s.findRefreshSession(ctx, input.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+	if err := s.checkRefreshToken(ctx, session, input.RefreshToken); err != nil {
+		return nil, err
+	}
+
+	// Đọc lại người dùng để token mới mang vai trò và nhà xe hiện tại.
+	user, err := s.users.FindByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, internalError("lỗi hệ thống khi tìm kiếm người dùng", err)
+	}
+
+	now := time.Now()
+	refreshToken, refreshHash, err := newRefreshToken(session.ID)
+	if err != nil {
+		return nil, internalError("không thể tạo refresh token", err)
+	}
+	err = s.sessions.Rotate(ctx, session.ID, hashRefreshToken(input.RefreshToken), refreshHash, now, now.Add(s.refreshTTL))
+	if err != nil {
+		if errors.Is(err, repositories.ErrConflict) {
+			// Một request khác vừa dùng cùng refresh token này.
+			return nil, s.revokeReusedSession(ctx, session)
+		}
+		return nil, internalError("không thể làm mới phiên đăng nhập", err)
+	}
+
+	accessToken, err := s.tokens.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, internalError("không thể tạo token xác thực", err)
+	}
+
+	return &AuthTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: s.tokens.TTL()}, nil
+}
+
+// Logout thu hồi phiên đăng nhập của refresh token. Đăng xuất một phiên đã bị thu hồi không báo lỗi.
+func (s *AuthService) Logout(input RefreshInput) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := s.findRefreshSession(ctx, input.RefreshToken)
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	if err := s.checkRefreshToken(ctx, session, input.RefreshToken); err != nil {
+		return err
+	}
+
+	if err := s.sessions.Revoke(ctx, session.ID, time.Now(), revokeReasonLogout); err != nil {
+		return internalError("không thể đăng xuất", err)
+	}
+	return nil
+}
+
+// LogoutAll thu hồi mọi phiên đăng nhập của người dùng, trả về số phiên bị thu hồi.
+func (s *AuthService) LogoutAll(userIDStr string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return 0, ErrInvalidUserID
+	}
+
+	revoked, err := s.sessions.RevokeAllForUser(ctx, userID, time.Now(), revokeReasonLogoutAll)
+	if err != nil {
+		return 0, internalError("không thể đăng xuất khỏi tất cả thiết bị", err)
+	}
+	return revoked, nil
+}
+
+// startSession tạo phiên đăng nhập mới và cặp token đầu tiên của phiên.
+func (s *AuthService) startSession(ctx context.Context, user *models.User, client ClientInfo) (*AuthTokens, error) {
+	now := time.Now()
+	session := models.Session{
+		ID:          primitive.NewObjectID(),
+		UserID:      user.ID,
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+		CreatedAt:   now,
+		RefreshedAt: now,
+		ExpiresAt:   now.Add(s.refreshTTL),
+	}
+
+	refreshToken, refreshHash, err := newRefreshToken(session.ID)
+	if err != nil {
+		return nil, internalError("không thể tạo refresh token", err)
+	}
+	session.RefreshTokenHash = refreshHash
+
+	if err := s.sessions.Create(ctx, &session); err != nil {
+		return nil, internalError("không thể tạo phiên đăng nhập", err)
+	}
+
+	accessToken, err := s.tokens.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, internalError("không thể tạo token xác thực", err)
+	}
+
+	return &AuthTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: s.tokens.TTL()}, nil
+}
+
+func (s *AuthService) findRefreshSession(ctx context.Context, refreshToken string) (*models.Session, error) {
+	sessionID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := s.sessions.FindByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, internalError("lỗi hệ thống khi tìm phiên đăng nhập", err)
+	}
+	return session, nil
+}
+
+// checkRefreshToken kiểm tra refresh token có phải token hiện tại của phiên còn hiệu lực không.
+// Token đúng định dạng của phiên nhưng không khớp là token cũ đã bị thay thế.
+func (s *AuthService) checkRefreshToken(ctx context.Context, session *models.Session, refreshToken string) error {
+	if subtle.ConstantTimeCompare([]byte(hashRefreshToken(refreshToken)), []byte(session.RefreshTokenHash)) != 1 {
+		return s.revokeReusedSession(ctx, session)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+func (s *AuthService) revokeReusedSession(ctx context.Context, session *models.Session) error {
+	log.Printf("Phát hiện refresh token bị dùng lại cho phiên %s của người dùng %s, thu hồi phiên", session.ID.Hex(), session.UserID.Hex())
+	if err := s.sessions.Revoke(ctx, session.ID, time.Now(), revokeReasonReused); err != nil {
+		log.Printf("Lỗi khi thu hồi phiên %s: %v", session.ID.Hex(), err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// newRefreshToken sinh refresh token dạng "<sessionID>.<chuỗi ngẫu nhiên>" và giá trị băm để lưu.
+func newRefreshToken(sessionID primitive.ObjectID) (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token = sessionID.Hex() + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashRefreshToken(token), nil
+}
+
+func parseRefreshToken(token string) (primitive.ObjectID, bool) {
+	sessionHex, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return primitive.NilObjectID, false
+	}
+	sessionID, err := primitive.ObjectIDFromHex(sessionHex)
+	if err != nil {
+		return primitive.NilObjectID, false
+	}
+	return sessionID, true
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
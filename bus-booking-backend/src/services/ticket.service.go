@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+	"github.com/Go_final_exam/bus-booking-backend/src/tickets"
+	"github.com/Go_final_exam/bus-booking-backend/src/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidTicketCode = apperrors.New(apperrors.ErrInvalidInput, "mã vé không hợp lệ").WithCode("invalid_ticket_code")
+	ErrTicketNotFound    = apperrors.New(apperrors.ErrNotFound, "không tìm thấy vé").WithCode("ticket_not_found")
+	ErrTicketNotIssued   = apperrors.New(apperrors.ErrConflict, "vé chưa được phát hành, vui lòng hoàn tất thanh toán").WithCode("ticket_not_issued")
+)
+
+// TicketVerification là thông tin tối thiểu để nhân viên nhà xe kiểm tra vé,
+// không chứa tên hay số điện thoại của hành khách.
+type TicketVerification struct {
+	TicketCode    string               `json:"ticketCode"`
+	Valid         bool                 `json:"valid"`
+	Status        models.BookingStatus `json:"status"`
+	TripID        primitive.ObjectID   `json:"tripId"`
+	From          string               `json:"from"`
+	To            string               `json:"to"`
+	DepartureTime time.Time            `json:"departureTime"`
+	SeatNumbers   []string             `json:"seatNumbers"`
+}
+
+// TicketService tra cứu vé và dựng dữ liệu vé điện tử.
+type TicketService struct {
+	bookings  repositories.BookingRepository
+	trips     repositories.TripRepository
+	companies repositories.CompanyRepository
+	// signingKey là khóa ký nội dung mã QR trên vé.
+	signingKey []byte
+	// location là múi giờ vận hành, dùng cho giờ khởi hành trả về và in trên vé.
+	location *time.Location
+}
+
+func NewTicketService(bookings repositories.BookingRepository, trips repositories.TripRepository, companies repositories.CompanyRepository, signingKey []byte, location *time.Location) *TicketService {
+	return &TicketService{bookings: bookings, trips: trips, companies: companies, signingKey: signingKey, location: location}
+}
+
+// VerifyTicket tra cứu vé theo mã vé hoặc theo nội dung đã ký trong mã QR.
+// Vé hợp lệ để lên xe khi booking đang "confirmed" hoặc "checked_in".
+func (s *TicketService) VerifyTicket(code string) (*TicketVerification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, trip, err := s.findTicket(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketVerification(booking, trip), nil
+}
+
+// CheckIn soát vé cho hành khách lên xe, chuyển booking từ "confirmed" sang "checked_in".
+// Chỉ quản trị viên hoặc nhân viên của nhà xe khai thác chuyến đi được soát vé.
+func (s *TicketService) CheckIn(code string, operator *AccessClaims) (*TicketVerification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, trip, err := s.findTicket(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeCompany(operator, trip.CompanyID); err != nil {
+		return nil, err
+	}
+
+	err = transitionBooking(ctx, s.bookings, booking, bookingTransition{
+		To:     models.BookingCheckedIn,
+		Actor:  models.UserActor(operator.UserID),
+		Reason: "soát vé lên xe",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ticketVerification(booking, trip), nil
+}
+
+// findTicket tìm booking và chuyến đi theo mã vé hoặc theo nội dung đã ký trong mã QR.
+func (s *TicketService) findTicket(ctx context.Context, code string) (*models.Booking, *models.Trip, error) {
+	if strings.Contains(code, ".") {
+		payload, err := tickets.ParsePayload(s.signingKey, code)
+		if err != nil {
+			return nil, nil, ErrInvalidTicketCode
+		}
+		code = payload.TicketCode
+	}
+	code = utils.NormalizeTicketCode(code)
+	if !utils.ValidateTicketCode(code) {
+		return nil, nil, ErrInvalidTicketCode
+	}
+
+	booking, err := s.bookings.FindByTicketCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, nil, ErrTicketNotFound
+		}
+		log.Printf("Lỗi khi tra cứu vé %s: %v", code, err)
+		return nil, nil, internalError("lỗi hệ thống khi tra cứu vé", err)
+	}
+
+	trip, err := s.trips.FindByID(ctx, booking.TripID)
+	if err != nil {
+		log.Printf("Lỗi khi tìm chuyến đi %s của vé %s: %v", booking.TripID.Hex(), code, err)
+		return nil, nil, internalError("lỗi hệ thống khi tra cứu chuyến đi", err)
+	}
+	trip.InLocation(s.location)
+	return booking, trip, nil
+}
+
+// ticketVerification dựng thông tin kiểm tra vé cho chặng đã đặt: điểm lên, điểm xuống và giờ
+// xe tới điểm lên.
+func ticketVerification(booking *models.Booking, trip *models.Trip) *TicketVerification {
+	segment := trip.DescribeSegment(bookingSegment(trip, booking), booking.TotalAmount)
+	return &TicketVerification{
+		TicketCode:    booking.TicketCode,
+		Valid:         booking.Status == models.BookingConfirmed || booking.Status == models.BookingCheckedIn,
+		Status:        booking.Status,
+		TripID:        trip.ID,
+		From:          segment.From,
+		To:            segment.To,
+		DepartureTime: segment.DepartureTime,
+		SeatNumbers:   bookingSeatNumbers(booking),
+	}
+}
+
+// GetBoardingPass dựng dữ liệu vé điện tử cho một booking đã được xác nhận của người dùng.
+func (s *TicketService) GetBoardingPass(bookingIDStr, userIDStr string) (*tickets.BoardingPass, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	booking, err := findUserBooking(ctx, s.bookings, bookingIDStr, userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	if booking.TicketCode == "" || (booking.Status != models.BookingConfirmed && booking.Status != models.BookingCheckedIn && booking.Status != models.BookingCompleted) {
+		return nil, ErrTicketNotIssued
+	}
+
+	trip, err := findBookingTrip(ctx, s.trips, booking)
+	if err != nil {
+		return nil, err
+	}
+	trip.InLocation(s.location)
+
+	company := &models.Company{}
+	if found, err := s.companies.FindByID(ctx, trip.CompanyID); err == nil {
+		company = found
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		log.Printf("Lỗi khi tìm nhà xe %s để in vé: %v", trip.CompanyID.Hex(), err)
+	}
+
+	qrContent, err := tickets.SignPayload(s.signingKey, booking.TicketCode, booking.ID.Hex())
+	if err != nil {
+		return nil, internalError("lỗi hệ thống khi tạo mã QR", err)
+	}
+
+	// Vé in chặng khách đã đặt: điểm lên, điểm xuống và giờ xe tới hai điểm đó.
+	segment := trip.DescribeSegment(bookingSegment(trip, booking), booking.TotalAmount)
+	pass := &tickets.BoardingPass{
+		TicketCode:    booking.TicketCode,
+		BookingID:     booking.ID.Hex(),
+		CompanyName:   company.Name,
+		CompanyCode:   company.Code,
+		From:          segment.From,
+		To:            segment.To,
+		DepartureTime: segment.DepartureTime,
+		ArrivalTime:   segment.ArrivalTime,
+		TotalAmount:   booking.TotalAmount,
+		QRContent:     qrContent,
+	}
+	for _, p := range booking.Passengers {
+		pass.Passengers = append(pass.Passengers, tickets.PassengerLine{Name: p.Name, SeatNumber: p.SeatNumber})
+	}
+	return pass, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+)
+
+var testTicketKey = []byte("test-ticket-signing-key")
+
+// addSegmentTrip nạp chuyến Hà Nội → Ninh Bình → Thanh Hóa khởi hành lúc departure, bán vé
+// chặng Ninh Bình → Thanh Hóa.
+func (e *testEnv) addSegmentTrip(t *testing.T, departure time.Time) models.Trip {
+	t.Helper()
+	trip := e.addTrip(t, "Hà Nội", "Thanh Hóa", departure, "A1")
+	trip.Route.Stops = []models.Stop{
+		{Name: "Hà Nội"},
+		{Name: "Ninh Bình", OffsetMinutes: 120},
+		{Name: "Thanh Hóa", OffsetMinutes: 210},
+	}
+	trip.Fares = []models.Fare{{From: "Ninh Bình", To: "Thanh Hóa", Price: 100000}}
+	e.putTrip(trip)
+	return trip
+}
+
+func TestVerifyTicketShowsBookedSegment(t *testing.T) {
+	env := newTestEnv(t)
+	ticketService := NewTicketService(env.repos.Bookings, env.repos.Trips, env.repos.Companies, testTicketKey, env.location)
+	departure := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	trip := env.addSegmentTrip(t, departure)
+
+	booking, _ := env.confirmedBooking(t, trip.ID, "Ninh Bình", "Thanh Hóa")
+	stored, err := env.repos.Bookings.FindByID(t.Context(), booking.ID)
+	if err != nil {
+		t.Fatalf("đọc booking: %v", err)
+	}
+
+	verification, err := ticketService.VerifyTicket(stored.TicketCode)
+	if err != nil {
+		t.Fatalf("VerifyTicket: %v", err)
+	}
+	if !verification.Valid || verification.From != "Ninh Bình" || verification.To != "Thanh Hóa" {
+		t.Fatalf("kết quả = %+v, muốn vé hợp lệ chặng Ninh Bình → Thanh Hóa", verification)
+	}
+	if want := departure.Add(120 * time.Minute); !verification.DepartureTime.Equal(want) {
+		t.Fatalf("giờ lên xe = %s, muốn %s", verification.DepartureTime, want)
+	}
+
+	if _, err := ticketService.VerifyTicket("000-000-001"); !errors.Is(err, ErrInvalidTicketCode) {
+		t.Fatalf("sai chữ số kiểm tra: lỗi = %v, muốn ErrInvalidTicketCode", err)
+	}
+}
+
+func TestGetBoardingPassPrintsBookedSegment(t *testing.T) {
+	env := newTestEnv(t)
+	ticketService := NewTicketService(env.repos.Bookings, env.repos.Trips, env.repos.Companies, testTicketKey, env.location)
+	departure := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	trip := env.addSegmentTrip(t, departure)
+
+	booking, user := env.confirmedBooking(t, trip.ID, "Ninh Bình", "Thanh Hóa")
+	pass, err := ticketService.GetBoardingPass(booking.ID.Hex(), user.ID.Hex())
+	if err != nil {
+		t.Fatalf("GetBoardingPass: %v", err)
+	}
+	if pass.From != "Ninh Bình" || pass.To != "Thanh Hóa" {
+		t.Fatalf("vé in chặng %s → %s, muốn Ninh Bình → Thanh Hóa", pass.From, pass.To)
+	}
+	if !pass.DepartureTime.Equal(departure.Add(120*time.Minute)) || !pass.ArrivalTime.Equal(departure.Add(210*time.Minute)) {
+		t.Fatalf("giờ trên vé = %s → %s, muốn giờ tới Ninh Bình và Thanh Hóa", pass.DepartureTime, pass.ArrivalTime)
+	}
+	if pass.DepartureTime.Location() != env.location {
+		t.Fatalf("múi giờ trên vé = %s, muốn %s", pass.DepartureTime.Location(), env.location)
+	}
+
+	// Mã QR trên vé được nhận khi soát vé.
+	verification, err := ticketService.VerifyTicket(pass.QRContent)
+	if err != nil || verification.TicketCode != pass.TicketCode {
+		t.Fatalf("VerifyTicket(QR) = %+v, err = %v; muốn vé %s", verification, err, pass.TicketCode)
+	}
+	if _, err := NewTicketService(env.repos.Bookings, env.repos.Trips, env.repos.Companies, []byte("khóa-khác"), env.location).VerifyTicket(pass.QRContent); !errors.Is(err, ErrInvalidTicketCode) {
+		t.Fatalf("QR ký bằng khóa khác: lỗi = %v, muốn ErrInvalidTicketCode", err)
+	}
+}
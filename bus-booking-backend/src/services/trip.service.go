@@ -3,138 +3,464 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
-	"github.com/Go_final_exam/bus-booking-backend/src/config"
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
 	"github.com/Go_final_exam/bus-booking-backend/src/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/Go_final_exam/bus-booking-backend/src/realtime"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func SearchTrips(from, to, date string) ([]models.Trip, error) {
-	tripCollection := config.DB.Collection("trips")
+var (
+	ErrTripHasBookings        = apperrors.New(apperrors.ErrConflict, "chuyến đi đã có booking, không thể đổi nhà xe, xe, số điểm dừng hoặc xóa chuyến đi").WithCode("trip_has_bookings")
+	ErrTripFaresLocked        = apperrors.New(apperrors.ErrConflict, "chuyến đi đang có booking còn hiệu lực, không thể sửa giá vé").WithCode("trip_fares_locked")
+	ErrTripTimesLocked        = apperrors.New(apperrors.ErrConflict, "chuyến đi đang có booking còn hiệu lực, không thể sửa giờ khởi hành hoặc giờ đến").WithCode("trip_times_locked")
+	ErrInvalidTripTimes       = apperrors.New(apperrors.ErrInvalidInput, "giờ khởi hành phải ở tương lai và trước giờ đến dự kiến").WithCode("invalid_trip_times")
+	ErrVehicleCompanyMismatch = apperrors.New(apperrors.ErrInvalidInput, "xe không thuộc nhà xe của chuyến đi").WithCode("vehicle_company_mismatch")
+	ErrVehicleWithoutSeatMap  = apperrors.New(apperrors.ErrInvalidInput, "xe chưa có sơ đồ ghế, không thể tạo chuyến đi").WithCode("vehicle_without_seat_map")
+)
+
+// TripInput là dữ liệu tạo hoặc cập nhật chuyến đi. Danh sách ghế không được gửi lên mà được
+// sinh từ sơ đồ ghế của xe. Price là giá trọn tuyến; Fares là giá các chặng giữa những điểm dừng.
+type TripInput struct {
+	CompanyID           string        `json:"companyId" validate:"required"`
+	VehicleID           string        `json:"vehicleId" validate:"required"`
+	Route               models.Route  `json:"route" validate:"required"`
+	DepartureTime       time.Time     `json:"departureTime" validate:"required"`
+	ExpectedArrivalTime time.Time     `json:"expectedArrivalTime" validate:"required"`
+	Price               float64       `json:"price" validate:"required,gt=0"`
+	Fares               []models.Fare `json:"fares" validate:"omitempty,dive"`
+}
+
+// TripService cung cấp các thao tác tra cứu chuyến đi và quản lý chuyến đi cho nhà xe.
+type TripService struct {
+	trips     repositories.TripRepository
+	companies repositories.CompanyRepository
+	vehicles  repositories.VehicleRepository
+	bookings  repositories.BookingRepository
+	locations repositories.LocationRepository
+	schedules repositories.ScheduleRepository
+	// location là múi giờ vận hành, dùng để hiểu ngày tìm kiếm và trả giờ về cho client.
+	location *time.Location
+	// seatEvents phát các thay đổi trạng thái ghế cho người đang xem sơ đồ ghế.
+	seatEvents realtime.SeatEventBus
+}
+
+func NewTripService(trips repositories.TripRepository, companies repositories.CompanyRepository, vehicles repositories.VehicleRepository, bookings repositories.BookingRepository, locations repositories.LocationRepository, schedules repositories.ScheduleRepository, location *time.Location, seatEvents realtime.SeatEventBus) *TripService {
+	return &TripService{trips: trips, companies: companies, vehicles: vehicles, bookings: bookings, locations: locations, schedules: schedules, location: location, seatEvents: seatEvents}
+}
+
+// SubscribeSeatEvents đăng ký nhận các thay đổi trạng thái ghế của chuyến đi. Người gọi phải gọi
+// hàm hủy đăng ký khi không còn đọc sự kiện.
+func (s *TripService) SubscribeSeatEvents(tripIDStr string) (<-chan realtime.SeatEvent, func(), error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
-
-	if from != "" {
-		filter["route.from.name"] = from
+	tripID, err := primitive.ObjectIDFromHex(tripIDStr)
+	if err != nil {
+		return nil, nil, ErrInvalidTripID
 	}
-	if to != "" {
-		filter["route.to.name"] = to
+	if _, err := s.trips.FindByID(ctx, tripID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, nil, ErrTripNotFound
+		}
+		log.Printf("Lỗi khi tìm chuyến đi %s để theo dõi ghế: %v", tripIDStr, err)
+		return nil, nil, internalError("lỗi máy chủ khi truy vấn dữ liệu", err)
 	}
 
-	if date != "" {
-		layout := "2006-01-02" 
-		startOfDay, err := time.Parse(layout, date)
-		if err != nil {
-			log.Printf("Lỗi phân tích ngày: %v", err)
-			return nil, err
-		}
-		endOfDay := startOfDay.Add(24 * time.Hour)
+	events, unsubscribe := s.seatEvents.Subscribe(tripID)
+	return events, unsubscribe, nil
+}
+
+// GetTripByID trả về chi tiết chuyến đi. Khi có from và to, trạng thái ghế và số ghế trống
+// được tính cho chặng đó; để trống cả hai để xem trọn tuyến.
+func (s *TripService) GetTripByID(tripID, from, to string) (*models.Trip, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(tripID)
+	if err != nil {
+		log.Printf("ID chuyến đi không hợp lệ: %s", tripID)
+		return nil, ErrInvalidTripID
+	}
 
-		filter["departureTime"] = bson.M{
-			"$gte": startOfDay,
-			"$lt":  endOfDay,
+	trip, err := s.trips.FindByID(ctx, objID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			log.Printf("Không tìm thấy chuyến đi với ID: %s", tripID)
+			return nil, ErrTripNotFound
 		}
+		log.Printf("Lỗi khi tìm chuyến đi bằng ID: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn dữ liệu", err)
+	}
+
+	segment, price, err := resolveSegment(trip, from, to)
+	if err != nil {
+		return nil, err
+	}
+	trip.SegmentInfo = trip.DescribeSegment(segment, price)
+	applySegment(trip, segment)
+
+	company, err := s.companies.FindByID(ctx, trip.CompanyID)
+	switch {
+	case err == nil:
+		trip.CompanyInfo = company
+	case !errors.Is(err, repositories.ErrNotFound):
+		log.Printf("Lỗi khi tìm nhà xe %s của chuyến đi %s: %v", trip.CompanyID.Hex(), tripID, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn nhà xe", err)
+	}
+
+	// Sơ đồ ghế của xe giúp client vẽ đúng vị trí từng ghế; chuyến cũ chưa gán xe thì bỏ qua.
+	vehicle, err := s.vehicles.FindByID(ctx, trip.VehicleID)
+	switch {
+	case err == nil:
+		trip.VehicleInfo = vehicle
+	case !errors.Is(err, repositories.ErrNotFound):
+		log.Printf("Lỗi khi tìm xe %s của chuyến đi %s: %v", trip.VehicleID.Hex(), tripID, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn xe", err)
+	}
+
+	trip.InLocation(s.location)
+	return trip, nil
+}
+
+// CreateTrip tạo chuyến đi mới; mọi ghế trong sơ đồ của xe được bán với trạng thái "available".
+func (s *TripService) CreateTrip(input TripInput, actor *AccessClaims) (*models.Trip, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if !input.DepartureTime.After(time.Now()) {
+		return nil, ErrInvalidTripTimes
 	}
+	trip, vehicle, err := s.buildTrip(ctx, input, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	trip.ID = primitive.NewObjectID()
+	trip.Seats = seatsFromMap(vehicle.SeatMap)
+	trip.CreatedAt = now
+	trip.UpdatedAt = now
+	if err := s.trips.Create(ctx, trip); err != nil {
+		log.Printf("Lỗi khi tạo chuyến đi cho nhà xe %s: %v", trip.CompanyID.Hex(), err)
+		return nil, internalError("không thể tạo chuyến đi", err)
+	}
+
+	applySegment(trip, trip.FullSegment())
+	trip.VehicleInfo = vehicle
+	trip.InLocation(s.location)
+	return trip, nil
+}
 
-	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{Key: "departureTime", Value: 1}})
+// UpdateTrip ghi đè thông tin chuyến đi. Khi chuyến đang có booking còn hiệu lực (xem
+// CountActiveByTrip), giá vé, giờ khởi hành, giờ đến, nhà xe, xe và số điểm dừng không được đổi;
+// các thông tin khác như tên điểm dừng vẫn sửa được và danh sách ghế được giữ nguyên. Khi đổi xe,
+// danh sách ghế được sinh lại theo sơ đồ của xe mới.
+func (s *TripService) UpdateTrip(tripIDStr string, input TripInput, actor *AccessClaims) (*models.Trip, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	cursor, err := tripCollection.Find(ctx, filter, findOptions)
+	existing, err := s.findManagedTrip(ctx, tripIDStr, actor)
 	if err != nil {
-		log.Printf("Lỗi khi tìm kiếm chuyến đi: %v", err)
 		return nil, err
 	}
-	defer cursor.Close(ctx)
+	timesChanged := !existing.DepartureTime.Equal(input.DepartureTime) || !existing.ExpectedArrivalTime.Equal(input.ExpectedArrivalTime)
+	if timesChanged && !input.DepartureTime.After(time.Now()) {
+		return nil, ErrInvalidTripTimes
+	}
 
-	var trips []models.Trip
-	if err = cursor.All(ctx, &trips); err != nil {
-		log.Printf("Lỗi khi đọc dữ liệu chuyến đi từ cursor: %v", err)
+	trip, vehicle, err := s.buildTrip(ctx, input, actor)
+	if err != nil {
 		return nil, err
 	}
+	trip.ID = existing.ID
+	// Chuyến sinh từ lịch chạy vẫn thuộc lịch đó sau khi sửa.
+	trip.ScheduleID = existing.ScheduleID
+	trip.Seats = existing.Seats
+	if trip.VehicleID != existing.VehicleID {
+		trip.Seats = seatsFromMap(vehicle.SeatMap)
+	}
+	trip.CreatedAt = existing.CreatedAt
+	trip.UpdatedAt = time.Now()
 
-	for i := range trips {
-		availableCount := 0
-		for _, seat := range trips[i].Seats {
-			if seat.Status == "available" {
-				availableCount++
+	locked := lockedTripChange(existing, trip)
+	if locked == nil {
+		// Vé đã bán không bị ảnh hưởng nên chỉ ghi thông tin chuyến đi, không đụng tới ghế.
+		if err := s.trips.UpdateDetails(ctx, trip); err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				return nil, ErrTripNotFound
+			}
+			log.Printf("Lỗi khi cập nhật chuyến đi %s: %v", tripIDStr, err)
+			return nil, internalError("không thể cập nhật chuyến đi", err)
+		}
+	} else {
+		if err := s.ensureNoActiveBookings(ctx, existing.ID, locked); err != nil {
+			return nil, err
+		}
+		// Replace chỉ ghi khi chưa có ghế nào được giữ hoặc bán, phòng trường hợp có người đặt chỗ
+		// ngay sau lần kiểm tra booking ở trên.
+		if err := s.trips.Replace(ctx, trip); err != nil {
+			switch {
+			case errors.Is(err, repositories.ErrConflict):
+				return nil, locked
+			case errors.Is(err, repositories.ErrNotFound):
+				return nil, ErrTripNotFound
 			}
+			log.Printf("Lỗi khi cập nhật chuyến đi %s: %v", tripIDStr, err)
+			return nil, internalError("không thể cập nhật chuyến đi", err)
 		}
-		trips[i].AvailableSeats = availableCount
 	}
 
-	return trips, nil
+	// Bộ sinh chuyến đi nhận biết chuyến đã sinh theo ngày khởi hành, nên chuyến bị dời sang ngày
+	// khác phải được đánh dấu để ngày cũ không bị sinh lại. Chuyến đã được lưu nên lỗi ở bước này
+	// chỉ được ghi log.
+	if s.serviceDate(existing) != s.serviceDate(trip) {
+		if err := s.skipScheduledDate(ctx, existing); err != nil {
+			log.Printf("Chuyến đi %s đã được dời ngày nhưng lịch chạy chưa bỏ qua ngày cũ: %v", tripIDStr, err)
+		}
+	}
+
+	applySegment(trip, trip.FullSegment())
+	trip.VehicleInfo = vehicle
+	trip.InLocation(s.location)
+	return trip, nil
 }
 
-func GetTripByID(tripID string) (*models.Trip, error) {
-	tripCollection := config.DB.Collection("trips")
+// DeleteTrip xóa chuyến đi chưa có booking nào, kể cả booking đã hủy, để lịch sử đặt vé
+// luôn tra được chuyến đi. Ngày của chuyến sinh từ lịch chạy được ghi vào SkippedDates của lịch
+// trước khi xóa để bộ sinh chuyến đi không tạo lại.
+func (s *TripService) DeleteTrip(tripIDStr string, actor *AccessClaims) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	objID, err := primitive.ObjectIDFromHex(tripID)
+	trip, err := s.findManagedTrip(ctx, tripIDStr, actor)
 	if err != nil {
-		log.Printf("ID chuyến đi không hợp lệ: %s", tripID)
-		return nil, errors.New("ID chuyến đi không hợp lệ")
+		return err
+	}
+	if err := s.ensureNoBookings(ctx, trip.ID); err != nil {
+		return err
+	}
+	if err := s.skipScheduledDate(ctx, trip); err != nil {
+		return err
 	}
 
-	var trip models.Trip
-	err = tripCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&trip)
+	if err := s.trips.Delete(ctx, trip.ID); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrConflict):
+			return ErrTripHasBookings
+		case errors.Is(err, repositories.ErrNotFound):
+			return ErrTripNotFound
+		}
+		log.Printf("Lỗi khi xóa chuyến đi %s: %v", tripIDStr, err)
+		return internalError("không thể xóa chuyến đi", err)
+	}
+	return nil
+}
+
+// buildTrip kiểm tra thời gian, tuyến đường, nhà xe và xe của chuyến đi rồi dựng chuyến đi chưa
+// có ID và danh sách ghế.
+func (s *TripService) buildTrip(ctx context.Context, input TripInput, actor *AccessClaims) (*models.Trip, *models.Vehicle, error) {
+	if !input.ExpectedArrivalTime.After(input.DepartureTime) {
+		return nil, nil, ErrInvalidTripTimes
+	}
+	route, fares, err := normalizeRoute(ctx, s.locations, input.Route, input.Fares, input.ExpectedArrivalTime.Sub(input.DepartureTime))
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.Printf("Không tìm thấy chuyến đi với ID: %s", tripID)
-			return nil, errors.New("không tìm thấy chuyến đi")
+		return nil, nil, err
+	}
+	vehicle, err := checkCompanyVehicle(ctx, s.companies, s.vehicles, input.CompanyID, input.VehicleID, actor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trip := &models.Trip{
+		CompanyID:           vehicle.CompanyID,
+		VehicleID:           vehicle.ID,
+		Route:               route,
+		DepartureTime:       input.DepartureTime,
+		ExpectedArrivalTime: input.ExpectedArrivalTime,
+		Price:               input.Price,
+		Fares:               fares,
+	}
+	return trip, vehicle, nil
+}
+
+// checkCompanyVehicle kiểm tra người dùng được quản lý nhà xe, nhà xe còn hoạt động, xe thuộc
+// nhà xe và có sơ đồ ghế để sinh danh sách ghế. Trả về xe đã tìm được.
+func checkCompanyVehicle(ctx context.Context, companies repositories.CompanyRepository, vehicles repositories.VehicleRepository, companyIDStr, vehicleIDStr string, actor *AccessClaims) (*models.Vehicle, error) {
+	companyID, err := primitive.ObjectIDFromHex(companyIDStr)
+	if err != nil {
+		return nil, ErrInvalidCompanyID
+	}
+	if err := authorizeCompany(actor, companyID); err != nil {
+		return nil, err
+	}
+	company, err := companies.FindByID(ctx, companyID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrCompanyNotFound
 		}
-		log.Printf("Lỗi khi tìm chuyến đi bằng ID: %v", err)
-		return nil, errors.New("lỗi máy chủ khi truy vấn dữ liệu")
+		log.Printf("Lỗi khi tìm nhà xe %s: %v", companyIDStr, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn nhà xe", err)
+	}
+	if !company.IsActive() {
+		return nil, ErrCompanyInactive
 	}
 
-	availableCount := 0
-	for _, seat := range trip.Seats {
-		if seat.Status == "available" {
-			availableCount++
+	vehicleID, err := primitive.ObjectIDFromHex(vehicleIDStr)
+	if err != nil {
+		return nil, ErrInvalidVehicleID
+	}
+	vehicle, err := vehicles.FindByID(ctx, vehicleID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrVehicleNotFound
 		}
+		log.Printf("Lỗi khi tìm xe %s: %v", vehicleIDStr, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn xe", err)
 	}
-	trip.AvailableSeats = availableCount
+	if vehicle.CompanyID != companyID {
+		return nil, ErrVehicleCompanyMismatch
+	}
+	if vehicle.SeatMap == nil || len(vehicle.SeatMap.Seats()) == 0 {
+		return nil, ErrVehicleWithoutSeatMap
+	}
+	return vehicle, nil
+}
 
-	return &trip, nil
+// normalizeRoute gắn tuyến đường với danh mục địa điểm (xem resolveRouteLocations) rồi chuẩn hóa
+// tuyến đường và bảng giá (xem models.NormalizeRoute). Khi tuyến có điểm dừng, điểm dừng cuối phải
+// tới đúng sau thời gian chạy duration kể từ lúc khởi hành.
+func normalizeRoute(ctx context.Context, locations repositories.LocationRepository, route models.Route, fares []models.Fare, duration time.Duration) (models.Route, []models.Fare, error) {
+	route.Stops = append([]models.Stop(nil), route.Stops...)
+	fares = append([]models.Fare(nil), fares...)
+	if err := resolveRouteLocations(ctx, locations, &route, fares); err != nil {
+		return route, fares, err
+	}
+	if err := models.NormalizeRoute(&route, fares); err != nil {
+		return route, fares, err
+	}
+	if n := len(route.Stops); n > 0 && route.Stops[n-1].OffsetMinutes != int(duration/time.Minute) {
+		return route, fares, apperrors.New(models.ErrInvalidRoute.Kind, fmt.Sprintf("%s: offsetMinutes của điểm dừng cuối phải bằng thời gian chạy (%d phút)", models.ErrInvalidRoute.Message, int(duration/time.Minute))).WithCode(models.ErrInvalidRoute.Code)
+	}
+	return route, fares, nil
 }
 
-func GetAllTrips() ([]models.Trip, error) {
-	tripCollection := config.DB.Collection("trips")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func (s *TripService) findManagedTrip(ctx context.Context, tripIDStr string, actor *AccessClaims) (*models.Trip, error) {
+	tripID, err := primitive.ObjectIDFromHex(tripIDStr)
+	if err != nil {
+		return nil, ErrInvalidTripID
+	}
+	trip, err := s.trips.FindByID(ctx, tripID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrTripNotFound
+		}
+		log.Printf("Lỗi khi tìm chuyến đi %s: %v", tripIDStr, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn chuyến đi", err)
+	}
+	if err := authorizeCompany(actor, trip.CompanyID); err != nil {
+		return nil, err
+	}
+	return trip, nil
+}
+
+// serviceDate là ngày khởi hành của chuyến theo múi giờ vận hành.
+func (s *TripService) serviceDate(trip *models.Trip) string {
+	return trip.DepartureTime.In(s.location).Format(models.DateLayout)
+}
 
-	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{Key: "departureTime", Value: 1}}) 
+// skipScheduledDate thêm ngày khởi hành của chuyến sinh từ lịch chạy vào SkippedDates của lịch.
+// Chuyến tạo thủ công hoặc lịch không còn tồn tại được bỏ qua.
+func (s *TripService) skipScheduledDate(ctx context.Context, trip *models.Trip) error {
+	if trip.ScheduleID == nil {
+		return nil
+	}
+	date := s.serviceDate(trip)
+	if err := s.schedules.AddSkippedDate(ctx, *trip.ScheduleID, date); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		log.Printf("Lỗi khi bỏ qua ngày %s của lịch chạy %s: %v", date, trip.ScheduleID.Hex(), err)
+		return internalError("không thể cập nhật lịch chạy của chuyến đi", err)
+	}
+	return nil
+}
 
-	cursor, err := tripCollection.Find(ctx, bson.M{}, findOptions) 
+func (s *TripService) ensureNoBookings(ctx context.Context, tripID primitive.ObjectID) error {
+	count, err := s.bookings.CountByTrip(ctx, tripID)
 	if err != nil {
-		log.Printf("Lỗi khi lấy tất cả chuyến đi: %v", err)
-		return nil, errors.New("lỗi máy chủ khi truy vấn tất cả chuyến đi")
+		log.Printf("Lỗi khi đếm booking của chuyến đi %s: %v", tripID.Hex(), err)
+		return internalError("lỗi máy chủ khi truy vấn booking", err)
 	}
-	defer cursor.Close(ctx)
+	if count > 0 {
+		return ErrTripHasBookings
+	}
+	return nil
+}
 
-	var trips []models.Trip
-	if err = cursor.All(ctx, &trips); err != nil {
-		log.Printf("Lỗi khi đọc dữ liệu tất cả chuyến đi từ cursor: %v", err)
-		return nil, errors.New("lỗi máy chủ khi đọc dữ liệu chuyến đi")
+// ensureNoActiveBookings trả về locked nếu chuyến đi đang có booking còn hiệu lực. Booking đã
+// hủy, hết hạn hoặc đã hoàn tiền không còn chiếm ghế nên không chặn việc sửa chuyến đi.
+func (s *TripService) ensureNoActiveBookings(ctx context.Context, tripID primitive.ObjectID, locked error) error {
+	count, err := s.bookings.CountActiveByTrip(ctx, tripID, time.Now())
+	if err != nil {
+		log.Printf("Lỗi khi đếm booking còn hiệu lực của chuyến đi %s: %v", tripID.Hex(), err)
+		return internalError("lỗi máy chủ khi truy vấn booking", err)
+	}
+	if count > 0 {
+		return locked
 	}
+	return nil
+}
 
-	for i := range trips {
-		availableCount := 0
-		for _, seat := range trips[i].Seats {
-			if seat.Status == "available" {
-				availableCount++
+// lockedTripChange trả về lỗi ứng với thay đổi ảnh hưởng tới vé đã bán khi sửa existing thành
+// updated, hoặc nil nếu không có thay đổi nào như vậy. Giờ và giá được so theo từng chặng nên đổi
+// tên điểm dừng không bị tính là đổi giá.
+func lockedTripChange(existing, updated *models.Trip) error {
+	stops := len(existing.Route.StopPoints())
+	if updated.CompanyID != existing.CompanyID || updated.VehicleID != existing.VehicleID || len(updated.Route.StopPoints()) != stops {
+		return ErrTripHasBookings
+	}
+	for i := 0; i+1 < stops; i++ {
+		leg := models.Segment{FromStop: i, ToStop: i + 1}
+		oldDeparture, oldArrival := existing.SegmentTimes(leg)
+		newDeparture, newArrival := updated.SegmentTimes(leg)
+		if !oldDeparture.Equal(newDeparture) || !oldArrival.Equal(newArrival) {
+			return ErrTripTimesLocked
+		}
+	}
+	for from := 0; from < stops; from++ {
+		for to := from + 1; to < stops; to++ {
+			segment := models.Segment{FromStop: from, ToStop: to}
+			oldPrice, oldSold := existing.FareFor(segment)
+			newPrice, newSold := updated.FareFor(segment)
+			if oldSold != newSold || oldPrice != newPrice {
+				return ErrTripFaresLocked
 			}
 		}
-		trips[i].AvailableSeats = availableCount
 	}
+	return nil
+}
 
-	return trips, nil
+// seatsFromMap sinh danh sách ghế của chuyến đi từ sơ đồ ghế của xe, tất cả đều còn trống.
+func seatsFromMap(seatMap *models.SeatMap) []models.Seat {
+	cells := seatMap.Seats()
+	seats := make([]models.Seat, 0, len(cells))
+	for _, cell := range cells {
+		seats = append(seats, models.Seat{SeatNumber: cell.Code, Status: models.SeatAvailable, SeatType: cell.SeatType})
+	}
+	return seats
+}
+
+// applySegment thay trạng thái từng ghế trong phản hồi bằng trạng thái trên chặng segment và
+// đếm số ghế còn trống của chặng.
+func applySegment(trip *models.Trip, segment models.Segment) {
+	availableCount := 0
+	for i := range trip.Seats {
+		trip.Seats[i].Status = trip.Seats[i].StatusFor(segment)
+		if trip.Seats[i].Status == models.SeatAvailable {
+			availableCount++
+		}
+	}
+	trip.AvailableSeats = availableCount
 }
@@ -0,0 +1,706 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+func TestSearchTrips(t *testing.T) {
+	env := newTestEnv(t)
+	day := time.Date(2030, 5, 10, 0, 0, 0, 0, env.location)
+	morning := env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(8*time.Hour), "A1", "A2")
+	evening := env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(20*time.Hour), "A1")
+	env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(32*time.Hour), "A1")
+	env.addTrip(t, "Hà Nội", "Đà Nẵng", day.Add(9*time.Hour), "A1")
+
+	user := env.addUser(t)
+	if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: morning.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+
+	result, err := env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", Date: "2030-05-10"})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	trips := result.Trips
+	if len(trips) != 2 || trips[0].ID != morning.ID || trips[1].ID != evening.ID {
+		t.Fatalf("kết quả = %+v, muốn hai chuyến trong ngày theo thứ tự giờ khởi hành", trips)
+	}
+	if trips[0].AvailableSeats != 1 || trips[1].AvailableSeats != 1 {
+		t.Fatalf("ghế trống = %d, %d; muốn 1, 1", trips[0].AvailableSeats, trips[1].AvailableSeats)
+	}
+	if result.NextCursor != "" {
+		t.Fatalf("nextCursor = %q, muốn rỗng khi hết kết quả", result.NextCursor)
+	}
+}
+
+func TestSearchTripsPaginatesWithCursor(t *testing.T) {
+	env := newTestEnv(t)
+	day := time.Date(2030, 5, 10, 0, 0, 0, 0, env.location)
+	// Hai cặp chuyến trùng giá để kiểm tra thứ tự phụ theo giờ khởi hành và ID.
+	prices := []float64{300000, 150000, 300000, 200000, 150000}
+	var trips []models.Trip
+	for i, price := range prices {
+		trip := env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(time.Duration(6+i)*time.Hour), "A1")
+		trip.Price = price
+		env.putTrip(trip)
+		trips = append(trips, trip)
+	}
+	want := []primitive.ObjectID{trips[1].ID, trips[4].ID, trips[3].ID, trips[0].ID, trips[2].ID}
+
+	var got []primitive.ObjectID
+	query := TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", Sort: SortByPrice, Limit: 2}
+	for page := 0; ; page++ {
+		if page > len(prices) {
+			t.Fatal("phân trang không kết thúc")
+		}
+		result, err := env.trips.SearchTrips(query)
+		if err != nil {
+			t.Fatalf("trang %d: %v", page, err)
+		}
+		for _, trip := range result.Trips {
+			got = append(got, trip.ID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("nhận %d chuyến, muốn %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("vị trí %d: nhận %s, muốn %s", i, got[i].Hex(), want[i].Hex())
+		}
+	}
+
+	// Cursor của cách sắp xếp này không dùng được cho cách sắp xếp khác.
+	first, err := env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", Sort: SortByPrice, Limit: 2})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	_, err = env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", Sort: SortByDeparture, Cursor: first.NextCursor})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("cursor khác cách sắp xếp: lỗi = %v, muốn ErrInvalidCursor", err)
+	}
+}
+
+func TestSearchTripsFiltersAndFacets(t *testing.T) {
+	env := newTestEnv(t)
+	day := time.Date(2030, 5, 10, 0, 0, 0, 0, env.location)
+	ctx := t.Context()
+
+	north := models.Company{ID: primitive.NewObjectID(), Name: "Nhà xe Bắc", Code: "BAC"}
+	south := models.Company{ID: primitive.NewObjectID(), Name: "Nhà xe Nam", Code: "NAM"}
+	for _, company := range []*models.Company{&north, &south} {
+		if err := env.repos.Companies.Create(ctx, company); err != nil {
+			t.Fatalf("tạo nhà xe: %v", err)
+		}
+	}
+	limousine := models.Vehicle{ID: primitive.NewObjectID(), CompanyID: north.ID, Type: "limousine"}
+	sleeper := models.Vehicle{ID: primitive.NewObjectID(), CompanyID: south.ID, Type: "giường nằm"}
+	for _, vehicle := range []*models.Vehicle{&limousine, &sleeper} {
+		if err := env.repos.Vehicles.Create(ctx, vehicle); err != nil {
+			t.Fatalf("tạo xe: %v", err)
+		}
+	}
+
+	add := func(company models.Company, vehicle models.Vehicle, hour int, price float64) models.Trip {
+		trip := env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(time.Duration(hour)*time.Hour), "A1")
+		trip.CompanyID, trip.VehicleID, trip.Price = company.ID, vehicle.ID, price
+		env.putTrip(trip)
+		return trip
+	}
+	add(north, limousine, 7, 250000)
+	northLate := add(north, limousine, 19, 250000)
+	add(south, sleeper, 8, 180000)
+
+	result, err := env.trips.SearchTrips(TripSearchQuery{
+		From:        "Hà Nội",
+		To:          "Hải Phòng",
+		CompanyIDs:  []string{north.ID.Hex()},
+		DepartAfter: "12:00",
+	})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	if len(result.Trips) != 1 || result.Trips[0].ID != northLate.ID {
+		t.Fatalf("kết quả = %+v, muốn chỉ chuyến chiều của Nhà xe Bắc", result.Trips)
+	}
+
+	// Facet nhà xe bỏ qua bộ lọc nhà xe nhưng vẫn áp dụng khung giờ: chỉ còn chuyến 19h.
+	companies := map[primitive.ObjectID]int{}
+	for _, facet := range result.Facets.Companies {
+		companies[facet.CompanyID] = facet.Count
+	}
+	if len(companies) != 1 || companies[north.ID] != 1 {
+		t.Fatalf("facet nhà xe = %+v", result.Facets.Companies)
+	}
+
+	result, err = env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", VehicleType: "limousine"})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	types := map[string]int{}
+	for _, facet := range result.Facets.VehicleTypes {
+		types[facet.VehicleType] = facet.Count
+	}
+	if len(result.Trips) != 2 || types["limousine"] != 2 || types["giường nằm"] != 1 {
+		t.Fatalf("chuyến = %d, facet loại xe = %+v", len(result.Trips), result.Facets.VehicleTypes)
+	}
+}
+
+func TestSearchTripsPriceAndSeatFilters(t *testing.T) {
+	env := newTestEnv(t)
+	day := time.Date(2030, 5, 10, 0, 0, 0, 0, env.location)
+	user := env.addUser(t)
+
+	cheap := env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(7*time.Hour), "A1")
+	cheap.Price = 150000
+	env.putTrip(cheap)
+	full := env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(8*time.Hour), "A1")
+	full.Price = 180000
+	env.putTrip(full)
+	if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: full.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+	env.addTrip(t, "Hà Nội", "Hải Phòng", day.Add(9*time.Hour), "A1") // 250000
+
+	result, err := env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", MaxPrice: 200000})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	if len(result.Trips) != 2 || result.Trips[0].ID != cheap.ID || result.Trips[1].ID != full.ID {
+		t.Fatalf("kết quả = %+v, muốn hai chuyến giá dưới 200000, kể cả chuyến hết ghế", result.Trips)
+	}
+
+	result, err = env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", MaxPrice: 200000, MinSeats: 1})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	if len(result.Trips) != 1 || result.Trips[0].ID != cheap.ID {
+		t.Fatalf("kết quả = %+v, muốn chỉ chuyến còn ghế", result.Trips)
+	}
+}
+
+func TestSearchTripsUsesOperatingTimezone(t *testing.T) {
+	env := newTestEnv(t)
+	// 23:00 UTC ngày 9/5 là 06:00 sáng ngày 10/5 theo giờ Việt Nam.
+	trip := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Date(2030, 5, 9, 23, 0, 0, 0, time.UTC), "A1")
+
+	for date, want := range map[string]int{"2030-05-09": 0, "2030-05-10": 1} {
+		result, err := env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", Date: date})
+		if err != nil {
+			t.Fatalf("SearchTrips(%s): %v", date, err)
+		}
+		if len(result.Trips) != want {
+			t.Fatalf("ngày %s: %d chuyến, muốn %d", date, len(result.Trips), want)
+		}
+	}
+
+	found, err := env.trips.GetTripByID(trip.ID.Hex(), "", "")
+	if err != nil {
+		t.Fatalf("GetTripByID: %v", err)
+	}
+	if got := found.DepartureTime.Format(time.RFC3339); got != "2030-05-10T06:00:00+07:00" {
+		t.Fatalf("giờ khởi hành = %s, muốn theo múi giờ vận hành", got)
+	}
+}
+
+func TestSearchTripsExcludesDepartedTrips(t *testing.T) {
+	env := newTestEnv(t)
+	env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(-2*time.Hour), "A1")
+	upcoming := env.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(2*time.Hour), "A1")
+
+	result, err := env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng"})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	if len(result.Trips) != 1 || result.Trips[0].ID != upcoming.ID {
+		t.Fatalf("kết quả = %+v, muốn chỉ chuyến chưa khởi hành", result.Trips)
+	}
+
+	result, err = env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", IncludePast: true})
+	if err != nil {
+		t.Fatalf("SearchTrips: %v", err)
+	}
+	if len(result.Trips) != 2 {
+		t.Fatalf("includePast: %d chuyến, muốn 2", len(result.Trips))
+	}
+}
+
+func TestSearchTripsInvalidDate(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.trips.SearchTrips(TripSearchQuery{From: "Hà Nội", To: "Hải Phòng", Date: "10/05/2030"})
+	if !errors.Is(err, apperrors.ErrInvalidInput) {
+		t.Fatalf("lỗi = %v, muốn ErrInvalidInput", err)
+	}
+}
+
+// addScheduledTrips tạo một lịch chạy hằng ngày lúc 08:00, sinh chuyến cho ba ngày tới và trả về
+// bộ sinh cùng chuyến đầu tiên của lịch.
+func (e *testEnv) addScheduledTrips(t *testing.T) (*ScheduleService, models.Trip) {
+	t.Helper()
+	ctx := t.Context()
+	company := models.Company{ID: primitive.NewObjectID(), Name: "Nhà xe Bắc", Code: "BAC"}
+	if err := e.repos.Companies.Create(ctx, &company); err != nil {
+		t.Fatalf("tạo nhà xe: %v", err)
+	}
+	vehicle := models.Vehicle{
+		ID:        primitive.NewObjectID(),
+		CompanyID: company.ID,
+		SeatMap: &models.SeatMap{Decks: []models.Deck{{
+			Rows:    1,
+			Columns: 1,
+			Cells:   []models.SeatCell{{Kind: models.CellSeat, Code: "A1"}},
+		}}},
+	}
+	if err := e.repos.Vehicles.Create(ctx, &vehicle); err != nil {
+		t.Fatalf("tạo xe: %v", err)
+	}
+	schedule := models.Schedule{
+		ID:              primitive.NewObjectID(),
+		CompanyID:       company.ID,
+		VehicleID:       vehicle.ID,
+		Route:           models.Route{From: models.LocationPoint{Name: "Hà Nội"}, To: models.LocationPoint{Name: "Hải Phòng"}},
+		Price:           250000,
+		DepartureTime:   "08:00",
+		DurationMinutes: 120,
+		Weekdays:        []time.Weekday{0, 1, 2, 3, 4, 5, 6},
+		ValidFrom:       "2000-01-01",
+	}
+	if err := e.repos.Schedules.Create(ctx, &schedule); err != nil {
+		t.Fatalf("tạo lịch chạy: %v", err)
+	}
+
+	generator := NewScheduleService(e.repos.Schedules, e.repos.Trips, e.repos.Vehicles, e.repos.Companies, e.repos.Bookings, e.repos.Locations, e.location, 3)
+	// Sinh từ nửa đêm để chuyến 08:00 hôm nay chưa khởi hành.
+	now := time.Now().In(e.location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, e.location)
+	if n, err := generator.GenerateTrips(ctx, midnight); err != nil || n != 3 {
+		t.Fatalf("GenerateTrips: n = %d, err = %v; muốn sinh 3 chuyến", n, err)
+	}
+	trips, err := e.repos.Trips.Find(ctx, repositories.TripFilter{ScheduleID: &schedule.ID, DepartureFrom: midnight})
+	if err != nil || len(trips) == 0 {
+		t.Fatalf("đọc chuyến đã sinh: %d chuyến, err = %v", len(trips), err)
+	}
+	return generator, trips[0]
+}
+
+func TestUpdateScheduledTripKeepsSchedule(t *testing.T) {
+	env := newTestEnv(t)
+	generator, trip := env.addScheduledTrips(t)
+	admin := &AccessClaims{Role: models.RoleAdmin}
+
+	departure := trip.DepartureTime.Add(time.Hour)
+	updated, err := env.trips.UpdateTrip(trip.ID.Hex(), TripInput{
+		CompanyID:           trip.CompanyID.Hex(),
+		VehicleID:           trip.VehicleID.Hex(),
+		Route:               trip.Route,
+		DepartureTime:       departure,
+		ExpectedArrivalTime: departure.Add(2 * time.Hour),
+		Price:               trip.Price,
+	}, admin)
+	if err != nil {
+		t.Fatalf("UpdateTrip: %v", err)
+	}
+	if updated.ScheduleID == nil || *updated.ScheduleID != *trip.ScheduleID {
+		t.Fatalf("scheduleId = %v, muốn giữ lịch chạy %s", updated.ScheduleID, trip.ScheduleID.Hex())
+	}
+
+	// Giờ khởi hành cũ không được sinh lại thành một chuyến thứ hai trong ngày.
+	if n, err := generator.GenerateTrips(t.Context(), trip.DepartureTime.Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("GenerateTrips sau khi sửa: n = %d, err = %v; muốn không sinh thêm", n, err)
+	}
+}
+
+func TestDeleteScheduledTripIsNotRegenerated(t *testing.T) {
+	env := newTestEnv(t)
+	generator, trip := env.addScheduledTrips(t)
+
+	if err := env.trips.DeleteTrip(trip.ID.Hex(), &AccessClaims{Role: models.RoleAdmin}); err != nil {
+		t.Fatalf("DeleteTrip: %v", err)
+	}
+	if n, err := generator.GenerateTrips(t.Context(), trip.DepartureTime.Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("GenerateTrips sau khi xóa: n = %d, err = %v; muốn không sinh lại", n, err)
+	}
+
+	// Sửa lịch chạy với danh sách ngày ngừng chạy của người dùng không làm chuyến đã xóa quay lại.
+	schedule, err := env.repos.Schedules.FindByID(t.Context(), *trip.ScheduleID)
+	if err != nil {
+		t.Fatalf("đọc lịch chạy: %v", err)
+	}
+	input := scheduleInputFrom(schedule)
+	input.Price = 260000
+	if _, err := generator.UpdateSchedule(schedule.ID.Hex(), input, &AccessClaims{Role: models.RoleAdmin}); err != nil {
+		t.Fatalf("UpdateSchedule: %v", err)
+	}
+	if n, err := generator.GenerateTrips(t.Context(), trip.DepartureTime.Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("GenerateTrips sau khi sửa lịch: n = %d, err = %v; muốn không sinh lại", n, err)
+	}
+
+	schedule, err = env.repos.Schedules.FindByID(t.Context(), schedule.ID)
+	if err != nil {
+		t.Fatalf("đọc lịch chạy: %v", err)
+	}
+	date := trip.DepartureTime.In(env.location).Format(models.DateLayout)
+	if len(schedule.SkippedDates) != 1 || schedule.SkippedDates[0] != date || len(schedule.ExceptionDates) != 0 {
+		t.Fatalf("skippedDates = %v, exceptionDates = %v; muốn chỉ [%s] trong skippedDates", schedule.SkippedDates, schedule.ExceptionDates, date)
+	}
+}
+
+func TestUpdateScheduleTimeMovesUnsoldTrips(t *testing.T) {
+	env := newTestEnv(t)
+	generator, trip := env.addScheduledTrips(t)
+	user := env.addUser(t)
+	if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+
+	schedule, err := env.repos.Schedules.FindByID(t.Context(), *trip.ScheduleID)
+	if err != nil {
+		t.Fatalf("đọc lịch chạy: %v", err)
+	}
+	input := scheduleInputFrom(schedule)
+	input.DepartureTime = "09:30"
+	if _, err := generator.UpdateSchedule(schedule.ID.Hex(), input, &AccessClaims{Role: models.RoleAdmin}); err != nil {
+		t.Fatalf("UpdateSchedule: %v", err)
+	}
+	if n, err := generator.GenerateTrips(t.Context(), trip.DepartureTime.Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("GenerateTrips sau khi đổi giờ: n = %d, err = %v; muốn không sinh thêm", n, err)
+	}
+
+	trips, err := env.repos.Trips.Find(t.Context(), repositories.TripFilter{ScheduleID: &schedule.ID, DepartureFrom: trip.DepartureTime.Add(-time.Hour)})
+	if err != nil || len(trips) != 3 {
+		t.Fatalf("chuyến của lịch: %d, err = %v; muốn mỗi ngày một chuyến", len(trips), err)
+	}
+	for _, got := range trips {
+		clock := got.DepartureTime.In(env.location).Format(models.TimeOfDayLayout)
+		// Chuyến đã có booking giữ giờ cũ, các chuyến còn lại được dời sang giờ mới.
+		want := "09:30"
+		if got.ID == trip.ID {
+			want = "08:00"
+		}
+		if clock != want {
+			t.Fatalf("chuyến %s khởi hành lúc %s, muốn %s", got.ID.Hex(), clock, want)
+		}
+	}
+}
+
+// scheduleInputFrom dựng input cập nhật giữ nguyên mọi thông tin của lịch chạy.
+func scheduleInputFrom(schedule *models.Schedule) ScheduleInput {
+	return ScheduleInput{
+		CompanyID:       schedule.CompanyID.Hex(),
+		VehicleID:       schedule.VehicleID.Hex(),
+		Route:           schedule.Route,
+		Price:           schedule.Price,
+		Fares:           schedule.Fares,
+		DepartureTime:   schedule.DepartureTime,
+		DurationMinutes: schedule.DurationMinutes,
+		Weekdays:        schedule.Weekdays,
+		ValidFrom:       schedule.ValidFrom,
+		ValidTo:         schedule.ValidTo,
+		ExceptionDates:  schedule.ExceptionDates,
+	}
+}
+
+// addCompanyVehicle nạp một nhà xe mã code cùng một xe có một ghế A1.
+func (e *testEnv) addCompanyVehicle(t *testing.T, code string) (models.Company, models.Vehicle) {
+	t.Helper()
+	company := models.Company{ID: primitive.NewObjectID(), Name: "Nhà xe " + code, Code: code}
+	if err := e.repos.Companies.Create(t.Context(), &company); err != nil {
+		t.Fatalf("tạo nhà xe: %v", err)
+	}
+	return company, e.addVehicle(t, company.ID)
+}
+
+// addVehicle nạp một xe có một ghế A1 cho nhà xe companyID.
+func (e *testEnv) addVehicle(t *testing.T, companyID primitive.ObjectID) models.Vehicle {
+	t.Helper()
+	vehicle := models.Vehicle{
+		ID:        primitive.NewObjectID(),
+		CompanyID: companyID,
+		SeatMap: &models.SeatMap{Decks: []models.Deck{{
+			Rows:    1,
+			Columns: 1,
+			Cells:   []models.SeatCell{{Kind: models.CellSeat, Code: "A1"}},
+		}}},
+	}
+	if err := e.repos.Vehicles.Create(t.Context(), &vehicle); err != nil {
+		t.Fatalf("tạo xe: %v", err)
+	}
+	return vehicle
+}
+
+// segmentTripInput dựng input chuyến Hà Nội → Ninh Bình → Thanh Hóa bằng xe vehicle, bán thêm
+// vé chặng Ninh Bình → Thanh Hóa.
+func segmentTripInput(vehicle models.Vehicle, departure time.Time) TripInput {
+	return TripInput{
+		CompanyID: vehicle.CompanyID.Hex(),
+		VehicleID: vehicle.ID.Hex(),
+		Route: models.Route{
+			From: models.LocationPoint{Name: "Hà Nội"},
+			To:   models.LocationPoint{Name: "Thanh Hóa"},
+			Stops: []models.Stop{
+				{Name: "Hà Nội"},
+				{Name: "Ninh Bình", OffsetMinutes: 120},
+				{Name: "Thanh Hóa", OffsetMinutes: 210},
+			},
+		},
+		DepartureTime:       departure,
+		ExpectedArrivalTime: departure.Add(210 * time.Minute),
+		Price:               150000,
+		Fares:               []models.Fare{{From: "Ninh Bình", To: "Thanh Hóa", Price: 100000}},
+	}
+}
+
+func TestCreateTripScopedToCompany(t *testing.T) {
+	env := newTestEnv(t)
+	company, vehicle := env.addCompanyVehicle(t, "BAC")
+	other, otherVehicle := env.addCompanyVehicle(t, "NAM")
+	departure := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+
+	tests := []struct {
+		name   string
+		actor  *AccessClaims
+		mutate func(*TripInput)
+		want   error
+	}{
+		{name: "quản trị viên", actor: &AccessClaims{Role: models.RoleAdmin}},
+		{name: "nhân viên của nhà xe", actor: &AccessClaims{Role: models.RoleOperatorStaff, CompanyID: company.ID.Hex()}},
+		{name: "nhân viên nhà xe khác", actor: &AccessClaims{Role: models.RoleOperatorStaff, CompanyID: other.ID.Hex()}, want: ErrCompanyForbidden},
+		{name: "khách hàng", actor: &AccessClaims{Role: models.RoleCustomer}, want: ErrCompanyForbidden},
+		{
+			name:   "xe của nhà xe khác",
+			actor:  &AccessClaims{Role: models.RoleAdmin},
+			mutate: func(in *TripInput) { in.VehicleID = otherVehicle.ID.Hex() },
+			want:   ErrVehicleCompanyMismatch,
+		},
+		{
+			name:  "khởi hành trong quá khứ",
+			actor: &AccessClaims{Role: models.RoleAdmin},
+			mutate: func(in *TripInput) {
+				in.DepartureTime = time.Now().Add(-time.Hour)
+				in.ExpectedArrivalTime = in.DepartureTime.Add(210 * time.Minute)
+			},
+			want: ErrInvalidTripTimes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := segmentTripInput(vehicle, departure)
+			if tt.mutate != nil {
+				tt.mutate(&input)
+			}
+			trip, err := env.trips.CreateTrip(input, tt.actor)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("CreateTrip: err = %v, muốn %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateTrip: %v", err)
+			}
+			if len(trip.Seats) != 1 || trip.Seats[0].SeatNumber != "A1" || trip.AvailableSeats != 1 {
+				t.Fatalf("ghế = %+v, còn trống %d; muốn ghế A1 còn trống sinh từ sơ đồ xe", trip.Seats, trip.AvailableSeats)
+			}
+		})
+	}
+}
+
+func TestUpdateTripLockedByActiveBooking(t *testing.T) {
+	env := newTestEnv(t)
+	company, vehicle := env.addCompanyVehicle(t, "BAC")
+	otherVehicle := env.addVehicle(t, company.ID)
+	admin := &AccessClaims{Role: models.RoleAdmin}
+	departure := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+	trip, err := env.trips.CreateTrip(segmentTripInput(vehicle, departure), admin)
+	if err != nil {
+		t.Fatalf("CreateTrip: %v", err)
+	}
+	env.confirmedBooking(t, trip.ID, "", "")
+
+	tests := []struct {
+		name   string
+		mutate func(*TripInput)
+		want   error
+	}{
+		{name: "đổi giá trọn tuyến", mutate: func(in *TripInput) { in.Price = 160000 }, want: ErrTripFaresLocked},
+		{name: "đổi giá chặng", mutate: func(in *TripInput) { in.Fares[0].Price = 90000 }, want: ErrTripFaresLocked},
+		{
+			name: "thêm giá chặng",
+			mutate: func(in *TripInput) {
+				in.Fares = append(in.Fares, models.Fare{From: "Hà Nội", To: "Ninh Bình", Price: 80000})
+			},
+			want: ErrTripFaresLocked,
+		},
+		{
+			name: "dời giờ khởi hành",
+			mutate: func(in *TripInput) {
+				in.DepartureTime = in.DepartureTime.Add(time.Hour)
+				in.ExpectedArrivalTime = in.ExpectedArrivalTime.Add(time.Hour)
+			},
+			want: ErrTripTimesLocked,
+		},
+		{
+			name: "đổi giờ đến",
+			mutate: func(in *TripInput) {
+				in.ExpectedArrivalTime = in.ExpectedArrivalTime.Add(30 * time.Minute)
+				in.Route.Stops[2].OffsetMinutes = 240
+			},
+			want: ErrTripTimesLocked,
+		},
+		{name: "đổi giờ tới điểm dừng giữa", mutate: func(in *TripInput) { in.Route.Stops[1].OffsetMinutes = 100 }, want: ErrTripTimesLocked},
+		{name: "đổi xe", mutate: func(in *TripInput) { in.VehicleID = otherVehicle.ID.Hex() }, want: ErrTripHasBookings},
+		{
+			name: "đổi tên điểm dừng",
+			mutate: func(in *TripInput) {
+				in.Route.Stops[1].Name = "TP. Ninh Bình"
+				in.Fares[0].From = "TP. Ninh Bình"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := segmentTripInput(vehicle, departure)
+			tt.mutate(&input)
+			_, err := env.trips.UpdateTrip(trip.ID.Hex(), input, admin)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("UpdateTrip: err = %v, muốn %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateTrip: %v", err)
+			}
+			stored, err := env.repos.Trips.FindByID(t.Context(), trip.ID)
+			if err != nil {
+				t.Fatalf("đọc chuyến đi: %v", err)
+			}
+			if names := stored.Route.StopNames(); names[1] != "TP. Ninh Bình" {
+				t.Fatalf("điểm dừng = %v, muốn đã đổi tên", names)
+			}
+			if got := env.seatStatuses(t, trip.ID)["A1"]; got != models.SeatBooked {
+				t.Fatalf("ghế A1 = %s sau khi sửa, muốn vẫn booked", got)
+			}
+		})
+	}
+}
+
+func TestUpdateTripIgnoresInactiveBookings(t *testing.T) {
+	tests := []struct {
+		name string
+		book func(env *testEnv, trip *models.Trip)
+	}{
+		{
+			name: "giữ chỗ đã hết hạn",
+			book: func(env *testEnv, trip *models.Trip) {
+				user := env.addUser(t)
+				if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+					t.Fatalf("CreateBooking: %v", err)
+				}
+				if _, err := env.bookings.ExpireHeldBookings(t.Context(), time.Now().Add(testHoldDuration+time.Minute)); err != nil {
+					t.Fatalf("ExpireHeldBookings: %v", err)
+				}
+			},
+		},
+		{
+			name: "booking đã hủy",
+			book: func(env *testEnv, trip *models.Trip) {
+				booking, user := env.confirmedBooking(t, trip.ID, "", "")
+				if _, err := env.bookings.CancelBooking(booking.ID.Hex(), user.ID.Hex(), CancelBookingInput{}); err != nil {
+					t.Fatalf("CancelBooking: %v", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			_, vehicle := env.addCompanyVehicle(t, "BAC")
+			admin := &AccessClaims{Role: models.RoleAdmin}
+			departure := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+			trip, err := env.trips.CreateTrip(segmentTripInput(vehicle, departure), admin)
+			if err != nil {
+				t.Fatalf("CreateTrip: %v", err)
+			}
+			tt.book(env, trip)
+
+			input := segmentTripInput(vehicle, departure.Add(time.Hour))
+			input.Price = 160000
+			updated, err := env.trips.UpdateTrip(trip.ID.Hex(), input, admin)
+			if err != nil {
+				t.Fatalf("UpdateTrip: %v", err)
+			}
+			if updated.Price != 160000 || !updated.DepartureTime.Equal(input.DepartureTime) {
+				t.Fatalf("chuyến sau khi sửa: giá %v, khởi hành %v; muốn giá và giờ mới", updated.Price, updated.DepartureTime)
+			}
+
+			// Booking không còn hiệu lực vẫn giữ chuyến đi lại để lịch sử đặt vé tra được chuyến.
+			if err := env.trips.DeleteTrip(trip.ID.Hex(), admin); !errors.Is(err, ErrTripHasBookings) {
+				t.Fatalf("DeleteTrip: err = %v, muốn %v", err, ErrTripHasBookings)
+			}
+		})
+	}
+}
+
+func TestDeleteTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor func(company models.Company) *AccessClaims
+		held  bool
+		want  error
+	}{
+		{name: "nhân viên của nhà xe", actor: func(company models.Company) *AccessClaims {
+			return &AccessClaims{Role: models.RoleOperatorStaff, CompanyID: company.ID.Hex()}
+		}},
+		{name: "nhân viên nhà xe khác", actor: func(models.Company) *AccessClaims {
+			return &AccessClaims{Role: models.RoleOperatorStaff, CompanyID: primitive.NewObjectID().Hex()}
+		}, want: ErrCompanyForbidden},
+		{name: "chuyến đang có người giữ chỗ", actor: func(models.Company) *AccessClaims {
+			return &AccessClaims{Role: models.RoleAdmin}
+		}, held: true, want: ErrTripHasBookings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			company, vehicle := env.addCompanyVehicle(t, "BAC")
+			trip, err := env.trips.CreateTrip(segmentTripInput(vehicle, time.Now().Add(24*time.Hour)), &AccessClaims{Role: models.RoleAdmin})
+			if err != nil {
+				t.Fatalf("CreateTrip: %v", err)
+			}
+			if tt.held {
+				user := env.addUser(t)
+				if _, err := env.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex()); err != nil {
+					t.Fatalf("CreateBooking: %v", err)
+				}
+			}
+
+			err = env.trips.DeleteTrip(trip.ID.Hex(), tt.actor(company))
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("DeleteTrip: err = %v, muốn %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeleteTrip: %v", err)
+			}
+			if _, err := env.repos.Trips.FindByID(t.Context(), trip.ID); !errors.Is(err, repositories.ErrNotFound) {
+				t.Fatalf("đọc chuyến đã xóa: err = %v, muốn ErrNotFound", err)
+			}
+		})
+	}
+}
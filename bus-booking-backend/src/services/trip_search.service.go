@@ -0,0 +1,289 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+// ErrInvalidCursor được trả về khi cursor phân trang bị sửa hoặc được dùng với cách sắp xếp khác.
+var ErrInvalidCursor = apperrors.New(apperrors.ErrInvalidInput, "cursor phân trang không hợp lệ").WithCode("invalid_cursor")
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// boardingLookback là khoảng tối đa từ giờ khởi hành của chuyến đến giờ xe tới một điểm lên
+	// ở giữa tuyến. Truy vấn theo giờ khởi hành được nới thêm khoảng này về trước rồi lọc lại theo
+	// giờ lên xe.
+	boardingLookback = 24 * time.Hour
+)
+
+// Các cách sắp xếp kết quả tìm kiếm. Giá và thời gian chạy tính theo chặng khách chọn; đánh
+// giá là điểm của nhà xe, cao trước.
+const (
+	SortByDeparture = repositories.TripSortDeparture
+	SortByPrice     = repositories.TripSortPrice
+	SortByDuration  = repositories.TripSortDuration
+	SortByRating    = repositories.TripSortRating
+)
+
+// TripSearchQuery là các tham số tìm kiếm chuyến đi; mọi tham số đều tùy chọn. Ngày và giờ trong
+// date, departAfter/departBefore được hiểu theo múi giờ vận hành và so với giờ xe tới điểm lên.
+// Chuyến có giờ lên xe đã qua bị bỏ qua, trừ khi includePast=true.
+type TripSearchQuery struct {
+	From         string   `form:"from" validate:"max=100"`
+	To           string   `form:"to" validate:"max=100"`
+	Date         string   `form:"date" validate:"omitempty,datetime=2006-01-02"`
+	CompanyIDs   []string `form:"companyId" validate:"max=20"`
+	VehicleType  string   `form:"vehicleType" validate:"max=100"`
+	MinPrice     float64  `form:"minPrice" validate:"gte=0"`
+	MaxPrice     float64  `form:"maxPrice" validate:"omitempty,gtefield=MinPrice"`
+	DepartAfter  string   `form:"departAfter" validate:"omitempty,datetime=15:04"`
+	DepartBefore string   `form:"departBefore" validate:"omitempty,datetime=15:04"`
+	MinSeats     int      `form:"minSeats" validate:"gte=0,lte=100"`
+	SeatType     string   `form:"seatType" validate:"omitempty,oneof=seat bed vip"`
+	Sort         string   `form:"sort" validate:"omitempty,oneof=departure price duration rating"`
+	Cursor       string   `form:"cursor" validate:"max=500"`
+	Limit        int      `form:"limit" validate:"gte=0,lte=100"`
+	IncludePast  bool     `form:"includePast"`
+}
+
+// TripSearchResult là một trang kết quả tìm kiếm. NextCursor rỗng khi không còn trang sau.
+type TripSearchResult struct {
+	Trips      []models.Trip `json:"trips"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	Facets     TripFacets    `json:"facets"`
+}
+
+// TripFacets đếm số chuyến khớp tìm kiếm theo nhà xe và loại xe. Mỗi nhóm được đếm với mọi bộ
+// lọc trừ bộ lọc của chính nhóm đó, để client hiển thị được các lựa chọn khác.
+type TripFacets struct {
+	Companies    []CompanyFacet     `json:"companies"`
+	VehicleTypes []VehicleTypeFacet `json:"vehicleTypes"`
+}
+
+type CompanyFacet struct {
+	CompanyID primitive.ObjectID `json:"companyId"`
+	Name      string             `json:"name"`
+	Count     int                `json:"count"`
+}
+
+type VehicleTypeFacet struct {
+	VehicleType string `json:"vehicleType"`
+	Count       int    `json:"count"`
+}
+
+// tripCursor là vị trí của chuyến cuối cùng trong trang trước theo thứ tự (Key, Departure, ID);
+// Departure là giờ lên xe tính bằng mili giây.
+type tripCursor struct {
+	Sort      string  `json:"s"`
+	Key       float64 `json:"k"`
+	Departure int64   `json:"d"`
+	ID        string  `json:"i"`
+}
+
+// SearchTrips tìm chuyến đi theo query. from/to là điểm dừng bất kỳ trên tuyến, theo đúng thứ tự
+// (thiếu from là điểm đầu, thiếu to là điểm cuối); khi có một trong hai, mỗi chuyến trả về kèm
+// segmentInfo, trạng thái ghế và số ghế trống được tính riêng cho chặng đó, và chuyến không bán
+// vé chặng này bị bỏ qua. Ngày và khung giờ được so với giờ xe tới điểm lên. Số ghế trống được
+// tính trong cơ sở dữ liệu và kết quả không kèm danh sách ghế (xem GetTripByID).
+func (s *TripService) SearchTrips(query TripSearchQuery) (*TripSearchResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	search := repositories.TripSearch{
+		Location:     s.location,
+		VehicleType:  query.VehicleType,
+		MinPrice:     query.MinPrice,
+		MaxPrice:     query.MaxPrice,
+		DepartAfter:  query.DepartAfter,
+		DepartBefore: query.DepartBefore,
+		MinSeats:     query.MinSeats,
+		SeatType:     models.SeatType(query.SeatType),
+		Sort:         cmp.Or(query.Sort, SortByDeparture),
+		Limit:        query.Limit,
+	}
+	if search.Limit < 1 || search.Limit > maxSearchLimit {
+		search.Limit = defaultSearchLimit
+	}
+	if query.Cursor != "" {
+		after, err := decodeTripCursor(query.Cursor, search.Sort)
+		if err != nil {
+			return nil, err
+		}
+		search.After = after
+	}
+	companyIDs, err := parseCompanyIDs(query.CompanyIDs)
+	if err != nil {
+		return nil, err
+	}
+	search.CompanyIDs = companyIDs
+
+	// Điểm đi và điểm đến người dùng gõ ("tp hcm", "Ho Chi Minh") được đổi sang địa điểm trong
+	// danh mục; tuyến đường được so khớp theo locationId nên vẫn tìm thấy khi địa điểm đổi tên.
+	if search.From, search.FromLocationID, err = resolveLocationQuery(ctx, s.locations, query.From); err != nil {
+		return nil, err
+	}
+	if search.To, search.ToLocationID, err = resolveLocationQuery(ctx, s.locations, query.To); err != nil {
+		return nil, err
+	}
+	if search.ExcludeCompanyIDs, err = s.inactiveCompanyIDs(ctx); err != nil {
+		return nil, err
+	}
+
+	// Điểm lên ở giữa tuyến có thể tới trong khoảng tìm kiếm dù xe đã khởi hành từ hôm trước, nên
+	// giờ khởi hành được nới thêm boardingLookback rồi lọc chính xác theo giờ lên xe.
+	now := time.Now()
+	if query.Date != "" {
+		// Ngày được hiểu theo múi giờ vận hành: chuyến 06:00 giờ Việt Nam (23:00 UTC hôm trước)
+		// thuộc về ngày của giờ Việt Nam.
+		startOfDay, err := time.ParseInLocation(models.DateLayout, query.Date, s.location)
+		if err != nil {
+			log.Printf("Lỗi phân tích ngày: %v", err)
+			return nil, apperrors.Wrap(apperrors.ErrInvalidInput, "định dạng ngày không hợp lệ, vui lòng sử dụng YYYY-MM-DD", err).WithCode("invalid_date")
+		}
+		search.BoardingFrom = startOfDay
+		search.BoardingTo = startOfDay.AddDate(0, 0, 1)
+	}
+	if !query.IncludePast && search.BoardingFrom.Before(now) {
+		search.BoardingFrom = now
+	}
+	if !search.BoardingFrom.IsZero() {
+		search.DepartureFrom = search.BoardingFrom.Add(-boardingLookback)
+	}
+	search.DepartureTo = search.BoardingTo
+
+	page, err := s.trips.Search(ctx, search)
+	if err != nil {
+		log.Printf("Lỗi khi tìm kiếm chuyến đi: %v", err)
+		return nil, internalError("lỗi máy chủ khi tìm kiếm chuyến đi", err)
+	}
+
+	result := &TripSearchResult{Trips: []models.Trip{}, Facets: buildFacets(page)}
+	summaries := page.Trips
+	if len(summaries) > search.Limit {
+		summaries = summaries[:search.Limit]
+		last := summaries[search.Limit-1]
+		result.NextCursor = encodeTripCursor(tripCursor{
+			Sort:      search.Sort,
+			Key:       last.SortKey,
+			Departure: last.BoardingTime.UnixMilli(),
+			ID:        last.ID.Hex(),
+		})
+	}
+
+	trips := make([]models.Trip, len(summaries))
+	for i := range summaries {
+		trips[i] = summaries[i].Trip
+	}
+	if err := attachCompanies(ctx, s.companies, trips); err != nil {
+		log.Printf("Lỗi khi lấy thông tin nhà xe cho danh sách chuyến đi: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn nhà xe", err)
+	}
+	vehicles, err := s.tripVehicles(ctx, trips)
+	if err != nil {
+		return nil, err
+	}
+	for i, trip := range trips {
+		if search.From != "" || search.To != "" {
+			trip.SegmentInfo = trip.DescribeSegment(summaries[i].Segment, summaries[i].FarePrice)
+		}
+		trip.AvailableSeats = summaries[i].AvailableSeats
+		if vehicle, ok := vehicles[trip.VehicleID]; ok {
+			// Danh sách chỉ cần loại xe; sơ đồ ghế có trong chi tiết chuyến đi.
+			info := *vehicle
+			info.SeatMap = nil
+			trip.VehicleInfo = &info
+		}
+		trip.InLocation(s.location)
+		result.Trips = append(result.Trips, trip)
+	}
+	return result, nil
+}
+
+// tripVehicles đọc các xe của danh sách chuyến đi bằng một truy vấn.
+func (s *TripService) tripVehicles(ctx context.Context, trips []models.Trip) (map[primitive.ObjectID]*models.Vehicle, error) {
+	ids := make([]primitive.ObjectID, 0, len(trips))
+	for _, trip := range trips {
+		if !slices.Contains(ids, trip.VehicleID) {
+			ids = append(ids, trip.VehicleID)
+		}
+	}
+	found, err := s.vehicles.FindByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("Lỗi khi lấy thông tin xe cho danh sách chuyến đi: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn xe", err)
+	}
+	byID := make(map[primitive.ObjectID]*models.Vehicle, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+	return byID, nil
+}
+
+func parseCompanyIDs(values []string) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			id, err := primitive.ObjectIDFromHex(part)
+			if err != nil {
+				return nil, ErrInvalidCompanyID
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// buildFacets sắp xếp các nhóm đếm, nhiều chuyến trước.
+func buildFacets(page *repositories.TripSearchPage) TripFacets {
+	facets := TripFacets{Companies: []CompanyFacet{}, VehicleTypes: []VehicleTypeFacet{}}
+	for _, count := range page.Companies {
+		facets.Companies = append(facets.Companies, CompanyFacet{CompanyID: count.CompanyID, Name: count.Name, Count: count.Count})
+	}
+	for _, count := range page.VehicleTypes {
+		facets.VehicleTypes = append(facets.VehicleTypes, VehicleTypeFacet{VehicleType: count.VehicleType, Count: count.Count})
+	}
+	slices.SortFunc(facets.Companies, func(a, b CompanyFacet) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Name, b.Name))
+	})
+	slices.SortFunc(facets.VehicleTypes, func(a, b VehicleTypeFacet) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.VehicleType, b.VehicleType))
+	})
+	return facets
+}
+
+func encodeTripCursor(cursor tripCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTripCursor(value, sortBy string) (*repositories.TripSearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor tripCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sortBy {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &repositories.TripSearchCursor{SortKey: cursor.Key, BoardingTime: time.UnixMilli(cursor.Departure), ID: id}, nil
+}
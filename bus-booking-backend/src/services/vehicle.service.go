@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+var (
+	ErrInvalidVehicleID = apperrors.New(apperrors.ErrInvalidID, "ID xe không hợp lệ").WithCode("invalid_vehicle_id")
+	ErrVehicleNotFound  = apperrors.New(apperrors.ErrNotFound, "không tìm thấy xe").WithCode("vehicle_not_found")
+	// ErrVehicleInUse được trả về khi xóa xe hoặc đổi sơ đồ ghế của xe đã được gán cho chuyến đi.
+	ErrVehicleInUse = apperrors.New(apperrors.ErrConflict, "xe đã được gán cho chuyến đi, không thể xóa hoặc thay đổi sơ đồ ghế").WithCode("vehicle_in_use")
+)
+
+// VehicleInput là dữ liệu tạo hoặc cập nhật xe. Số ô "seat" trong sơ đồ phải bằng totalSeats.
+type VehicleInput struct {
+	CompanyID  string          `json:"companyId" validate:"required"`
+	Type       string          `json:"type" validate:"required,max=100"`
+	TotalSeats int             `json:"totalSeats" validate:"required,min=1,max=100"`
+	SeatMap    *models.SeatMap `json:"seatMap" validate:"required"`
+}
+
+// VehicleService quản lý xe và sơ đồ ghế của các nhà xe. Quản trị viên quản lý mọi xe,
+// nhân viên nhà xe chỉ quản lý xe của nhà xe mình.
+type VehicleService struct {
+	vehicles  repositories.VehicleRepository
+	companies repositories.CompanyRepository
+	trips     repositories.TripRepository
+}
+
+func NewVehicleService(vehicles repositories.VehicleRepository, companies repositories.CompanyRepository, trips repositories.TripRepository) *VehicleService {
+	return &VehicleService{vehicles: vehicles, companies: companies, trips: trips}
+}
+
+func (s *VehicleService) CreateVehicle(input VehicleInput, actor *AccessClaims) (*models.Vehicle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companyID, err := s.checkVehicleInput(ctx, input, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	vehicle := models.Vehicle{
+		ID:         primitive.NewObjectID(),
+		CompanyID:  companyID,
+		Type:       strings.TrimSpace(input.Type),
+		TotalSeats: input.TotalSeats,
+		SeatMap:    input.SeatMap,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.vehicles.Create(ctx, &vehicle); err != nil {
+		log.Printf("Lỗi khi tạo xe cho nhà xe %s: %v", companyID.Hex(), err)
+		return nil, internalError("không thể tạo xe", err)
+	}
+	return &vehicle, nil
+}
+
+// UpdateVehicle cập nhật xe. Sơ đồ ghế và số ghế chỉ được đổi khi xe chưa được gán cho chuyến
+// đi nào, vì danh sách ghế của chuyến đi được sinh từ sơ đồ này.
+func (s *VehicleService) UpdateVehicle(vehicleIDStr string, input VehicleInput, actor *AccessClaims) (*models.Vehicle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vehicle, err := s.findManagedVehicle(ctx, vehicleIDStr, actor)
+	if err != nil {
+		return nil, err
+	}
+	companyID, err := s.checkVehicleInput(ctx, input, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	layoutChanged := vehicle.TotalSeats != input.TotalSeats || !reflect.DeepEqual(vehicle.SeatMap, input.SeatMap)
+	if layoutChanged || vehicle.CompanyID != companyID {
+		if err := s.ensureUnused(ctx, vehicle.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	vehicle.CompanyID = companyID
+	vehicle.Type = strings.TrimSpace(input.Type)
+	vehicle.TotalSeats = input.TotalSeats
+	vehicle.SeatMap = input.SeatMap
+	vehicle.UpdatedAt = time.Now()
+	if err := s.vehicles.Update(ctx, vehicle); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrVehicleNotFound
+		}
+		log.Printf("Lỗi khi cập nhật xe %s: %v", vehicleIDStr, err)
+		return nil, internalError("không thể cập nhật xe", err)
+	}
+	return vehicle, nil
+}
+
+// DeleteVehicle xóa xe chưa được gán cho chuyến đi nào.
+func (s *VehicleService) DeleteVehicle(vehicleIDStr string, actor *AccessClaims) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vehicle, err := s.findManagedVehicle(ctx, vehicleIDStr, actor)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureUnused(ctx, vehicle.ID); err != nil {
+		return err
+	}
+
+	if err := s.vehicles.Delete(ctx, vehicle.ID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrVehicleNotFound
+		}
+		log.Printf("Lỗi khi xóa xe %s: %v", vehicleIDStr, err)
+		return internalError("không thể xóa xe", err)
+	}
+	return nil
+}
+
+func (s *VehicleService) GetVehicle(vehicleIDStr string, actor *AccessClaims) (*models.Vehicle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.findManagedVehicle(ctx, vehicleIDStr, actor)
+}
+
+// ListVehicles liệt kê xe. Nhân viên nhà xe luôn chỉ thấy xe của nhà xe mình; quản trị viên
+// có thể lọc theo companyIDStr hoặc để trống để xem tất cả.
+func (s *VehicleService) ListVehicles(companyIDStr string, actor *AccessClaims) ([]models.Vehicle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if actor.Role == models.RoleOperatorStaff {
+		companyIDStr = actor.CompanyID
+	}
+
+	filter := repositories.VehicleFilter{}
+	if companyIDStr != "" {
+		companyID, err := primitive.ObjectIDFromHex(companyIDStr)
+		if err != nil {
+			return nil, ErrInvalidCompanyID
+		}
+		if err := authorizeCompany(actor, companyID); err != nil {
+			return nil, err
+		}
+		filter.CompanyID = &companyID
+	}
+
+	vehicles, err := s.vehicles.List(ctx, filter)
+	if err != nil {
+		log.Printf("Lỗi khi liệt kê xe: %v", err)
+		return nil, internalError("lỗi máy chủ khi truy vấn xe", err)
+	}
+	return vehicles, nil
+}
+
+// checkVehicleInput kiểm tra quyền trên nhà xe đích, nhà xe còn hoạt động và sơ đồ ghế khớp số ghế.
+func (s *VehicleService) checkVehicleInput(ctx context.Context, input VehicleInput, actor *AccessClaims) (primitive.ObjectID, error) {
+	companyID, err := primitive.ObjectIDFromHex(input.CompanyID)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidCompanyID
+	}
+	if err := authorizeCompany(actor, companyID); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	company, err := s.companies.FindByID(ctx, companyID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return primitive.NilObjectID, ErrCompanyNotFound
+		}
+		log.Printf("Lỗi khi tìm nhà xe %s: %v", input.CompanyID, err)
+		return primitive.NilObjectID, internalError("lỗi máy chủ khi truy vấn nhà xe", err)
+	}
+	if !company.IsActive() {
+		return primitive.NilObjectID, ErrCompanyInactive
+	}
+
+	if err := input.SeatMap.Validate(input.TotalSeats); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return companyID, nil
+}
+
+// findManagedVehicle tìm xe và kiểm tra người dùng được quản lý nhà xe sở hữu xe đó. Xe của nhà
+// xe khác được báo là không tồn tại để không lộ ID xe của nhà xe khác.
+func (s *VehicleService) findManagedVehicle(ctx context.Context, vehicleIDStr string, actor *AccessClaims) (*models.Vehicle, error) {
+	vehicleID, err := primitive.ObjectIDFromHex(vehicleIDStr)
+	if err != nil {
+		return nil, ErrInvalidVehicleID
+	}
+	vehicle, err := s.vehicles.FindByID(ctx, vehicleID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrVehicleNotFound
+		}
+		log.Printf("Lỗi khi tìm xe %s: %v", vehicleIDStr, err)
+		return nil, internalError("lỗi máy chủ khi truy vấn xe", err)
+	}
+	if authorizeCompany(actor, vehicle.CompanyID) != nil {
+		return nil, ErrVehicleNotFound
+	}
+	return vehicle, nil
+}
+
+func (s *VehicleService) ensureUnused(ctx context.Context, vehicleID primitive.ObjectID) error {
+	count, err := s.trips.CountByVehicle(ctx, vehicleID)
+	if err != nil {
+		log.Printf("Lỗi khi đếm chuyến đi của xe %s: %v", vehicleID.Hex(), err)
+		return internalError("lỗi máy chủ khi truy vấn chuyến đi", err)
+	}
+	if count > 0 {
+		return ErrVehicleInUse
+	}
+	return nil
+}
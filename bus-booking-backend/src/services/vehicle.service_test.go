@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+func TestCreateVehicleValidatesSeatMap(t *testing.T) {
+	seat := func(row, column int, code string) models.SeatCell {
+		return models.SeatCell{Row: row, Column: column, Kind: models.CellSeat, Code: code}
+	}
+	deck := func(name string, cells ...models.SeatCell) models.Deck {
+		return models.Deck{Name: name, Rows: 2, Columns: 3, Cells: cells}
+	}
+
+	tests := []struct {
+		name       string
+		totalSeats int
+		decks      []models.Deck
+		// wantProblems là các đoạn phải có trong thông báo lỗi; rỗng khi sơ đồ hợp lệ.
+		wantProblems []string
+	}{
+		{
+			name:       "ghế ngồi có lối đi và ô không bán",
+			totalSeats: 3,
+			decks: []models.Deck{deck("",
+				seat(0, 0, "A1"), models.SeatCell{Row: 0, Column: 1, Kind: models.CellAisle}, seat(0, 2, "A2"),
+				seat(1, 0, "B1"), models.SeatCell{Row: 1, Column: 2, Kind: models.CellDisabled},
+			)},
+		},
+		{
+			name:       "giường nằm hai tầng",
+			totalSeats: 2,
+			decks: []models.Deck{
+				deck("Tầng dưới", models.SeatCell{Kind: models.CellSeat, Code: "A1", SeatType: models.SeatTypeBed}),
+				deck("Tầng trên", models.SeatCell{Kind: models.CellSeat, Code: "B1", SeatType: models.SeatTypeBed}),
+			},
+		},
+		{
+			name:         "số ghế không khớp tổng số ghế",
+			totalSeats:   3,
+			decks:        []models.Deck{deck("", seat(0, 0, "A1"), seat(0, 1, "A2"))},
+			wantProblems: []string{"sơ đồ có 2 ghế nhưng tổng số ghế là 3"},
+		},
+		{
+			name:         "ô không bán không tính là ghế",
+			totalSeats:   2,
+			decks:        []models.Deck{deck("", seat(0, 0, "A1"), models.SeatCell{Row: 0, Column: 1, Kind: models.CellDisabled, Code: "A2"})},
+			wantProblems: []string{"sơ đồ có 1 ghế nhưng tổng số ghế là 2"},
+		},
+		{
+			name:         "ô nằm ngoài lưới",
+			totalSeats:   2,
+			decks:        []models.Deck{deck("", seat(0, 0, "A1"), seat(2, 0, "C1"))},
+			wantProblems: []string{"tầng 1, ô (2,0) nằm ngoài lưới"},
+		},
+		{
+			name:         "hai ô cùng vị trí",
+			totalSeats:   1,
+			decks:        []models.Deck{deck("", seat(0, 0, "A1"), models.SeatCell{Row: 0, Column: 0, Kind: models.CellAisle})},
+			wantProblems: []string{"tầng 1, ô (0,0) bị khai báo hai lần"},
+		},
+		{
+			name:         "ghế thiếu mã",
+			totalSeats:   2,
+			decks:        []models.Deck{deck("", seat(0, 0, "A1"), seat(0, 1, " "))},
+			wantProblems: []string{"tầng 1, ô (0,1) là ghế nhưng thiếu mã ghế"},
+		},
+		{
+			name:       "mã ghế trùng giữa hai tầng",
+			totalSeats: 2,
+			decks: []models.Deck{
+				deck("Tầng dưới", seat(0, 0, "A1")),
+				deck("Tầng trên", seat(0, 0, "A1")),
+			},
+			wantProblems: []string{"mã ghế 'A1' bị trùng"},
+		},
+		{
+			name:       "gộp mọi vi phạm vào một lỗi",
+			totalSeats: 4,
+			decks:      []models.Deck{deck("", seat(0, 0, "A1"), seat(0, 0, "A1"), seat(5, 5, "F6"))},
+			wantProblems: []string{
+				"tầng 1, ô (0,0) bị khai báo hai lần",
+				"mã ghế 'A1' bị trùng",
+				"tầng 1, ô (5,5) nằm ngoài lưới",
+				"sơ đồ có 3 ghế nhưng tổng số ghế là 4",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			vehicles := NewVehicleService(env.repos.Vehicles, env.repos.Companies, env.repos.Trips)
+			company, _ := env.addCompanyVehicle(t, "XE")
+			input := VehicleInput{
+				CompanyID:  company.ID.Hex(),
+				Type:       "Limousine",
+				TotalSeats: tt.totalSeats,
+				SeatMap:    &models.SeatMap{Decks: tt.decks},
+			}
+
+			vehicle, err := vehicles.CreateVehicle(input, &AccessClaims{Role: models.RoleAdmin})
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("CreateVehicle: %v", err)
+				}
+				if got := len(vehicle.SeatMap.Seats()); got != tt.totalSeats {
+					t.Fatalf("sơ đồ có %d ghế bán được, muốn %d", got, tt.totalSeats)
+				}
+				return
+			}
+
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) || appErr.Code != models.ErrInvalidSeatMap.Code {
+				t.Fatalf("CreateVehicle: err = %v, muốn %v", err, models.ErrInvalidSeatMap)
+			}
+			for _, problem := range tt.wantProblems {
+				if !strings.Contains(appErr.Message, problem) {
+					t.Errorf("thông báo lỗi %q thiếu %q", appErr.Message, problem)
+				}
+			}
+			// Nhà xe chỉ còn chiếc xe do addCompanyVehicle nạp sẵn.
+			stored, err := env.repos.Vehicles.List(t.Context(), repositories.VehicleFilter{CompanyID: &company.ID})
+			if err != nil {
+				t.Fatalf("liệt kê xe: %v", err)
+			}
+			if len(stored) != 1 {
+				t.Fatalf("nhà xe có %d xe, muốn sơ đồ sai không được lưu", len(stored))
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/apperrors"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+var (
+	// ErrWebhookReplayed được trả về khi sự kiện đã được xử lý trước đó.
+	ErrWebhookReplayed = apperrors.New(apperrors.ErrConflict, "sự kiện webhook đã được xử lý trước đó").WithCode("webhook_replayed")
+	// ErrWebhookInProgress được trả về khi cùng một sự kiện đang được xử lý ở một request khác.
+	ErrWebhookInProgress = apperrors.New(apperrors.ErrConflict, "sự kiện webhook đang được xử lý").WithCode("webhook_in_progress")
+	// ErrPaymentNotFound được trả về khi webhook tham chiếu tới giao dịch không tồn tại.
+	ErrPaymentNotFound = apperrors.New(apperrors.ErrNotFound, "không tìm thấy giao dịch thanh toán").WithCode("payment_not_found")
+)
+
+// webhookLease là thời gian một request được giữ quyền xử lý sự kiện webhook, dài hơn thời gian
+// chờ tối đa của request. Hết hạn mà sự kiện vẫn "processing" nghĩa là request đó đã dừng giữa
+// chừng và lần gửi lại tiếp theo được nhận xử lý.
+const webhookLease = time.Minute
+
+// HandlePaymentWebhook xác thực và áp dụng một webhook từ cổng thanh toán.
+// Mỗi sự kiện chỉ được áp dụng đúng một lần theo mã sự kiện; các lần gửi lại
+// trả về ErrWebhookReplayed, hoặc ErrWebhookInProgress khi lần xử lý trước còn
+// trong hạn webhookLease. Bản thân các chuyển trạng thái cũng idempotent nên
+// có thể chạy lại an toàn nếu lần xử lý trước bị gián đoạn.
+func (s *PaymentService) HandlePaymentWebhook(payload []byte, header http.Header) (*payments.WebhookEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	event, err := s.gateway.ParseWebhook(payload, header)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lockedUntil := now.Add(webhookLease)
+	record := models.WebhookEvent{
+		ID:          event.ID,
+		Provider:    s.gateway.Name(),
+		Type:        event.Type,
+		IntentID:    event.IntentID,
+		Status:      "processing",
+		ReceivedAt:  now,
+		LockedUntil: &lockedUntil,
+	}
+	if err := s.events.Create(ctx, &record); err != nil {
+		if !errors.Is(err, repositories.ErrDuplicate) {
+			log.Printf("Lỗi khi lưu sự kiện webhook %s: %v", event.ID, err)
+			return nil, internalError("lỗi hệ thống khi ghi nhận webhook", err)
+		}
+		if err := s.takeOverWebhookEvent(ctx, event.ID, now, lockedUntil); err != nil {
+			return event, err
+		}
+		log.Printf("Nhận xử lý tiếp sự kiện webhook %s đã quá hạn xử lý", event.ID)
+	}
+
+	if err := s.applyWebhookEvent(ctx, event); err != nil {
+		// Xóa bản ghi để lần gửi lại tiếp theo của cổng thanh toán được xử lý lại.
+		if delErr := s.events.Delete(ctx, event.ID); delErr != nil {
+			log.Printf("Lỗi khi xóa sự kiện webhook %s: %v", event.ID, delErr)
+		}
+		return event, err
+	}
+
+	if err := s.events.MarkProcessed(ctx, event.ID, time.Now()); err != nil {
+		log.Printf("Lỗi khi đánh dấu sự kiện webhook %s đã xử lý: %v", event.ID, err)
+	}
+	return event, nil
+}
+
+// takeOverWebhookEvent nhận xử lý sự kiện đã được ghi nhận trước đó nếu lần xử lý trước đã quá hạn.
+func (s *PaymentService) takeOverWebhookEvent(ctx context.Context, id string, now, lockedUntil time.Time) error {
+	err := s.events.TakeOver(ctx, id, now, lockedUntil)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repositories.ErrNotFound):
+		// Lần xử lý trước vừa thất bại và xóa bản ghi; cổng thanh toán sẽ gửi lại.
+		return ErrWebhookInProgress
+	case !errors.Is(err, repositories.ErrConflict):
+		log.Printf("Lỗi khi nhận xử lý sự kiện webhook %s: %v", id, err)
+		return internalError("lỗi hệ thống khi ghi nhận webhook", err)
+	}
+	if existing, err := s.events.FindByID(ctx, id); err == nil && existing.Status == "processing" {
+		return ErrWebhookInProgress
+	}
+	return ErrWebhookReplayed
+}
+
+func (s *PaymentService) applyWebhookEvent(ctx context.Context, event *payments.WebhookEvent) error {
+	payment, err := s.payments.FindByIntent(ctx, s.gateway.Name(), event.IntentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrPaymentNotFound
+		}
+		log.Printf("Lỗi khi tìm giao dịch %s: %v", event.IntentID, err)
+		return internalError("lỗi hệ thống khi truy vấn giao dịch", err)
+	}
+
+	switch event.Type {
+	case payments.EventPaymentSucceeded:
+		if payment.Status == "succeeded" || payment.Status == "refunded" {
+			return nil
+		}
+		booking, err := s.bookings.FindByID(ctx, payment.BookingID)
+		if err != nil {
+			log.Printf("Lỗi khi tìm booking %s của giao dịch %s: %v", payment.BookingID.Hex(), payment.IntentID, err)
+			return internalError("lỗi hệ thống khi truy vấn booking", err)
+		}
+		if booking.PaymentID != nil && *booking.PaymentID == payment.ID {
+			// Lần xử lý trước đã xác nhận booking nhưng có thể chưa ghi xong ghế.
+			s.setPaymentStatus(ctx, payment, "succeeded", "")
+			if err := s.bookSeats(ctx, booking); err != nil {
+				return ErrSeatSyncPending
+			}
+			return nil
+		}
+		// Booking đã hết hạn hoặc đã được thanh toán bằng giao dịch khác:
+		// confirmBookingPayment sẽ hoàn tiền cho giao dịch này.
+		if err := s.confirmBookingPayment(ctx, booking, payment, models.ActorGateway); err != nil && !errors.Is(err, ErrBookingNotPayable) {
+			return err
+		}
+	case payments.EventPaymentFailed:
+		if payment.Status == "pending" {
+			s.setPaymentStatus(ctx, payment, "failed", "cổng thanh toán báo giao dịch thất bại")
+		}
+	case payments.EventRefundSucceeded:
+		// Hoàn tiền do hệ thống khởi tạo đã được ghi nhận khi hủy vé; ở đây chỉ xử lý
+		// trường hợp nhà xe hoàn toàn bộ tiền trực tiếp trên cổng thanh toán.
+		if payment.Status == "succeeded" && len(payment.Refunds) == 0 && event.Amount >= payment.Amount {
+			s.setPaymentStatus(ctx, payment, "refunded", "")
+		}
+	default:
+		log.Printf("Bỏ qua sự kiện webhook %s không được hỗ trợ: %s", event.ID, event.Type)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
+	"github.com/Go_final_exam/bus-booking-backend/src/payments"
+	"github.com/Go_final_exam/bus-booking-backend/src/repositories"
+)
+
+// pendingPayment tạo booking đã thanh toán nhưng cổng thanh toán chưa trả kết quả, để webhook
+// xác nhận sau.
+func (e *testEnv) pendingPayment(t *testing.T) (models.Trip, *models.Booking) {
+	t.Helper()
+	e.gateway.SetOutcome(payments.MockOutcomeTimeout)
+	trip := e.addTrip(t, "Hà Nội", "Hải Phòng", time.Now().Add(48*time.Hour), "A1")
+	user := e.addUser(t)
+	booking, err := e.bookings.CreateBooking(CreateBookingInput{TripID: trip.ID.Hex(), SeatNumbers: []string{"A1"}}, user.ID.Hex())
+	if err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+	if _, err := e.bookings.UpdatePassengers(booking.ID.Hex(), user.ID.Hex(), UpdatePassengersInput{}); err != nil {
+		t.Fatalf("UpdatePassengers: %v", err)
+	}
+	if _, err := e.payments.PayBooking(booking.ID.Hex(), user.ID.Hex()); !errors.Is(err, ErrPaymentTimeout) {
+		t.Fatalf("PayBooking: lỗi = %v, muốn ErrPaymentTimeout", err)
+	}
+	return trip, booking
+}
+
+// signedWebhook dựng webhook "payment.succeeded" cho giao dịch đầu tiên của cổng giả lập, ký bằng secret.
+func signedWebhook(t *testing.T, eventID, secret string) ([]byte, http.Header) {
+	t.Helper()
+	payload, err := json.Marshal(payments.WebhookEvent{ID: eventID, Type: payments.EventPaymentSucceeded, IntentID: "mock_pi_000001"})
+	if err != nil {
+		t.Fatalf("mã hóa webhook: %v", err)
+	}
+	header := http.Header{}
+	header.Set(payments.SignatureHeader, payments.SignPayload(secret, payload))
+	return payload, header
+}
+
+func (e *testEnv) assertConfirmed(t *testing.T, trip models.Trip, booking *models.Booking) {
+	t.Helper()
+	stored, err := e.repos.Bookings.FindByID(t.Context(), booking.ID)
+	if err != nil {
+		t.Fatalf("đọc booking: %v", err)
+	}
+	if stored.Status != models.BookingConfirmed {
+		t.Fatalf("trạng thái booking = %s, muốn confirmed", stored.Status)
+	}
+	if status := e.seatStatuses(t, trip.ID)["A1"]; status != models.SeatBooked {
+		t.Fatalf("ghế A1 = %s, muốn booked", status)
+	}
+}
+
+func TestHandlePaymentWebhookRejectsBadSignature(t *testing.T) {
+	env := newTestEnv(t)
+	_, booking := env.pendingPayment(t)
+
+	payload, header := signedWebhook(t, "evt_1", "secret-khác")
+	if _, err := env.payments.HandlePaymentWebhook(payload, header); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Fatalf("lỗi = %v, muốn ErrInvalidSignature", err)
+	}
+	if _, err := env.payments.HandlePaymentWebhook(payload, http.Header{}); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Fatalf("thiếu chữ ký: lỗi = %v, muốn ErrInvalidSignature", err)
+	}
+	if _, err := env.repos.WebhookEvents.FindByID(t.Context(), "evt_1"); !errors.Is(err, repositories.ErrNotFound) {
+		t.Fatalf("sự kiện sai chữ ký vẫn được ghi nhận: err = %v", err)
+	}
+	stored, err := env.repos.Bookings.FindByID(t.Context(), booking.ID)
+	if err != nil || stored.Status != models.BookingHeld {
+		t.Fatalf("booking = %+v, err = %v; muốn vẫn held", stored, err)
+	}
+}
+
+func TestHandlePaymentWebhookReplay(t *testing.T) {
+	env := newTestEnv(t)
+	trip, booking := env.pendingPayment(t)
+	payload, header := signedWebhook(t, "evt_1", "test-webhook-secret")
+
+	if _, err := env.payments.HandlePaymentWebhook(payload, header); err != nil {
+		t.Fatalf("lần đầu: %v", err)
+	}
+	env.assertConfirmed(t, trip, booking)
+
+	if _, err := env.payments.HandlePaymentWebhook(payload, header); !errors.Is(err, ErrWebhookReplayed) {
+		t.Fatalf("gửi lại: lỗi = %v, muốn ErrWebhookReplayed", err)
+	}
+}
+
+func TestHandlePaymentWebhookConcurrentDelivery(t *testing.T) {
+	env := newTestEnv(t)
+	trip, booking := env.pendingPayment(t)
+	payload, header := signedWebhook(t, "evt_1", "test-webhook-secret")
+
+	const deliveries = 10
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied int
+		others  []error
+	)
+	start := make(chan struct{})
+	for i := 0; i < deliveries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := env.payments.HandlePaymentWebhook(payload, header)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				applied++
+			case errors.Is(err, ErrWebhookInProgress), errors.Is(err, ErrWebhookReplayed):
+			default:
+				others = append(others, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if applied != 1 || len(others) != 0 {
+		t.Fatalf("áp dụng = %d, lỗi khác = %v; muốn đúng một lần áp dụng", applied, others)
+	}
+	env.assertConfirmed(t, trip, booking)
+}
+
+func TestHandlePaymentWebhookTakesOverStaleEvent(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockedUntil time.Time
+		wantErr     error
+	}{
+		{"lease còn hạn", time.Now().Add(time.Minute), ErrWebhookInProgress},
+		{"lease quá hạn", time.Now().Add(-time.Minute), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			trip, booking := env.pendingPayment(t)
+			// Lần xử lý trước đã ghi nhận sự kiện rồi dừng giữa chừng.
+			record := models.WebhookEvent{ID: "evt_1", Provider: env.gateway.Name(), Status: "processing", LockedUntil: &tt.lockedUntil}
+			if err := env.repos.WebhookEvents.Create(t.Context(), &record); err != nil {
+				t.Fatalf("ghi sự kiện: %v", err)
+			}
+
+			payload, header := signedWebhook(t, "evt_1", "test-webhook-secret")
+			if _, err := env.payments.HandlePaymentWebhook(payload, header); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("lỗi = %v, muốn %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				env.assertConfirmed(t, trip, booking)
+				stored, err := env.repos.WebhookEvents.FindByID(t.Context(), "evt_1")
+				if err != nil || stored.Status != "processed" {
+					t.Fatalf("sự kiện = %+v, err = %v; muốn processed", stored, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package tickets
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var regularFont []byte
+
+//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+var boldFont []byte
+
+// BoardingPass chứa toàn bộ thông tin được in lên vé điện tử.
+type BoardingPass struct {
+	TicketCode    string
+	BookingID     string
+	CompanyName   string
+	CompanyCode   string
+	From          string
+	To            string
+	DepartureTime time.Time
+	ArrivalTime   time.Time
+	Passengers    []PassengerLine
+	TotalAmount   float64
+	// QRContent là chuỗi đã ký được mã hóa vào QR (xem SignPayload).
+	QRContent string
+}
+
+type PassengerLine struct {
+	Name       string
+	SeatNumber string
+}
+
+// formatTime in giờ theo múi giờ của chính t; TicketService đã đổi giờ sang múi giờ vận hành.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.Format("15:04 02/01/2006")
+}
+
+func (bp *BoardingPass) companyLine() string {
+	switch {
+	case bp.CompanyName != "" && bp.CompanyCode != "":
+		return fmt.Sprintf("%s (%s)", bp.CompanyName, bp.CompanyCode)
+	case bp.CompanyName != "":
+		return bp.CompanyName
+	}
+	return "—"
+}
+
+func (bp *BoardingPass) seatList() string {
+	seats := make([]string, 0, len(bp.Passengers))
+	for _, p := range bp.Passengers {
+		seats = append(seats, p.SeatNumber)
+	}
+	return strings.Join(seats, ", ")
+}
+
+func (p PassengerLine) displayName() string {
+	if strings.TrimSpace(p.Name) == "" {
+		return "(chưa cập nhật)"
+	}
+	return p.Name
+}
+
+func formatAmount(amount float64) string {
+	s := fmt.Sprintf("%.0f", amount)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + " VNĐ"
+}
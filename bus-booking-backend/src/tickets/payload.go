@@ -0,0 +1,58 @@
+package tickets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPayload được trả về khi nội dung mã QR bị sửa đổi hoặc sai định dạng.
+var ErrInvalidPayload = errors.New("nội dung mã QR không hợp lệ")
+
+// Payload là dữ liệu được mã hóa trong QR của vé.
+type Payload struct {
+	TicketCode string `json:"t"`
+	BookingID  string `json:"b"`
+	IssuedAt   int64  `json:"iat"`
+}
+
+// SignPayload mã hóa payload thành chuỗi "<base64url(json)>.<base64url(hmac-sha256)>".
+func SignPayload(secret []byte, ticketCode, bookingID string) (string, error) {
+	body, err := json.Marshal(Payload{
+		TicketCode: ticketCode,
+		BookingID:  bookingID,
+		IssuedAt:   time.Now().Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	return encoded + "." + sign(secret, encoded), nil
+}
+
+// ParsePayload kiểm tra chữ ký và giải mã payload từ nội dung mã QR.
+func ParsePayload(secret []byte, token string) (*Payload, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(secret, encoded))) {
+		return nil, ErrInvalidPayload
+	}
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.TicketCode == "" {
+		return nil, ErrInvalidPayload
+	}
+	return &payload, nil
+}
+
+func sign(secret []byte, encoded string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,67 @@
+package tickets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("test-ticket-signing-key")
+
+func TestSignAndParsePayload(t *testing.T) {
+	token, err := SignPayload(testKey, "ABC-DEF-GHJ", "6650c0ffee0000000000abcd")
+	if err != nil {
+		t.Fatalf("SignPayload: %v", err)
+	}
+	payload, err := ParsePayload(testKey, token)
+	if err != nil {
+		t.Fatalf("ParsePayload: %v", err)
+	}
+	if payload.TicketCode != "ABC-DEF-GHJ" || payload.BookingID != "6650c0ffee0000000000abcd" || payload.IssuedAt == 0 {
+		t.Fatalf("payload = %+v, muốn đúng mã vé, booking và thời điểm phát hành", payload)
+	}
+}
+
+func TestParsePayloadRejectsTampering(t *testing.T) {
+	token, err := SignPayload(testKey, "ABC-DEF-GHJ", "6650c0ffee0000000000abcd")
+	if err != nil {
+		t.Fatalf("SignPayload: %v", err)
+	}
+	encoded, signature, _ := strings.Cut(token, ".")
+	last := "A"
+	if strings.HasSuffix(signature, last) {
+		last = "B"
+	}
+
+	// Đổi mã vé trong payload nhưng giữ chữ ký cũ.
+	forged, err := json.Marshal(Payload{TicketCode: "XYZ-XYZ-XYZ", BookingID: "6650c0ffee0000000000abcd"})
+	if err != nil {
+		t.Fatalf("mã hóa payload: %v", err)
+	}
+	otherKey, err := SignPayload([]byte("khóa-khác"), "ABC-DEF-GHJ", "6650c0ffee0000000000abcd")
+	if err != nil {
+		t.Fatalf("SignPayload: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"sửa payload", base64.RawURLEncoding.EncodeToString(forged) + "." + signature},
+		{"sửa chữ ký", encoded + "." + signature[:len(signature)-1] + last},
+		{"ký bằng khóa khác", otherKey},
+		{"thiếu chữ ký", encoded},
+		{"chữ ký rỗng", encoded + "."},
+		{"không phải base64", "!!!." + sign(testKey, "!!!")},
+		{"không phải JSON", "bm90LWpzb24." + sign(testKey, "bm90LWpzb24")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePayload(testKey, tt.token); !errors.Is(err, ErrInvalidPayload) {
+				t.Fatalf("lỗi = %v, muốn ErrInvalidPayload", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package tickets
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// RenderPDF vẽ vé điện tử khổ A5 ngang. Font và thư viện đều thuần Go nên chạy được offline.
+func RenderPDF(bp *BoardingPass) ([]byte, error) {
+	qrPNG, err := qrcode.Encode(bp.QRContent, qrcode.Medium, 512)
+	if err != nil {
+		return nil, fmt.Errorf("không thể tạo mã QR: %w", err)
+	}
+
+	pdf := fpdf.New("L", "mm", "A5", "")
+	pdf.SetTitle("Vé xe "+bp.TicketCode, true)
+	pdf.AddUTF8FontFromBytes("DejaVu", "", regularFont)
+	pdf.AddUTF8FontFromBytes("DejaVu", "B", boldFont)
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+
+	// Dải tiêu đề.
+	pdf.SetFillColor(13, 110, 253)
+	pdf.Rect(0, 0, pageW, 22, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("DejaVu", "B", 16)
+	pdf.SetXY(12, 6)
+	pdf.CellFormat(120, 10, "VÉ XE ĐIỆN TỬ", "", 0, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 11)
+	pdf.SetXY(pageW-92, 6)
+	pdf.CellFormat(80, 10, bp.companyLine(), "", 0, "R", false, 0, "")
+
+	pdf.SetTextColor(33, 37, 41)
+	pdf.SetXY(12, 30)
+	pdf.SetFont("DejaVu", "B", 18)
+	pdf.CellFormat(130, 10, bp.From+"  →  "+bp.To, "", 1, "L", false, 0, "")
+
+	row := func(label, value string) {
+		pdf.SetX(12)
+		pdf.SetFont("DejaVu", "", 10)
+		pdf.SetTextColor(108, 117, 125)
+		pdf.CellFormat(38, 7, label, "", 0, "L", false, 0, "")
+		pdf.SetFont("DejaVu", "B", 11)
+		pdf.SetTextColor(33, 37, 41)
+		pdf.CellFormat(92, 7, value, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(2)
+	row("Khởi hành", formatTime(bp.DepartureTime))
+	row("Dự kiến đến", formatTime(bp.ArrivalTime))
+	row("Ghế", bp.seatList())
+	row("Tổng tiền", formatAmount(bp.TotalAmount))
+	row("Mã đặt chỗ", bp.BookingID)
+
+	pdf.Ln(3)
+	pdf.SetX(12)
+	pdf.SetFont("DejaVu", "B", 11)
+	pdf.CellFormat(130, 7, "Hành khách", "B", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 10)
+	for _, p := range bp.Passengers {
+		pdf.SetX(12)
+		pdf.CellFormat(20, 6, p.SeatNumber, "", 0, "L", false, 0, "")
+		pdf.CellFormat(110, 6, p.displayName(), "", 1, "L", false, 0, "")
+	}
+
+	// Mã QR và mã vé ở cột bên phải.
+	pdf.RegisterImageOptionsReader("qr", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qrPNG))
+	pdf.ImageOptions("qr", pageW-62, 30, 50, 50, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetXY(pageW-62, 82)
+	pdf.SetFont("DejaVu", "", 9)
+	pdf.SetTextColor(108, 117, 125)
+	pdf.CellFormat(50, 5, "Mã vé", "", 2, "C", false, 0, "")
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.SetTextColor(33, 37, 41)
+	pdf.CellFormat(50, 8, bp.TicketCode, "", 0, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("không thể tạo file PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
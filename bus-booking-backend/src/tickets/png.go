@@ -0,0 +1,122 @@
+package tickets
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	pngWidth  = 1000
+	pngHeight = 560
+)
+
+var (
+	colorPrimary = color.RGBA{R: 13, G: 110, B: 253, A: 255}
+	colorText    = color.RGBA{R: 33, G: 37, B: 41, A: 255}
+	colorMuted   = color.RGBA{R: 108, G: 117, B: 125, A: 255}
+)
+
+// RenderPNG vẽ vé điện tử dạng ảnh PNG với cùng nội dung như bản PDF.
+func RenderPNG(bp *BoardingPass) ([]byte, error) {
+	regular, err := opentype.Parse(regularFont)
+	if err != nil {
+		return nil, fmt.Errorf("không thể đọc font: %w", err)
+	}
+	bold, err := opentype.Parse(boldFont)
+	if err != nil {
+		return nil, fmt.Errorf("không thể đọc font: %w", err)
+	}
+	face := func(f *opentype.Font, size float64) (font.Face, error) {
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	}
+	titleFace, err := face(bold, 34)
+	if err != nil {
+		return nil, err
+	}
+	routeFace, err := face(bold, 38)
+	if err != nil {
+		return nil, err
+	}
+	labelFace, err := face(regular, 20)
+	if err != nil {
+		return nil, err
+	}
+	valueFace, err := face(bold, 22)
+	if err != nil {
+		return nil, err
+	}
+	codeFace, err := face(bold, 30)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, pngWidth, pngHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, pngWidth, 80), image.NewUniform(colorPrimary), image.Point{}, draw.Src)
+
+	text := func(f font.Face, c color.Color, x, y int, s string) {
+		d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: f, Dot: fixed.P(x, y)}
+		d.DrawString(s)
+	}
+	textRight := func(f font.Face, c color.Color, right, y int, s string) {
+		w := font.MeasureString(f, s).Ceil()
+		text(f, c, right-w, y, s)
+	}
+
+	text(titleFace, color.White, 32, 52, "VÉ XE ĐIỆN TỬ")
+	textRight(labelFace, color.White, pngWidth-32, 50, bp.companyLine())
+
+	text(routeFace, colorText, 32, 140, bp.From+"  →  "+bp.To)
+
+	y := 190
+	row := func(label, value string) {
+		text(labelFace, colorMuted, 32, y, label)
+		text(valueFace, colorText, 200, y, value)
+		y += 36
+	}
+	row("Khởi hành", formatTime(bp.DepartureTime))
+	row("Dự kiến đến", formatTime(bp.ArrivalTime))
+	row("Ghế", bp.seatList())
+	row("Tổng tiền", formatAmount(bp.TotalAmount))
+
+	y += 10
+	text(valueFace, colorText, 32, y, "Hành khách")
+	y += 34
+	for _, p := range bp.Passengers {
+		if y > pngHeight-20 {
+			break
+		}
+		text(labelFace, colorText, 32, y, p.SeatNumber)
+		text(labelFace, colorText, 110, y, p.displayName())
+		y += 30
+	}
+
+	qr, err := qrcode.New(bp.QRContent, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("không thể tạo mã QR: %w", err)
+	}
+	const qrSize = 300
+	qrImg := qr.Image(qrSize)
+	qrX := pngWidth - qrSize - 32
+	draw.Draw(img, image.Rect(qrX, 110, qrX+qrSize, 110+qrSize), qrImg, image.Point{}, draw.Src)
+
+	centerX := qrX + qrSize/2
+	label := "Mã vé"
+	text(labelFace, colorMuted, centerX-font.MeasureString(labelFace, label).Ceil()/2, 445, label)
+	text(codeFace, colorText, centerX-font.MeasureString(codeFace, bp.TicketCode).Ceil()/2, 485, bp.TicketCode)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("không thể tạo ảnh PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
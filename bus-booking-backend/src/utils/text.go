@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldText chuẩn hóa chuỗi để so khớp không phân biệt hoa thường và dấu tiếng Việt: bỏ dấu
+// ("Hồ Chí Minh" → "ho chi minh", "Đà Lạt" → "da lat"), thay mọi ký tự không phải chữ hoặc
+// số bằng khoảng trắng và gộp các khoảng trắng liên tiếp.
+func FoldText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ' || r == 'Đ':
+			r = 'd'
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			space = b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Slugify tạo slug dạng "ho-chi-minh" từ tên có dấu.
+func Slugify(s string) string {
+	return strings.ReplaceAll(FoldText(s), " ", "-")
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestFoldText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Hồ Chí Minh", "ho chi minh"},
+		{"Đà Lạt", "da lat"},
+		{"ĐÀ NẴNG", "da nang"},
+		{"TP. Hồ Chí Minh", "tp ho chi minh"},
+		{"  Bến xe   Mỹ Đình ", "ben xe my dinh"},
+		{"quy-nhon", "quy nhon"},
+		{"Phan Rang–Tháp Chàm", "phan rang thap cham"},
+		{"Quận 1", "quan 1"},
+		// Tổ hợp dấu rời (NFD) cho cùng kết quả với ký tự dựng sẵn.
+		{"Hue\u0302\u0301", "hue"},
+		{"...", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := FoldText(tt.in); got != tt.want {
+			t.Errorf("FoldText(%q) = %q, muốn %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Hồ Chí Minh", "ho-chi-minh"},
+		{"TP. Đà Lạt", "tp-da-lat"},
+		{"ho-chi-minh", "ho-chi-minh"},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.in); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, muốn %q", tt.in, got, tt.want)
+		}
+	}
+}
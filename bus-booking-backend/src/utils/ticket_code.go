@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// ticketAlphabet là bảng chữ Crockford Base32: bỏ I, L, O, U để tránh nhầm lẫn khi đọc.
+const ticketAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const ticketDataLength = 8
+
+// GenerateTicketCode tạo mã vé ngẫu nhiên dạng "XXX-XXX-XXX", trong đó ký tự cuối cùng
+// là chữ số kiểm tra (Luhn mod 32) giúp phát hiện lỗi gõ sai một ký tự hoặc đảo hai ký tự liền kề.
+func GenerateTicketCode() (string, error) {
+	data := make([]byte, ticketDataLength)
+	max := big.NewInt(int64(len(ticketAlphabet)))
+	for i := range data {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		data[i] = ticketAlphabet[n.Int64()]
+	}
+	code := string(data) + string(ticketCheckChar(string(data)))
+	return code[0:3] + "-" + code[3:6] + "-" + code[6:9], nil
+}
+
+// NormalizeTicketCode chuẩn hóa mã vé người dùng nhập: bỏ dấu gạch và khoảng trắng,
+// viết hoa, và đổi các ký tự dễ nhầm (O→0, I/L→1) theo quy ước Crockford.
+func NormalizeTicketCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		b.WriteRune(r)
+	}
+	raw := b.String()
+	if len(raw) != ticketDataLength+1 {
+		return raw
+	}
+	return raw[0:3] + "-" + raw[3:6] + "-" + raw[6:9]
+}
+
+// ValidateTicketCode kiểm tra định dạng và chữ số kiểm tra của một mã vé đã chuẩn hóa.
+func ValidateTicketCode(code string) bool {
+	raw := strings.ReplaceAll(code, "-", "")
+	if len(raw) != ticketDataLength+1 {
+		return false
+	}
+	for i := 0; i < len(raw); i++ {
+		if strings.IndexByte(ticketAlphabet, raw[i]) < 0 {
+			return false
+		}
+	}
+	return ticketCheckChar(raw[:ticketDataLength]) == raw[ticketDataLength]
+}
+
+// ticketCheckChar tính ký tự kiểm tra theo thuật toán Luhn mod N.
+func ticketCheckChar(data string) byte {
+	n := len(ticketAlphabet)
+	factor := 2
+	sum := 0
+	for i := len(data) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(ticketAlphabet, data[i])
+		addend = addend/n + addend%n
+		sum += addend
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+	return ticketAlphabet[(n-sum%n)%n]
+}
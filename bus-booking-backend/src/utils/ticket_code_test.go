@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// ticketCodes sinh n mã vé ngẫu nhiên.
+func ticketCodes(t *testing.T, n int) []string {
+	t.Helper()
+	codes := make([]string, n)
+	for i := range codes {
+		code, err := GenerateTicketCode()
+		if err != nil {
+			t.Fatalf("GenerateTicketCode: %v", err)
+		}
+		codes[i] = code
+	}
+	return codes
+}
+
+func formatTicketCode(raw string) string {
+	return raw[0:3] + "-" + raw[3:6] + "-" + raw[6:9]
+}
+
+func TestTicketCodeCheckCharacter(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"000-000-000", true},
+		{"000-000-001", false},
+		{"ABC-DEF-GH" + string(ticketCheckChar("ABCDEFGH")), true},
+		{"ABC-DEF-GH", false},
+		{"ABC-DEF-GHUU", false},
+		{"ABC-DEF-GHU", false}, // U không thuộc bảng chữ
+	}
+	for _, tt := range tests {
+		if got := ValidateTicketCode(tt.code); got != tt.want {
+			t.Errorf("ValidateTicketCode(%q) = %v, muốn %v", tt.code, got, tt.want)
+		}
+	}
+
+	for _, code := range ticketCodes(t, 200) {
+		if !ValidateTicketCode(code) {
+			t.Fatalf("mã vừa sinh %q không hợp lệ", code)
+		}
+		if normalized := NormalizeTicketCode(strings.ToLower(strings.ReplaceAll(code, "-", " "))); normalized != code {
+			t.Fatalf("NormalizeTicketCode = %q, muốn %q", normalized, code)
+		}
+	}
+}
+
+func TestTicketCodeDetectsSingleCharacterErrors(t *testing.T) {
+	for _, code := range ticketCodes(t, 50) {
+		raw := strings.ReplaceAll(code, "-", "")
+		for i := range raw {
+			for j := 0; j < len(ticketAlphabet); j++ {
+				if ticketAlphabet[j] == raw[i] {
+					continue
+				}
+				typo := raw[:i] + string(ticketAlphabet[j]) + raw[i+1:]
+				if ValidateTicketCode(formatTicketCode(typo)) {
+					t.Fatalf("%q sai ký tự thứ %d thành %q vẫn được chấp nhận", code, i, typo)
+				}
+			}
+		}
+	}
+}
+
+func TestTicketCodeDetectsAdjacentSwaps(t *testing.T) {
+	last := ticketAlphabet[len(ticketAlphabet)-1]
+	for _, code := range ticketCodes(t, 200) {
+		raw := strings.ReplaceAll(code, "-", "")
+		for i := 0; i+1 < len(raw); i++ {
+			a, b := raw[i], raw[i+1]
+			// Luhn mod N không phát hiện được việc đảo hai ký tự giống nhau (không đổi gì) hoặc
+			// cặp ký tự đầu và cuối bảng chữ ("0" và "Z"), giống cặp 09/90 của Luhn mod 10.
+			if a == b || (a == '0' && b == last) || (a == last && b == '0') {
+				continue
+			}
+			swapped := raw[:i] + string(b) + string(a) + raw[i+2:]
+			if ValidateTicketCode(formatTicketCode(swapped)) {
+				t.Fatalf("%q đảo ký tự %d và %d thành %q vẫn được chấp nhận", code, i, i+1, swapped)
+			}
+		}
+	}
+}
@@ -1,38 +1,71 @@
 package utils
 
 import (
-	"strconv"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
-	"github.com/Go_final_exam/bus-booking-backend/src/config"
+	"github.com/Go_final_exam/bus-booking-backend/src/models"
 )
 
-func GenerateToken(userID primitive.ObjectID) (string, error) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return "", err
-	}
+// TokenIssuer ký và kiểm tra access token (JWT) bằng khóa bí mật và thời hạn lấy từ cấu hình
+// lúc khởi động.
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+}
 
-	expirationHours, err := strconv.Atoi(cfg.JwtExpirationInHours)
-	if err != nil {
-		return "", err
-	}
+func NewTokenIssuer(secret string, ttl time.Duration) *TokenIssuer {
+	return &TokenIssuer{secret: []byte(secret), ttl: ttl}
+}
+
+// TTL trả về thời hạn của access token.
+func (t *TokenIssuer) TTL() time.Duration {
+	return t.ttl
+}
 
+// GenerateToken tạo access token cho người dùng, kèm vai trò và nhà xe (với nhân viên nhà xe);
+// claim "jti" là ID phiên đăng nhập để có thể thu hồi token trước khi hết hạn.
+func (t *TokenIssuer) GenerateToken(user *models.User, sessionID primitive.ObjectID) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
-		"userId": userID.Hex(),                                     
-		"exp":    time.Now().Add(time.Hour * time.Duration(expirationHours)).Unix(),
-		"iat":    time.Now().Unix(),                                               
+		"userId": user.ID.Hex(),
+		"role":   string(user.EffectiveRole()),
+		"jti":    sessionID.Hex(),
+		"exp":    now.Add(t.ttl).Unix(),
+		"iat":    now.Unix(),
+	}
+	if user.CompanyID != nil {
+		claims["companyId"] = user.CompanyID.Hex()
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	tokenString, err := token.SignedString([]byte(cfg.JwtSecretKey))
+	tokenString, err := token.SignedString(t.secret)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
-}
\ No newline at end of file
+}
+
+// ParseToken kiểm tra chữ ký, thời hạn và trả về các claim của token.
+func (t *TokenIssuer) ParseToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("thuật toán ký không được hỗ trợ")
+		}
+		return t.secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("token không hợp lệ")
+	}
+	return claims, nil
+}
@@ -1,4 +1,4 @@
-import axios from "axios";
+import axios, { type AxiosError, type InternalAxiosRequestConfig } from "axios";
 
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL;
 
@@ -6,6 +6,11 @@ if (!API_BASE_URL) {
   throw new Error("Biến môi trường VITE_API_BASE_URL chưa được thiết lập!");
 }
 
+export const ACCESS_TOKEN_KEY = "authToken";
+export const REFRESH_TOKEN_KEY = "refreshToken";
+// Sự kiện phát ra khi không thể làm mới phiên đăng nhập, để AuthContext đăng xuất người dùng.
+export const SESSION_EXPIRED_EVENT = "auth:session-expired";
+
 const apiClient = axios.create({
   baseURL: API_BASE_URL,
   headers: {
@@ -13,4 +18,68 @@ const apiClient = axios.create({
   },
 });
 
+export const setAccessToken = (token: string | null) => {
+  if (token) {
+    apiClient.defaults.headers.common["Authorization"] = `Bearer ${token}`;
+  } else {
+    delete apiClient.defaults.headers.common["Authorization"];
+  }
+};
+
+// Chỉ gửi một request làm mới tại một thời điểm: refresh token bị thay mới sau mỗi lần dùng,
+// nên hai request làm mới song song sẽ khiến server coi là dùng lại token và thu hồi phiên.
+let refreshPromise: Promise<string> | null = null;
+
+const refreshAccessToken = (): Promise<string> => {
+  if (!refreshPromise) {
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    refreshPromise = (
+      refreshToken
+        ? axios
+            .post(`${API_BASE_URL}/auth/refresh`, { refreshToken })
+            .then((response) => {
+              const { token, refreshToken: nextRefreshToken } = response.data;
+              localStorage.setItem(ACCESS_TOKEN_KEY, token);
+              localStorage.setItem(REFRESH_TOKEN_KEY, nextRefreshToken);
+              setAccessToken(token);
+              return token as string;
+            })
+        : Promise.reject(new Error("Không có refresh token"))
+    ).finally(() => {
+      refreshPromise = null;
+    });
+  }
+  return refreshPromise;
+};
+
+apiClient.interceptors.response.use(
+  (response) => response,
+  async (error: AxiosError) => {
+    const original = error.config as
+      | (InternalAxiosRequestConfig & { _retried?: boolean })
+      | undefined;
+    if (
+      error.response?.status !== 401 ||
+      !original ||
+      original._retried ||
+      original.url?.startsWith("/auth/")
+    ) {
+      return Promise.reject(error);
+    }
+
+    original._retried = true;
+    try {
+      const token = await refreshAccessToken();
+      original.headers["Authorization"] = `Bearer ${token}`;
+      return apiClient(original);
+    } catch {
+      localStorage.removeItem(ACCESS_TOKEN_KEY);
+      localStorage.removeItem(REFRESH_TOKEN_KEY);
+      setAccessToken(null);
+      window.dispatchEvent(new Event(SESSION_EXPIRED_EVENT));
+      return Promise.reject(error);
+    }
+  }
+);
+
 export default apiClient;
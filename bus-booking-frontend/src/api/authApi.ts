@@ -15,6 +15,8 @@ export interface LoginPayload {
 interface AuthResponse {
   "thông báo": string;
   token?: string;
+  refreshToken?: string;
+  expiresIn?: number;
   dữ_liệu?: {
     id: string;
     name: string;
@@ -42,3 +44,7 @@ export const loginUser = async (
   });
   return response.data;
 };
+
+export const logoutSession = async (refreshToken: string): Promise<void> => {
+  await apiClient.post("/auth/logout", { refreshToken });
+};
@@ -1,9 +1,11 @@
-import { type Booking } from "../types/booking.types";
+import { type Booking, type Payment } from "../types/booking.types";
 import apiClient from "./apiClient";
 
 export interface CreateBookingPayload {
   tripId: string;
   seatNumbers: string[];
+  from?: string;
+  to?: string;
 }
 
 interface BookingApiResponse<T> {
@@ -101,3 +103,73 @@ export const getMyBookings = async (): Promise<Booking[]> => {
     return [];
   }
 };
+
+export const payBooking = async (
+  bookingId: string
+): Promise<BookingApiResponse<Payment>> => {
+  try {
+    const response = await apiClient.post<BookingApiResponse<Payment>>(
+      `/bookings/${bookingId}/pay`
+    );
+    return response.data;
+  } catch (error: any) {
+    console.error(
+      `Lỗi khi thanh toán booking ${bookingId}:`,
+      error.response?.data || error.message
+    );
+    if (error.response && error.response.data && error.response.data.lỗi) {
+      return error.response.data as BookingApiResponse<Payment>;
+    }
+    return {
+      "thông báo": "Lỗi không xác định khi thanh toán.",
+      lỗi: error.message || "Unknown error",
+    };
+  }
+};
+
+export interface PassengerPayload {
+  seatNumber: string;
+  name?: string;
+  phone?: string;
+}
+
+export const updatePassengers = async (
+  bookingId: string,
+  passengers: PassengerPayload[]
+): Promise<BookingApiResponse<Booking>> => {
+  try {
+    const response = await apiClient.put<BookingApiResponse<Booking>>(
+      `/bookings/${bookingId}/passengers`,
+      { passengers }
+    );
+    return response.data;
+  } catch (error: any) {
+    console.error(
+      `Lỗi khi cập nhật hành khách cho booking ${bookingId}:`,
+      error.response?.data || error.message
+    );
+    if (error.response && error.response.data && error.response.data.lỗi) {
+      return error.response.data as BookingApiResponse<Booking>;
+    }
+    return {
+      "thông báo": "Lỗi không xác định khi cập nhật hành khách.",
+      lỗi: error.message || "Unknown error",
+    };
+  }
+};
+
+export const downloadTicket = async (
+  bookingId: string,
+  format: "pdf" | "png"
+): Promise<void> => {
+  const response = await apiClient.get<Blob>(
+    `/bookings/${bookingId}/ticket.${format}`,
+    { responseType: "blob" }
+  );
+  const url = URL.createObjectURL(response.data);
+  const link = document.createElement("a");
+  link.href = url;
+  link.download = `ve-${bookingId}.${format}`;
+  link.click();
+  URL.revokeObjectURL(url);
+};
@@ -0,0 +1,24 @@
+import type { Location } from "../types/location.types";
+import apiClient from "./apiClient";
+
+interface LocationListApiResponse {
+  "thông báo": string;
+  dữ_liệu: Location[];
+}
+
+// Gợi ý địa điểm theo chuỗi người dùng đang gõ; không phân biệt dấu và hoa thường.
+export const suggestLocations = async (
+  q: string,
+  limit = 8
+): Promise<Location[]> => {
+  try {
+    const response = await apiClient.get<LocationListApiResponse>(
+      "/locations/suggest",
+      { params: { q, limit } }
+    );
+    return response.data?.dữ_liệu || [];
+  } catch (error) {
+    console.error("Lỗi khi lấy gợi ý địa điểm:", error);
+    return [];
+  }
+};
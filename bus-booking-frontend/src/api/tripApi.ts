@@ -1,4 +1,10 @@
-import type { Trip } from "../types/trip.types";
+import type {
+  FareCalendarDay,
+  SeatEvent,
+  Trip,
+  TripSearchParams,
+  TripSearchResult,
+} from "../types/trip.types";
 import apiClient from "./apiClient";
 
 interface TripDetailsApiResponse {
@@ -6,43 +12,38 @@ interface TripDetailsApiResponse {
   dữ_liệu: Trip;
 }
 
-export const getAllTrips = async (): Promise<Trip[]> => {
+// searchTrips gọi GET /trips với các bộ lọc; companyIds được gửi dạng phân tách bằng dấu phẩy.
+export const searchTrips = async (
+  params: TripSearchParams = {}
+): Promise<TripSearchResult> => {
+  const { companyIds, ...rest } = params;
   try {
-    const response = await apiClient.get("/trips");
-    if (response.data && response.data.dữ_liệu) {
-      return response.data.dữ_liệu;
-    } else {
-      return [];
-    }
-  } catch (error) {
-    console.error("Lỗi trong getAllTrips function (tripApi.ts):", error);
-    return [];
-  }
-};
-
-interface SearchParams {
-  from: string;
-  to: string;
-  date: string;
-}
-export const searchTrips = async (params: SearchParams): Promise<Trip[]> => {
-  try {
-    const response = await apiClient.get("/trips", { params });
-    if (response.data && response.data.dữ_liệu) {
-      return response.data.dữ_liệu;
-    } else {
-      return [];
-    }
+    const response = await apiClient.get("/trips", {
+      params: {
+        ...rest,
+        companyId: companyIds?.length ? companyIds.join(",") : undefined,
+      },
+    });
+    return {
+      trips: response.data?.dữ_liệu ?? [],
+      nextCursor: response.data?.nextCursor || undefined,
+      facets: response.data?.facets ?? { companies: [], vehicleTypes: [] },
+    };
   } catch (error) {
     console.error("Lỗi khi tìm kiếm chuyến đi:", error);
     throw error;
   }
 };
 
-export const getTripDetails = async (tripId: string): Promise<Trip | null> => {
+// from/to là điểm lên và điểm xuống; bỏ trống để xem trọn tuyến.
+export const getTripDetails = async (
+  tripId: string,
+  segment?: { from: string; to: string }
+): Promise<Trip | null> => {
   try {
     const response = await apiClient.get<TripDetailsApiResponse>(
-      `/trips/${tripId}`
+      `/trips/${tripId}`,
+      { params: segment }
     );
     if (response.data && response.data.dữ_liệu) {
       return response.data.dữ_liệu;
@@ -54,3 +55,50 @@ export const getTripDetails = async (tripId: string): Promise<Trip | null> => {
     return null;
   }
 };
+
+interface FareCalendarParams {
+  from: string;
+  to: string;
+  start?: string;
+  days?: number;
+}
+
+export const getFareCalendar = async (
+  params: FareCalendarParams
+): Promise<FareCalendarDay[]> => {
+  try {
+    const response = await apiClient.get("/trips/calendar", { params });
+    return response.data?.dữ_liệu ?? [];
+  } catch (error) {
+    console.error("Lỗi khi lấy lịch giá vé:", error);
+    return [];
+  }
+};
+
+// subscribeSeatEvents mở luồng SSE trạng thái ghế của chuyến đi. onReconnect được gọi mỗi khi
+// kết nối được mở lại sau khi bị ngắt, vì có thể đã bỏ lỡ sự kiện trong lúc mất kết nối.
+// Trả về hàm đóng luồng.
+export const subscribeSeatEvents = (
+  tripId: string,
+  onEvent: (event: SeatEvent) => void,
+  onReconnect: () => void
+): (() => void) => {
+  const source = new EventSource(
+    `${apiClient.defaults.baseURL}/trips/${tripId}/seats/stream`
+  );
+  let opened = false;
+  source.addEventListener("open", () => {
+    if (opened) {
+      onReconnect();
+    }
+    opened = true;
+  });
+  source.addEventListener("seat", (message) => {
+    try {
+      onEvent(JSON.parse((message as MessageEvent).data));
+    } catch (error) {
+      console.error("Sự kiện ghế không hợp lệ:", error);
+    }
+  });
+  return () => source.close();
+};
@@ -25,8 +25,8 @@ const LoginForm: React.FC<{
     try {
       const payload: LoginPayload = { email, password };
       const response = await loginUser(payload);
-      if (response.token) {
-        login(response.token);
+      if (response.token && response.refreshToken) {
+        login(response.token, response.refreshToken);
         handleCloseModal();
       } else {
         setError(
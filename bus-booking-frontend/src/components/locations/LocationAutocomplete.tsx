@@ -0,0 +1,63 @@
+import React, { useEffect, useState } from "react";
+import { Form } from "react-bootstrap";
+import { suggestLocations } from "../../api/locationApi";
+import type { Location } from "../../types/location.types";
+
+interface LocationAutocompleteProps {
+  id: string;
+  value: string;
+  onChange: (value: string) => void;
+  placeholder?: string;
+}
+
+// Ô nhập địa điểm có gợi ý từ danh mục; gõ không dấu hoặc tên gọi khác (VD: "sai gon") vẫn ra gợi ý.
+const LocationAutocomplete: React.FC<LocationAutocompleteProps> = ({
+  id,
+  value,
+  onChange,
+  placeholder,
+}) => {
+  const [suggestions, setSuggestions] = useState<Location[]>([]);
+
+  useEffect(() => {
+    if (!value.trim()) {
+      setSuggestions([]);
+      return;
+    }
+    let cancelled = false;
+    const timer = setTimeout(async () => {
+      const results = await suggestLocations(value);
+      if (!cancelled) {
+        setSuggestions(results);
+      }
+    }, 250);
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [value]);
+
+  const listId = `${id}-suggestions`;
+  return (
+    <>
+      <Form.Control
+        id={id}
+        type="text"
+        value={value}
+        onChange={(e) => onChange(e.target.value)}
+        placeholder={placeholder}
+        list={listId}
+        autoComplete="off"
+      />
+      <datalist id={listId}>
+        {suggestions.map((location) => (
+          <option key={location.id} value={location.name}>
+            {location.province}
+          </option>
+        ))}
+      </datalist>
+    </>
+  );
+};
+
+export default LocationAutocomplete;
@@ -0,0 +1,97 @@
+import React, { useEffect, useState } from "react";
+import { Button } from "react-bootstrap";
+import { getFareCalendar } from "../../api/tripApi";
+import type { FareCalendarDay } from "../../types/trip.types";
+
+interface FareCalendarStripProps {
+  from: string;
+  to: string;
+  date: string;
+  onSelectDate: (date: string) => void;
+}
+
+const CALENDAR_DAYS = 7;
+
+// startDate lùi 3 ngày trước date (không sớm hơn hôm nay) để ngày đang chọn nằm giữa dải lịch.
+const startDate = (date: string): string => {
+  const today = new Date();
+  today.setHours(0, 0, 0, 0);
+  const start = new Date(`${date}T00:00:00`);
+  start.setDate(start.getDate() - Math.floor(CALENDAR_DAYS / 2));
+  const value = start < today ? today : start;
+  const month = String(value.getMonth() + 1).padStart(2, "0");
+  const day = String(value.getDate()).padStart(2, "0");
+  return `${value.getFullYear()}-${month}-${day}`;
+};
+
+// Dải lịch giá vé quanh ngày đang chọn, giúp khách thấy ngày nào rẻ nhất.
+const FareCalendarStrip: React.FC<FareCalendarStripProps> = ({
+  from,
+  to,
+  date,
+  onSelectDate,
+}) => {
+  const [days, setDays] = useState<FareCalendarDay[]>([]);
+
+  useEffect(() => {
+    let cancelled = false;
+    getFareCalendar({
+      from,
+      to,
+      start: startDate(date),
+      days: CALENDAR_DAYS,
+    }).then((result) => {
+      if (!cancelled) {
+        setDays(result);
+      }
+    });
+    return () => {
+      cancelled = true;
+    };
+  }, [from, to, date]);
+
+  if (days.length === 0) {
+    return null;
+  }
+
+  const cheapest = Math.min(
+    ...days.map((day) => day.minPrice ?? Number.POSITIVE_INFINITY)
+  );
+
+  return (
+    <div className="d-flex gap-2 overflow-auto mb-3">
+      {days.map((day) => (
+        <Button
+          key={day.date}
+          variant={day.date === date ? "primary" : "outline-secondary"}
+          className="flex-fill text-nowrap"
+          disabled={day.trips === 0}
+          onClick={() => onSelectDate(day.date)}
+        >
+          <div className="small">
+            {new Date(`${day.date}T00:00:00`).toLocaleDateString("vi-VN", {
+              weekday: "short",
+              day: "2-digit",
+              month: "2-digit",
+            })}
+          </div>
+          <div
+            className={
+              day.minPrice === cheapest && day.date !== date
+                ? "fw-bold text-success"
+                : "fw-bold"
+            }
+          >
+            {day.minPrice !== null
+              ? `${day.minPrice.toLocaleString()} VNĐ`
+              : day.trips > 0
+              ? "Hết vé"
+              : "—"}
+          </div>
+        </Button>
+      ))}
+    </div>
+  );
+};
+
+export default FareCalendarStrip;
@@ -0,0 +1,70 @@
+import React from "react";
+import type { Seat, SeatMap } from "../../types/trip.types";
+import SeatButton from "./SeatButton";
+
+interface SeatMapGridProps {
+  seatMap: SeatMap;
+  seats: Seat[];
+  selectedSeats: string[];
+  onSeatClick: (seatNumber: string) => void;
+}
+
+// Vẽ ghế theo đúng vị trí trên sơ đồ của xe, mỗi tầng là một lưới hàng x cột.
+const SeatMapGrid: React.FC<SeatMapGridProps> = ({
+  seatMap,
+  seats,
+  selectedSeats,
+  onSeatClick,
+}) => {
+  const seatsByNumber = new Map(seats.map((seat) => [seat.seatNumber, seat]));
+
+  return (
+    <div className="d-flex justify-content-center flex-wrap gap-4">
+      {seatMap.decks.map((deck, deckIndex) => {
+        const cellAt = new Map(
+          deck.cells.map((cell) => [`${cell.row}-${cell.column}`, cell])
+        );
+        return (
+          <div key={deckIndex}>
+            {seatMap.decks.length > 1 && (
+              <h6 className="text-muted">
+                {deck.name || `Tầng ${deckIndex + 1}`}
+              </h6>
+            )}
+            {Array.from({ length: deck.rows }, (_, row) => (
+              <div key={row} className="d-flex justify-content-center">
+                {Array.from({ length: deck.columns }, (_, column) => {
+                  const cell = cellAt.get(`${row}-${column}`);
+                  const seat = cell?.code
+                    ? seatsByNumber.get(cell.code)
+                    : undefined;
+                  if (cell?.kind === "seat" && seat) {
+                    return (
+                      <SeatButton
+                        key={column}
+                        seat={seat}
+                        isSelected={selectedSeats.includes(seat.seatNumber)}
+                        onSeatClick={onSeatClick}
+                      />
+                    );
+                  }
+                  return (
+                    <div
+                      key={column}
+                      className={`m-1 ${
+                        cell?.kind === "disabled" ? "bg-light border" : ""
+                      }`}
+                      style={{ minWidth: "50px", height: "40px" }}
+                    />
+                  );
+                })}
+              </div>
+            ))}
+          </div>
+        );
+      })}
+    </div>
+  );
+};
+
+export default SeatMapGrid;
@@ -5,12 +5,18 @@ import React, {
   useEffect,
   type ReactNode,
 } from "react";
-import apiClient from "../api/apiClient";
+import {
+  ACCESS_TOKEN_KEY,
+  REFRESH_TOKEN_KEY,
+  SESSION_EXPIRED_EVENT,
+  setAccessToken,
+} from "../api/apiClient";
+import { logoutSession } from "../api/authApi";
 
 interface AuthContextType {
   isAuthenticated: boolean;
   token: string | null;
-  login: (token: string) => void;
+  login: (token: string, refreshToken: string) => void;
   logout: () => void;
   isLoading: boolean;
 }
@@ -21,35 +27,48 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({
   children,
 }) => {
   const [token, setToken] = useState<string | null>(
-    localStorage.getItem("authToken")
+    localStorage.getItem(ACCESS_TOKEN_KEY)
   );
   const [isAuthenticated, setIsAuthenticated] = useState<boolean>(!!token);
   const [isLoading, setIsLoading] = useState<boolean>(true);
 
   useEffect(() => {
-    const storedToken = localStorage.getItem("authToken");
+    const storedToken = localStorage.getItem(ACCESS_TOKEN_KEY);
     if (storedToken) {
       setToken(storedToken);
       setIsAuthenticated(true);
-      apiClient.defaults.headers.common[
-        "Authorization"
-      ] = `Bearer ${storedToken}`;
+      setAccessToken(storedToken);
     }
     setIsLoading(false);
+
+    const handleSessionExpired = () => {
+      setToken(null);
+      setIsAuthenticated(false);
+    };
+    window.addEventListener(SESSION_EXPIRED_EVENT, handleSessionExpired);
+    return () =>
+      window.removeEventListener(SESSION_EXPIRED_EVENT, handleSessionExpired);
   }, []);
 
-  const login = (newToken: string) => {
-    localStorage.setItem("authToken", newToken);
+  const login = (newToken: string, refreshToken: string) => {
+    localStorage.setItem(ACCESS_TOKEN_KEY, newToken);
+    localStorage.setItem(REFRESH_TOKEN_KEY, refreshToken);
     setToken(newToken);
     setIsAuthenticated(true);
-    apiClient.defaults.headers.common["Authorization"] = `Bearer ${newToken}`;
+    setAccessToken(newToken);
   };
 
   const logout = () => {
-    localStorage.removeItem("authToken");
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    if (refreshToken) {
+      // Thu hồi phiên trên server; lỗi mạng không ngăn việc đăng xuất ở client.
+      logoutSession(refreshToken).catch(() => {});
+    }
+    localStorage.removeItem(ACCESS_TOKEN_KEY);
+    localStorage.removeItem(REFRESH_TOKEN_KEY);
     setToken(null);
     setIsAuthenticated(false);
-    delete apiClient.defaults.headers.common["Authorization"];
+    setAccessToken(null);
   };
 
   return (
@@ -9,7 +9,11 @@ import {
   Spinner,
 } from "react-bootstrap";
 import type { Trip } from "../types/trip.types";
-import { createBooking, type CreateBookingPayload } from "../api/bookingApi";
+import {
+  createBooking,
+  updatePassengers,
+  type CreateBookingPayload,
+} from "../api/bookingApi";
 import { useAuth } from "../contexts/AuthContext";
 
 interface LocationState {
@@ -66,14 +70,26 @@ const BookingConfirmationPage: React.FC = () => {
     setError(null);
     setSuccessMessage(null);
 
+    const segment = bookingDetails.tripDetails.segmentInfo;
     const payload: CreateBookingPayload = {
       tripId: bookingDetails.tripId,
       seatNumbers: bookingDetails.selectedSeatNumbers,
+      from: segment?.from,
+      to: segment?.to,
     };
 
     try {
       const response = await createBooking(payload);
       if (response.dữ_liệu && response.dữ_liệu.id) {
+        // Mặc định mọi ghế là chủ tài khoản; có thể chỉnh sửa sau ở trang chi tiết vé.
+        const passengersResponse = await updatePassengers(
+          response.dữ_liệu.id,
+          []
+        );
+        if (passengersResponse.lỗi) {
+          setError(passengersResponse.lỗi);
+          return;
+        }
         setSuccessMessage(
           response["thông báo"] ||
             "Giữ chỗ thành công! Chuẩn bị chuyển đến trang thanh toán."
@@ -84,6 +100,7 @@ const BookingConfirmationPage: React.FC = () => {
             state: {
               bookingId: response.dữ_liệu?.id,
               totalAmount: bookingDetails.totalAmount,
+              holdExpiresAt: response.dữ_liệu?.holdExpiresAt,
             },
           });
         }, 2000);
@@ -157,16 +174,20 @@ const BookingConfirmationPage: React.FC = () => {
               <h4 className="mb-3">Thông tin chuyến đi:</h4>
               <ListGroup variant="flush" className="mb-4">
                 <ListGroup.Item>
-                  <strong>Nhà xe:</strong> {tripDetails.companyName}
+                  <strong>Nhà xe:</strong> {tripDetails.companyInfo?.name}
                 </ListGroup.Item>
                 <ListGroup.Item>
-                  <strong>Tuyến:</strong> {tripDetails.route.from.name}{" "}
+                  <strong>Tuyến:</strong>{" "}
+                  {tripDetails.segmentInfo?.from || tripDetails.route.from.name}{" "}
                   <i className="bi bi-arrow-right"></i>{" "}
-                  {tripDetails.route.to.name}
+                  {tripDetails.segmentInfo?.to || tripDetails.route.to.name}
                 </ListGroup.Item>
                 <ListGroup.Item>
                   <strong>Khởi hành:</strong>{" "}
-                  {new Date(tripDetails.departureTime).toLocaleString()}
+                  {new Date(
+                    tripDetails.segmentInfo?.departureTime ||
+                      tripDetails.departureTime
+                  ).toLocaleString()}
                 </ListGroup.Item>
               </ListGroup>
 
@@ -216,7 +237,18 @@ const BookingConfirmationPage: React.FC = () => {
                 </Button>
                 <Button
                   variant="outline-secondary"
-                  onClick={() => navigate(`/trips/${tripDetails.id}`)}
+                  onClick={() =>
+                    navigate(
+                      `/trips/${tripDetails.id}${
+                        tripDetails.segmentInfo
+                          ? `?${new URLSearchParams({
+                              from: tripDetails.segmentInfo.from,
+                              to: tripDetails.segmentInfo.to,
+                            })}`
+                          : ""
+                      }`
+                    )
+                  }
                   disabled={loading || !!successMessage || !bookingDetails}
                 >
                   Chọn lại ghế
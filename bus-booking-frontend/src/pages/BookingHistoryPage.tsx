@@ -112,7 +112,7 @@ const BookingHistoryPage: React.FC = () => {
                   </h5>
                   <p className="mb-1">
                     <strong>Nhà xe:</strong>{" "}
-                    {booking.tripInfo?.companyName || "N/A"}
+                    {booking.tripInfo?.companyInfo?.name || "N/A"}
                   </p>
                   <p className="mb-1">
                     <strong>Ngày đi:</strong>{" "}
@@ -8,11 +8,19 @@ import {
   Card,
   Spinner,
   Alert,
+  Badge,
 } from "react-bootstrap";
-import type { Trip } from "../types/trip.types";
-import { searchTrips, getAllTrips } from "../api/tripApi";
+import type {
+  Trip,
+  TripFacets,
+  TripSearchParams,
+  TripSort,
+} from "../types/trip.types";
+import { searchTrips } from "../api/tripApi";
 import { useAuth } from "../contexts/AuthContext";
 import { useNavigate } from "react-router-dom";
+import LocationAutocomplete from "../components/locations/LocationAutocomplete";
+import FareCalendarStrip from "../components/trips/FareCalendarStrip";
 
 interface HomePageProps {
   onShowAuthModal: () => void;
@@ -25,55 +33,94 @@ const HomePage: React.FC<HomePageProps> = ({ onShowAuthModal }) => {
   const [to, setTo] = useState("");
   const [date, setDate] = useState("");
 
+  const [companyIds, setCompanyIds] = useState<string[]>([]);
+  const [vehicleType, setVehicleType] = useState("");
+  const [sort, setSort] = useState<TripSort>("departure");
+
   const [displayedTrips, setDisplayedTrips] = useState<Trip[]>([]);
+  const [facets, setFacets] = useState<TripFacets>({
+    companies: [],
+    vehicleTypes: [],
+  });
+  const [nextCursor, setNextCursor] = useState<string | undefined>();
   const [loading, setLoading] = useState(false);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [error, setError] = useState<string | null>(null);
 
+  // Bộ lọc đang áp dụng; điểm đi, điểm đến và ngày chỉ được lấy khi bấm "Tìm chuyến".
+  const [searched, setSearched] = useState({ from: "", to: "", date: "" });
+
+  const buildParams = (): TripSearchParams => ({
+    from: searched.from || undefined,
+    to: searched.to || undefined,
+    date: searched.date || undefined,
+    companyIds,
+    vehicleType: vehicleType || undefined,
+    sort,
+  });
+
   useEffect(() => {
-    const fetchInitialTrips = async () => {
+    const fetchTrips = async () => {
       setLoading(true);
       setError(null);
       try {
-        const results = await getAllTrips();
-        if (results && results.length > 0) {
-          setDisplayedTrips(results);
-        } else {
-          setDisplayedTrips([]);
+        const result = await searchTrips(buildParams());
+        setDisplayedTrips(result.trips);
+        setFacets(result.facets);
+        setNextCursor(result.nextCursor);
+        if (result.trips.length === 0 && searched.from) {
+          setError("Không tìm thấy chuyến đi nào phù hợp với tìm kiếm của bạn.");
         }
       } catch (err) {
-        setError("Không thể tải danh sách chuyến đi ban đầu.");
+        setError("Lỗi khi tìm kiếm. Vui lòng thử lại.");
         setDisplayedTrips([]);
+        setNextCursor(undefined);
       } finally {
         setLoading(false);
       }
     };
-    fetchInitialTrips();
-  }, []);
+    fetchTrips();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [searched, companyIds, vehicleType, sort]);
 
-  const handleSearch = async (e: React.FormEvent) => {
+  const handleSearch = (e: React.FormEvent) => {
     e.preventDefault();
-    if (!from || !to || !date) {
-      setError("Vui lòng nhập đầy đủ thông tin điểm đi, điểm đến và ngày đi.");
-      return;
-    }
-    setLoading(true);
-    setError(null);
+    setCompanyIds([]);
+    setVehicleType("");
+    setSearched({ from: from.trim(), to: to.trim(), date });
+  };
+
+  const handleLoadMore = async () => {
+    if (!nextCursor) return;
+    setLoadingMore(true);
     try {
-      const results = await searchTrips({ from, to, date });
-      setDisplayedTrips(results);
-      if (results.length === 0) {
-        setError("Không tìm thấy chuyến đi nào phù hợp với tìm kiếm của bạn.");
-      }
+      const result = await searchTrips({ ...buildParams(), cursor: nextCursor });
+      setDisplayedTrips((prev) => [...prev, ...result.trips]);
+      setNextCursor(result.nextCursor);
     } catch (err) {
-      setError("Lỗi khi tìm kiếm. Vui lòng thử lại.");
+      setError("Không thể tải thêm chuyến đi.");
     } finally {
-      setLoading(false);
+      setLoadingMore(false);
     }
   };
 
-  const handleBookTripClick = (tripId: string) => {
+  const toggleCompany = (companyId: string) => {
+    setCompanyIds((prev) =>
+      prev.includes(companyId)
+        ? prev.filter((id) => id !== companyId)
+        : [...prev, companyId]
+    );
+  };
+
+  const handleBookTripClick = (trip: Trip) => {
     if (isAuthenticated) {
-      navigate(`/trips/${tripId}`);
+      const segment = trip.segmentInfo
+        ? `?${new URLSearchParams({
+            from: trip.segmentInfo.from,
+            to: trip.segmentInfo.to,
+          })}`
+        : "";
+      navigate(`/trips/${trip.id}${segment}`);
     } else {
       onShowAuthModal();
     }
@@ -88,22 +135,22 @@ const HomePage: React.FC<HomePageProps> = ({ onShowAuthModal }) => {
             <Row className="align-items-end">
               <Col md={3}>
                 <Form.Group>
-                  <Form.Label>Điểm đi</Form.Label>
-                  <Form.Control
-                    type="text"
+                  <Form.Label htmlFor="search-from">Điểm đi</Form.Label>
+                  <LocationAutocomplete
+                    id="search-from"
                     value={from}
-                    onChange={(e) => setFrom(e.target.value)}
+                    onChange={setFrom}
                     placeholder="VD: Hà Nội"
                   />
                 </Form.Group>
               </Col>
               <Col md={3}>
                 <Form.Group>
-                  <Form.Label>Điểm đến</Form.Label>
-                  <Form.Control
-                    type="text"
+                  <Form.Label htmlFor="search-to">Điểm đến</Form.Label>
+                  <LocationAutocomplete
+                    id="search-to"
                     value={to}
-                    onChange={(e) => setTo(e.target.value)}
+                    onChange={setTo}
                     placeholder="VD: Sài Gòn"
                   />
                 </Form.Group>
@@ -130,8 +177,76 @@ const HomePage: React.FC<HomePageProps> = ({ onShowAuthModal }) => {
             </Row>
           </Form>
         </Card>
+        {searched.from && searched.to && searched.date && (
+          <FareCalendarStrip
+            from={searched.from}
+            to={searched.to}
+            date={searched.date}
+            onSelectDate={(selected) => {
+              setDate(selected);
+              setSearched((prev) => ({ ...prev, date: selected }));
+            }}
+          />
+        )}
       </Container>
 
+      {(facets.companies.length > 0 || facets.vehicleTypes.length > 0) && (
+        <Container className="mb-3">
+          <div className="d-flex flex-wrap align-items-center gap-2">
+            {facets.companies.map((facet) => (
+              <Button
+                key={facet.companyId}
+                size="sm"
+                variant={
+                  companyIds.includes(facet.companyId)
+                    ? "primary"
+                    : "outline-primary"
+                }
+                onClick={() => toggleCompany(facet.companyId)}
+              >
+                {facet.name}{" "}
+                <Badge bg="light" text="dark">
+                  {facet.count}
+                </Badge>
+              </Button>
+            ))}
+            {facets.vehicleTypes.map((facet) => (
+              <Button
+                key={facet.vehicleType}
+                size="sm"
+                variant={
+                  vehicleType === facet.vehicleType
+                    ? "secondary"
+                    : "outline-secondary"
+                }
+                onClick={() =>
+                  setVehicleType((prev) =>
+                    prev === facet.vehicleType ? "" : facet.vehicleType
+                  )
+                }
+              >
+                {facet.vehicleType}{" "}
+                <Badge bg="light" text="dark">
+                  {facet.count}
+                </Badge>
+              </Button>
+            ))}
+            <Form.Select
+              size="sm"
+              className="ms-auto"
+              style={{ width: "auto" }}
+              value={sort}
+              onChange={(e) => setSort(e.target.value as TripSort)}
+            >
+              <option value="departure">Giờ khởi hành sớm nhất</option>
+              <option value="price">Giá thấp nhất</option>
+              <option value="duration">Thời gian đi ngắn nhất</option>
+              <option value="rating">Đánh giá cao nhất</option>
+            </Form.Select>
+          </div>
+        </Container>
+      )}
+
       {error && (
         <Alert
           variant="danger"
@@ -167,31 +282,55 @@ const HomePage: React.FC<HomePageProps> = ({ onShowAuthModal }) => {
               <Card className="h-100 shadow-sm">
                 <Card.Body className="d-flex flex-column">
                   <Card.Title className="text-primary">
-                    {trip.companyName || "Nhà xe ABC"}
+                    {trip.companyInfo?.name || "Nhà xe ABC"}
                   </Card.Title>
+                  {(trip.companyInfo?.rating || trip.vehicleInfo?.type) && (
+                    <Card.Subtitle className="mb-2 text-muted small">
+                      {trip.companyInfo?.rating ? (
+                        <>
+                          <i className="bi bi-star-fill text-warning"></i>{" "}
+                          {trip.companyInfo.rating.toFixed(1)}
+                        </>
+                      ) : null}
+                      {trip.companyInfo?.rating && trip.vehicleInfo?.type
+                        ? " · "
+                        : null}
+                      {trip.vehicleInfo?.type}
+                    </Card.Subtitle>
+                  )}
                   <Card.Text>
-                    <strong>{trip.route.from.name}</strong>{" "}
+                    <strong>{trip.segmentInfo?.from || trip.route.from.name}</strong>{" "}
                     <i className="bi bi-arrow-right"></i>{" "}
-                    <strong>{trip.route.to.name}</strong>
+                    <strong>{trip.segmentInfo?.to || trip.route.to.name}</strong>
+                    {trip.segmentInfo && (
+                      <small className="d-block text-muted">
+                        Tuyến {trip.route.from.name} - {trip.route.to.name}
+                      </small>
+                    )}
                   </Card.Text>
                   <Card.Text>
-                    Khởi hành:{" "}
-                    {new Date(trip.departureTime).toLocaleTimeString([], {
+                    {trip.segmentInfo ? "Đón tại điểm lên" : "Khởi hành"}:{" "}
+                    {new Date(
+                      trip.segmentInfo?.departureTime || trip.departureTime
+                    ).toLocaleTimeString([], {
                       hour: "2-digit",
                       minute: "2-digit",
                     })}{" "}
-                    - {new Date(trip.departureTime).toLocaleDateString()}
+                    -{" "}
+                    {new Date(
+                      trip.segmentInfo?.departureTime || trip.departureTime
+                    ).toLocaleDateString()}
                   </Card.Text>
                   <div className="mt-auto d-flex justify-content-between align-items-center">
                     <div>
                       <h5 className="mb-0 text-danger">
-                        {trip.price.toLocaleString()} VNĐ
+                        {(trip.segmentInfo?.price ?? trip.price).toLocaleString()} VNĐ
                       </h5>
                       <small>Còn {trip.availableSeats} ghế</small>
                     </div>
                     <Button
                       variant="success"
-                      onClick={() => handleBookTripClick(trip.id)}
+                      onClick={() => handleBookTripClick(trip)}
                     >
                       Đặt vé
                     </Button>
@@ -202,6 +341,22 @@ const HomePage: React.FC<HomePageProps> = ({ onShowAuthModal }) => {
           ))}
         </Row>
       )}
+
+      {!loading && nextCursor && (
+        <div className="text-center my-4">
+          <Button
+            variant="outline-primary"
+            onClick={handleLoadMore}
+            disabled={loadingMore}
+          >
+            {loadingMore ? (
+              <Spinner as="span" animation="border" size="sm" />
+            ) : (
+              "Xem thêm"
+            )}
+          </Button>
+        </div>
+      )}
     </Container>
   );
 };
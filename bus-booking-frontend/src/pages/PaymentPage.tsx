@@ -2,6 +2,7 @@ import React, { useEffect, useState } from "react";
 import { useLocation, useNavigate, useParams } from "react-router-dom";
 import { Container, Card, Spinner, Alert, Button } from "react-bootstrap";
 import { useAuth } from "../contexts/AuthContext";
+import { payBooking } from "../api/bookingApi";
 
 const PaymentPage: React.FC = () => {
   const location = useLocation();
@@ -16,22 +17,35 @@ const PaymentPage: React.FC = () => {
   const [bookingInfo, setBookingInfo] = useState<{
     bookingId: string;
     totalAmount: number;
+    holdExpiresAt?: string;
   } | null>(null);
+  const [secondsLeft, setSecondsLeft] = useState<number | null>(null);
 
   useEffect(() => {
     const currentBookingId = bookingIdFromParams || location.state?.bookingId;
     const currentTotalAmount = location.state?.totalAmount;
+    const currentHoldExpiresAt = location.state?.holdExpiresAt;
 
     if (currentBookingId && currentTotalAmount !== undefined) {
       setBookingInfo({
         bookingId: currentBookingId,
         totalAmount: currentTotalAmount,
+        holdExpiresAt: currentHoldExpiresAt,
       });
       setPaymentStatus("processing");
-      const paymentTimer = setTimeout(() => {
-        setPaymentStatus("success");
-      }, 3000);
-      return () => clearTimeout(paymentTimer);
+      let cancelled = false;
+      payBooking(currentBookingId).then((response) => {
+        if (cancelled) return;
+        if (response.dữ_liệu && response.dữ_liệu.status === "succeeded") {
+          setPaymentStatus("success");
+        } else {
+          setError(response.lỗi || response["thông báo"]);
+          setPaymentStatus("failed");
+        }
+      });
+      return () => {
+        cancelled = true;
+      };
     } else {
       setError(
         "Không tìm thấy thông tin thanh toán hợp lệ. Vui lòng thử lại quy trình đặt vé."
@@ -40,6 +54,29 @@ const PaymentPage: React.FC = () => {
     }
   }, [location.state, bookingIdFromParams]);
 
+  useEffect(() => {
+    if (!bookingInfo?.holdExpiresAt || paymentStatus !== "processing") {
+      return;
+    }
+    const expiresAt = new Date(bookingInfo.holdExpiresAt).getTime();
+    const tick = () => {
+      const remaining = Math.max(
+        0,
+        Math.floor((expiresAt - Date.now()) / 1000)
+      );
+      setSecondsLeft(remaining);
+      if (remaining === 0) {
+        setError(
+          "Thời gian giữ chỗ đã hết. Ghế đã được trả lại, vui lòng đặt lại."
+        );
+        setPaymentStatus("failed");
+      }
+    };
+    tick();
+    const countdownTimer = setInterval(tick, 1000);
+    return () => clearInterval(countdownTimer);
+  }, [bookingInfo, paymentStatus]);
+
   useEffect(() => {
     if (!authLoading && !isAuthenticated) {
       navigate("/");
@@ -83,9 +120,15 @@ const PaymentPage: React.FC = () => {
             {bookingInfo.totalAmount.toLocaleString()} VNĐ
           </strong>
         </p>
-        <Alert variant="info" className="mt-3">
-          Đây là trang giả lập thanh toán.
-        </Alert>
+        {secondsLeft !== null && (
+          <p>
+            Thời gian giữ chỗ còn lại:{" "}
+            <strong>
+              {Math.floor(secondsLeft / 60)}:
+              {String(secondsLeft % 60).padStart(2, "0")}
+            </strong>
+          </p>
+        )}
       </>
     );
   } else if (paymentStatus === "success") {
@@ -11,7 +11,7 @@ import {
   Badge,
   Button,
 } from "react-bootstrap";
-import { getBookingDetails } from "../api/bookingApi";
+import { downloadTicket, getBookingDetails } from "../api/bookingApi";
 import type { Booking } from "../types/booking.types";
 import { useAuth } from "../contexts/AuthContext";
 
@@ -173,7 +173,7 @@ const TicketDetailsPage: React.FC = () => {
           <Row className="mb-4">
             <Col md={7}>
               <h4 className="text-primary">
-                {trip?.companyName || "N/A (Không có tên nhà xe)"}
+                {trip?.companyInfo?.name || "N/A (Không có tên nhà xe)"}
               </h4>
               <h5>
                 {trip?.route?.from?.name || "N/A (Điểm đi)"}{" "}
@@ -227,13 +227,13 @@ const TicketDetailsPage: React.FC = () => {
                 <strong>Ngày đặt:</strong>{" "}
                 {new Date(booking.bookingTime).toLocaleString("vi-VN")}
               </p>
-              {/* Giả định paymentStatus luôn là "paid" vì chúng ta giả lập thành công ở frontend */}
-              {/* Trong thực tế, bạn sẽ dùng booking.paymentStatus từ backend */}
               <p>
                 <strong>Thanh toán:</strong>{" "}
-                <span className="text-success fw-bold">
-                  Đã thanh toán (Giả lập)
-                </span>
+                {booking.paymentStatus === "paid" ? (
+                  <span className="text-success fw-bold">Đã thanh toán</span>
+                ) : (
+                  <span className="text-warning fw-bold">Chưa thanh toán</span>
+                )}
               </p>
             </Col>
           </Row>
@@ -284,6 +284,24 @@ const TicketDetailsPage: React.FC = () => {
           </Alert>
         </Card.Body>
         <Card.Footer className="text-center p-3">
+          {booking.ticketCode && (
+            <>
+              <Button
+                variant="success"
+                className="me-2"
+                onClick={() => downloadTicket(booking.id, "pdf")}
+              >
+                Tải vé PDF
+              </Button>
+              <Button
+                variant="outline-success"
+                className="me-2"
+                onClick={() => downloadTicket(booking.id, "png")}
+              >
+                Tải ảnh vé
+              </Button>
+            </>
+          )}
           <Link to="/my-bookings" className="btn btn-outline-primary me-2">
             Xem tất cả vé
           </Link>
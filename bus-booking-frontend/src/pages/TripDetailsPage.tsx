@@ -1,5 +1,5 @@
-import React, { useEffect, useState } from "react";
-import { useParams, useNavigate } from "react-router-dom";
+import React, { useCallback, useEffect, useState } from "react";
+import { useParams, useNavigate, useSearchParams } from "react-router-dom";
 import {
   Container,
   Row,
@@ -10,13 +10,17 @@ import {
   Alert,
   ListGroup,
 } from "react-bootstrap";
-import { getTripDetails } from "../api/tripApi";
-import type { Trip, Seat } from "../types/trip.types";
+import { getTripDetails, subscribeSeatEvents } from "../api/tripApi";
+import type { Trip, Seat, SeatEvent } from "../types/trip.types";
 import SeatButton from "../components/trips/SeatButton";
+import SeatMapGrid from "../components/trips/SeatMapGrid";
 import { useAuth } from "../contexts/AuthContext";
 
 const TripDetailsPage: React.FC = () => {
   const { tripId } = useParams<{ tripId: string }>();
+  const [searchParams] = useSearchParams();
+  const boardingStop = searchParams.get("from") || "";
+  const alightingStop = searchParams.get("to") || "";
   const navigate = useNavigate();
   const { isAuthenticated, isLoading: authLoading } = useAuth();
 
@@ -25,13 +29,18 @@ const TripDetailsPage: React.FC = () => {
   const [error, setError] = useState<string | null>(null);
   const [selectedSeats, setSelectedSeats] = useState<string[]>([]);
 
+  const segment =
+    boardingStop && alightingStop
+      ? { from: boardingStop, to: alightingStop }
+      : undefined;
+
   useEffect(() => {
     if (tripId) {
       const fetchTripDetails = async () => {
         setLoading(true);
         setError(null);
         try {
-          const data = await getTripDetails(tripId);
+          const data = await getTripDetails(tripId, segment);
           if (data) {
             setTrip(data);
           } else {
@@ -48,7 +57,57 @@ const TripDetailsPage: React.FC = () => {
       setError("ID chuyến đi không hợp lệ.");
       setLoading(false);
     }
-  }, [tripId]);
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [tripId, boardingStop, alightingStop]);
+
+  // Tải lại sơ đồ ghế mà không hiện spinner, dùng khi ghế được trả lại hoặc sau khi mất kết nối.
+  const refreshSeats = useCallback(async () => {
+    if (!tripId) return;
+    const data = await getTripDetails(tripId, segment);
+    if (data) {
+      setTrip(data);
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [tripId, boardingStop, alightingStop]);
+
+  // Cập nhật sơ đồ ghế theo thời gian thực khi người khác giữ, đặt hoặc trả ghế.
+  const viewedFromStop = trip?.segmentInfo?.fromStop;
+  const viewedToStop = trip?.segmentInfo?.toStop;
+  useEffect(() => {
+    if (!tripId) return;
+    const handleSeatEvent = (event: SeatEvent) => {
+      if (event.status === "available") {
+        // Ghế được trả trên một chặng vẫn có thể đang bị chiếm ở chặng khác; hỏi lại máy chủ.
+        refreshSeats();
+        return;
+      }
+      const overlaps =
+        !event.segment ||
+        viewedFromStop === undefined ||
+        viewedToStop === undefined ||
+        (event.segment.fromStop < viewedToStop &&
+          viewedFromStop < event.segment.toStop);
+      if (!overlaps) return;
+      setTrip((current) => {
+        if (!current?.seats) return current;
+        const seats = current.seats.map((seat) =>
+          event.seatNumbers.includes(seat.seatNumber)
+            ? { ...seat, status: event.status }
+            : seat
+        );
+        return {
+          ...current,
+          seats,
+          availableSeats: seats.filter((seat) => seat.status === "available")
+            .length,
+        };
+      });
+      setSelectedSeats((prev) =>
+        prev.filter((seatNumber) => !event.seatNumbers.includes(seatNumber))
+      );
+    };
+    return subscribeSeatEvents(tripId, handleSeatEvent, refreshSeats);
+  }, [tripId, refreshSeats, viewedFromStop, viewedToStop]);
 
   const handleSeatClick = (seatNumber: string) => {
     setSelectedSeats((prevSelected) =>
@@ -60,7 +119,7 @@ const TripDetailsPage: React.FC = () => {
 
   const calculateTotalPrice = () => {
     if (!trip) return 0;
-    return selectedSeats.length * trip.price;
+    return selectedSeats.length * (trip.segmentInfo?.price ?? trip.price);
   };
 
   const handleProceedToBooking = () => {
@@ -124,7 +183,7 @@ const TripDetailsPage: React.FC = () => {
         <Col md={8}>
           <Card className="mb-4 shadow-sm">
             <Card.Header as="h4" className="bg-primary text-white">
-              Chi tiết chuyến đi: {trip.companyName}
+              Chi tiết chuyến đi: {trip.companyInfo?.name}
             </Card.Header>
             <Card.Body>
               <Card.Title>
@@ -141,8 +200,22 @@ const TripDetailsPage: React.FC = () => {
                   <strong>Dự kiến đến:</strong>{" "}
                   {new Date(trip.expectedArrivalTime).toLocaleString()}
                 </ListGroup.Item>
+                {trip.segmentInfo &&
+                  (trip.route.stops?.length ?? 0) > 2 && (
+                    <ListGroup.Item>
+                      <strong>Chặng của bạn:</strong> {trip.segmentInfo.from} (
+                      {new Date(
+                        trip.segmentInfo.departureTime
+                      ).toLocaleString()}
+                      ) <i className="bi bi-arrow-right"></i>{" "}
+                      {trip.segmentInfo.to} (
+                      {new Date(trip.segmentInfo.arrivalTime).toLocaleString()})
+                    </ListGroup.Item>
+                  )}
                 <ListGroup.Item>
-                  <strong>Giá vé:</strong> {trip.price.toLocaleString()} VNĐ/ghế
+                  <strong>Giá vé:</strong>{" "}
+                  {(trip.segmentInfo?.price ?? trip.price).toLocaleString()}{" "}
+                  VNĐ/ghế
                 </ListGroup.Item>
                 <ListGroup.Item>
                   <strong>Số ghế trống:</strong> {trip.availableSeats}
@@ -195,21 +268,30 @@ const TripDetailsPage: React.FC = () => {
                 </span>
               </p>
               <hr />
-              {seatRows.map((row, rowIndex) => (
-                <div
-                  key={rowIndex}
-                  className="mb-2 d-flex justify-content-center"
-                >
-                  {row.map((seat) => (
-                    <SeatButton
-                      key={seat.seatNumber}
-                      seat={seat}
-                      isSelected={selectedSeats.includes(seat.seatNumber)}
-                      onSeatClick={handleSeatClick}
-                    />
-                  ))}
-                </div>
-              ))}
+              {trip.vehicleInfo?.seatMap && trip.seats ? (
+                <SeatMapGrid
+                  seatMap={trip.vehicleInfo.seatMap}
+                  seats={trip.seats}
+                  selectedSeats={selectedSeats}
+                  onSeatClick={handleSeatClick}
+                />
+              ) : (
+                seatRows.map((row, rowIndex) => (
+                  <div
+                    key={rowIndex}
+                    className="mb-2 d-flex justify-content-center"
+                  >
+                    {row.map((seat) => (
+                      <SeatButton
+                        key={seat.seatNumber}
+                        seat={seat}
+                        isSelected={selectedSeats.includes(seat.seatNumber)}
+                        onSeatClick={handleSeatClick}
+                      />
+                    ))}
+                  </div>
+                ))
+              )}
               {!trip.seats ||
                 (trip.seats.length === 0 && (
                   <p>Không có thông tin sơ đồ ghế.</p>
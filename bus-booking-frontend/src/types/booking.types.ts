@@ -6,18 +6,52 @@ export interface Passenger {
   seatNumber: string;
 }
 
+export type BookingStatus =
+  | "held"
+  | "confirmed"
+  | "checked_in"
+  | "completed"
+  | "expired"
+  | "cancelled"
+  | "refunded";
+
+export interface StatusChange {
+  from?: BookingStatus;
+  to: BookingStatus;
+  at: string;
+  actor: string;
+  reason?: string;
+}
+
 export interface Booking {
   id: string;
   userId: string;
   tripId: string;
   bookingTime: string;
-  status: "pending" | "held" | "confirmed" | "cancelled" | "expired";
-  heldUntil?: string;
+  status: BookingStatus;
+  holdExpiresAt?: string;
   paymentStatus?: "pending" | "paid" | "failed";
   totalAmount: number;
+  segment?: { fromStop: number; toStop: number };
   passengers: Passenger[];
   ticketCode?: string;
+  statusHistory?: StatusChange[];
   createdAt: string;
   updatedAt: string;
   tripInfo?: Trip;
 }
+
+export interface Payment {
+  id: string;
+  bookingId: string;
+  userId: string;
+  provider: string;
+  intentId: string;
+  transactionId?: string;
+  amount: number;
+  currency: string;
+  status: "pending" | "succeeded" | "failed" | "refunded";
+  failureReason?: string;
+  createdAt: string;
+  updatedAt: string;
+}
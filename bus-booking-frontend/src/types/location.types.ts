@@ -0,0 +1,8 @@
+export interface Location {
+  id: string;
+  name: string;
+  slug: string;
+  province?: string;
+  aliases?: string[];
+  coordinates?: { lat: number; lng: number };
+}
@@ -1,20 +1,144 @@
 export interface Seat {
   seatNumber: string;
   status: "available" | "held" | "booked";
+  seatType?: "seat" | "bed" | "vip";
+}
+
+export interface Stop {
+  name: string;
+  offsetMinutes: number;
+  locationId?: string;
+}
+
+export interface Fare {
+  from: string;
+  to: string;
+  price: number;
 }
 
 export interface Route {
-  from: { name: string };
-  to: { name: string };
+  from: { name: string; locationId?: string };
+  to: { name: string; locationId?: string };
+  stops?: Stop[];
+}
+
+// Chặng khách chọn trên chuyến đi: điểm lên, điểm xuống, giờ và giá vé của chặng.
+export interface SegmentInfo {
+  fromStop: number;
+  toStop: number;
+  from: string;
+  to: string;
+  departureTime: string;
+  arrivalTime: string;
+  price: number;
+}
+
+export interface Company {
+  id: string;
+  name: string;
+  code: string;
+  logoUrl?: string;
+  rating?: number;
+}
+
+export interface SeatCell {
+  row: number;
+  column: number;
+  kind: "seat" | "aisle" | "disabled";
+  code?: string;
+  seatType?: "seat" | "bed" | "vip";
+}
+
+export interface Deck {
+  name: string;
+  rows: number;
+  columns: number;
+  cells: SeatCell[];
+}
+
+export interface SeatMap {
+  decks: Deck[];
+}
+
+export interface Vehicle {
+  id: string;
+  companyId: string;
+  type: string;
+  totalSeats: number;
+  seatMap?: SeatMap;
 }
 
 export interface Trip {
   id: string;
-  companyName: string;
+  companyInfo?: Company;
+  vehicleInfo?: Vehicle;
   route: Route;
   departureTime: string;
   expectedArrivalTime: string;
   price: number;
-  seats: Seat[];
+  fares?: Fare[];
+  // Chỉ có trong chi tiết chuyến đi (GET /trips/:id).
+  seats?: Seat[];
+  availableSeats: number;
+  segmentInfo?: SegmentInfo;
+}
+
+export type TripSort = "departure" | "price" | "duration" | "rating";
+
+// Tham số tìm kiếm chuyến đi; mọi trường đều tùy chọn.
+export interface TripSearchParams {
+  from?: string;
+  to?: string;
+  date?: string;
+  companyIds?: string[];
+  vehicleType?: string;
+  minPrice?: number;
+  maxPrice?: number;
+  departAfter?: string;
+  departBefore?: string;
+  minSeats?: number;
+  seatType?: "seat" | "bed" | "vip";
+  sort?: TripSort;
+  cursor?: string;
+  limit?: number;
+}
+
+export interface CompanyFacet {
+  companyId: string;
+  name: string;
+  count: number;
+}
+
+export interface VehicleTypeFacet {
+  vehicleType: string;
+  count: number;
+}
+
+export interface TripFacets {
+  companies: CompanyFacet[];
+  vehicleTypes: VehicleTypeFacet[];
+}
+
+export interface TripSearchResult {
+  trips: Trip[];
+  nextCursor?: string;
+  facets: TripFacets;
+}
+
+// Thống kê của một ngày trong lịch giá vé; minPrice là null khi không còn chuyến bán được.
+export interface FareCalendarDay {
+  date: string;
+  minPrice: number | null;
+  trips: number;
   availableSeats: number;
 }
+
+// Thay đổi trạng thái ghế nhận qua luồng SSE; status "available" nghĩa là ghế vừa được trả lại.
+// segment là chặng bị ảnh hưởng, không có nghĩa là cả chuyến.
+export interface SeatEvent {
+  tripId: string;
+  seatNumbers: string[];
+  status: Seat["status"];
+  segment?: { fromStop: number; toStop: number };
+  at: string;
+}